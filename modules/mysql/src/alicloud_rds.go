@@ -2,32 +2,189 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
 	"kusionstack.io/kusion-module-framework/pkg/module"
 )
 
-var ErrEmptyAlicloudProviderRegion = errors.New("empty alicloud provider region")
+var (
+	ErrEmptyAlicloudProviderRegion = errors.New("empty alicloud provider region")
+	ErrInvalidAlicloudConfig       = errors.New("invalid alicloud config in mysql module config")
+)
 
 var (
 	alicloudRegionEnv    = "ALICLOUD_REGION"
 	alicloudDBInstance   = "alicloud_db_instance"
 	alicloudDBConnection = "alicloud_db_connection"
 	alicloudRDSAccount   = "alicloud_rds_account"
+	alicloudDBDatabase   = "alicloud_db_database"
+	alicloudDBReadonly   = "alicloud_db_readonly_instance"
+	alicloudRDSClone     = "alicloud_rds_clone_db_instance"
+)
+
+const (
+	PrepaidChargeType    = "Prepaid"
+	PostpaidChargeType   = "Postpaid"
+	ServerlessChargeType = "Serverless"
 )
 
+var (
+	alicloudServerlessStorageType = "cloud_essd"
+	alicloudLocalStorageType      = "local_ssd"
+	alicloudStorageTypes          = []string{
+		"local_ssd", "cloud_ssd", "cloud_essd", "cloud_essd2", "cloud_essd3", "general_essd",
+	}
+	alicloudPrepaidPeriods = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 24, 36}
+	alicloudMaxZones       = 3
+	alicloudBasicCategory  = "basic"
+
+	defaultAlicloudCloneStorageType = "cloud_essd"
+)
+
+var defaultAlicloudServerless = AlicloudServerless{
+	MaxCapacity: 8,
+	MinCapacity: 1,
+}
+
 var defaultAlicloudProviderCfg = module.ProviderConfig{
 	Source:  "aliyun/alicloud",
 	Version: "1.209.1",
 }
 
 type alicloudServerlessConfig struct {
-	AutoPause   bool `yaml:"auto_pause" json:"auto_pause"`
-	SwitchForce bool `yaml:"switch_force" json:"switch_force"`
-	MaxCapacity int  `yaml:"max_capacity,omitempty" json:"max_capacity,omitempty"`
-	MinCapacity int  `yaml:"min_capacity,omitempty" json:"min_capacity,omitempty"`
+	AutoPause   bool    `yaml:"auto_pause" json:"auto_pause"`
+	SwitchForce bool    `yaml:"switch_force" json:"switch_force"`
+	MaxCapacity float64 `yaml:"max_capacity,omitempty" json:"max_capacity,omitempty"`
+	MinCapacity float64 `yaml:"min_capacity,omitempty" json:"min_capacity,omitempty"`
+}
+
+// AlicloudServerless describes the scaling config of the Alicloud serverless RDS MySQL instance,
+// with the capacity in RDS capacity units (RCUs).
+type AlicloudServerless struct {
+	// The maximum capacity of the serverless instance.
+	MaxCapacity float64 `json:"maxCapacity,omitempty" yaml:"maxCapacity,omitempty"`
+	// The minimum capacity of the serverless instance.
+	MinCapacity float64 `json:"minCapacity,omitempty" yaml:"minCapacity,omitempty"`
+	// Whether the serverless instance is paused automatically when there is no connection.
+	AutoPause bool `json:"autoPause,omitempty" yaml:"autoPause,omitempty"`
+	// Whether to scale the serverless instance forcibly, which may interrupt the connections.
+	SwitchForce bool `json:"switchForce,omitempty" yaml:"switchForce,omitempty"`
+}
+
+// parseAlicloudServerless parses the serverless block of the platform config.
+func parseAlicloudServerless(config any) (*AlicloudServerless, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: serverless should be a map but got %T", ErrInvalidAlicloudConfig, config)
+	}
+
+	serverless := defaultAlicloudServerless
+	if err := parseConfigBlock(configMap, ErrInvalidAlicloudConfig, "serverless.", map[string]configValueParser{
+		"maxCapacity": configValue(&serverless.MaxCapacity, toConfigFloat),
+		"minCapacity": configValue(&serverless.MinCapacity, toConfigFloat),
+		"autoPause":   configValue(&serverless.AutoPause, toConfigBool),
+		"switchForce": configValue(&serverless.SwitchForce, toConfigBool),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &serverless, nil
+}
+
+// isAlicloudServerless returns whether the Alicloud RDS MySQL instance is of a serverless category.
+func (mysql *MySQL) isAlicloudServerless() bool {
+	return strings.Contains(mysql.Category, "serverless")
+}
+
+// generateAlicloudChargeType generates the charge type of the Alicloud RDS MySQL instance, which is
+// fixed for the serverless instance and defaults to pay-as-you-go.
+func (mysql *MySQL) generateAlicloudChargeType() string {
+	switch {
+	case mysql.isAlicloudServerless():
+		return ServerlessChargeType
+	case strings.EqualFold(mysql.ChargeType, PrepaidChargeType):
+		return PrepaidChargeType
+	default:
+		return PostpaidChargeType
+	}
+}
+
+// validateAlicloudConfig validates whether the combination of the category, charge type, storage
+// type, serverless config and zones is accepted by the Alicloud RDS MySQL instance.
+func (mysql *MySQL) validateAlicloudConfig() error {
+	isServerless := mysql.isAlicloudServerless()
+	isPrepaid := strings.EqualFold(mysql.ChargeType, PrepaidChargeType)
+
+	if mysql.ChargeType != "" && !isPrepaid && !strings.EqualFold(mysql.ChargeType, PostpaidChargeType) {
+		return fmt.Errorf("%w: unsupported charge type %q, which should be %s or %s", ErrInvalidAlicloudConfig,
+			mysql.ChargeType, PrepaidChargeType, PostpaidChargeType)
+	}
+
+	if isServerless && isPrepaid {
+		return fmt.Errorf("%w: serverless instance does not support the %s charge type", ErrInvalidAlicloudConfig, PrepaidChargeType)
+	}
+
+	if mysql.Period != 0 {
+		if !isPrepaid {
+			return fmt.Errorf("%w: period is only supported with the %s charge type", ErrInvalidAlicloudConfig, PrepaidChargeType)
+		}
+		if !slices.Contains(alicloudPrepaidPeriods, mysql.Period) {
+			return fmt.Errorf("%w: unsupported period %d, which should be one of %v", ErrInvalidAlicloudConfig,
+				mysql.Period, alicloudPrepaidPeriods)
+		}
+	}
+
+	if mysql.Serverless != nil {
+		if !isServerless {
+			return fmt.Errorf("%w: serverless is only supported by the serverless categories", ErrInvalidAlicloudConfig)
+		}
+		if mysql.Serverless.MinCapacity <= 0 || mysql.Serverless.MinCapacity > mysql.Serverless.MaxCapacity {
+			return fmt.Errorf("%w: invalid serverless capacity range [%v, %v]", ErrInvalidAlicloudConfig,
+				mysql.Serverless.MinCapacity, mysql.Serverless.MaxCapacity)
+		}
+	}
+
+	if mysql.StorageType != "" {
+		if !slices.Contains(alicloudStorageTypes, mysql.StorageType) {
+			return fmt.Errorf("%w: unsupported storage type %q", ErrInvalidAlicloudConfig, mysql.StorageType)
+		}
+		if isServerless && mysql.StorageType != alicloudServerlessStorageType {
+			return fmt.Errorf("%w: serverless instance only supports the %s storage type", ErrInvalidAlicloudConfig,
+				alicloudServerlessStorageType)
+		}
+		if strings.EqualFold(mysql.Category, alicloudBasicCategory) && mysql.StorageType == alicloudLocalStorageType {
+			return fmt.Errorf("%w: basic instance does not support the %s storage type", ErrInvalidAlicloudConfig,
+				alicloudLocalStorageType)
+		}
+	}
+
+	if len(mysql.Zones) > alicloudMaxZones {
+		return fmt.Errorf("%w: at most %d zones are supported", ErrInvalidAlicloudConfig, alicloudMaxZones)
+	}
+
+	// The basic instances have only one node, which cannot be placed in multiple zones.
+	if len(mysql.Zones) > 1 && strings.HasSuffix(strings.ToLower(mysql.Category), alicloudBasicCategory) {
+		return fmt.Errorf("%w: %s instance does not support multiple zones", ErrInvalidAlicloudConfig, mysql.Category)
+	}
+
+	// The Alicloud instance is cloned from the backup set or the point in time of the source instance.
+	if restoreFrom := mysql.RestoreFrom; restoreFrom != nil {
+		if restoreFrom.SourceInstance == "" {
+			return fmt.Errorf("%w: sourceInstance of restoreFrom should be specified", ErrInvalidAlicloudConfig)
+		}
+		if restoreFrom.UseLatestRestorableTime {
+			return fmt.Errorf("%w: useLatestRestorableTime of restoreFrom is not supported", ErrInvalidAlicloudConfig)
+		}
+		if isServerless {
+			return fmt.Errorf("%w: serverless instance cannot be restored", ErrInvalidAlicloudConfig)
+		}
+	}
+
+	return nil
 }
 
 // GenerateAlicloudResources generates Alicloud provided MySQL database instance.
@@ -46,6 +203,20 @@ func (mysql *MySQL) GenerateAlicloudResources(request *module.GeneratorRequest)
 		return nil, nil, ErrEmptyAlicloudProviderRegion
 	}
 
+	// The IAM database authentication is only supported by the AWS provided MySQL instance.
+	if mysql.isIAMAuth() {
+		return nil, nil, ErrUnsupportedIAMAuth
+	}
+
+	// The pooler is only supported by the local and the AWS provided MySQL instance yet.
+	if mysql.Pooler != nil {
+		return nil, nil, ErrUnsupportedPooler
+	}
+
+	if err := mysql.validateAlicloudConfig(); err != nil {
+		return nil, nil, err
+	}
+
 	// Build random_password resource.
 	randomPasswordRes, randomPasswordID, err := mysql.GenerateTFRandomPassword(request)
 	if err != nil {
@@ -53,10 +224,13 @@ func (mysql *MySQL) GenerateAlicloudResources(request *module.GeneratorRequest)
 	}
 	resources = append(resources, *randomPasswordRes)
 
-	// Build alicloud_db_instance resource.
-	alicloudDBInstanceRes, alicloudDBInstanceID, err := mysql.generateAlicloudDBInstance(
-		alicloudProviderCfg, region,
-	)
+	// Build alicloud_db_instance resource, or alicloud_rds_clone_db_instance resource if restored
+	// from the source instance.
+	generateDBInstance := mysql.generateAlicloudDBInstance
+	if mysql.RestoreFrom != nil {
+		generateDBInstance = mysql.generateAlicloudRDSCloneDBInstance
+	}
+	alicloudDBInstanceRes, alicloudDBInstanceID, err := generateDBInstance(alicloudProviderCfg, region)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -68,7 +242,7 @@ func (mysql *MySQL) GenerateAlicloudResources(request *module.GeneratorRequest)
 	if IsPublicAccessible(mysql.SecurityIPs) {
 		alicloudDBConnectionRes, alicloudDBConnectionID, err = mysql.generateAlicloudDBConnection(
 			alicloudProviderCfg,
-			region, alicloudDBInstanceID,
+			region, mysql.DatabaseName, alicloudDBInstanceID,
 		)
 		if err != nil {
 			return nil, nil, err
@@ -87,16 +261,75 @@ func (mysql *MySQL) GenerateAlicloudResources(request *module.GeneratorRequest)
 	}
 	resources = append(resources, *alicloudRDSAccountRes)
 
+	dependsOn := []string{alicloudDBInstanceID, alicloudRDSAccountRes.ID}
+
+	// Build alicloud_db_database resource, unless the database is restored from the source instance.
+	if mysql.RestoreFrom == nil {
+		alicloudDBDatabaseRes, err := mysql.generateAlicloudDBDatabase(alicloudProviderCfg, region, alicloudDBInstanceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *alicloudDBDatabaseRes)
+		dependsOn = append(dependsOn, alicloudDBDatabaseRes.ID)
+	}
+	if alicloudDBConnectionRes != nil {
+		dependsOn = append(dependsOn, alicloudDBConnectionID)
+	}
+
 	hostAddress := module.KusionPathDependency(alicloudDBInstanceID, "connection_string")
 	if !mysql.PrivateRouting {
 		// Set the public network connection string as the host address.
 		hostAddress = module.KusionPathDependency(alicloudDBConnectionID, "connection_string")
 	}
-	password := module.KusionPathDependency(randomPasswordID, "result")
+
+	// Build alicloud_db_readonly_instance resources of the read replicas, with the public network
+	// connections if the source instance is accessed publicly.
+	var readHostAddresses []string
+	for i := 0; i < mysql.Replicas; i++ {
+		alicloudDBReadonlyRes, alicloudDBReadonlyID, err := mysql.generateAlicloudDBReadonlyInstance(
+			alicloudProviderCfg,
+			region, alicloudDBInstanceID, i,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *alicloudDBReadonlyRes)
+
+		readHostAddress := module.KusionPathDependency(alicloudDBReadonlyID, "connection_string")
+		if !mysql.PrivateRouting && alicloudDBConnectionRes != nil {
+			alicloudDBReadonlyConnectionRes, alicloudDBReadonlyConnectionID, err := mysql.generateAlicloudDBConnection(
+				alicloudProviderCfg,
+				region, mysql.generateReplicaName(i), alicloudDBReadonlyID,
+			)
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, *alicloudDBReadonlyConnectionRes)
+			readHostAddress = module.KusionPathDependency(alicloudDBReadonlyConnectionID, "connection_string")
+		}
+		readHostAddresses = append(readHostAddresses, readHostAddress)
+	}
+
+	// Build the logical databases, application users and grants inside the Alicloud provided MySQL instance.
+	dbUserResources, username, password, err := mysql.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), dependsOn)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// Write the password into Alicloud KMS secrets for the ExternalSecret to sync it into the cluster.
+	if mysql.isExternalSecretDelivery() {
+		alicloudKMSSecretRes, err := mysql.generateAlicloudKMSSecret(request, alicloudProviderCfg, region, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *alicloudKMSSecretRes)
+	}
 
 	// Build Kubernetes Secret with the hostAddress, username and password of the Alicloud provided MySQL instance,
 	// and inject the credentials as the environment variable patcher.
-	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, mysql.Username, password)
+	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, username, password, readHostAddresses)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -121,20 +354,48 @@ func (mysql *MySQL) generateAlicloudDBInstance(alicloudProviderCfg module.Provid
 		"instance_name":    mysql.DatabaseName,
 	}
 
+	if mysql.StorageType != "" {
+		resAttrs["db_instance_storage_type"] = mysql.StorageType
+	}
+
+	// Set the charge type, along with the subscription period of the prepaid instance.
+	resAttrs["instance_charge_type"] = mysql.generateAlicloudChargeType()
+	if mysql.Period != 0 {
+		resAttrs["period"] = mysql.Period
+	}
+
+	// Set the primary zone and the secondary zones of the multi-zone instance.
+	for i, zoneKey := range []string{"zone_id", "zone_id_slave_a", "zone_id_slave_b"} {
+		if i < len(mysql.Zones) {
+			resAttrs[zoneKey] = mysql.Zones[i]
+		}
+	}
+
+	if len(mysql.Parameters) > 0 {
+		resAttrs["parameters"] = mysql.generateParameters()
+	}
+
+	// Enable the SSL encryption of the connections if the TLS connections are enforced.
+	if mysql.TLS != nil {
+		resAttrs["ssl_action"] = alicloudSSLAction
+	}
+
 	// Set the serverless-specific attributes of the alicloud_db_instance resource.
-	if strings.Contains(mysql.Category, "serverless") {
-		resAttrs["db_instance_storage_type"] = "cloud_essd"
-		resAttrs["instance_charge_type"] = "Serverless"
+	if mysql.isAlicloudServerless() {
+		resAttrs["db_instance_storage_type"] = alicloudServerlessStorageType
 
-		serverlessConfig := alicloudServerlessConfig{
-			MaxCapacity: 8,
-			MinCapacity: 1,
+		serverless := defaultAlicloudServerless
+		if mysql.Serverless != nil {
+			serverless = *mysql.Serverless
 		}
-		serverlessConfig.AutoPause = false
-		serverlessConfig.SwitchForce = false
 
 		resAttrs["serverless_config"] = []alicloudServerlessConfig{
-			serverlessConfig,
+			{
+				AutoPause:   serverless.AutoPause,
+				SwitchForce: serverless.SwitchForce,
+				MaxCapacity: serverless.MaxCapacity,
+				MinCapacity: serverless.MinCapacity,
+			},
 		}
 	}
 
@@ -152,16 +413,82 @@ func (mysql *MySQL) generateAlicloudDBInstance(alicloudProviderCfg module.Provid
 	return resource, id, nil
 }
 
+// generateAlicloudRDSCloneDBInstance generates alicloud_rds_clone_db_instance resource for the Alicloud
+// provided MySQL database instance restored from the backup set or the point in time of the source instance.
+func (mysql *MySQL) generateAlicloudRDSCloneDBInstance(alicloudProviderCfg module.ProviderConfig,
+	region string,
+) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"category":                 mysql.Category,
+		"db_instance_class":        mysql.InstanceType,
+		"db_instance_description":  mysql.DatabaseName,
+		"db_instance_storage":      mysql.Size,
+		"db_instance_storage_type": defaultAlicloudCloneStorageType,
+		"payment_type":             "PayAsYouGo",
+		"security_ips":             mysql.SecurityIPs,
+		"source_db_instance_id":    mysql.RestoreFrom.SourceInstance,
+		"vswitch_id":               mysql.SubnetID,
+	}
+
+	if mysql.RestoreFrom.Snapshot != "" {
+		resAttrs["backup_id"] = mysql.RestoreFrom.Snapshot
+	} else {
+		resAttrs["restore_time"] = mysql.RestoreFrom.generateRestoreTime()
+	}
+
+	if mysql.StorageType != "" {
+		resAttrs["db_instance_storage_type"] = mysql.StorageType
+	}
+
+	// Set the subscription duration of the prepaid instance, in years if possible.
+	if strings.EqualFold(mysql.ChargeType, PrepaidChargeType) {
+		resAttrs["payment_type"] = "Subscription"
+		if mysql.Period != 0 {
+			resAttrs["period"], resAttrs["used_time"] = "Month", mysql.Period
+			if mysql.Period%12 == 0 {
+				resAttrs["period"], resAttrs["used_time"] = "Year", mysql.Period/12
+			}
+		}
+	}
+
+	for i, zoneKey := range []string{"zone_id", "zone_id_slave_a", "zone_id_slave_b"} {
+		if i < len(mysql.Zones) {
+			resAttrs[zoneKey] = mysql.Zones[i]
+		}
+	}
+
+	if len(mysql.Parameters) > 0 {
+		resAttrs["parameters"] = mysql.generateParameters()
+	}
+
+	if mysql.TLS != nil {
+		resAttrs["ssl_enabled"] = 1
+	}
+
+	id, err := module.TerraformResourceID(alicloudProviderCfg, alicloudRDSClone, mysql.DatabaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	alicloudProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(alicloudProviderCfg, alicloudRDSClone, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
 // generateAlicloudDBConnection generates alicloud_db_connection resource
-// for the Alicloud provided MySQL database instance.
+// for the Alicloud provided MySQL database instance or read replica with the name.
 func (mysql *MySQL) generateAlicloudDBConnection(alicloudProviderCfg module.ProviderConfig,
-	region, dbInstanceID string,
+	region, name, dbInstanceID string,
 ) (*kusionapiv1.Resource, string, error) {
 	resAttrs := map[string]interface{}{
 		"instance_id": module.KusionPathDependency(dbInstanceID, "id"),
 	}
 
-	id, err := module.TerraformResourceID(alicloudProviderCfg, alicloudDBConnection, mysql.DatabaseName)
+	id, err := module.TerraformResourceID(alicloudProviderCfg, alicloudDBConnection, name)
 	if err != nil {
 		return nil, "", err
 	}
@@ -200,3 +527,60 @@ func (mysql *MySQL) generateAlicloudRDSAccount(alicloudProviderCfg module.Provid
 
 	return resource, nil
 }
+
+// generateAlicloudDBDatabase generates alicloud_db_database resource
+// for the Alicloud provided MySQL database instance.
+func (mysql *MySQL) generateAlicloudDBDatabase(alicloudProviderCfg module.ProviderConfig,
+	region, dbInstanceID string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"instance_id":   module.KusionPathDependency(dbInstanceID, "id"),
+		"name":          mysql.generateLogicalDBName(),
+		"character_set": "utf8mb4",
+	}
+
+	id, err := module.TerraformResourceID(alicloudProviderCfg, alicloudDBDatabase, mysql.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	alicloudProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(alicloudProviderCfg, alicloudDBDatabase, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateAlicloudDBReadonlyInstance generates alicloud_db_readonly_instance resource
+// for the read replica of the Alicloud provided MySQL database instance.
+func (mysql *MySQL) generateAlicloudDBReadonlyInstance(alicloudProviderCfg module.ProviderConfig,
+	region, dbInstanceID string, index int,
+) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"master_db_instance_id": module.KusionPathDependency(dbInstanceID, "id"),
+		"engine_version":        mysql.Version,
+		"instance_storage":      mysql.Size,
+		"instance_type":         mysql.InstanceType,
+		"instance_name":         mysql.generateReplicaName(index),
+		"vswitch_id":            mysql.SubnetID,
+	}
+
+	if mysql.TLS != nil {
+		resAttrs["ssl_enabled"] = 1
+	}
+
+	id, err := module.TerraformResourceID(alicloudProviderCfg, alicloudDBReadonly, mysql.generateReplicaName(index))
+	if err != nil {
+		return nil, "", err
+	}
+
+	alicloudProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(alicloudProviderCfg, alicloudDBReadonly, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
@@ -39,7 +39,7 @@ func TestMySQLModule_GenerateAlicloudResources(t *testing.T) {
 
 		resources, patchers, err := mysql.GenerateAlicloudResources(r)
 
-		assert.Equal(t, 5, len(resources))
+		assert.Equal(t, 6, len(resources))
 		assert.NotNil(t, patchers)
 		assert.NoError(t, err)
 	})
@@ -80,7 +80,7 @@ func TestMySQLModule_GenerateAlicloudDBConnection(t *testing.T) {
 		SubnetID:       "test-subnet-id",
 	}
 
-	res, id, err := mysql.generateAlicloudDBConnection(defaultAlicloudProviderCfg, "test-region", "db_instance_id")
+	res, id, err := mysql.generateAlicloudDBConnection(defaultAlicloudProviderCfg, "test-region", mysql.DatabaseName, "db_instance_id")
 
 	assert.NotNil(t, res)
 	assert.NotEqual(t, id, "")
@@ -107,3 +107,337 @@ func TestMySQLModule_GenerateAlicloudRDSAccount(t *testing.T) {
 	assert.NotNil(t, res)
 	assert.NoError(t, err)
 }
+
+func TestMySQLModule_GenerateAlicloudDBDatabase(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+	}
+
+	res, err := mysql.generateAlicloudDBDatabase(defaultAlicloudProviderCfg, "test-region", "db_instance_id")
+
+	assert.NotNil(t, res)
+	assert.Equal(t, "test_database", res.Attributes["name"])
+	assert.NoError(t, err)
+}
+
+func TestMySQLModule_GenerateAlicloudDBReadonlyInstance(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Size:         defaultSize,
+		InstanceType: "mysql.n2.medium.1",
+		SubnetID:     "test-subnet-id",
+		Replicas:     1,
+	}
+
+	res, id, err := mysql.generateAlicloudDBReadonlyInstance(defaultAlicloudProviderCfg, "test-region", "db_instance_id", 0)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, "")
+	assert.Equal(t, "test-database-replica-0", res.Attributes["instance_name"])
+	assert.Equal(t, module.KusionPathDependency("db_instance_id", "id"), res.Attributes["master_db_instance_id"])
+}
+
+func TestMySQLModule_GenerateAlicloudDBInstanceWithPlatformConfig(t *testing.T) {
+	t.Run("default serverless config", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "cloud",
+			Version:      "8.0",
+			DatabaseName: "test-database",
+			Size:         defaultSize,
+			InstanceType: "mysql.n2.serverless.1c",
+			Category:     "serverless_basic",
+		}
+
+		res, _, err := mysql.generateAlicloudDBInstance(defaultAlicloudProviderCfg, "test-region")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "cloud_essd", res.Attributes["db_instance_storage_type"])
+		assert.Equal(t, "Serverless", res.Attributes["instance_charge_type"])
+		assert.Equal(t, []alicloudServerlessConfig{
+			{MaxCapacity: 8, MinCapacity: 1},
+		}, res.Attributes["serverless_config"])
+	})
+
+	t.Run("custom serverless config", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "cloud",
+			Version:      "8.0",
+			DatabaseName: "test-database",
+			Size:         defaultSize,
+			InstanceType: "mysql.n2.serverless.2c",
+			Category:     "serverless_standard",
+			Zones:        []string{"cn-hangzhou-i", "cn-hangzhou-j"},
+			Serverless: &AlicloudServerless{
+				MaxCapacity: 16,
+				MinCapacity: 0.5,
+				AutoPause:   true,
+			},
+		}
+
+		res, _, err := mysql.generateAlicloudDBInstance(defaultAlicloudProviderCfg, "test-region")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "cn-hangzhou-i", res.Attributes["zone_id"])
+		assert.Equal(t, "cn-hangzhou-j", res.Attributes["zone_id_slave_a"])
+		assert.NotContains(t, res.Attributes, "zone_id_slave_b")
+		assert.Equal(t, []alicloudServerlessConfig{
+			{AutoPause: true, MaxCapacity: 16, MinCapacity: 0.5},
+		}, res.Attributes["serverless_config"])
+	})
+
+	t.Run("prepaid instance", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "cloud",
+			Version:      "8.0",
+			DatabaseName: "test-database",
+			Size:         defaultSize,
+			InstanceType: "mysql.n2.medium.2c",
+			Category:     "HighAvailability",
+			StorageType:  "cloud_essd2",
+			ChargeType:   "PrePaid",
+			Period:       12,
+		}
+
+		res, _, err := mysql.generateAlicloudDBInstance(defaultAlicloudProviderCfg, "test-region")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "cloud_essd2", res.Attributes["db_instance_storage_type"])
+		assert.Equal(t, "Prepaid", res.Attributes["instance_charge_type"])
+		assert.Equal(t, 12, res.Attributes["period"])
+		assert.NotContains(t, res.Attributes, "serverless_config")
+	})
+}
+
+func TestMySQLModule_ValidateAlicloudConfig(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name: "valid prepaid multi-zone instance",
+			mysql: &MySQL{
+				Category:    "HighAvailability",
+				StorageType: "local_ssd",
+				ChargeType:  "PrePaid",
+				Period:      1,
+				Zones:       []string{"cn-hangzhou-i", "cn-hangzhou-j"},
+			},
+			success: true,
+		},
+		{
+			name: "restore from backup set",
+			mysql: &MySQL{
+				Category:    "HighAvailability",
+				RestoreFrom: &RestoreFrom{Snapshot: "test-backup-id", SourceInstance: "test-source"},
+			},
+			success: true,
+		},
+		{
+			name: "restore without source instance",
+			mysql: &MySQL{
+				Category:    "HighAvailability",
+				RestoreFrom: &RestoreFrom{Snapshot: "test-backup-id"},
+			},
+			success: false,
+		},
+		{
+			name: "restore to latest restorable time",
+			mysql: &MySQL{
+				Category:    "HighAvailability",
+				RestoreFrom: &RestoreFrom{SourceInstance: "test-source", UseLatestRestorableTime: true},
+			},
+			success: false,
+		},
+		{
+			name: "restore serverless instance",
+			mysql: &MySQL{
+				Category:    "serverless_basic",
+				RestoreFrom: &RestoreFrom{Snapshot: "test-backup-id", SourceInstance: "test-source"},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported charge type",
+			mysql: &MySQL{
+				Category:   "Basic",
+				ChargeType: "Spot",
+			},
+			success: false,
+		},
+		{
+			name: "prepaid serverless instance",
+			mysql: &MySQL{
+				Category:   "serverless_basic",
+				ChargeType: "Prepaid",
+			},
+			success: false,
+		},
+		{
+			name: "period of postpaid instance",
+			mysql: &MySQL{
+				Category: "Basic",
+				Period:   1,
+			},
+			success: false,
+		},
+		{
+			name: "unsupported period",
+			mysql: &MySQL{
+				Category:   "Basic",
+				ChargeType: "Prepaid",
+				Period:     10,
+			},
+			success: false,
+		},
+		{
+			name: "serverless config of non-serverless instance",
+			mysql: &MySQL{
+				Category:   "Basic",
+				Serverless: &AlicloudServerless{MaxCapacity: 8, MinCapacity: 1},
+			},
+			success: false,
+		},
+		{
+			name: "invalid serverless capacity range",
+			mysql: &MySQL{
+				Category:   "serverless_basic",
+				Serverless: &AlicloudServerless{MaxCapacity: 1, MinCapacity: 2},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported storage type of serverless instance",
+			mysql: &MySQL{
+				Category:    "serverless_basic",
+				StorageType: "cloud_ssd",
+			},
+			success: false,
+		},
+		{
+			name: "local storage of basic instance",
+			mysql: &MySQL{
+				Category:    "Basic",
+				StorageType: "local_ssd",
+			},
+			success: false,
+		},
+		{
+			name: "multiple zones of basic instance",
+			mysql: &MySQL{
+				Category: "serverless_basic",
+				Zones:    []string{"cn-hangzhou-i", "cn-hangzhou-j"},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateAlicloudConfig()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidAlicloudConfig)
+			}
+		})
+	}
+}
+
+func TestParseAlicloudServerless(t *testing.T) {
+	serverless, err := parseAlicloudServerless(map[string]any{
+		"maxCapacity": "4",
+		"autoPause":   true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &AlicloudServerless{MaxCapacity: 4, MinCapacity: 1, AutoPause: true}, serverless)
+
+	_, err = parseAlicloudServerless(map[string]any{"capacity": 4})
+	assert.ErrorIs(t, err, ErrInvalidAlicloudConfig)
+}
+
+func TestMySQLModule_GenerateAlicloudDBInstanceWithParameters(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Size:         defaultSize,
+		InstanceType: "mysql.n2.medium.1",
+		Category:     defaultCategory,
+		Parameters: map[string]string{
+			"max_connections": "500",
+		},
+	}
+
+	res, _, err := mysql.generateAlicloudDBInstance(defaultAlicloudProviderCfg, "test-region")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Parameter{{Name: "max_connections", Value: "500"}}, res.Attributes["parameters"])
+}
+
+func TestMySQLModule_GenerateAlicloudDBInstanceWithTLS(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Size:         defaultSize,
+		InstanceType: "mysql.n2.medium.1",
+		Category:     defaultCategory,
+		TLS:          &TLS{},
+	}
+
+	res, _, err := mysql.generateAlicloudDBInstance(defaultAlicloudProviderCfg, "test-region")
+	assert.NoError(t, err)
+	assert.Equal(t, "Open", res.Attributes["ssl_action"])
+
+	res, _, err = mysql.generateAlicloudDBReadonlyInstance(defaultAlicloudProviderCfg, "test-region", "alicloud_db_instance_id", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Attributes["ssl_enabled"])
+}
+
+func TestMySQLModule_GenerateAlicloudRDSCloneDBInstance(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		SecurityIPs:  defaultSecurityIPs,
+		Size:         defaultSize,
+		InstanceType: "mysql.n2.medium.1",
+		Category:     defaultCategory,
+		ChargeType:   "Prepaid",
+		Period:       24,
+		RestoreFrom:  &RestoreFrom{SourceInstance: "test-source", RestoreTime: "2024-06-01T08:00:00Z"},
+	}
+
+	res, id, err := mysql.generateAlicloudRDSCloneDBInstance(defaultAlicloudProviderCfg, "test-region")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "aliyun:alicloud:alicloud_rds_clone_db_instance:test-database", id)
+	assert.Equal(t, "test-source", res.Attributes["source_db_instance_id"])
+	assert.Equal(t, "2024-06-01T08:00:00Z", res.Attributes["restore_time"])
+	assert.NotContains(t, res.Attributes, "backup_id")
+	assert.Equal(t, "cloud_essd", res.Attributes["db_instance_storage_type"])
+	assert.Equal(t, "Subscription", res.Attributes["payment_type"])
+	assert.Equal(t, "Year", res.Attributes["period"])
+	assert.Equal(t, 2, res.Attributes["used_time"])
+
+	t.Run("restore without logical database", func(t *testing.T) {
+		t.Setenv(alicloudRegionEnv, "test-region")
+		mysql.ChargeType, mysql.Period = "", 0
+		mysql.RestoreFrom = &RestoreFrom{Snapshot: "test-backup-id", SourceInstance: "test-source"}
+
+		resources, _, err := mysql.GenerateAlicloudResources(&module.GeneratorRequest{Project: "test-project"})
+
+		assert.NoError(t, err)
+		for _, resource := range resources {
+			assert.NotContains(t, resource.ID, alicloudDBDatabase)
+			assert.NotContains(t, resource.ID, alicloudDBInstance+":")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestMySQLModule_GenerateAWSDBProxyResources(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		TLS:          &TLS{},
+		Pooler: &Pooler{
+			SubnetIDs:             []string{"subnet-a", "subnet-b"},
+			MaxConnectionsPercent: 80,
+		},
+	}
+	secretARN := module.KusionPathDependency("aws_cloudformation_stack_id", "outputs.SecretARN")
+
+	resources, hostAddress, err := mysql.generateAWSDBProxyResources(defaultAWSProviderCfg, "us-east-1",
+		"test-project/test-database-mysql", "aws_cloudformation_stack_id", "aws_security_group_id",
+		"db_instance_identifier", module.KusionPathDependency("aws_db_instance_id", "identifier"),
+		[]string{"aws_db_instance_id"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(resources))
+	assert.Equal(t, "hashicorp:aws:aws_db_proxy:test-database-db-pooler", resources[2].ID)
+	assert.Equal(t, module.KusionPathDependency(resources[2].ID, "endpoint"), hostAddress)
+
+	// The role is only allowed to read the secret of the workload.
+	assert.Equal(t, "hashicorp:aws:aws_iam_role:test-database-db-proxy", resources[0].ID)
+	assert.Contains(t, resources[1].Attributes["policy"],
+		`"Resource":"arn:aws:secretsmanager:us-east-1:*:secret:test-project/test-database-mysql-*"`)
+	assert.Equal(t, []string{"aws_cloudformation_stack_id"}, resources[1].DependsOn)
+
+	proxy := resources[2].Attributes
+	assert.Equal(t, "MYSQL", proxy["engine_family"])
+	assert.Equal(t, true, proxy["require_tls"])
+	assert.Equal(t, secretARN, proxy["auth"].([]awsDBProxyAuth)[0].SecretARN)
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, proxy["vpc_subnet_ids"])
+	assert.Equal(t, []string{resources[1].ID}, resources[2].DependsOn)
+
+	assert.Equal(t, 80,
+		resources[3].Attributes["connection_pool_config"].([]awsDBProxyConnectionPoolConfig)[0].MaxConnectionsPercent)
+	assert.Equal(t, module.KusionPathDependency("aws_db_instance_id", "identifier"),
+		resources[4].Attributes["db_instance_identifier"])
+	assert.Equal(t, []string{"aws_db_instance_id"}, resources[4].DependsOn)
+	assertResolvableKusionPaths(t, resources)
+}
+
+func TestAWSPartition(t *testing.T) {
+	assert.Equal(t, "aws", awsPartition("us-east-1"))
+	assert.Equal(t, "aws-cn", awsPartition("cn-north-1"))
+	assert.Equal(t, "aws-us-gov", awsPartition("us-gov-west-1"))
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+const testOIDCProviderARN = "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+
+func TestParseOIDCProviderARN(t *testing.T) {
+	oidcProvider, ok := parseOIDCProviderARN(testOIDCProviderARN)
+	assert.True(t, ok)
+	assert.Equal(t, &awsOIDCProvider{
+		ARN:       testOIDCProviderARN,
+		Partition: "aws",
+		AccountID: "123456789012",
+		Issuer:    "oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE",
+	}, oidcProvider)
+
+	for _, arn := range []string{
+		"",
+		"arn:aws:iam::123456789012:role/test-role",
+		"arn:aws:iam:::oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE",
+		"arn:aws:iam::123456789012:oidc-provider/",
+	} {
+		_, ok = parseOIDCProviderARN(arn)
+		assert.False(t, ok, arn)
+	}
+}
+
+func TestMySQLModule_ValidateAuth(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name:    "password auth by default",
+			mysql:   &MySQL{Type: "local"},
+			success: true,
+		},
+		{
+			name: "iam auth without binding the service account",
+			mysql: &MySQL{
+				Type:            "cloud",
+				Auth:            "iam",
+				OIDCProviderARN: testOIDCProviderARN,
+				ServiceAccount:  "test-sa",
+				Users:           []DatabaseUser{{Name: "app"}},
+			},
+			success: false,
+		},
+		{
+			name: "iam auth without service account",
+			mysql: &MySQL{
+				Type:            "cloud",
+				Auth:            "iam",
+				OIDCProviderARN: testOIDCProviderARN,
+				Users:           []DatabaseUser{{Name: "app"}},
+			},
+			success: false,
+		},
+		{
+			name: "iam auth with default service account",
+			mysql: &MySQL{
+				Type:            "cloud",
+				Auth:            "iam",
+				OIDCProviderARN: testOIDCProviderARN,
+				ServiceAccount:  "default",
+				Users:           []DatabaseUser{{Name: "app"}},
+			},
+			success: false,
+		},
+		{
+			name:    "unsupported auth",
+			mysql:   &MySQL{Type: "cloud", Auth: "kerberos"},
+			success: false,
+		},
+		{
+			name: "iam auth for local database",
+			mysql: &MySQL{
+				Type:            "local",
+				Auth:            "iam",
+				OIDCProviderARN: testOIDCProviderARN,
+				Users:           []DatabaseUser{{Name: "app"}},
+			},
+			success: false,
+		},
+		{
+			name: "iam auth without application users",
+			mysql: &MySQL{
+				Type:            "cloud",
+				Auth:            "iam",
+				OIDCProviderARN: testOIDCProviderARN,
+			},
+			success: false,
+		},
+		{
+			name: "iam auth without oidc provider",
+			mysql: &MySQL{
+				Type:  "cloud",
+				Auth:  "iam",
+				Users: []DatabaseUser{{Name: "app"}},
+			},
+			success: false,
+		},
+		{
+			name: "iam auth with migration",
+			mysql: &MySQL{
+				Type:            "cloud",
+				Auth:            "iam",
+				OIDCProviderARN: testOIDCProviderARN,
+				Users:           []DatabaseUser{{Name: "app"}},
+				Migration:       &Migration{Image: "flyway/flyway:10"},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateAuth()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidAuthConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateAWSIAMAuthResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:            "cloud",
+		DatabaseName:    "test-database",
+		Auth:            "iam",
+		OIDCProviderARN: testOIDCProviderARN,
+		ServiceAccount:  "test-sa",
+		Users:           []DatabaseUser{{Name: "app"}},
+	}
+
+	resources, envVars, err := mysql.generateAWSIAMAuthResources(r, defaultAWSProviderCfg, "us-east-1", "app",
+		"test-db-instance-id")
+
+	assert.NoError(t, err)
+	// aws_iam_role, aws_iam_role_policy and the ServiceAccount.
+	assert.Equal(t, 3, len(resources))
+	assertResolvableKusionPaths(t, resources)
+	assert.Equal(t, []v1.EnvVar{{Name: "KUSION_DB_REGION_TEST_DATABASE", Value: "us-east-1"}}, envVars)
+
+	var trustPolicy awsIAMPolicyDocument
+	assert.NoError(t, json.Unmarshal([]byte(resources[0].Attributes["assume_role_policy"].(string)), &trustPolicy))
+	assert.Equal(t, map[string]string{"Federated": testOIDCProviderARN}, trustPolicy.Statement[0].Principal)
+	assert.Equal(t, map[string]string{
+		"oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE:aud": "sts.amazonaws.com",
+		"oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE:sub": "system:serviceaccount:test-project:test-sa",
+	}, trustPolicy.Statement[0].Condition["StringEquals"])
+
+	var policy awsIAMPolicyDocument
+	assert.NoError(t, json.Unmarshal([]byte(resources[1].Attributes["policy"].(string)), &policy))
+	assert.Equal(t, "rds-db:connect", policy.Statement[0].Action)
+	assert.Equal(t, "arn:aws:rds-db:us-east-1:123456789012:dbuser:*/app", policy.Statement[0].Resource)
+	assert.Equal(t, module.KusionPathDependency(resources[0].ID, "id"), resources[1].Attributes["role"])
+	assert.Equal(t, []string{"test-db-instance-id"}, resources[1].DependsOn)
+
+	assert.Equal(t, "v1:ServiceAccount:test-project:test-sa", resources[2].ID)
+	metadata := resources[2].Attributes["metadata"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"eks.amazonaws.com/role-arn": module.KusionPathDependency(resources[0].ID, "arn"),
+	}, metadata["annotations"])
+}
+
+func TestMySQLModule_GenerateDBSecretWithIAMAuth(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Auth:         "iam",
+	}
+
+	res, patcher, err := mysql.GenerateDBSecret(r, "test-host-address", "app", "", nil)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, res.Attributes["stringData"], "password")
+	for _, envVar := range patcher.Environments {
+		assert.NotEqual(t, "KUSION_DB_PASSWORD_TEST_DATABASE", envVar.Name)
+	}
+}
@@ -15,6 +15,13 @@ var (
 	awsRegionEnv     = "AWS_REGION"
 	awsSecurityGroup = "aws_security_group"
 	awsDBInstance    = "aws_db_instance"
+
+	awsDBParameterGroup     = "aws_db_parameter_group"
+	awsParameterGroupSuffix = "-parameter-group"
+	awsParameterApplyMethod = "pending-reboot"
+
+	awsFinalSnapshotSuffix     = "-final-snapshot"
+	awsFinalSnapshotByteLength = 4
 )
 
 var defaultAWSProviderCfg = module.ProviderConfig{
@@ -22,6 +29,18 @@ var defaultAWSProviderCfg = module.ProviderConfig{
 	Version: "5.0.1",
 }
 
+type awsDBParameter struct {
+	Name        string `yaml:"name" json:"name"`
+	Value       string `yaml:"value" json:"value"`
+	ApplyMethod string `yaml:"apply_method" json:"apply_method"`
+}
+
+type awsRestoreToPointInTime struct {
+	SourceDBInstanceIdentifier string `yaml:"source_db_instance_identifier" json:"source_db_instance_identifier"`
+	RestoreTime                string `yaml:"restore_time,omitempty" json:"restore_time,omitempty"`
+	UseLatestRestorableTime    bool   `yaml:"use_latest_restorable_time,omitempty" json:"use_latest_restorable_time,omitempty"`
+}
+
 type awsSecurityGroupTraffic struct {
 	CidrBlocks     []string `yaml:"cidr_blocks" json:"cidr_blocks"`
 	Description    string   `yaml:"description" json:"description"`
@@ -50,6 +69,17 @@ func (mysql *MySQL) GenerateAWSResources(request *module.GeneratorRequest) ([]ku
 		return nil, nil, ErrEmptyAWSProviderRegion
 	}
 
+	// The AWS snapshots are identified by themselves without the source instance.
+	if mysql.RestoreFrom != nil && mysql.RestoreFrom.Snapshot != "" && mysql.RestoreFrom.SourceInstance != "" {
+		return nil, nil, fmt.Errorf("%w: sourceInstance should not be specified with the snapshot for aws",
+			ErrInvalidRestoreFrom)
+	}
+
+	// Build the AWS Aurora MySQL cluster instead of the single instance if declared.
+	if mysql.Aurora != nil {
+		return mysql.generateAWSAuroraResources(request, awsProviderCfg, region)
+	}
+
 	// Build random_password resource.
 	randomPasswordRes, randomPasswordID, err := mysql.GenerateTFRandomPassword(request)
 	if err != nil {
@@ -64,24 +94,103 @@ func (mysql *MySQL) GenerateAWSResources(request *module.GeneratorRequest) ([]ku
 	}
 	resources = append(resources, *awsSecurityGroupRes)
 
+	// Build aws_db_parameter_group resource with the engine parameters if declared, which reject the
+	// insecure connections if the TLS connections are enforced.
+	if len(mysql.generateAWSParameters()) > 0 {
+		awsDBParameterGroupRes, _, err := mysql.generateAWSDBParameterGroup(awsProviderCfg, region)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsDBParameterGroupRes)
+	}
+
+	// Build random_id resource as the identifier of the final snapshot unless it is skipped.
+	var finalSnapshotID string
+	if !mysql.SkipFinalSnapshot {
+		finalSnapshotRes, id, err := mysql.generateAWSFinalSnapshotID()
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *finalSnapshotRes)
+		finalSnapshotID = id
+	}
+
 	// Build aws_db_instance resource.
-	awsDBInstance, awsDBInstanceID, err := mysql.generateAWSDBInstance(awsProviderCfg, region, randomPasswordID, awsSecurityGroupID)
+	awsDBInstance, awsDBInstanceID, err := mysql.generateAWSDBInstance(awsProviderCfg, region, randomPasswordID,
+		awsSecurityGroupID, finalSnapshotID)
 	if err != nil {
 		return nil, nil, err
 	}
 	resources = append(resources, *awsDBInstance)
 
 	hostAddress := module.KusionPathDependency(awsDBInstanceID, "address")
-	password := module.KusionPathDependency(randomPasswordID, "result")
+
+	// Build aws_db_instance resources of the read replicas replicating from the source instance.
+	var readHostAddresses []string
+	for i := 0; i < mysql.Replicas; i++ {
+		awsDBReplica, awsDBReplicaID, err := mysql.generateAWSDBReplica(awsProviderCfg, region, awsDBInstanceID, awsSecurityGroupID, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsDBReplica)
+		readHostAddresses = append(readHostAddresses, module.KusionPathDependency(awsDBReplicaID, "address"))
+	}
+
+	// Build the logical databases, application users and grants inside the AWS provided MySQL instance.
+	dbUserResources, username, password, err := mysql.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), []string{awsDBInstanceID})
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// The default logical database is only created by the Job along with the declared databases and
+	// application users, since declaring it on the instance forces the existing one to be replaced.
+	mysql.noDefaultDatabase = len(dbUserResources) == 0
+
+	// Write the credentials into AWS Secrets Manager for the ExternalSecret to sync them into the cluster,
+	// and for AWS RDS Proxy to authenticate the workload with.
+	if mysql.isExternalSecretDelivery() || mysql.Pooler != nil {
+		awsCredentialsStackRes, err := mysql.generateAWSCredentialsStack(request, awsProviderCfg, region, username, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsCredentialsStackRes)
+
+		// Build AWS RDS Proxy in front of the instance for the workload to connect to if declared.
+		if mysql.Pooler != nil {
+			poolerResources, poolerHostAddress, err := mysql.generateAWSDBProxyResources(awsProviderCfg, region,
+				mysql.generateRemoteSecretName(request), awsCredentialsStackRes.ID, awsSecurityGroupID, "db_instance_identifier",
+				module.KusionPathDependency(awsDBInstanceID, "identifier"), []string{awsDBInstanceID})
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, poolerResources...)
+			hostAddress = poolerHostAddress
+		}
+	}
 
 	// Build Kubernetes Secret with the hostAddress, username and password of the AWS provided MySQL instance,
 	// and inject the credentials as the environment variable patcher.
-	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, mysql.Username, password)
+	dbSecret, patcher, err := mysql.generateDBSecret(request, hostAddress, username, password, readHostAddresses,
+		mysql.generateAWSRemoteSecretRef(request))
 	if err != nil {
 		return nil, nil, err
 	}
 	resources = append(resources, *dbSecret)
 
+	// Build the IAM role assumed by the workload with the annotated ServiceAccount for the IAM database
+	// authentication, and inject the region for the workload to generate the authentication tokens.
+	if mysql.isIAMAuth() {
+		iamResources, iamEnvVars, err := mysql.generateAWSIAMAuthResources(request, awsProviderCfg, region, username,
+			awsDBInstanceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, iamResources...)
+		patcher.Environments = append(patcher.Environments, iamEnvVars...)
+	}
+
 	return resources, patcher, nil
 }
 
@@ -108,12 +217,23 @@ func (mysql *MySQL) generateAWSSecurityGroup(awsProviderCfg module.ProviderConfi
 			{
 				CidrBlocks: mysql.SecurityIPs,
 				Protocol:   "tcp",
-				FromPort:   3306,
-				ToPort:     3306,
+				FromPort:   dbPort,
+				ToPort:     dbPort,
 			},
 		},
 	}
 
+	// AWS RDS Proxy shares the security group with the instance, which connects to the instance within it.
+	if mysql.Pooler != nil {
+		resAttrs["ingress"] = append(resAttrs["ingress"].([]awsSecurityGroupTraffic), awsSecurityGroupTraffic{
+			Description: "AWS RDS Proxy",
+			Protocol:    "tcp",
+			FromPort:    dbPort,
+			ToPort:      dbPort,
+			Self:        true,
+		})
+	}
+
 	id, err := module.TerraformResourceID(awsProviderCfg, awsSecurityGroup, mysql.DatabaseName+dbResSuffix)
 	if err != nil {
 		return nil, "", err
@@ -128,27 +248,116 @@ func (mysql *MySQL) generateAWSSecurityGroup(awsProviderCfg module.ProviderConfi
 	return resource, id, nil
 }
 
+// generateAWSFinalSnapshotID generates the terraform random_id resource as the identifier of the final
+// snapshot of the AWS provided MySQL instance or Aurora cluster, which is suffixed with the random bytes
+// so that it does not collide with the final snapshot of the former instance with the same identifier.
+// The identifier is regenerated once the instance is replaced by the other identifier or restore source.
+func (mysql *MySQL) generateAWSFinalSnapshotID() (*kusionapiv1.Resource, string, error) {
+	keepers := map[string]string{
+		"identifier": mysql.DatabaseName,
+	}
+	if mysql.RestoreFrom != nil {
+		keepers["restore_snapshot"] = mysql.RestoreFrom.Snapshot
+		keepers["restore_source_instance"] = mysql.RestoreFrom.SourceInstance
+	}
+
+	resAttrs := map[string]any{
+		"byte_length": awsFinalSnapshotByteLength,
+		"prefix":      mysql.DatabaseName + awsFinalSnapshotSuffix + "-",
+		"keepers":     keepers,
+	}
+
+	// Set the random_id provider with the default provider config.
+	randomIDProvider := defaultRandomProviderCfg
+
+	id, err := module.TerraformResourceID(randomIDProvider, randomID, mysql.DatabaseName+awsFinalSnapshotSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resource, err := module.WrapTFResourceToKusionResource(randomIDProvider, randomID, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
 // generateAWSDBInstance generates aws_db_instance resource for the AWS provided MySQL database instance.
-func (mysql *MySQL) generateAWSDBInstance(awsProviderCfg module.ProviderConfig, region, randomPasswordID, awsSecurityGroupID string) (*kusionapiv1.Resource, string, error) {
+func (mysql *MySQL) generateAWSDBInstance(awsProviderCfg module.ProviderConfig, region, randomPasswordID, awsSecurityGroupID,
+	finalSnapshotID string,
+) (*kusionapiv1.Resource, string, error) {
 	resAttrs := map[string]interface{}{
-		"allocated_storage":   mysql.Size,
-		"engine":              dbEngine,
-		"engine_version":      mysql.Version,
-		"identifier":          mysql.DatabaseName,
-		"instance_class":      mysql.InstanceType,
-		"password":            module.KusionPathDependency(randomPasswordID, "result"),
-		"publicly_accessible": IsPublicAccessible(mysql.SecurityIPs),
-		"skip_final_snapshot": true,
-		"username":            mysql.Username,
+		"allocated_storage":          mysql.Size,
+		"auto_minor_version_upgrade": mysql.AutoMinorVersionUpgrade,
+		"backup_retention_period":    mysql.BackupRetentionPeriod,
+		"deletion_protection":        mysql.DeletionProtection,
+		"engine":                     dbEngine,
+		"engine_version":             mysql.Version,
+		"identifier":                 mysql.DatabaseName,
+		"instance_class":             mysql.InstanceType,
+		"multi_az":                   mysql.MultiAZ,
+		"password":                   module.KusionPathDependency(randomPasswordID, "result"),
+		"publicly_accessible":        IsPublicAccessible(mysql.SecurityIPs),
+		"skip_final_snapshot":        mysql.SkipFinalSnapshot,
+		"username":                   mysql.Username,
 		"vpc_security_group_ids": []string{
 			module.KusionPathDependency(awsSecurityGroupID, "id"),
 		},
 	}
 
+	// A final snapshot is created before the instance is deleted unless it is skipped explicitly.
+	if !mysql.SkipFinalSnapshot {
+		resAttrs["final_snapshot_identifier"] = module.KusionPathDependency(finalSnapshotID, "hex")
+	}
+
+	if mysql.BackupWindow != "" {
+		resAttrs["backup_window"] = mysql.BackupWindow
+	}
+
+	if mysql.MaintenanceWindow != "" {
+		resAttrs["maintenance_window"] = mysql.MaintenanceWindow
+	}
+
+	if mysql.StorageType != "" {
+		resAttrs["storage_type"] = mysql.StorageType
+	}
+
+	if mysql.Iops > 0 {
+		resAttrs["iops"] = mysql.Iops
+	}
+
 	if mysql.SubnetID != "" {
 		resAttrs["db_subnet_group_name"] = mysql.SubnetID
 	}
 
+	if mysql.isIAMAuth() {
+		resAttrs["iam_database_authentication_enabled"] = true
+	}
+
+	// The restored instance inherits the master username and the databases from the source, while
+	// the password is reset to the generated one.
+	if restoreFrom := mysql.RestoreFrom; restoreFrom != nil {
+		delete(resAttrs, "username")
+
+		if restoreFrom.Snapshot != "" {
+			resAttrs["snapshot_identifier"] = restoreFrom.Snapshot
+		} else {
+			restoreToPointInTime := awsRestoreToPointInTime{
+				SourceDBInstanceIdentifier: restoreFrom.SourceInstance,
+				UseLatestRestorableTime:    restoreFrom.UseLatestRestorableTime,
+			}
+			if restoreFrom.RestoreTime != "" {
+				restoreToPointInTime.RestoreTime = restoreFrom.generateRestoreTime()
+			}
+			resAttrs["restore_to_point_in_time"] = []awsRestoreToPointInTime{restoreToPointInTime}
+		}
+	}
+
+	if err := mysql.setAWSDBParameterGroupName(awsProviderCfg, resAttrs); err != nil {
+		return nil, "", err
+	}
+
 	id, err := module.TerraformResourceID(awsProviderCfg, awsDBInstance, mysql.DatabaseName)
 	if err != nil {
 		return nil, "", err
@@ -162,3 +371,90 @@ func (mysql *MySQL) generateAWSDBInstance(awsProviderCfg module.ProviderConfig,
 
 	return resource, id, nil
 }
+
+// generateAWSDBReplica generates aws_db_instance resource for the read replica of the AWS provided MySQL
+// database instance, which inherits the engine, storage and credentials from the source instance.
+func (mysql *MySQL) generateAWSDBReplica(awsProviderCfg module.ProviderConfig, region, awsDBInstanceID, awsSecurityGroupID string, index int) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"auto_minor_version_upgrade": mysql.AutoMinorVersionUpgrade,
+		"deletion_protection":        mysql.DeletionProtection,
+		"identifier":                 mysql.generateReplicaName(index),
+		"instance_class":             mysql.InstanceType,
+		"publicly_accessible":        IsPublicAccessible(mysql.SecurityIPs),
+		"replicate_source_db":        module.KusionPathDependency(awsDBInstanceID, "identifier"),
+		"skip_final_snapshot":        true,
+		"vpc_security_group_ids": []string{
+			module.KusionPathDependency(awsSecurityGroupID, "id"),
+		},
+	}
+
+	if mysql.isIAMAuth() {
+		resAttrs["iam_database_authentication_enabled"] = true
+	}
+
+	if err := mysql.setAWSDBParameterGroupName(awsProviderCfg, resAttrs); err != nil {
+		return nil, "", err
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBInstance, mysql.generateReplicaName(index))
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBInstance, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSDBParameterGroup generates aws_db_parameter_group resource with the engine parameters
+// for the AWS provided MySQL database instance. The parameters are applied after the reboot of the
+// instance, which is accepted by both the static and dynamic parameters.
+func (mysql *MySQL) generateAWSDBParameterGroup(awsProviderCfg module.ProviderConfig, region string) (*kusionapiv1.Resource, string, error) {
+	var parameters []awsDBParameter
+	for _, parameter := range mysql.generateAWSParameters() {
+		parameters = append(parameters, awsDBParameter{
+			Name:        parameter.Name,
+			Value:       parameter.Value,
+			ApplyMethod: awsParameterApplyMethod,
+		})
+	}
+
+	resAttrs := map[string]interface{}{
+		"family":    mysql.generateParameterGroupFamily(dbEngine),
+		"name":      mysql.DatabaseName + awsParameterGroupSuffix,
+		"parameter": parameters,
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBParameterGroup, mysql.DatabaseName+awsParameterGroupSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBParameterGroup, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// setAWSDBParameterGroupName sets the name of the parameter group as the dependency of the AWS
+// provided MySQL database instance if the engine parameters are declared.
+func (mysql *MySQL) setAWSDBParameterGroupName(awsProviderCfg module.ProviderConfig, resAttrs map[string]interface{}) error {
+	if len(mysql.generateAWSParameters()) == 0 {
+		return nil
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBParameterGroup, mysql.DatabaseName+awsParameterGroupSuffix)
+	if err != nil {
+		return err
+	}
+	resAttrs["parameter_group_name"] = module.KusionPathDependency(id, "name")
+
+	return nil
+}
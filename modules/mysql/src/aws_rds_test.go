@@ -38,7 +38,7 @@ func TestMySQLModule_GenerateAWSResources(t *testing.T) {
 
 		resources, patchers, err := mysql.GenerateAWSResources(r)
 
-		assert.Equal(t, 4, len(resources))
+		assert.Equal(t, 5, len(resources))
 		assert.NotNil(t, patchers)
 		assert.NoError(t, err)
 	})
@@ -64,23 +64,315 @@ func TestMySQLModule_GenerateAWSSecurityGroup(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMySQLModule_GenerateAWSFinalSnapshotID(t *testing.T) {
+	t.Run("suffixed final snapshot identifier", func(t *testing.T) {
+		mysql := &MySQL{DatabaseName: "test-database"}
+
+		res, id, err := mysql.generateAWSFinalSnapshotID()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hashicorp:random:random_id:test-database-final-snapshot", id)
+		assert.Equal(t, "test-database-final-snapshot-", res.Attributes["prefix"])
+		assert.Equal(t, awsFinalSnapshotByteLength, res.Attributes["byte_length"])
+		assert.Equal(t, map[string]string{"identifier": "test-database"}, res.Attributes["keepers"])
+	})
+
+	t.Run("regenerated by restore source", func(t *testing.T) {
+		mysql := &MySQL{
+			DatabaseName: "test-database",
+			RestoreFrom:  &RestoreFrom{Snapshot: "test-snapshot"},
+		}
+
+		res, _, err := mysql.generateAWSFinalSnapshotID()
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"identifier":              "test-database",
+			"restore_snapshot":        "test-snapshot",
+			"restore_source_instance": "",
+		}, res.Attributes["keepers"])
+	})
+}
+
 func TestMySQLModule_GenerateAWSDBInstance(t *testing.T) {
+	t.Run("default production options", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:                    "cloud",
+			Version:                 "8.0",
+			DatabaseName:            "test-database",
+			Username:                defaultUsername,
+			SecurityIPs:             defaultSecurityIPs,
+			Size:                    defaultSize,
+			InstanceType:            "db.t3.micro",
+			BackupRetentionPeriod:   defaultBackupRetentionPeriod,
+			DeletionProtection:      defaultDeletionProtection,
+			AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+		}
+
+		res, id, err := mysql.generateAWSDBInstance(defaultAWSProviderCfg, "test-region",
+			"random_password_id", "aws_security_group_id", "random_id_id")
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "", id)
+		assert.Equal(t, false, res.Attributes["skip_final_snapshot"])
+		assert.Equal(t, module.KusionPathDependency("random_id_id", "hex"), res.Attributes["final_snapshot_identifier"])
+		assert.Equal(t, true, res.Attributes["deletion_protection"])
+		assert.Equal(t, defaultBackupRetentionPeriod, res.Attributes["backup_retention_period"])
+		assert.NotContains(t, res.Attributes, "iops")
+	})
+
+	t.Run("skip final snapshot with provisioned iops", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:              "cloud",
+			Version:           "8.0",
+			DatabaseName:      "test-database",
+			Username:          defaultUsername,
+			SecurityIPs:       defaultSecurityIPs,
+			Size:              defaultSize,
+			InstanceType:      "db.t3.micro",
+			MultiAZ:           true,
+			SkipFinalSnapshot: true,
+			StorageType:       "io1",
+			Iops:              3000,
+		}
+
+		res, _, err := mysql.generateAWSDBInstance(defaultAWSProviderCfg, "test-region",
+			"random_password_id", "aws_security_group_id", "random_id_id")
+
+		assert.NoError(t, err)
+		assert.NotContains(t, res.Attributes, "final_snapshot_identifier")
+		assert.Equal(t, true, res.Attributes["multi_az"])
+		assert.Equal(t, "io1", res.Attributes["storage_type"])
+		assert.Equal(t, 3000, res.Attributes["iops"])
+	})
+}
+
+func TestMySQLModule_GenerateAWSResourcesWithExternalSecret(t *testing.T) {
+	t.Setenv(awsRegionEnv, "test-region")
+
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+	}
+
 	mysql := &MySQL{
-		Type:           "local",
-		Version:        "8.0",
-		DatabaseName:   "test-database",
-		Username:       defaultUsername,
-		Category:       defaultCategory,
-		SecurityIPs:    defaultSecurityIPs,
-		PrivateRouting: false,
-		Size:           defaultSize,
-		InstanceType:   "db.t3.micro",
+		Type:                  "cloud",
+		Version:               "8.0",
+		DatabaseName:          "test-database",
+		Username:              defaultUsername,
+		SecurityIPs:           defaultSecurityIPs,
+		Size:                  defaultSize,
+		InstanceType:          "db.t3.micro",
+		SkipFinalSnapshot:     true,
+		Users:                 []DatabaseUser{{Name: "app"}},
+		BackupRetentionPeriod: defaultBackupRetentionPeriod,
+		CredentialDelivery: &CredentialDelivery{
+			Mode:            "externalSecret",
+			SecretStore:     "aws-secrets-manager",
+			SecretStoreKind: "ClusterSecretStore",
+			RefreshInterval: "1h",
+		},
 	}
 
-	res, id, err := mysql.generateAWSDBInstance(defaultAWSProviderCfg, "test-region",
-		"random_password_id", "aws_security_group_id")
+	resources, _, err := mysql.GenerateAWSResources(r)
+
+	assert.NoError(t, err)
+	assertResolvableKusionPaths(t, resources)
+
+	var stack, externalSecret *kusionapiv1.Resource
+	for i := range resources {
+		switch resources[i].ID {
+		case "hashicorp:aws:aws_cloudformation_stack:test-database":
+			stack = &resources[i]
+		case "external-secrets.io/v1beta1:ExternalSecret:test-project:test-database-mysql":
+			externalSecret = &resources[i]
+		}
+	}
+
+	// The credentials of the application user are written into AWS Secrets Manager by the stack, and
+	// synced by the ExternalSecret with the name of the secret known at generate time.
+	assert.NotNil(t, stack)
+	assert.Equal(t, map[string]any{
+		"Username": "app",
+		"Password": module.KusionPathDependency("hashicorp:random:random_password:test-database-mysql-app", "result"),
+	}, stack.Attributes["parameters"])
+	assert.NotNil(t, externalSecret)
+	assert.Equal(t, []any{
+		map[string]any{
+			"secretKey": "password",
+			"remoteRef": map[string]any{
+				"key":      "test-project/test-database-mysql",
+				"property": "password",
+			},
+		},
+	}, externalSecret.Attributes["spec"].(map[string]any)["data"])
+}
+
+func TestMySQLModule_GenerateAWSResourcesWithDatabases(t *testing.T) {
+	t.Setenv(awsRegionEnv, "test-region")
+
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+	}
+
+	generate := func(databases []string) (*kusionapiv1.Resource, map[string]any) {
+		mysql := &MySQL{
+			Type:                  "cloud",
+			Version:               "8.0",
+			DatabaseName:          "test-database",
+			Username:              defaultUsername,
+			SecurityIPs:           defaultSecurityIPs,
+			Size:                  defaultSize,
+			InstanceType:          "db.t3.micro",
+			SkipFinalSnapshot:     true,
+			Databases:             databases,
+			BackupRetentionPeriod: defaultBackupRetentionPeriod,
+		}
+
+		resources, _, err := mysql.GenerateAWSResources(r)
+		assert.NoError(t, err)
+
+		var instance *kusionapiv1.Resource
+		var stringData map[string]any
+		for i := range resources {
+			switch resources[i].ID {
+			case "hashicorp:aws:aws_db_instance:test-database":
+				instance = &resources[i]
+			case "v1:Secret:test-project:test-database-mysql":
+				stringData = resources[i].Attributes["stringData"].(map[string]any)
+			}
+		}
+
+		return instance, stringData
+	}
+
+	t.Run("without databases", func(t *testing.T) {
+		instance, stringData := generate(nil)
+
+		// The instance is not replaced for the logical database, and the workload connects without it.
+		assert.NotContains(t, instance.Attributes, "db_name")
+		assert.Equal(t, "", stringData["database"])
+	})
+
+	t.Run("with databases", func(t *testing.T) {
+		instance, stringData := generate([]string{"orders"})
+
+		// The declared databases are created by the Job instead of the instance.
+		assert.NotContains(t, instance.Attributes, "db_name")
+		assert.Equal(t, "orders", stringData["database"])
+	})
+}
+
+func TestMySQLModule_GenerateAWSDBReplica(t *testing.T) {
+	mysql := &MySQL{
+		Type:                    "cloud",
+		Version:                 "8.0",
+		DatabaseName:            "test-database",
+		SecurityIPs:             defaultSecurityIPs,
+		InstanceType:            "db.t3.micro",
+		DeletionProtection:      defaultDeletionProtection,
+		AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+		Replicas:                2,
+	}
+
+	res, id, err := mysql.generateAWSDBReplica(defaultAWSProviderCfg, "test-region",
+		"aws_db_instance_id", "aws_security_group_id", 1)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", id)
+	assert.Equal(t, "test-database-replica-1", res.Attributes["identifier"])
+	assert.Equal(t, module.KusionPathDependency("aws_db_instance_id", "identifier"), res.Attributes["replicate_source_db"])
+	assert.Equal(t, true, res.Attributes["skip_final_snapshot"])
+	assert.NotContains(t, res.Attributes, "password")
+}
+
+func TestMySQLModule_GenerateAWSDBInstanceWithRestoreFrom(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		SecurityIPs:  defaultSecurityIPs,
+		Size:         defaultSize,
+		InstanceType: "db.t3.micro",
+	}
+
+	t.Run("restore from snapshot", func(t *testing.T) {
+		mysql.RestoreFrom = &RestoreFrom{Snapshot: "test-snapshot"}
+
+		res, _, err := mysql.generateAWSDBInstance(defaultAWSProviderCfg, "test-region",
+			"random_password_id", "aws_security_group_id", "random_id_id")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-snapshot", res.Attributes["snapshot_identifier"])
+		assert.NotContains(t, res.Attributes, "restore_to_point_in_time")
+		assert.NotContains(t, res.Attributes, "username")
+		assert.NotContains(t, res.Attributes, "db_name")
+		assert.Equal(t, module.KusionPathDependency("random_password_id", "result"), res.Attributes["password"])
+	})
+
+	t.Run("restore to point in time", func(t *testing.T) {
+		mysql.RestoreFrom = &RestoreFrom{SourceInstance: "test-source", RestoreTime: "2024-06-01T16:00:00+08:00"}
+
+		res, _, err := mysql.generateAWSDBInstance(defaultAWSProviderCfg, "test-region",
+			"random_password_id", "aws_security_group_id", "random_id_id")
+
+		assert.NoError(t, err)
+		assert.NotContains(t, res.Attributes, "snapshot_identifier")
+		assert.Equal(t, []awsRestoreToPointInTime{
+			{SourceDBInstanceIdentifier: "test-source", RestoreTime: "2024-06-01T08:00:00Z"},
+		}, res.Attributes["restore_to_point_in_time"])
+	})
+
+	t.Run("snapshot with source instance", func(t *testing.T) {
+		mysql.RestoreFrom = &RestoreFrom{Snapshot: "test-snapshot", SourceInstance: "test-source"}
+		t.Setenv(awsRegionEnv, "test-region")
+
+		_, _, err := mysql.GenerateAWSResources(&module.GeneratorRequest{})
+
+		assert.ErrorIs(t, err, ErrInvalidRestoreFrom)
+	})
+}
+
+func TestMySQLModule_GenerateAWSParameters(t *testing.T) {
+	mysql := &MySQL{}
+	assert.Empty(t, mysql.generateAWSParameters())
+
+	// The enforced TLS connections take precedence without modifying the declared parameters.
+	mysql.TLS = &TLS{}
+	mysql.Parameters = map[string]string{"require_secure_transport": "0", "max_connections": "200"}
+	assert.Equal(t, []Parameter{
+		{Name: "max_connections", Value: "200"},
+		{Name: "require_secure_transport", Value: "1"},
+	}, mysql.generateAWSParameters())
+	assert.Equal(t, map[string]string{"require_secure_transport": "0", "max_connections": "200"}, mysql.Parameters)
+}
+
+func TestMySQLModule_GenerateAWSDBParameterGroup(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		InstanceType: "db.t3.micro",
+		Parameters: map[string]string{
+			"max_connections": "500",
+		},
+	}
+
+	res, id, err := mysql.generateAWSDBParameterGroup(defaultAWSProviderCfg, "test-region")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql8.0", res.Attributes["family"])
+	assert.Equal(t, []awsDBParameter{
+		{Name: "max_connections", Value: "500", ApplyMethod: "pending-reboot"},
+	}, res.Attributes["parameter"])
+
+	instance, _, err := mysql.generateAWSDBInstance(defaultAWSProviderCfg, "test-region",
+		"random_password_id", "aws_security_group_id", "random_id_id")
 
-	assert.NotNil(t, res)
-	assert.NotEqual(t, id, "")
 	assert.NoError(t, err)
+	assert.Equal(t, module.KusionPathDependency(id, "name"), instance.Attributes["parameter_group_name"])
 }
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrEmptyAzureProviderLocation       = errors.New("empty azure provider location")
+	ErrEmptyAzureResourceGroup          = errors.New("empty resource group for azure mysql flexible server")
+	ErrEmptyAzureSubnetForPrivateAccess = errors.New("empty subnetID for azure mysql flexible server with private routing")
+	ErrReservedAzureAdministratorLogin  = errors.New("reserved administrator login for azure mysql flexible server")
+)
+
+var (
+	azureLocationEnv                   = "AZURE_REGION"
+	azureMySQLFlexibleServer           = "azurerm_mysql_flexible_server"
+	azureMySQLFlexibleServerFirewall   = "azurerm_mysql_flexible_server_firewall_rule"
+	azureMySQLFlexibleDatabase         = "azurerm_mysql_flexible_database"
+	azureManagementLock                = "azurerm_management_lock"
+	azureMySQLFlexibleServerMinStorage = 20
+
+	azureDeletionLockSuffix = "-deletion-lock"
+	azureDeletionLockLevel  = "CanNotDelete"
+)
+
+// azureReservedAdministratorLogins are the administrator logins not allowed by
+// the Azure Database flexible server.
+var azureReservedAdministratorLogins = []string{
+	"azure_superuser", "azure_pg_admin", "admin", "administrator", "root", "guest", "public",
+}
+
+// defaultAzureAdministratorLogin is the default administrator login of the Azure provided MySQL
+// instance, as the default username "root" is reserved by the Azure Database flexible server.
+var defaultAzureAdministratorLogin = "kusion_admin"
+
+var defaultAzureProviderCfg = module.ProviderConfig{
+	Source:  "hashicorp/azurerm",
+	Version: "3.116.0",
+}
+
+type azureMySQLStorage struct {
+	SizeGB int `yaml:"size_gb" json:"size_gb"`
+}
+
+// GenerateAzureResources generates the Azure provided MySQL flexible server.
+func (mysql *MySQL) GenerateAzureResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
+	var resources []kusionapiv1.Resource
+
+	// The read replicas of the Azure provided MySQL instance are not supported yet.
+	if mysql.Replicas > 0 {
+		return nil, nil, ErrUnsupportedReplicas
+	}
+
+	// The engine parameters of the Azure provided MySQL instance are not supported yet.
+	if len(mysql.Parameters) > 0 {
+		return nil, nil, ErrUnsupportedParameters
+	}
+
+	// Restoring the Azure provided MySQL instance is not supported yet.
+	if mysql.RestoreFrom != nil {
+		return nil, nil, ErrUnsupportedRestoreFrom
+	}
+
+	// Enforcing the TLS connections to the Azure provided MySQL instance is not supported yet.
+	if mysql.TLS != nil {
+		return nil, nil, ErrUnsupportedTLS
+	}
+
+	// The IAM database authentication is only supported by the AWS provided MySQL instance.
+	if mysql.isIAMAuth() {
+		return nil, nil, ErrUnsupportedIAMAuth
+	}
+
+	// The pooler is only supported by the local and the AWS provided MySQL instance yet.
+	if mysql.Pooler != nil {
+		return nil, nil, ErrUnsupportedPooler
+	}
+
+	// Delivering the password of the Azure provided MySQL instance with the ExternalSecret is not supported yet.
+	if mysql.isExternalSecretDelivery() {
+		return nil, nil, ErrUnsupportedCredentialDelivery
+	}
+
+	// Set the Azure provider with the default provider config.
+	azureProviderCfg := defaultAzureProviderCfg
+
+	// Get the Azure location of the flexible server, which should not be empty.
+	var location string
+	if location = module.TerraformProviderRegion(azureProviderCfg); location == "" {
+		location = os.Getenv(azureLocationEnv)
+	}
+	if location == "" {
+		return nil, nil, ErrEmptyAzureProviderLocation
+	}
+
+	if mysql.ResourceGroup == "" {
+		return nil, nil, ErrEmptyAzureResourceGroup
+	}
+
+	// Build random_password resource.
+	randomPasswordRes, randomPasswordID, err := mysql.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build azurerm_mysql_flexible_server resource.
+	azureFlexibleServerRes, azureFlexibleServerID, err := mysql.generateAzureFlexibleServer(
+		azureProviderCfg, location, randomPasswordID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *azureFlexibleServerRes)
+
+	// Build azurerm_mysql_flexible_database resource.
+	azureFlexibleDatabaseRes, err := mysql.generateAzureFlexibleDatabase(azureProviderCfg, azureFlexibleServerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *azureFlexibleDatabaseRes)
+	dependsOn := []string{azureFlexibleServerID, azureFlexibleDatabaseRes.ID}
+
+	// Build azurerm_mysql_flexible_server_firewall_rule resources for the flexible server
+	// with public access, the server in the delegated subnet is only accessible inside the
+	// virtual network.
+	if !mysql.PrivateRouting {
+		azureFirewallRules, err := mysql.generateAzureFirewallRules(azureProviderCfg, azureFlexibleServerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, azureFirewallRules...)
+		for _, rule := range azureFirewallRules {
+			dependsOn = append(dependsOn, rule.ID)
+		}
+	}
+
+	// Build azurerm_management_lock resource protecting the flexible server from being deleted if declared.
+	if mysql.DeletionProtection {
+		azureDeletionLockRes, err := mysql.generateAzureDeletionLock(azureProviderCfg, azureFlexibleServerID, dependsOn)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *azureDeletionLockRes)
+	}
+
+	hostAddress := module.KusionPathDependency(azureFlexibleServerID, "fqdn")
+
+	// Build the logical databases, application users and grants inside the Azure provided MySQL instance.
+	dbUserResources, username, password, err := mysql.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), dependsOn)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// Build Kubernetes Secret with the hostAddress, username and password of the Azure provided MySQL instance,
+	// and inject the credentials as the environment variable patcher.
+	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, username, password, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *dbSecret)
+
+	return resources, patcher, nil
+}
+
+// generateAzureFlexibleServer generates azurerm_mysql_flexible_server resource
+// for the Azure provided MySQL database instance.
+func (mysql *MySQL) generateAzureFlexibleServer(azureProviderCfg module.ProviderConfig,
+	location, randomPasswordID string,
+) (*kusionapiv1.Resource, string, error) {
+	for _, login := range azureReservedAdministratorLogins {
+		if mysql.Username == login {
+			return nil, "", fmt.Errorf("%w: %s", ErrReservedAzureAdministratorLogin, mysql.Username)
+		}
+	}
+
+	// The delegated subnet is required for the workload to connect with the flexible
+	// server via the private network.
+	if mysql.PrivateRouting && mysql.SubnetID == "" {
+		return nil, "", ErrEmptyAzureSubnetForPrivateAccess
+	}
+
+	// The storage size of the Azure MySQL flexible server should not be less than 20 GB.
+	size := mysql.Size
+	if size < azureMySQLFlexibleServerMinStorage {
+		size = azureMySQLFlexibleServerMinStorage
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":                   mysql.DatabaseName,
+		"resource_group_name":    mysql.ResourceGroup,
+		"location":               location,
+		"administrator_login":    mysql.Username,
+		"administrator_password": module.KusionPathDependency(randomPasswordID, "result"),
+		"sku_name":               mysql.InstanceType,
+		"version":                azureMySQLVersion(mysql.Version),
+		"storage": []azureMySQLStorage{
+			{
+				SizeGB: size,
+			},
+		},
+	}
+
+	if mysql.PrivateRouting {
+		resAttrs["delegated_subnet_id"] = mysql.SubnetID
+		if mysql.PrivateDNSZoneID != "" {
+			resAttrs["private_dns_zone_id"] = mysql.PrivateDNSZoneID
+		}
+	}
+
+	id, err := module.TerraformResourceID(azureProviderCfg, azureMySQLFlexibleServer, mysql.DatabaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azureMySQLFlexibleServer, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAzureFlexibleDatabase generates azurerm_mysql_flexible_database resource
+// for the Azure provided MySQL database instance.
+func (mysql *MySQL) generateAzureFlexibleDatabase(azureProviderCfg module.ProviderConfig,
+	flexibleServerID string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"name":                mysql.generateLogicalDBName(),
+		"resource_group_name": mysql.ResourceGroup,
+		"server_name":         module.KusionPathDependency(flexibleServerID, "name"),
+		"charset":             "utf8mb4",
+		"collation":           "utf8mb4_unicode_ci",
+	}
+
+	id, err := module.TerraformResourceID(azureProviderCfg, azureMySQLFlexibleDatabase, mysql.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azureMySQLFlexibleDatabase, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateAzureDeletionLock generates azurerm_management_lock resource protecting the Azure provided
+// MySQL flexible server and its child resources from being deleted, as the flexible server has no
+// deletion protection on its own. The lock depends on the child resources for it to be removed first
+// once the flexible server is destroyed by Kusion.
+func (mysql *MySQL) generateAzureDeletionLock(azureProviderCfg module.ProviderConfig,
+	flexibleServerID string, dependsOn []string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"name":       mysql.DatabaseName + azureDeletionLockSuffix,
+		"scope":      module.KusionPathDependency(flexibleServerID, "id"),
+		"lock_level": azureDeletionLockLevel,
+		"notes":      fmt.Sprintf("The mysql instance %s is protected from being deleted", mysql.DatabaseName),
+	}
+
+	id, err := module.TerraformResourceID(azureProviderCfg, azureManagementLock, mysql.DatabaseName+azureDeletionLockSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azureManagementLock, id, resAttrs,
+		append([]string(nil), dependsOn...))
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateAzureFirewallRules generates azurerm_mysql_flexible_server_firewall_rule resources
+// for the Azure provided MySQL database instance according to the securityIPs.
+func (mysql *MySQL) generateAzureFirewallRules(azureProviderCfg module.ProviderConfig,
+	flexibleServerID string,
+) ([]kusionapiv1.Resource, error) {
+	var resources []kusionapiv1.Resource
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	for i, ip := range mysql.SecurityIPs {
+		startIP, endIP, err := IPRange(ip)
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("%s-%d", mysql.DatabaseName, i)
+		resAttrs := map[string]interface{}{
+			"name":                name,
+			"resource_group_name": mysql.ResourceGroup,
+			"server_name":         module.KusionPathDependency(flexibleServerID, "name"),
+			"start_ip_address":    startIP,
+			"end_ip_address":      endIP,
+		}
+
+		id, err := module.TerraformResourceID(azureProviderCfg, azureMySQLFlexibleServerFirewall, name)
+		if err != nil {
+			return nil, err
+		}
+
+		resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azureMySQLFlexibleServerFirewall, id, resAttrs, nil)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *resource)
+	}
+
+	return resources, nil
+}
+
+// azureMySQLVersion returns the version of the Azure MySQL flexible server,
+// which only accepts "5.7" and "8.0.21".
+func azureMySQLVersion(version string) string {
+	if version == "8.0" {
+		return "8.0.21"
+	}
+
+	return version
+}
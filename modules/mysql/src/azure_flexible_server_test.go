@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestMySQLModule_GenerateAzureResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	t.Run("empty resource group", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			SecurityIPs:    defaultSecurityIPs,
+			PrivateRouting: false,
+			Size:           defaultSize,
+			InstanceType:   "B_Standard_B1s",
+		}
+
+		mockey.PatchConvey("set azure region env", t, func() {
+			mockey.Mock(os.Getenv).Return("test-region").Build()
+
+			resources, patchers, err := mysql.GenerateAzureResources(r)
+
+			assert.Nil(t, resources)
+			assert.Nil(t, patchers)
+			assert.ErrorIs(t, err, ErrEmptyAzureResourceGroup)
+		})
+	})
+
+	t.Run("public access with firewall rules", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			SecurityIPs:    []string{"0.0.0.0/0", "172.16.0.1"},
+			PrivateRouting: false,
+			Size:           defaultSize,
+			InstanceType:   "B_Standard_B1s",
+			ResourceGroup:  "test-resource-group",
+		}
+
+		mockey.PatchConvey("set azure region env", t, func() {
+			mockey.Mock(os.Getenv).Return("test-region").Build()
+
+			resources, patchers, err := mysql.GenerateAzureResources(r)
+
+			assert.Equal(t, 6, len(resources))
+			assert.NotNil(t, patchers)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestMySQLModule_GenerateAzureFlexibleServer(t *testing.T) {
+	t.Run("reserved administrator login", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:          "cloud",
+			Version:       "8.0",
+			DatabaseName:  "test-database",
+			Username:      "root",
+			InstanceType:  "B_Standard_B1s",
+			ResourceGroup: "test-resource-group",
+		}
+
+		res, id, err := mysql.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.Nil(t, res)
+		assert.Equal(t, id, "")
+		assert.ErrorIs(t, err, ErrReservedAzureAdministratorLogin)
+	})
+
+	t.Run("default administrator login", func(t *testing.T) {
+		mysql := &MySQL{}
+		err := mysql.GetCompleteConfig(kusionapiv1.Accessory{
+			"type":    "cloud",
+			"version": "8.0",
+		}, kusionapiv1.GenericConfig{
+			"cloud":          "azure",
+			"instanceType":   "B_Standard_B1s",
+			"resourceGroup":  "test-resource-group",
+			"privateRouting": false,
+		})
+		assert.NoError(t, err)
+		mysql.DatabaseName = "test-database"
+
+		res, _, err := mysql.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "kusion_admin", res.Attributes["administrator_login"])
+	})
+
+	t.Run("private routing without delegated subnet", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			PrivateRouting: true,
+			InstanceType:   "B_Standard_B1s",
+			ResourceGroup:  "test-resource-group",
+		}
+
+		res, id, err := mysql.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.Nil(t, res)
+		assert.Equal(t, id, "")
+		assert.ErrorIs(t, err, ErrEmptyAzureSubnetForPrivateAccess)
+	})
+
+	t.Run("private routing with delegated subnet", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			PrivateRouting: true,
+			Size:           defaultSize,
+			InstanceType:   "B_Standard_B1s",
+			SubnetID:       "test-subnet-id",
+			ResourceGroup:  "test-resource-group",
+		}
+
+		res, id, err := mysql.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.NotNil(t, res)
+		assert.NotEqual(t, id, "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestMySQLModule_GenerateAzureFirewallRules(t *testing.T) {
+	mysql := &MySQL{
+		Type:          "cloud",
+		Version:       "8.0",
+		DatabaseName:  "test-database",
+		Username:      "kusion_admin",
+		SecurityIPs:   []string{"172.16.0.0/24", "illegal-ip"},
+		ResourceGroup: "test-resource-group",
+	}
+
+	res, err := mysql.generateAzureFirewallRules(defaultAzureProviderCfg, "flexible_server_id")
+
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, "illegal security ip format")
+}
+
+func TestMySQLModule_GenerateAzureDeletionLock(t *testing.T) {
+	mysql := &MySQL{
+		Type:               "cloud",
+		Version:            "8.0",
+		DatabaseName:       "test-database",
+		ResourceGroup:      "test-resource-group",
+		DeletionProtection: true,
+	}
+
+	res, err := mysql.generateAzureDeletionLock(defaultAzureProviderCfg, "flexible_server_id",
+		[]string{"flexible_server_id", "flexible_database_id"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hashicorp:azurerm:azurerm_management_lock:test-database-deletion-lock", res.ID)
+	assert.Equal(t, module.KusionPathDependency("flexible_server_id", "id"), res.Attributes["scope"])
+	assert.Equal(t, "CanNotDelete", res.Attributes["lock_level"])
+	assert.Equal(t, []string{"flexible_server_id", "flexible_database_id"}, res.DependsOn)
+}
+
+func TestMySQLModule_GenerateAzureFlexibleDatabase(t *testing.T) {
+	mysql := &MySQL{
+		Type:          "cloud",
+		Version:       "8.0",
+		DatabaseName:  "test-database",
+		ResourceGroup: "test-resource-group",
+	}
+
+	res, err := mysql.generateAzureFlexibleDatabase(defaultAzureProviderCfg, "flexible_server_id")
+
+	assert.NotNil(t, res)
+	assert.Equal(t, "test_database", res.Attributes["name"])
+	assert.NoError(t, err)
+}
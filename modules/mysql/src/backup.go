@@ -0,0 +1,391 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidBackupConfig = errors.New("invalid backup config in mysql module config")
+
+var (
+	backupSuffix              = "-db-backup"
+	backupVolume              = "backup"
+	backupPath                = "/backup"
+	backupBackoffLimit        = int32(1)
+	backupDumpContainerName   = "dump"
+	backupUploadContainerName = "upload"
+	defaultBackupRetention    = 7
+	defaultBackupUploadImage  = "amazon/aws-cli:2.17.0"
+)
+
+// Backup describes the Kubernetes CronJob dumping the logical databases of the local MySQL instance
+// on schedule, which are kept either in the backup PVC or in the S3 compatible object storage.
+type Backup struct {
+	// The schedule of the backups in the cron format, such as "0 3 * * *".
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	// The days to keep the backups, after which they are deleted.
+	Retention int `json:"retention,omitempty" yaml:"retention,omitempty"`
+	// The storage size of the backup PVC in Gi, which defaults to the size of the instance.
+	Size int `json:"size,omitempty" yaml:"size,omitempty"`
+	// The storage class of the backup PVC, which defaults to the one of the instance.
+	StorageClass string `json:"storageClass,omitempty" yaml:"storageClass,omitempty"`
+	// The S3 compatible object storage the backups are uploaded to instead of the backup PVC.
+	S3 *BackupS3 `json:"s3,omitempty" yaml:"s3,omitempty"`
+}
+
+// BackupS3 describes the S3 compatible object storage the backups are uploaded to.
+type BackupS3 struct {
+	// The endpoint of the S3 compatible object storage, which defaults to AWS S3.
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	// The bucket the backups are uploaded to.
+	Bucket string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	// The key prefix of the backups, which defaults to the name of the instance.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// The region of the bucket.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// The name of the existing Kubernetes Secret holding AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	CredentialsSecret string `json:"credentialsSecret,omitempty" yaml:"credentialsSecret,omitempty"`
+}
+
+// parseBackup parses the backup block of the devConfig.
+func parseBackup(config any) (*Backup, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidBackupConfig, config)
+	}
+
+	backup := &Backup{Retention: defaultBackupRetention}
+	if err := parseConfigBlock(configMap, ErrInvalidBackupConfig, "", map[string]configValueParser{
+		"schedule":     configValue(&backup.Schedule, toConfigString),
+		"retention":    configValue(&backup.Retention, toConfigInt),
+		"size":         configValue(&backup.Size, toConfigInt),
+		"storageClass": configValue(&backup.StorageClass, toConfigString),
+		"s3": func(value any) (err error) {
+			backup.S3, err = parseBackupS3(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// parseBackupS3 parses the s3 block of the backup.
+func parseBackupS3(config any) (*BackupS3, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map of s3 but got %T", ErrInvalidBackupConfig, config)
+	}
+
+	s3 := &BackupS3{}
+	if err := parseConfigBlock(configMap, ErrInvalidBackupConfig, "s3.", map[string]configValueParser{
+		"endpoint":          configValue(&s3.Endpoint, toConfigString),
+		"bucket":            configValue(&s3.Bucket, toConfigString),
+		"prefix":            configValue(&s3.Prefix, toConfigString),
+		"region":            configValue(&s3.Region, toConfigString),
+		"credentialsSecret": configValue(&s3.CredentialsSecret, toConfigString),
+	}); err != nil {
+		return nil, err
+	}
+
+	return s3, nil
+}
+
+// validateBackup validates whether the backups of the local MySQL instance are scheduled with the
+// retention, and kept in either the backup PVC or the bucket with the credentials.
+func (mysql *MySQL) validateBackup() error {
+	backup := mysql.Backup
+	if backup == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(mysql.Type, LocalDBType) {
+		return fmt.Errorf("%w: only supported by the %s type", ErrInvalidBackupConfig, LocalDBType)
+	}
+
+	if !isValidBackupSchedule(backup.Schedule) {
+		return fmt.Errorf("%w: invalid schedule %q", ErrInvalidBackupConfig, backup.Schedule)
+	}
+
+	if backup.Retention <= 0 {
+		return fmt.Errorf("%w: retention should be positive", ErrInvalidBackupConfig)
+	}
+
+	if backup.Size < 0 {
+		return fmt.Errorf("%w: size should not be negative", ErrInvalidBackupConfig)
+	}
+
+	if s3 := backup.S3; s3 != nil {
+		if backup.Size > 0 || backup.StorageClass != "" {
+			return fmt.Errorf("%w: size and storageClass of the backup PVC cannot be specified with s3",
+				ErrInvalidBackupConfig)
+		}
+		if s3.Bucket == "" || s3.CredentialsSecret == "" {
+			return fmt.Errorf("%w: bucket and credentialsSecret of s3 should be specified", ErrInvalidBackupConfig)
+		}
+	}
+
+	return nil
+}
+
+// isValidBackupSchedule returns whether the schedule is either the five cron fields or a macro such
+// as "@daily".
+func isValidBackupSchedule(schedule string) bool {
+	fields := strings.Fields(schedule)
+
+	return len(fields) == 5 || (len(fields) == 1 && strings.HasPrefix(fields[0], "@"))
+}
+
+// generateLocalBackupResources generates the Kubernetes CronJob resource dumping the local MySQL
+// instance on schedule, along with the backup PVC if the backups are not uploaded to S3.
+func (mysql *MySQL) generateLocalBackupResources(request *module.GeneratorRequest, hostAddress string) (
+	[]kusionapiv1.Resource, error,
+) {
+	var resources []kusionapiv1.Resource
+
+	backupVolumeSource := v1.VolumeSource{
+		EmptyDir: &v1.EmptyDirVolumeSource{},
+	}
+	if mysql.Backup.S3 == nil {
+		backupPVC, err := mysql.generateLocalBackupPVC(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *backupPVC)
+
+		backupVolumeSource = v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: mysql.DatabaseName + backupSuffix,
+			},
+		}
+	}
+
+	backupCronJob, err := mysql.generateLocalBackupCronJob(request, hostAddress, backupVolumeSource)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, *backupCronJob)
+
+	return resources, nil
+}
+
+// generateLocalBackupPVC generates the Kubernetes PersistentVolumeClaim resource keeping the backups
+// of the local MySQL instance.
+func (mysql *MySQL) generateLocalBackupPVC(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	size, storageClass := mysql.Backup.Size, mysql.Backup.StorageClass
+	if size == 0 {
+		size = mysql.Size
+	}
+	if storageClass == "" {
+		storageClass = mysql.StorageClass
+	}
+
+	pvc := &v1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + backupSuffix,
+			Namespace: request.Project,
+			Labels:    mysql.generateLocalMatchLabels(),
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{
+				v1.ReadWriteOnce,
+			},
+			Resources: v1.VolumeResourceRequirements{
+				Requests: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceStorage: resource.MustParse(strconv.Itoa(size) + "Gi"),
+				},
+			},
+		},
+	}
+
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+
+	resourceID := module.KubernetesResourceID(pvc.TypeMeta, pvc.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, pvc)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateLocalBackupCronJob generates the Kubernetes CronJob resource dumping the local MySQL instance
+// into the backup volume. The dumps are pruned in the backup PVC directly, or uploaded to S3 and pruned
+// there by the following container.
+func (mysql *MySQL) generateLocalBackupCronJob(request *module.GeneratorRequest, hostAddress string,
+	backupVolumeSource v1.VolumeSource,
+) (*kusionapiv1.Resource, error) {
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      backupVolume,
+			MountPath: backupPath,
+		},
+	}
+
+	dumpScript := mysql.generateBackupDumpScript(hostAddress)
+	if mysql.Backup.S3 == nil {
+		dumpScript += "\n" + mysql.generateBackupPruneScript()
+	}
+
+	dumpContainer := v1.Container{
+		Name:    backupDumpContainerName,
+		Image:   dbEngine + ":" + mysql.Version,
+		Command: []string{"sh", "-c", dumpScript},
+		Env: []v1.EnvVar{
+			{
+				Name: "MYSQL_PWD",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: mysql.DatabaseName + localSecretSuffix,
+						},
+						Key: "password",
+					},
+				},
+			},
+		},
+		VolumeMounts: volumeMounts,
+	}
+
+	podSpec := v1.PodSpec{
+		RestartPolicy: v1.RestartPolicyNever,
+		Containers:    []v1.Container{dumpContainer},
+		Volumes: []v1.Volume{
+			{
+				Name:         backupVolume,
+				VolumeSource: backupVolumeSource,
+			},
+		},
+	}
+
+	// The dump is taken in the init container before being uploaded to S3.
+	if s3 := mysql.Backup.S3; s3 != nil {
+		uploadContainer := v1.Container{
+			Name:    backupUploadContainerName,
+			Image:   defaultBackupUploadImage,
+			Command: []string{"sh", "-c", mysql.generateBackupUploadScript()},
+			EnvFrom: []v1.EnvFromSource{
+				{
+					SecretRef: &v1.SecretEnvSource{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: s3.CredentialsSecret,
+						},
+					},
+				},
+			},
+			VolumeMounts: volumeMounts,
+		}
+		if s3.Region != "" {
+			uploadContainer.Env = []v1.EnvVar{{Name: "AWS_DEFAULT_REGION", Value: s3.Region}}
+		}
+
+		podSpec.InitContainers = []v1.Container{dumpContainer}
+		podSpec.Containers = []v1.Container{uploadContainer}
+	}
+
+	backoffLimit := backupBackoffLimit
+	cronJob := &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJob",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + backupSuffix,
+			Namespace: request.Project,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          mysql.Backup.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: mysql.generateClientMatchLabels(),
+						},
+						Spec: podSpec,
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(cronJob.TypeMeta, cronJob.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, cronJob)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateBackupFilePattern generates the pattern of the backup file names, which are suffixed with
+// the UTC time of the backups.
+func (mysql *MySQL) generateBackupFilePattern() string {
+	return mysql.DatabaseName + "-*.sql.gz"
+}
+
+// generateBackupDumpScript generates the script dumping the logical databases of the local MySQL
+// instance into a compressed file in the backup volume, which can be restored with the mysql client.
+func (mysql *MySQL) generateBackupDumpScript(hostAddress string) string {
+	file := fmt.Sprintf("%s/%s-$(date -u +%%Y%%m%%d%%H%%M%%S).sql", backupPath, mysql.DatabaseName)
+
+	return strings.Join([]string{
+		"set -e",
+		fmt.Sprintf("file=%s", file),
+		fmt.Sprintf("mysqldump --host=%s --port=%d --user=%s --single-transaction --no-tablespaces "+
+			"--result-file=\"$file\" --databases %s", hostAddress, dbPort, mysql.Username,
+			strings.Join(mysql.generateLogicalDBNames(), " ")),
+		"gzip \"$file\"",
+	}, "\n")
+}
+
+// generateBackupPruneScript generates the script deleting the backups older than the retention
+// in the backup PVC.
+func (mysql *MySQL) generateBackupPruneScript() string {
+	return fmt.Sprintf("find %s -name '%s' -mmin +%d -delete", backupPath, mysql.generateBackupFilePattern(),
+		mysql.Backup.Retention*24*60)
+}
+
+// generateBackupUploadScript generates the script uploading the backups to S3 and deleting the ones
+// older than the retention there.
+func (mysql *MySQL) generateBackupUploadScript() string {
+	s3 := mysql.Backup.S3
+
+	prefix := s3.Prefix
+	if prefix == "" {
+		prefix = mysql.DatabaseName
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	var endpoint string
+	if s3.Endpoint != "" {
+		endpoint = " --endpoint-url " + s3.Endpoint
+	}
+
+	return strings.Join([]string{
+		"set -e",
+		fmt.Sprintf("aws s3 cp %s/ s3://%s/%s --recursive --exclude '*' --include '%s'%s", backupPath,
+			s3.Bucket, prefix, mysql.generateBackupFilePattern(), endpoint),
+		fmt.Sprintf("expired=$(date -u -d '-%d days' +%%Y-%%m-%%dT%%H:%%M:%%SZ)", mysql.Backup.Retention),
+		fmt.Sprintf("aws s3api list-objects-v2 --bucket %s --prefix %s --query \"Contents[?LastModified<='$expired'].Key\" "+
+			"--output text%s | tr '\\t' '\\n' | grep -v '^None$' | while read -r key; do "+
+			"if [ -n \"$key\" ]; then aws s3 rm \"s3://%s/$key\"%s; fi; done", s3.Bucket, prefix, endpoint, s3.Bucket, endpoint),
+	}, "\n")
+}
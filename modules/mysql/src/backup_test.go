@@ -0,0 +1,229 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseBackup(t *testing.T) {
+	backup, err := parseBackup(map[string]any{
+		"schedule": "0 3 * * *",
+		"s3": map[string]any{
+			"endpoint":          "https://minio.example.com",
+			"bucket":            "backups",
+			"credentialsSecret": "minio-credentials",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Backup{
+		Schedule:  "0 3 * * *",
+		Retention: 7,
+		S3: &BackupS3{
+			Endpoint:          "https://minio.example.com",
+			Bucket:            "backups",
+			CredentialsSecret: "minio-credentials",
+		},
+	}, backup)
+
+	_, err = parseBackup(map[string]any{"schedule": "@daily", "retention": "14d"})
+	assert.ErrorIs(t, err, ErrInvalidBackupConfig)
+
+	_, err = parseBackup(map[string]any{"s3": map[string]any{"accessKey": "test"}})
+	assert.ErrorIs(t, err, ErrInvalidBackupConfig)
+}
+
+func TestMySQLModule_ValidateBackup(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name: "backup to pvc",
+			mysql: &MySQL{
+				Type:   "local",
+				Backup: &Backup{Schedule: "0 3 * * *", Retention: 7},
+			},
+			success: true,
+		},
+		{
+			name: "backup to s3 with macro schedule",
+			mysql: &MySQL{
+				Type: "local",
+				Backup: &Backup{
+					Schedule:  "@daily",
+					Retention: 7,
+					S3:        &BackupS3{Bucket: "backups", CredentialsSecret: "s3-credentials"},
+				},
+			},
+			success: true,
+		},
+		{
+			name: "backup of cloud database",
+			mysql: &MySQL{
+				Type:   "cloud",
+				Backup: &Backup{Schedule: "0 3 * * *", Retention: 7},
+			},
+			success: false,
+		},
+		{
+			name: "invalid schedule",
+			mysql: &MySQL{
+				Type:   "local",
+				Backup: &Backup{Schedule: "0 3 * *", Retention: 7},
+			},
+			success: false,
+		},
+		{
+			name: "invalid retention",
+			mysql: &MySQL{
+				Type:   "local",
+				Backup: &Backup{Schedule: "0 3 * * *"},
+			},
+			success: false,
+		},
+		{
+			name: "s3 without credentials",
+			mysql: &MySQL{
+				Type: "local",
+				Backup: &Backup{
+					Schedule:  "0 3 * * *",
+					Retention: 7,
+					S3:        &BackupS3{Bucket: "backups"},
+				},
+			},
+			success: false,
+		},
+		{
+			name: "s3 with backup pvc size",
+			mysql: &MySQL{
+				Type: "local",
+				Backup: &Backup{
+					Schedule:  "0 3 * * *",
+					Retention: 7,
+					Size:      20,
+					S3:        &BackupS3{Bucket: "backups", CredentialsSecret: "s3-credentials"},
+				},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateBackup()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidBackupConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateLocalBackupResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	t.Run("backup to pvc", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "local",
+			Version:      "8.0",
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+			Size:         defaultSize,
+			Backup:       &Backup{Schedule: "0 3 * * *", Retention: 7, StorageClass: "standard"},
+		}
+
+		resources, err := mysql.generateLocalBackupResources(r, "test-database-db-local-service")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(resources))
+		assert.Equal(t, "v1:PersistentVolumeClaim:test-project:test-database-db-backup", resources[0].ID)
+		assert.Equal(t, "standard", resources[0].Attributes["spec"].(map[string]any)["storageClassName"])
+		assert.Equal(t, "batch/v1:CronJob:test-project:test-database-db-backup", resources[1].ID)
+
+		spec := resources[1].Attributes["spec"].(map[string]any)
+		assert.Equal(t, "0 3 * * *", spec["schedule"])
+		assert.Equal(t, "Forbid", spec["concurrencyPolicy"])
+
+		podSpec := spec["jobTemplate"].(map[string]any)["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+		assert.NotContains(t, podSpec, "initContainers")
+		containers := podSpec["containers"].([]any)
+		assert.Equal(t, 1, len(containers))
+		assert.Equal(t, "mysql:8.0", containers[0].(map[string]any)["image"])
+		assert.Equal(t, map[string]any{"claimName": "test-database-db-backup"},
+			podSpec["volumes"].([]any)[0].(map[string]any)["persistentVolumeClaim"])
+	})
+
+	t.Run("backup to s3", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "local",
+			Version:      "8.0",
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+			Backup: &Backup{
+				Schedule:  "0 3 * * *",
+				Retention: 7,
+				S3: &BackupS3{
+					Bucket:            "backups",
+					Region:            "us-east-1",
+					CredentialsSecret: "s3-credentials",
+				},
+			},
+		}
+
+		resources, err := mysql.generateLocalBackupResources(r, "test-database-db-local-service")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(resources))
+
+		spec := resources[0].Attributes["spec"].(map[string]any)
+		podSpec := spec["jobTemplate"].(map[string]any)["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+		assert.Equal(t, "mysql:8.0", podSpec["initContainers"].([]any)[0].(map[string]any)["image"])
+
+		upload := podSpec["containers"].([]any)[0].(map[string]any)
+		assert.Equal(t, "amazon/aws-cli:2.17.0", upload["image"])
+		assert.Equal(t, []any{
+			map[string]any{"secretRef": map[string]any{"name": "s3-credentials"}},
+		}, upload["envFrom"])
+		assert.Equal(t, []any{
+			map[string]any{"name": "AWS_DEFAULT_REGION", "value": "us-east-1"},
+		}, upload["env"])
+		assert.Equal(t, map[string]any{}, podSpec["volumes"].([]any)[0].(map[string]any)["emptyDir"])
+	})
+}
+
+func TestMySQLModule_GenerateBackupScripts(t *testing.T) {
+	mysql := &MySQL{
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Databases:    []string{"orders", "audit"},
+		Backup: &Backup{
+			Retention: 7,
+			S3: &BackupS3{
+				Endpoint: "https://minio.example.com",
+				Bucket:   "backups",
+				Prefix:   "staging/",
+			},
+		},
+	}
+
+	assert.Equal(t, "set -e\n"+
+		"file=/backup/test-database-$(date -u +%Y%m%d%H%M%S).sql\n"+
+		"mysqldump --host=test-host --port=3306 --user=root --single-transaction --no-tablespaces "+
+		"--result-file=\"$file\" --databases orders audit\n"+
+		"gzip \"$file\"", mysql.generateBackupDumpScript("test-host"))
+
+	assert.Equal(t, "find /backup -name 'test-database-*.sql.gz' -mmin +10080 -delete",
+		mysql.generateBackupPruneScript())
+
+	uploadScript := mysql.generateBackupUploadScript()
+	assert.Contains(t, uploadScript, "aws s3 cp /backup/ s3://backups/staging/ --recursive "+
+		"--exclude '*' --include 'test-database-*.sql.gz' --endpoint-url https://minio.example.com")
+	assert.Contains(t, uploadScript, "expired=$(date -u -d '-7 days' +%Y-%m-%dT%H:%M:%SZ)")
+	assert.Contains(t, uploadScript, "aws s3 rm \"s3://backups/$key\" --endpoint-url https://minio.example.com")
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidModuleConfig = errors.New("invalid mysql module config")
+
+const (
+	devConfigSource      = "devConfig"
+	platformConfigSource = "platformConfig"
+)
+
+// devConfigKeys are the keys of the MySQL module config declared by the developers in the
+// AppConfiguration, while the others are declared by the platform engineers in the workspace.
+var devConfigKeys = map[string]bool{
+	"type":        true,
+	"version":     true,
+	"databases":   true,
+	"users":       true,
+	"initScripts": true,
+	"migration":   true,
+	"replicas":    true,
+	"external":    true,
+	"restoreFrom": true,
+	"backup":      true,
+}
+
+// platformConfigExtraKeys are the keys of the platform config which are not decoded into the
+// MySQL fields but read elsewhere.
+var platformConfigExtraKeys = map[string]bool{
+	"cloud": true,
+}
+
+// configParser parses the value of a structured key in the module config into the MySQL fields.
+type configParser func(value any) error
+
+// configParsers returns the parsers of the structured keys in the module config, while the other
+// keys are decoded into the MySQL fields of the scalar or string list types.
+func (mysql *MySQL) configParsers() map[string]configParser {
+	return map[string]configParser{
+		"databases": func(value any) (err error) {
+			mysql.Databases, err = parseDatabases(value)
+			return err
+		},
+		"users": func(value any) (err error) {
+			mysql.Users, err = parseDatabaseUsers(value)
+			return err
+		},
+		"initScripts": func(value any) (err error) {
+			mysql.InitScripts, err = parseInitScripts(value)
+			return err
+		},
+		"migration": func(value any) (err error) {
+			mysql.Migration, err = parseMigration(value)
+			return err
+		},
+		"serverless": func(value any) (err error) {
+			mysql.Serverless, err = parseAlicloudServerless(value)
+			return err
+		},
+		"parameters": func(value any) (err error) {
+			mysql.Parameters, err = parseParameters(value)
+			return err
+		},
+		"aurora": func(value any) (err error) {
+			mysql.Aurora, err = parseAuroraCluster(value)
+			return err
+		},
+		"external": func(value any) (err error) {
+			mysql.External, err = parseExternalDatabase(value)
+			return err
+		},
+		"credentialDelivery": func(value any) (err error) {
+			mysql.CredentialDelivery, err = parseCredentialDelivery(value)
+			return err
+		},
+		"backup": func(value any) (err error) {
+			mysql.Backup, err = parseBackup(value)
+			return err
+		},
+		"pooler": func(value any) (err error) {
+			mysql.Pooler, err = parsePooler(value)
+			return err
+		},
+		"operator": func(value any) (err error) {
+			mysql.Operator, err = parseOperator(value)
+			return err
+		},
+		"exporter": func(value any) (err error) {
+			mysql.Exporter, err = parseExporter(value)
+			return err
+		},
+		"networkPolicy": func(value any) (err error) {
+			mysql.NetworkPolicy, err = parseNetworkPolicy(value)
+			return err
+		},
+		"tls": func(value any) (err error) {
+			mysql.TLS, err = parseTLS(value)
+			return err
+		},
+		"restoreFrom": func(value any) (err error) {
+			mysql.RestoreFrom, err = parseRestoreFrom(value)
+			return err
+		},
+		"connectionURL": func(value any) (err error) {
+			mysql.ConnectionURL, err = parseConnectionURL(value)
+			return err
+		},
+	}
+}
+
+// decodeConfig decodes the devConfig or platformConfig into the MySQL fields named by the json tags,
+// with the values coerced into the field types. The keys starting with an underscore are reserved
+// by Kusion and skipped, while the unknown keys and the keys declared in the other config are
+// skipped with the warnings, so that the config written for the other versions of the module
+// still works.
+func (mysql *MySQL) decodeConfig(config map[string]any, source string) error {
+	fields := mysql.configFields()
+	parsers := mysql.configParsers()
+
+	for _, key := range sortedConfigKeys(config) {
+		if strings.HasPrefix(key, "_") || (source == platformConfigSource && platformConfigExtraKeys[key]) {
+			continue
+		}
+
+		field, isField := fields[key]
+		parser, isParsed := parsers[key]
+		if !isField && !isParsed {
+			mysql.warnings = append(mysql.warnings, fmt.Sprintf("unknown key %q in %s is ignored", key, source))
+			continue
+		}
+		if devConfigKeys[key] != (source == devConfigSource) {
+			mysql.warnings = append(mysql.warnings, fmt.Sprintf("key %q should not be declared in %s and is ignored",
+				key, source))
+			continue
+		}
+
+		value := config[key]
+		if isParsed {
+			if err := parser(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := setConfigField(field, value); err != nil {
+			return fmt.Errorf("%w: %s in %s %v", ErrInvalidModuleConfig, key, source, err)
+		}
+	}
+
+	return nil
+}
+
+// errInvalidConfigValue is returned by the config value parsers when the value can not be converted
+// into the field type.
+var errInvalidConfigValue = errors.New("invalid config value")
+
+// configValueParser parses the value of a key in the block of the module config.
+type configValueParser func(value any) error
+
+// configValue returns the config value parser setting the field with the value converted by convert.
+func configValue[T any](field *T, convert func(any) (T, bool)) configValueParser {
+	return func(value any) error {
+		v, ok := convert(value)
+		if !ok {
+			return errInvalidConfigValue
+		}
+		*field = v
+
+		return nil
+	}
+}
+
+// parseConfigBlock parses the keys of the block in the module config with the parsers, and returns
+// the unknown keys and the invalid values as blockErr. The keys in the errors are prefixed with
+// prefix for the nested blocks, such as "s3.".
+func parseConfigBlock(configMap map[string]any, blockErr error, prefix string,
+	parsers map[string]configValueParser,
+) error {
+	for _, key := range sortedConfigKeys(configMap) {
+		parser, ok := parsers[key]
+		if !ok {
+			return fmt.Errorf("%w: unknown key %s%s", blockErr, prefix, key)
+		}
+
+		if err := parser(configMap[key]); err != nil {
+			if errors.Is(err, errInvalidConfigValue) {
+				return fmt.Errorf("%w: invalid value of %s%s", blockErr, prefix, key)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortedConfigKeys returns the keys of the module config in order, so that the errors and the
+// warnings are reported deterministically.
+func sortedConfigKeys(config map[string]any) []string {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// configFields returns the settable MySQL fields keyed by the names in their json tags.
+func (mysql *MySQL) configFields() map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+
+	v := reflect.ValueOf(mysql).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = v.Field(i)
+		}
+	}
+
+	return fields
+}
+
+// setConfigField sets the field with the value coerced into the field type.
+func setConfigField(field reflect.Value, value any) error {
+	switch {
+	case field.Kind() == reflect.String:
+		str, ok := toConfigString(value)
+		if !ok {
+			return fmt.Errorf("should be a string but got %T", value)
+		}
+		field.SetString(str)
+	case field.Kind() == reflect.Int:
+		i, ok := toConfigInt(value)
+		if !ok {
+			return fmt.Errorf("should be an integer but got %T %v", value, value)
+		}
+		field.SetInt(int64(i))
+	case field.Kind() == reflect.Bool:
+		b, ok := toConfigBool(value)
+		if !ok {
+			return fmt.Errorf("should be a bool but got %T %v", value, value)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		strs, ok := toConfigStringSlice(value)
+		if !ok {
+			return fmt.Errorf("should be a list of strings but got %T", value)
+		}
+		field.Set(reflect.ValueOf(strs))
+	default:
+		return fmt.Errorf("has an unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+// toConfigString converts the string or number value in the module config into a string.
+func toConfigString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// assertConfigString asserts the value in the module config is a string, without converting the
+// numbers into strings.
+func assertConfigString(value any) (string, bool) {
+	str, ok := value.(string)
+	return str, ok
+}
+
+// toConfigInt converts the integer, integral float or numeric string value in the module config
+// into an integer.
+func toConfigInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int(v), true
+	case string:
+		i, err := strconv.Atoi(v)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// toConfigFloat converts the number or numeric string value in the module config into a float.
+func toConfigFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		i, ok := toConfigInt(value)
+		return float64(i), ok
+	}
+}
+
+// toConfigBool converts the bool or boolean string value in the module config into a bool.
+func toConfigBool(value any) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+// toConfigStringSlice converts the list value in the module config into a string slice, with the
+// items converted into strings.
+func toConfigStringSlice(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []any:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := toConfigString(item)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, str)
+		}
+
+		return strs, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConnectionURL(t *testing.T) {
+	t.Run("default connection url config", func(t *testing.T) {
+		actual, err := parseConnectionURL(map[string]any{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ConnectionURL{
+			Format:    URLFormat,
+			EnvPrefix: defaultConnectionURLEnvPrefix,
+		}, actual)
+	})
+
+	t.Run("specified connection url config", func(t *testing.T) {
+		actual, err := parseConnectionURL(map[string]any{
+			"format":  "jdbc",
+			"envName": "DATABASE_URL",
+			"params": map[string]any{
+				"useSSL":         true,
+				"connectTimeout": 10,
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ConnectionURL{
+			Format:    JDBCFormat,
+			EnvName:   "DATABASE_URL",
+			EnvPrefix: defaultConnectionURLEnvPrefix,
+			Params: map[string]string{
+				"useSSL":         "true",
+				"connectTimeout": "10",
+			},
+		}, actual)
+	})
+
+	t.Run("invalid connection url config", func(t *testing.T) {
+		_, err := parseConnectionURL("jdbc")
+		assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+
+		_, err = parseConnectionURL(map[string]any{"format": 1})
+		assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+
+		_, err = parseConnectionURL(map[string]any{"unknown": "value"})
+		assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+	})
+}
+
+func TestMySQLModule_ValidateConnectionURL(t *testing.T) {
+	mysql := &MySQL{Username: defaultUsername}
+	assert.NoError(t, mysql.validateConnectionURL())
+
+	mysql.ConnectionURL = &ConnectionURL{Format: DSNFormat}
+	assert.NoError(t, mysql.validateConnectionURL())
+
+	mysql.ConnectionURL = &ConnectionURL{Format: "odbc"}
+	assert.ErrorIs(t, mysql.validateConnectionURL(), ErrUnsupportedConnectionURLFormat)
+
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name: "external database credentials",
+			mysql: &MySQL{
+				Type:     ExternalDBType,
+				Username: defaultUsername,
+				External: &ExternalDatabase{Host: "test-host", Password: "secret://test-secret/password"},
+			},
+			success: false,
+		},
+		{
+			name: "username to be escaped",
+			mysql: &MySQL{
+				Type:     CloudDBType,
+				Username: "app@example.com",
+			},
+			success: false,
+		},
+		{
+			name: "generated password of application user",
+			mysql: &MySQL{
+				Type:               CloudDBType,
+				Username:           defaultUsername,
+				CredentialDelivery: &CredentialDelivery{Mode: ExternalSecretDelivery},
+				Databases:          []string{"orders"},
+				Users:              []DatabaseUser{{Name: "app"}},
+			},
+			success: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mysql.ConnectionURL = &ConnectionURL{Format: URLFormat}
+
+			err := tc.mysql.validateConnectionURL()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateConnectionURLEnv(t *testing.T) {
+	testcases := []struct {
+		name          string
+		connectionURL *ConnectionURL
+		expectedName  string
+		expectedValue string
+	}{
+		{
+			name: "url format",
+			connectionURL: &ConnectionURL{
+				Format:    URLFormat,
+				EnvPrefix: defaultConnectionURLEnvPrefix,
+				Params:    map[string]string{"tls": "true"},
+			},
+			expectedName:  "KUSION_DB_URL_TEST_DATABASE",
+			expectedValue: "mysql://$(USER):$(PASS)@$(HOST):3306/test_database?tls=true",
+		},
+		{
+			name: "jdbc format",
+			connectionURL: &ConnectionURL{
+				Format:  JDBCFormat,
+				EnvName: "DATABASE_URL",
+				Params:  map[string]string{"useSSL": "true", "connectTimeout": "10"},
+			},
+			expectedName:  "DATABASE_URL",
+			expectedValue: "jdbc:mysql://$(HOST):3306/test_database?user=$(USER)&password=$(PASS)&connectTimeout=10&useSSL=true",
+		},
+		{
+			name: "dsn format",
+			connectionURL: &ConnectionURL{
+				Format:    DSNFormat,
+				EnvPrefix: "MYSQL_DSN",
+			},
+			expectedName:  "MYSQL_DSN_TEST_DATABASE",
+			expectedValue: "$(USER):$(PASS)@tcp($(HOST):3306)/test_database",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mysql := &MySQL{
+				DatabaseName:  "test-database",
+				ConnectionURL: tc.connectionURL,
+			}
+
+			actual := mysql.generateConnectionURLEnv("HOST", "USER", "PASS")
+
+			assert.Equal(t, tc.expectedName, actual.Name)
+			assert.Equal(t, tc.expectedValue, actual.Value)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseCredentialDelivery(t *testing.T) {
+	delivery, err := parseCredentialDelivery(map[string]any{
+		"mode":        "externalSecret",
+		"secretStore": "aws-secrets-manager",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &CredentialDelivery{
+		Mode:            "externalSecret",
+		SecretStore:     "aws-secrets-manager",
+		SecretStoreKind: "ClusterSecretStore",
+		RefreshInterval: "1h",
+	}, delivery)
+
+	delivery, err = parseCredentialDelivery(map[string]any{
+		"mode":        "externalSecret",
+		"backend":     "vault",
+		"secretStore": "vault",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "vault", delivery.Backend)
+
+	_, err = parseCredentialDelivery("externalSecret")
+	assert.ErrorIs(t, err, ErrInvalidCredentialDelivery)
+
+	_, err = parseCredentialDelivery(map[string]any{"store": "vault"})
+	assert.ErrorIs(t, err, ErrInvalidCredentialDelivery)
+}
+
+func TestMySQLModule_ValidateCredentialDelivery(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name:    "secret delivery by default",
+			mysql:   &MySQL{Type: "local"},
+			success: true,
+		},
+		{
+			name: "valid external secret delivery",
+			mysql: &MySQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "SecretStore",
+				},
+			},
+			success: true,
+		},
+		{
+			name: "unsupported mode",
+			mysql: &MySQL{
+				Type:               "cloud",
+				CredentialDelivery: &CredentialDelivery{Mode: "vault"},
+			},
+			success: false,
+		},
+		{
+			name: "external secret delivery for local database",
+			mysql: &MySQL{
+				Type: "local",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "external secret delivery with iam auth",
+			mysql: &MySQL{
+				Type: "cloud",
+				Auth: "iam",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "empty secret store",
+			mysql: &MySQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "vault backend",
+			mysql: &MySQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					Backend:         "vault",
+					SecretStore:     "vault",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported backend",
+			mysql: &MySQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					Backend:         "gcpSecretManager",
+					SecretStore:     "gcp-secret-manager",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported secret store kind",
+			mysql: &MySQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "Vault",
+				},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateCredentialDelivery()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidCredentialDelivery)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateAWSCredentialsStack(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	mysql := &MySQL{
+		DatabaseName: "test-database",
+	}
+
+	password := module.KusionPathDependency("random_password_id", "result")
+	res, err := mysql.generateAWSCredentialsStack(r, defaultAWSProviderCfg, "test-region", defaultUsername, password)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hashicorp:aws:aws_cloudformation_stack:test-database", res.ID)
+	assert.Equal(t, "test-database-db-credentials", res.Attributes["name"])
+	// The password is passed as a whole parameter value for Kusion to resolve.
+	assert.Equal(t, map[string]any{"Username": "root", "Password": password}, res.Attributes["parameters"])
+	assertResolvableKusionPaths(t, []kusionapiv1.Resource{*res})
+
+	var template map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(res.Attributes["template_body"].(string)), &template))
+	secret := template["Resources"].(map[string]any)["Secret"].(map[string]any)
+	assert.Equal(t, "AWS::SecretsManager::Secret", secret["Type"])
+	assert.Equal(t, "test-project/test-database-mysql", secret["Properties"].(map[string]any)["Name"])
+	assert.Equal(t, map[string]any{"Value": map[string]any{"Ref": "Secret"}},
+		template["Outputs"].(map[string]any)["SecretARN"])
+	assert.Equal(t, remoteSecretRef{Key: "test-project/test-database-mysql", Property: "password"},
+		mysql.generateAWSRemoteSecretRef(r))
+}
+
+func TestMySQLModule_GenerateAlicloudKMSSecret(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	mysql := &MySQL{
+		DatabaseName:     "test-database",
+		PasswordRotation: "2024-06-01",
+	}
+
+	res, err := mysql.generateAlicloudKMSSecret(r, defaultAlicloudProviderCfg, "test-region",
+		module.KusionPathDependency("random_password_id", "result"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-project/test-database-mysql", res.Attributes["secret_name"])
+	assert.Equal(t, module.KusionPathDependency("random_password_id", "result"), res.Attributes["secret_data"])
+	assert.Equal(t, "2024-06-01", res.Attributes["version_id"])
+	assert.Equal(t, true, res.Attributes["force_delete_without_recovery"])
+}
+
+func TestMySQLModule_GenerateDBSecretWithExternalSecret(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+	}
+
+	_, expectedPatcher, err := mysql.GenerateDBSecret(r, "test-host-address", "test-username", "test-password", nil)
+	assert.NoError(t, err)
+
+	mysql.CredentialDelivery = &CredentialDelivery{
+		Mode:            "externalSecret",
+		SecretStore:     "aws-secrets-manager",
+		SecretStoreKind: "ClusterSecretStore",
+		RefreshInterval: "1h",
+	}
+
+	res, patcher, err := mysql.GenerateDBSecret(r, "test-host-address", "test-username", "test-password", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "external-secrets.io/v1beta1:ExternalSecret:test-project:test-database-mysql", res.ID)
+	assert.Equal(t, expectedPatcher, patcher)
+
+	spec := res.Attributes["spec"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"name": "aws-secrets-manager",
+		"kind": "ClusterSecretStore",
+	}, spec["secretStoreRef"])
+	assert.Equal(t, []any{
+		map[string]any{
+			"secretKey": "password",
+			"remoteRef": map[string]any{"key": "test-project/test-database-mysql"},
+		},
+	}, spec["data"])
+
+	template := spec["target"].(map[string]any)["template"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"hostAddress": "test-host-address",
+		"port":        "3306",
+		"database":    "test_database",
+		"username":    "test-username",
+		"password":    "{{ .password }}",
+	}, template["data"])
+}
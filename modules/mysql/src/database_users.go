@@ -0,0 +1,373 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidDatabaseName     = errors.New("invalid logical database name in mysql module config")
+	ErrInvalidDatabaseUser     = errors.New("invalid database user in mysql module config")
+	ErrInvalidGrantPrivilege   = errors.New("invalid grant privilege in mysql module config")
+	ErrUndeclaredGrantDatabase = errors.New("grant on undeclared logical database in mysql module config")
+)
+
+var (
+	dbUsersJobSuffix     = "-db-users"
+	dbUsersSecretSuffix  = "-db-users-secret"
+	dbUsersContainerName = "db-users"
+	dbUsersImage         = "mysql:8.0"
+	dbUsersBackoffLimit  = int32(6)
+	userPasswordEnv      = "KUSION_USER_PASSWORD"
+)
+
+var (
+	sqlIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	sqlPrivilegeRegexp  = regexp.MustCompile(`^[A-Z]+( [A-Z]+)*$`)
+)
+
+// DatabaseUser describes an application user with the least privileges created in the
+// MySQL instance.
+type DatabaseUser struct {
+	// The name of the application user.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The privileges granted to the application user on the logical databases.
+	Grants []DatabaseGrant `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// DatabaseGrant describes the privileges granted on a logical database.
+type DatabaseGrant struct {
+	// The name of the logical database.
+	Database string `json:"database,omitempty" yaml:"database,omitempty"`
+	// The privileges granted on all the tables of the logical database, such as SELECT and INSERT.
+	Privileges []string `json:"privileges,omitempty" yaml:"privileges,omitempty"`
+}
+
+// parseDatabases parses the logical databases declared in the devConfig.
+func parseDatabases(config any) ([]string, error) {
+	databases, ok := toConfigStringSlice(config)
+	if !ok {
+		return nil, fmt.Errorf("%w: databases should be a list of strings", ErrInvalidDatabaseName)
+	}
+
+	return databases, nil
+}
+
+// parseDatabaseUsers parses the application users declared in the devConfig.
+func parseDatabaseUsers(config any) ([]DatabaseUser, error) {
+	items, ok := config.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: users should be a list", ErrInvalidDatabaseUser)
+	}
+
+	users := make([]DatabaseUser, 0, len(items))
+	for _, item := range items {
+		userMap, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: user should be a map", ErrInvalidDatabaseUser)
+		}
+
+		var user DatabaseUser
+		if user.Name, ok = userMap["name"].(string); !ok {
+			return nil, fmt.Errorf("%w: user name should be a string", ErrInvalidDatabaseUser)
+		}
+
+		grants, _ := userMap["grants"].([]any)
+		for _, g := range grants {
+			grantMap, ok := g.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: grant of user %s should be a map", ErrInvalidDatabaseUser, user.Name)
+			}
+
+			var grant DatabaseGrant
+			if grant.Database, ok = grantMap["database"].(string); !ok {
+				return nil, fmt.Errorf("%w: grant database of user %s should be a string", ErrInvalidDatabaseUser, user.Name)
+			}
+
+			privileges, ok := toConfigStringSlice(grantMap["privileges"])
+			if !ok {
+				return nil, fmt.Errorf("%w: grant privileges of user %s should be a list of strings",
+					ErrInvalidDatabaseUser, user.Name)
+			}
+			for _, privilege := range privileges {
+				grant.Privileges = append(grant.Privileges, strings.ToUpper(strings.TrimSpace(privilege)))
+			}
+
+			user.Grants = append(user.Grants, grant)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// validateDatabaseUsers validates whether the logical databases, application users and grants
+// are valid, which are restricted to plain SQL identifiers to be safely used in the statements.
+func (mysql *MySQL) validateDatabaseUsers() error {
+	databases := make(map[string]bool)
+	for _, database := range mysql.Databases {
+		if !sqlIdentifierRegexp.MatchString(database) {
+			return fmt.Errorf("%w: %s", ErrInvalidDatabaseName, database)
+		}
+		databases[database] = true
+	}
+	databases[mysql.generateLogicalDBName()] = true
+
+	users := make(map[string]bool)
+	for _, user := range mysql.Users {
+		if !sqlIdentifierRegexp.MatchString(user.Name) || user.Name == mysql.Username || users[user.Name] {
+			return fmt.Errorf("%w: %s", ErrInvalidDatabaseUser, user.Name)
+		}
+		users[user.Name] = true
+
+		grants := make(map[string]bool)
+		for _, grant := range user.Grants {
+			if !databases[grant.Database] {
+				return fmt.Errorf("%w: %s", ErrUndeclaredGrantDatabase, grant.Database)
+			}
+			if grants[grant.Database] {
+				return fmt.Errorf("%w: duplicate grants of user %s on %s", ErrInvalidDatabaseUser, user.Name, grant.Database)
+			}
+			grants[grant.Database] = true
+
+			if len(grant.Privileges) == 0 {
+				return fmt.Errorf("%w: empty privileges of user %s on %s", ErrInvalidGrantPrivilege, user.Name, grant.Database)
+			}
+			for _, privilege := range grant.Privileges {
+				if !sqlPrivilegeRegexp.MatchString(privilege) {
+					return fmt.Errorf("%w: %s", ErrInvalidGrantPrivilege, privilege)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// generateLogicalDBNames generates the names of all the logical databases in the MySQL instance.
+func (mysql *MySQL) generateLogicalDBNames() []string {
+	if len(mysql.Databases) > 0 {
+		return mysql.Databases
+	}
+
+	return []string{mysql.generateLogicalDBName()}
+}
+
+// generateUserRandomPasswords generates the random_password resources of the application users,
+// and returns the IDs of them in the order of the users.
+func (mysql *MySQL) generateUserRandomPasswords() ([]kusionapiv1.Resource, []string, error) {
+	var resources []kusionapiv1.Resource
+	var ids []string
+	for _, user := range mysql.Users {
+		resource, id, err := mysql.generateTFRandomPassword(mysql.DatabaseName + dbResSuffix + "-" + user.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *resource)
+		ids = append(ids, id)
+	}
+
+	return resources, ids, nil
+}
+
+// GenerateDBUsers generates the logical databases, application users and grants inside the cloud
+// provided MySQL instance. They are applied by a Kubernetes Job connecting via the administrator account
+// from the cluster, which reaches the instance with private routing as the workload does. It returns the
+// credentials for the workload, which belong to the first application user if declared.
+func (mysql *MySQL) GenerateDBUsers(request *module.GeneratorRequest, hostAddress, password string,
+	dependsOn []string,
+) ([]kusionapiv1.Resource, string, string, error) {
+	var resources []kusionapiv1.Resource
+	username := mysql.Username
+
+	if len(mysql.Databases) == 0 && len(mysql.Users) == 0 {
+		return nil, username, password, nil
+	}
+
+	// Build random_password resources for the application users, which are authenticated with the
+	// IAM authentication tokens instead for the IAM database authentication.
+	var userPasswords []string
+	if !mysql.isIAMAuth() {
+		passwordResources, ids, err := mysql.generateUserRandomPasswords()
+		if err != nil {
+			return nil, "", "", err
+		}
+		resources = append(resources, passwordResources...)
+
+		for _, id := range ids {
+			userPasswords = append(userPasswords, module.KusionPathDependency(id, "result"))
+		}
+	}
+
+	// Build Kubernetes Secret with the passwords of the administrator and the application users for the Job.
+	secretName := mysql.DatabaseName + dbUsersSecretSuffix
+	secret, err := mysql.generateCredentialsSecret(request, secretName, password, userPasswords)
+	if err != nil {
+		return nil, "", "", err
+	}
+	resources = append(resources, *secret)
+
+	// Build Kubernetes Job applying the statements after the instance and the Secret are created.
+	job, err := mysql.generateDBUsersJob(request, hostAddress, secretName, append(slices.Clone(dependsOn), secret.ID))
+	if err != nil {
+		return nil, "", "", err
+	}
+	resources = append(resources, *job)
+
+	if len(mysql.Users) > 0 {
+		username, password = mysql.Users[0].Name, ""
+		if len(userPasswords) > 0 {
+			password = userPasswords[0]
+		}
+	}
+
+	return resources, username, password, nil
+}
+
+// generateDBUsersJob generates the Kubernetes Job resource running the statements of the logical databases,
+// application users and grants with the mysql client, with the passwords read from the Secret. The Job name
+// is suffixed with the hash of the statements and the password rotation trigger, so that a new Job is
+// created to apply them once they change instead of updating the immutable pod template.
+func (mysql *MySQL) generateDBUsersJob(request *module.GeneratorRequest, hostAddress, secretName string,
+	dependsOn []string,
+) (*kusionapiv1.Resource, error) {
+	env := []v1.EnvVar{
+		{
+			Name:  dbHostAddressEnv,
+			Value: hostAddress,
+		},
+		{
+			Name: "MYSQL_PWD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secretName,
+					},
+					Key: "password",
+				},
+			},
+		},
+	}
+	if !mysql.isIAMAuth() {
+		for i, user := range mysql.Users {
+			env = append(env, v1.EnvVar{
+				Name: generateUserPasswordEnv(i),
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: secretName,
+						},
+						Key: generateUserPasswordKey(user),
+					},
+				},
+			})
+		}
+	}
+
+	script := fmt.Sprintf("mysql --host=\"$%s\" --port=%d --user=%s <<EOSQL\n%s\nEOSQL",
+		dbHostAddressEnv, dbPort, mysql.Username, strings.Join(mysql.generateUserStatements(), "\n"))
+
+	sum := sha256.Sum256([]byte(dbUsersImage + "\n" + script + "\n" + mysql.PasswordRotation))
+	specHash := hex.EncodeToString(sum[:])[:migrationSpecHashLength]
+
+	backoffLimit := dbUsersBackoffLimit
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + dbUsersJobSuffix + "-" + specHash,
+			Namespace: request.Project,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: mysql.generateClientMatchLabels(),
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:    dbUsersContainerName,
+							Image:   dbUsersImage,
+							Command: []string{"sh", "-c", script},
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(job.TypeMeta, job.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, job)
+	if err != nil {
+		return nil, err
+	}
+	resource.DependsOn = dependsOn
+
+	return resource, nil
+}
+
+// generateUserPasswordKey generates the key of the application user password in the Kubernetes
+// Secret of the local MySQL instance.
+func generateUserPasswordKey(user DatabaseUser) string {
+	return user.Name + "-password"
+}
+
+// generateUserPasswordEnv generates the name of the environment variable holding the password
+// of the application user in the local MySQL instance.
+func generateUserPasswordEnv(index int) string {
+	return userPasswordEnv + "_" + strconv.Itoa(index)
+}
+
+// generateUserStatements generates the idempotent SQL statements creating the logical databases,
+// application users and grants in the MySQL instance, with the passwords read from the environment
+// variables. The application users of the IAM database authentication are identified by the plugin
+// instead of the passwords.
+func (mysql *MySQL) generateUserStatements() []string {
+	if len(mysql.Databases) == 0 && len(mysql.Users) == 0 {
+		return nil
+	}
+
+	var statements []string
+
+	for _, database := range mysql.generateLogicalDBNames() {
+		statements = append(statements, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS \\`%s\\`;", database))
+	}
+
+	for i, user := range mysql.Users {
+		if mysql.isIAMAuth() {
+			statements = append(statements, fmt.Sprintf("CREATE USER IF NOT EXISTS '%s'@'%%' IDENTIFIED WITH %s AS 'RDS';",
+				user.Name, awsIAMAuthPlugin))
+		} else {
+			passwordEnv := generateUserPasswordEnv(i)
+			statements = append(statements,
+				fmt.Sprintf("CREATE USER IF NOT EXISTS '%s'@'%%' IDENTIFIED BY '${%s}';", user.Name, passwordEnv),
+				fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY '${%s}';", user.Name, passwordEnv),
+			)
+		}
+
+		for _, grant := range user.Grants {
+			statements = append(statements, fmt.Sprintf("GRANT %s ON \\`%s\\`.* TO '%s'@'%%';",
+				strings.Join(grant.Privileges, ", "), grant.Database, user.Name))
+		}
+	}
+
+	return statements
+}
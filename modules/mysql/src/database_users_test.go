@@ -0,0 +1,247 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseDatabaseUsers(t *testing.T) {
+	t.Run("successfully parse databases and users", func(t *testing.T) {
+		databases, err := parseDatabases([]any{"orders", "audit"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"orders", "audit"}, databases)
+
+		users, err := parseDatabaseUsers([]any{
+			map[string]any{
+				"name": "app",
+				"grants": []any{
+					map[string]any{
+						"database":   "orders",
+						"privileges": []any{"select", "insert"},
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []DatabaseUser{
+			{
+				Name: "app",
+				Grants: []DatabaseGrant{
+					{
+						Database:   "orders",
+						Privileges: []string{"SELECT", "INSERT"},
+					},
+				},
+			},
+		}, users)
+	})
+
+	t.Run("failed to parse databases and users", func(t *testing.T) {
+		_, err := parseDatabases("orders")
+		assert.ErrorIs(t, err, ErrInvalidDatabaseName)
+
+		_, err = parseDatabaseUsers([]any{map[string]any{"name": 1}})
+		assert.ErrorIs(t, err, ErrInvalidDatabaseUser)
+
+		_, err = parseDatabaseUsers([]any{
+			map[string]any{
+				"name":   "app",
+				"grants": []any{map[string]any{"database": "orders", "privileges": "ALL"}},
+			},
+		})
+		assert.ErrorIs(t, err, ErrInvalidDatabaseUser)
+	})
+}
+
+func TestMySQLModule_ValidateDatabaseUsers(t *testing.T) {
+	testcases := []struct {
+		name        string
+		databases   []string
+		users       []DatabaseUser
+		expectedErr error
+	}{
+		{
+			name:      "valid databases and users",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT"}}}},
+			},
+		},
+		{
+			name:      "grant on the default logical database",
+			databases: nil,
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "test_database", Privileges: []string{"ALL PRIVILEGES"}}}},
+			},
+		},
+		{
+			name:        "invalid database name",
+			databases:   []string{"orders; DROP"},
+			expectedErr: ErrInvalidDatabaseName,
+		},
+		{
+			name:        "user conflicts with the administrator",
+			users:       []DatabaseUser{{Name: "root"}},
+			expectedErr: ErrInvalidDatabaseUser,
+		},
+		{
+			name:      "grant on undeclared database",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "audit", Privileges: []string{"SELECT"}}}},
+			},
+			expectedErr: ErrUndeclaredGrantDatabase,
+		},
+		{
+			name:      "invalid privilege",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT;"}}}},
+			},
+			expectedErr: ErrInvalidGrantPrivilege,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mysql := &MySQL{
+				DatabaseName: "test-database",
+				Username:     defaultUsername,
+				Databases:    tc.databases,
+				Users:        tc.users,
+			}
+
+			err := mysql.validateDatabaseUsers()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateDBUsers(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+	}
+	adminPassword := module.KusionPathDependency("random_password_id", "result")
+
+	t.Run("without declared databases and users", func(t *testing.T) {
+		mysql := &MySQL{
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+		}
+
+		resources, username, password, err := mysql.GenerateDBUsers(r, "test-host", adminPassword, nil)
+
+		assert.NoError(t, err)
+		assert.Nil(t, resources)
+		assert.Equal(t, defaultUsername, username)
+		assert.Equal(t, module.KusionPathDependency("random_password_id", "result"), password)
+	})
+
+	t.Run("with declared databases and users", func(t *testing.T) {
+		mysql := &MySQL{
+			Version:      "8.0",
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+			Databases:    []string{"orders", "audit"},
+			Users: []DatabaseUser{
+				{
+					Name: "app",
+					Grants: []DatabaseGrant{
+						{Database: "orders", Privileges: []string{"SELECT", "INSERT"}},
+						{Database: "audit", Privileges: []string{"INSERT"}},
+					},
+				},
+			},
+		}
+
+		resources, username, password, err := mysql.GenerateDBUsers(r, "test-host", adminPassword, []string{"db_instance_id"})
+
+		// random_password, the Secret and the Job.
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(resources))
+		assert.Equal(t, "app", username)
+		assert.Equal(t, module.KusionPathDependency(resources[0].ID, "result"), password)
+
+		secret := resources[1]
+		assert.Equal(t, "v1:Secret:test-project:test-database-db-users-secret", secret.ID)
+		assert.Equal(t, map[string]any{
+			"password":     module.KusionPathDependency("random_password_id", "result"),
+			"app-password": module.KusionPathDependency(resources[0].ID, "result"),
+		}, secret.Attributes["stringData"])
+
+		// The Job connects to the instance from the cluster after the instance and the Secret are created.
+		job := resources[2]
+		assert.Contains(t, job.ID, "batch/v1:Job:test-project:test-database-db-users-")
+		assert.Equal(t, []string{"db_instance_id", secret.ID}, job.DependsOn)
+
+		container := job.Attributes["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+		assert.Equal(t, "mysql:8.0", container["image"])
+		script := container["command"].([]any)[2].(string)
+		assert.Contains(t, script, "mysql --host=\"$KUSION_DB_HOST\" --port=3306 --user=root <<EOSQL")
+		assert.Contains(t, script, "CREATE DATABASE IF NOT EXISTS \\`audit\\`;")
+		assert.Contains(t, script, "GRANT INSERT ON \\`audit\\`.* TO 'app'@'%';")
+
+		env := container["env"].([]any)
+		assert.Equal(t, map[string]any{"name": "KUSION_DB_HOST", "value": "test-host"}, env[0])
+		assert.Equal(t, "MYSQL_PWD", env[1].(map[string]any)["name"])
+		assert.Equal(t, "KUSION_USER_PASSWORD_0", env[2].(map[string]any)["name"])
+
+		// A new Job is created once the statements change.
+		mysql.Databases = []string{"orders", "audit", "reports"}
+		changed, _, _, err := mysql.GenerateDBUsers(r, "test-host", adminPassword, []string{"db_instance_id"})
+		assert.NoError(t, err)
+		assert.NotEqual(t, job.ID, changed[2].ID)
+	})
+
+	t.Run("with iam auth", func(t *testing.T) {
+		mysql := &MySQL{
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+			Auth:         "iam",
+			Users:        []DatabaseUser{{Name: "app"}},
+		}
+
+		resources, username, password, err := mysql.GenerateDBUsers(r, "test-host", adminPassword, nil)
+
+		// The Secret and the Job without the random_password.
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(resources))
+		assert.Equal(t, map[string]any{
+			"password": module.KusionPathDependency("random_password_id", "result"),
+		}, resources[0].Attributes["stringData"])
+		assert.Equal(t, "app", username)
+		assert.Equal(t, "", password)
+	})
+}
+
+func TestMySQLModule_GenerateUserStatements(t *testing.T) {
+	mysql := &MySQL{
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Databases:    []string{"orders"},
+		Users: []DatabaseUser{
+			{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT", "INSERT"}}}},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"CREATE DATABASE IF NOT EXISTS \\`orders\\`;",
+		"CREATE USER IF NOT EXISTS 'app'@'%' IDENTIFIED BY '${KUSION_USER_PASSWORD_0}';",
+		"ALTER USER 'app'@'%' IDENTIFIED BY '${KUSION_USER_PASSWORD_0}';",
+		"GRANT SELECT, INSERT ON \\`orders\\`.* TO 'app'@'%';",
+	}, mysql.generateUserStatements())
+
+	assert.Nil(t, (&MySQL{DatabaseName: "test-database"}).generateUserStatements())
+
+	mysql.Auth = "iam"
+	assert.Equal(t, "CREATE USER IF NOT EXISTS 'app'@'%' IDENTIFIED WITH AWSAuthenticationPlugin AS 'RDS';",
+		mysql.generateUserStatements()[1])
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidExporterConfig = errors.New("invalid exporter config in mysql module config")
+
+var (
+	exporterSuffix        = "-db-exporter"
+	exporterServiceSuffix = "-db-exporter-service"
+	exporterMonitorSuffix = "-db-metrics"
+	exporterContainerName = "exporter"
+	exporterPortName      = "metrics"
+	exporterPort          = 9104
+	exporterPath          = "/metrics"
+	exporterPasswordEnv   = "MYSQLD_EXPORTER_PASSWORD"
+
+	serviceMonitorAPIVersion = "monitoring.coreos.com/v1"
+	serviceMonitorKind       = "ServiceMonitor"
+
+	defaultExporterImage    = "prom/mysqld-exporter:v0.15.1"
+	defaultExporterInterval = "30s"
+	defaultExporterTimeout  = "15s"
+)
+
+// Exporter describes the mysqld_exporter exposing the metrics of the MySQL instance, which are scraped
+// with the ServiceMonitor by the Prometheus Operator the same way as the monitoring module does. The
+// exporter runs as the sidecar of the local instance, or as the standalone Deployment connecting to
+// the cloud provided instance with the generated credentials.
+type Exporter struct {
+	// The image of mysqld_exporter.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The interval of scraping the metrics, such as "30s".
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// The timeout of scraping the metrics, which should not be greater than the interval.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// parseExporter parses the exporter of the platform config, which is either a bool enabling the
+// exporter with the default settings or a block with the settings.
+func parseExporter(config any) (*Exporter, error) {
+	exporter := &Exporter{
+		Image:    defaultExporterImage,
+		Interval: defaultExporterInterval,
+		Timeout:  defaultExporterTimeout,
+	}
+
+	if enabled, ok := toConfigBool(config); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return exporter, nil
+	}
+
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a bool or map but got %T", ErrInvalidExporterConfig, config)
+	}
+
+	if err := parseConfigBlock(configMap, ErrInvalidExporterConfig, "", map[string]configValueParser{
+		"image":    configValue(&exporter.Image, toConfigString),
+		"interval": configValue(&exporter.Interval, toConfigString),
+		"timeout":  configValue(&exporter.Timeout, toConfigString),
+	}); err != nil {
+		return nil, err
+	}
+
+	return exporter, nil
+}
+
+// validateExporter validates whether the exporter is declared for the local or cloud provided MySQL
+// instance authenticated with the password, and whether the scrape timeout is within the interval.
+func (mysql *MySQL) validateExporter() error {
+	exporter := mysql.Exporter
+	if exporter == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(mysql.Type, LocalDBType) && !strings.EqualFold(mysql.Type, CloudDBType) {
+		return fmt.Errorf("%w: only supported by the %s and %s types", ErrInvalidExporterConfig, LocalDBType, CloudDBType)
+	}
+
+	if mysql.isIAMAuth() {
+		return fmt.Errorf("%w: not supported with %s auth", ErrInvalidExporterConfig, IAMAuth)
+	}
+
+	if exporter.Image == "" {
+		return fmt.Errorf("%w: empty image", ErrInvalidExporterConfig)
+	}
+
+	interval, err := time.ParseDuration(exporter.Interval)
+	if err != nil {
+		return fmt.Errorf("%w: invalid interval %q", ErrInvalidExporterConfig, exporter.Interval)
+	}
+
+	timeout, err := time.ParseDuration(exporter.Timeout)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timeout %q", ErrInvalidExporterConfig, exporter.Timeout)
+	}
+
+	if timeout > interval {
+		return fmt.Errorf("%w: timeout cannot be greater than interval", ErrInvalidExporterConfig)
+	}
+
+	return nil
+}
+
+// generateExporterResources generates the ServiceMonitor scraping the metrics of the MySQL instance,
+// along with the standalone exporter Deployment and Service for the cloud provided instance. The
+// local instance exposes the metrics with the sidecar through the metrics port of its Service.
+func (mysql *MySQL) generateExporterResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, error) {
+	var resources []kusionapiv1.Resource
+
+	selector := mysql.generateLocalMatchLabels()
+	if strings.EqualFold(mysql.Type, CloudDBType) {
+		exporterDeployment, err := mysql.generateExporterDeployment(request)
+		if err != nil {
+			return nil, err
+		}
+
+		exporterService, err := mysql.generateExporterService(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *exporterDeployment, *exporterService)
+
+		selector = mysql.generateExporterMatchLabels()
+	}
+
+	serviceMonitor, err := mysql.generateServiceMonitor(request, selector)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, *serviceMonitor)
+
+	return resources, nil
+}
+
+// generateLocalExporterContainer generates the exporter sidecar of the local MySQL instance, which
+// connects to the instance via the loopback address with the password in the local secret.
+func (mysql *MySQL) generateLocalExporterContainer() v1.Container {
+	env := []v1.EnvVar{
+		{
+			Name: exporterPasswordEnv,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: mysql.DatabaseName + localSecretSuffix,
+					},
+					Key: "password",
+				},
+			},
+		},
+	}
+	args := []string{
+		fmt.Sprintf("--mysqld.address=127.0.0.1:%d", dbPort),
+		"--mysqld.username=" + mysql.Username,
+	}
+
+	return mysql.generateExporterContainer(env, args)
+}
+
+// generateExporterContainer generates the exporter container with the connection environment variables
+// and arguments.
+func (mysql *MySQL) generateExporterContainer(env []v1.EnvVar, args []string) v1.Container {
+	probe := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{
+				Path: "/",
+				Port: intstr.FromString(exporterPortName),
+			},
+		},
+		PeriodSeconds:  10,
+		TimeoutSeconds: 5,
+	}
+
+	return v1.Container{
+		Name:  exporterContainerName,
+		Image: mysql.Exporter.Image,
+		Args:  args,
+		Env:   env,
+		Ports: []v1.ContainerPort{
+			{
+				Name:          exporterPortName,
+				ContainerPort: int32(exporterPort),
+			},
+		},
+		ReadinessProbe: probe,
+		LivenessProbe:  probe,
+	}
+}
+
+// generateExporterDeployment generates the Kubernetes Deployment of the standalone exporter, which
+// connects to the cloud provided MySQL instance with the credentials in the database secret.
+func (mysql *MySQL) generateExporterDeployment(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	secretName := mysql.DatabaseName + dbResSuffix
+	credentials := []struct {
+		name string
+		key  string
+	}{
+		{name: dbHostAddressEnv, key: "hostAddress"},
+		{name: dbPortEnv, key: "port"},
+		{name: dbUsernameEnv, key: "username"},
+		{name: exporterPasswordEnv, key: "password"},
+	}
+
+	var env []v1.EnvVar
+	for _, credential := range credentials {
+		env = append(env, v1.EnvVar{
+			Name: credential.name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: mysql.generateSecretKeySelector(secretName, credential.key),
+			},
+		})
+	}
+	args := []string{
+		fmt.Sprintf("--mysqld.address=$(%s):$(%s)", dbHostAddressEnv, dbPortEnv),
+		fmt.Sprintf("--mysqld.username=$(%s)", dbUsernameEnv),
+	}
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + exporterSuffix,
+			Namespace: request.Project,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: mysql.generateExporterMatchLabels(),
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      mysql.generateExporterMatchLabels(),
+					Annotations: mysql.generatePasswordRotationAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{mysql.generateExporterContainer(env, args)},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(deployment.TypeMeta, deployment.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExporterService generates the Kubernetes Service of the standalone exporter.
+func (mysql *MySQL) generateExporterService(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + exporterServiceSuffix,
+			Namespace: request.Project,
+			Labels:    mysql.generateExporterMatchLabels(),
+		},
+		Spec: v1.ServiceSpec{
+			Ports:    []v1.ServicePort{mysql.generateExporterSvcPort()},
+			Selector: mysql.generateExporterMatchLabels(),
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(service.TypeMeta, service.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, service)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExporterSvcPort generates the named metrics port of the Service exposing the exporter.
+func (mysql *MySQL) generateExporterSvcPort() v1.ServicePort {
+	return v1.ServicePort{
+		Name:       exporterPortName,
+		Port:       int32(exporterPort),
+		TargetPort: intstr.FromString(exporterPortName),
+	}
+}
+
+// generateServiceMonitor generates the ServiceMonitor resource scraping the metrics port of the Service
+// selected by the labels.
+func (mysql *MySQL) generateServiceMonitor(request *module.GeneratorRequest, selector map[string]string) (
+	*kusionapiv1.Resource, error,
+) {
+	matchLabels := make(map[string]any, len(selector))
+	for key, value := range selector {
+		matchLabels[key] = value
+	}
+
+	name := mysql.DatabaseName + exporterMonitorSuffix
+	serviceMonitor := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": serviceMonitorAPIVersion,
+			"kind":       serviceMonitorKind,
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": request.Project,
+			},
+			"spec": map[string]any{
+				"selector": map[string]any{
+					"matchLabels": matchLabels,
+				},
+				"endpoints": []any{
+					map[string]any{
+						"port":          exporterPortName,
+						"path":          exporterPath,
+						"interval":      mysql.Exporter.Interval,
+						"scrapeTimeout": mysql.Exporter.Timeout,
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(metav1.TypeMeta{
+		Kind:       serviceMonitorKind,
+		APIVersion: serviceMonitorAPIVersion,
+	}, metav1.ObjectMeta{
+		Name:      name,
+		Namespace: request.Project,
+	})
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, serviceMonitor)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExporterMatchLabels generates the match labels of the standalone exporter.
+func (mysql *MySQL) generateExporterMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": mysql.DatabaseName + exporterSuffix,
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseExporter(t *testing.T) {
+	exporter, err := parseExporter(true)
+	assert.NoError(t, err)
+	assert.Equal(t, &Exporter{
+		Image:    "prom/mysqld-exporter:v0.15.1",
+		Interval: "30s",
+		Timeout:  "15s",
+	}, exporter)
+
+	exporter, err = parseExporter("false")
+	assert.NoError(t, err)
+	assert.Nil(t, exporter)
+
+	exporter, err = parseExporter(map[string]any{"interval": "1m"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Exporter{
+		Image:    "prom/mysqld-exporter:v0.15.1",
+		Interval: "1m",
+		Timeout:  "15s",
+	}, exporter)
+
+	_, err = parseExporter(1)
+	assert.ErrorIs(t, err, ErrInvalidExporterConfig)
+
+	_, err = parseExporter(map[string]any{"port": 9104})
+	assert.ErrorIs(t, err, ErrInvalidExporterConfig)
+}
+
+func TestMySQLModule_ValidateExporter(t *testing.T) {
+	exporter := &Exporter{Image: "prom/mysqld-exporter:v0.15.1", Interval: "30s", Timeout: "15s"}
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name:    "exporter of local database",
+			mysql:   &MySQL{Type: "local", Exporter: exporter},
+			success: true,
+		},
+		{
+			name:    "exporter of cloud database",
+			mysql:   &MySQL{Type: "cloud", Exporter: exporter},
+			success: true,
+		},
+		{
+			name:    "exporter of external database",
+			mysql:   &MySQL{Type: "external", Exporter: exporter},
+			success: false,
+		},
+		{
+			name:    "exporter with iam auth",
+			mysql:   &MySQL{Type: "cloud", Auth: "iam", Exporter: exporter},
+			success: false,
+		},
+		{
+			name: "invalid interval",
+			mysql: &MySQL{
+				Type:     "local",
+				Exporter: &Exporter{Image: "prom/mysqld-exporter:v0.15.1", Interval: "30", Timeout: "15s"},
+			},
+			success: false,
+		},
+		{
+			name: "timeout greater than interval",
+			mysql: &MySQL{
+				Type:     "local",
+				Exporter: &Exporter{Image: "prom/mysqld-exporter:v0.15.1", Interval: "10s", Timeout: "15s"},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateExporter()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidExporterConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateExporterResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	t.Run("exporter of local database", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "local",
+			DatabaseName: "test-database",
+			Exporter:     &Exporter{Image: "prom/mysqld-exporter:v0.15.1", Interval: "30s", Timeout: "15s"},
+		}
+
+		resources, err := mysql.generateExporterResources(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(resources))
+		assert.Equal(t, "monitoring.coreos.com/v1:ServiceMonitor:test-project:test-database-db-metrics", resources[0].ID)
+		assert.Equal(t, map[string]any{
+			"selector": map[string]any{
+				"matchLabels": map[string]any{"accessory": "test-database"},
+			},
+			"endpoints": []any{
+				map[string]any{
+					"port":          "metrics",
+					"path":          "/metrics",
+					"interval":      "30s",
+					"scrapeTimeout": "15s",
+				},
+			},
+		}, resources[0].Attributes["spec"])
+	})
+
+	t.Run("exporter of cloud database", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "cloud",
+			DatabaseName: "test-database",
+			Exporter:     &Exporter{Image: "prom/mysqld-exporter:v0.15.1", Interval: "30s", Timeout: "15s"},
+		}
+
+		resources, err := mysql.generateExporterResources(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(resources))
+		assert.Equal(t, "apps/v1:Deployment:test-project:test-database-db-exporter", resources[0].ID)
+		assert.Equal(t, "v1:Service:test-project:test-database-db-exporter-service", resources[1].ID)
+		assert.Equal(t, map[string]any{"accessory": "test-database-db-exporter"},
+			resources[2].Attributes["spec"].(map[string]any)["selector"].(map[string]any)["matchLabels"])
+
+		podSpec := resources[0].Attributes["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+		container := podSpec["containers"].([]any)[0].(map[string]any)
+		assert.Equal(t, []any{
+			"--mysqld.address=$(KUSION_DB_HOST):$(KUSION_DB_PORT)",
+			"--mysqld.username=$(KUSION_DB_USERNAME)",
+		}, container["args"])
+		assert.Equal(t, map[string]any{
+			"name": "MYSQLD_EXPORTER_PASSWORD",
+			"valueFrom": map[string]any{
+				"secretKeyRef": map[string]any{"name": "test-database-mysql", "key": "password"},
+			},
+		}, container["env"].([]any)[3])
+	})
+}
+
+func TestMySQLModule_GenerateLocalPodSpecWithExporter(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "local",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Exporter:     &Exporter{Image: "prom/mysqld-exporter:v0.15.1", Interval: "30s", Timeout: "15s"},
+	}
+
+	podSpec, err := mysql.generateLocalPodSpec(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(podSpec.Containers))
+	exporter := podSpec.Containers[1]
+	assert.Equal(t, "prom/mysqld-exporter:v0.15.1", exporter.Image)
+	assert.Equal(t, []string{"--mysqld.address=127.0.0.1:3306", "--mysqld.username=root"}, exporter.Args)
+	assert.Equal(t, "test-database-db-local-secret", exporter.Env[0].ValueFrom.SecretKeyRef.Name)
+
+	svcPort := mysql.generateLocalSvcPort()
+	assert.Equal(t, 2, len(svcPort))
+	assert.Equal(t, "mysql", svcPort[0].Name)
+	assert.Equal(t, "metrics", svcPort[1].Name)
+	assert.Equal(t, int32(9104), svcPort[1].Port)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseExternalDatabase(t *testing.T) {
+	t.Run("default external port", func(t *testing.T) {
+		actual, err := parseExternalDatabase(map[string]any{
+			"host":     "mysql.example.com",
+			"username": "root",
+			"password": "secret://mysql-credentials/password",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ExternalDatabase{
+			Host:     "mysql.example.com",
+			Port:     3306,
+			Username: "root",
+			Password: "secret://mysql-credentials/password",
+		}, actual)
+	})
+
+	t.Run("specified external port", func(t *testing.T) {
+		actual, err := parseExternalDatabase(map[string]any{
+			"host": "mysql.example.com",
+			"port": "13306",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 13306, actual.Port)
+	})
+
+	t.Run("invalid external config", func(t *testing.T) {
+		_, err := parseExternalDatabase("mysql.example.com")
+		assert.ErrorIs(t, err, ErrInvalidExternalConfig)
+
+		_, err = parseExternalDatabase(map[string]any{"port": "port"})
+		assert.ErrorIs(t, err, ErrInvalidExternalConfig)
+
+		_, err = parseExternalDatabase(map[string]any{"unknown": "value"})
+		assert.ErrorIs(t, err, ErrInvalidExternalConfig)
+	})
+}
+
+func TestParseSecretRef(t *testing.T) {
+	ref, ok := parseSecretRef("secret://mysql-credentials/password")
+	assert.True(t, ok)
+	assert.Equal(t, "mysql-credentials", ref.Name)
+	assert.Equal(t, "password", ref.Key)
+
+	for _, invalid := range []string{"password", "secret://mysql-credentials", "secret:///password", "secret://a/b/c"} {
+		_, ok = parseSecretRef(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestMySQLModule_ValidateExternalDatabase(t *testing.T) {
+	validExternal := func() *ExternalDatabase {
+		return &ExternalDatabase{
+			Host:     "mysql.example.com",
+			Port:     3306,
+			Username: "root",
+			Password: "secret://mysql-credentials/password",
+		}
+	}
+
+	testcases := []struct {
+		name        string
+		mysql       *MySQL
+		expectedErr error
+	}{
+		{
+			name:  "local type without external",
+			mysql: &MySQL{Type: "local"},
+		},
+		{
+			name:  "valid external database",
+			mysql: &MySQL{Type: "external", External: validExternal(), Databases: []string{"app"}},
+		},
+		{
+			name: "username referenced from secret",
+			mysql: &MySQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Username = "secret://mysql-credentials/username"
+				return external
+			}()},
+		},
+		{
+			name:        "external type without external",
+			mysql:       &MySQL{Type: "external"},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name:        "external with local type",
+			mysql:       &MySQL{Type: "local", External: validExternal()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "empty host",
+			mysql: &MySQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Host = ""
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "invalid port",
+			mysql: &MySQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Port = 65536
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "plain password",
+			mysql: &MySQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Password = "password"
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "invalid username secret reference",
+			mysql: &MySQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Username = "secret://mysql-credentials"
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name:        "replicas of external database",
+			mysql:       &MySQL{Type: "external", External: validExternal(), Replicas: 1},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name:        "users of external database",
+			mysql:       &MySQL{Type: "external", External: validExternal(), Users: []DatabaseUser{{Name: "app"}}},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateExternalDatabase()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateExternalResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	t.Run("plain username", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "external",
+			DatabaseName: "test-database",
+			External: &ExternalDatabase{
+				Host:     "mysql.example.com",
+				Port:     13306,
+				Username: "root",
+				Password: "secret://mysql-credentials/password",
+			},
+		}
+
+		resources, patcher, err := mysql.GenerateExternalResources(r)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(resources))
+		assert.Equal(t, map[string]any{
+			"hostAddress": "mysql.example.com",
+			"port":        "13306",
+			"database":    "test_database",
+			"username":    "root",
+		}, resources[0].Attributes["stringData"])
+
+		refs := make(map[string]*v1.SecretKeySelector)
+		for _, env := range patcher.Environments {
+			refs[env.Name] = env.ValueFrom.SecretKeyRef
+		}
+		assert.Equal(t, "test-database-mysql", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Name)
+		assert.Equal(t, "username", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Key)
+		assert.Equal(t, "mysql-credentials", refs["KUSION_DB_PASSWORD_TEST_DATABASE"].Name)
+		assert.Equal(t, "password", refs["KUSION_DB_PASSWORD_TEST_DATABASE"].Key)
+	})
+
+	t.Run("username referenced from secret", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:         "external",
+			DatabaseName: "test-database",
+			External: &ExternalDatabase{
+				Host:     "mysql.example.com",
+				Port:     3306,
+				Username: "secret://mysql-credentials/user",
+				Password: "secret://mysql-credentials/password",
+			},
+		}
+
+		resources, patcher, err := mysql.GenerateExternalResources(r)
+		assert.NoError(t, err)
+		assert.NotContains(t, resources[0].Attributes["stringData"], "username")
+
+		refs := make(map[string]*v1.SecretKeySelector)
+		for _, env := range patcher.Environments {
+			refs[env.Name] = env.ValueFrom.SecretKeyRef
+		}
+		assert.Equal(t, "mysql-credentials", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Name)
+		assert.Equal(t, "user", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Key)
+	})
+}
+
+func TestMySQLModule_GenerateMigrationEnvWithExternalDatabase(t *testing.T) {
+	mysql := &MySQL{
+		Migration: &Migration{Tool: GolangMigrateTool},
+		External: &ExternalDatabase{
+			Host:     "mysql.example.com",
+			Port:     3306,
+			Username: "root",
+			Password: "secret://mysql-credentials/password",
+		},
+	}
+
+	env := mysql.generateMigrationEnv("test-secret")
+
+	assert.Equal(t, dbPasswordEnv, env[4].Name)
+	assert.Equal(t, "mysql-credentials", env[4].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", env[4].ValueFrom.SecretKeyRef.Key)
+}
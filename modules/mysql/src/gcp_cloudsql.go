@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrEmptyGCPProviderRegion      = errors.New("empty gcp provider region")
+	ErrEmptyGCPNetworkForPrivateIP = errors.New("empty subnetID for gcp cloud sql instance with private routing")
+)
+
+var (
+	gcpRegionEnv                  = "GOOGLE_REGION"
+	googleSQLDatabaseInstance     = "google_sql_database_instance"
+	googleSQLDatabase             = "google_sql_database"
+	googleSQLUser                 = "google_sql_user"
+	googleSQLHighAvailability     = "HighAvailability"
+	googleSQLRegionalAvailability = "REGIONAL"
+	googleSQLZonalAvailability    = "ZONAL"
+)
+
+var defaultGoogleProviderCfg = module.ProviderConfig{
+	Source:  "hashicorp/google",
+	Version: "5.40.0",
+}
+
+type googleSQLSettings struct {
+	Tier                      string                     `yaml:"tier" json:"tier"`
+	DiskSize                  int                        `yaml:"disk_size" json:"disk_size"`
+	AvailabilityType          string                     `yaml:"availability_type" json:"availability_type"`
+	IPConfiguration           []googleSQLIPConfiguration `yaml:"ip_configuration" json:"ip_configuration"`
+	DeletionProtectionEnabled bool                       `yaml:"deletion_protection_enabled" json:"deletion_protection_enabled"`
+}
+
+type googleSQLIPConfiguration struct {
+	IPv4Enabled        bool                         `yaml:"ipv4_enabled" json:"ipv4_enabled"`
+	PrivateNetwork     string                       `yaml:"private_network,omitempty" json:"private_network,omitempty"`
+	AuthorizedNetworks []googleSQLAuthorizedNetwork `yaml:"authorized_networks,omitempty" json:"authorized_networks,omitempty"`
+}
+
+type googleSQLAuthorizedNetwork struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// GenerateGCPResources generates the GCP provided MySQL database instance.
+func (mysql *MySQL) GenerateGCPResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
+	var resources []kusionapiv1.Resource
+
+	// The read replicas of the GCP provided MySQL instance are not supported yet.
+	if mysql.Replicas > 0 {
+		return nil, nil, ErrUnsupportedReplicas
+	}
+
+	// The engine parameters of the GCP provided MySQL instance are not supported yet.
+	if len(mysql.Parameters) > 0 {
+		return nil, nil, ErrUnsupportedParameters
+	}
+
+	// Restoring the GCP provided MySQL instance is not supported yet.
+	if mysql.RestoreFrom != nil {
+		return nil, nil, ErrUnsupportedRestoreFrom
+	}
+
+	// Enforcing the TLS connections to the GCP provided MySQL instance is not supported yet.
+	if mysql.TLS != nil {
+		return nil, nil, ErrUnsupportedTLS
+	}
+
+	// The IAM database authentication is only supported by the AWS provided MySQL instance.
+	if mysql.isIAMAuth() {
+		return nil, nil, ErrUnsupportedIAMAuth
+	}
+
+	// The pooler is only supported by the local and the AWS provided MySQL instance yet.
+	if mysql.Pooler != nil {
+		return nil, nil, ErrUnsupportedPooler
+	}
+
+	// Delivering the password of the GCP provided MySQL instance with the ExternalSecret is not supported yet.
+	if mysql.isExternalSecretDelivery() {
+		return nil, nil, ErrUnsupportedCredentialDelivery
+	}
+
+	// Set the Google provider with the default provider config.
+	googleProviderCfg := defaultGoogleProviderCfg
+
+	// Get the Google Terraform provider region, which should not be empty.
+	var region string
+	if region = module.TerraformProviderRegion(googleProviderCfg); region == "" {
+		region = os.Getenv(gcpRegionEnv)
+	}
+	if region == "" {
+		return nil, nil, ErrEmptyGCPProviderRegion
+	}
+
+	// Build random_password resource.
+	randomPasswordRes, randomPasswordID, err := mysql.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build google_sql_database_instance resource.
+	googleSQLInstanceRes, googleSQLInstanceID, err := mysql.generateGoogleSQLDatabaseInstance(googleProviderCfg, region)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *googleSQLInstanceRes)
+
+	// Build google_sql_database resource.
+	googleSQLDatabaseRes, err := mysql.generateGoogleSQLDatabase(googleProviderCfg, region, googleSQLInstanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *googleSQLDatabaseRes)
+
+	// Build google_sql_user resource.
+	googleSQLUserRes, err := mysql.generateGoogleSQLUser(googleProviderCfg, region, randomPasswordID, googleSQLInstanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *googleSQLUserRes)
+
+	hostAddress := module.KusionPathDependency(googleSQLInstanceID, "private_ip_address")
+	if !mysql.PrivateRouting {
+		// Set the public ip address as the host address.
+		hostAddress = module.KusionPathDependency(googleSQLInstanceID, "public_ip_address")
+	}
+
+	// Build the logical databases, application users and grants inside the GCP provided MySQL instance.
+	dbUserResources, username, password, err := mysql.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), []string{googleSQLInstanceID, googleSQLDatabaseRes.ID, googleSQLUserRes.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// Build Kubernetes Secret with the hostAddress, username and password of the GCP provided MySQL instance,
+	// and inject the credentials as the environment variable patcher.
+	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, username, password, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *dbSecret)
+
+	return resources, patcher, nil
+}
+
+// generateGoogleSQLDatabaseInstance generates google_sql_database_instance resource
+// for the GCP provided MySQL database instance.
+func (mysql *MySQL) generateGoogleSQLDatabaseInstance(googleProviderCfg module.ProviderConfig,
+	region string,
+) (*kusionapiv1.Resource, string, error) {
+	// The private network is required for the workload to connect with the instance
+	// via the private ip address.
+	if mysql.PrivateRouting && mysql.SubnetID == "" {
+		return nil, "", ErrEmptyGCPNetworkForPrivateIP
+	}
+
+	// SecurityIPs should be in the format of IP address or Classes Inter-Domain
+	// Routing (CIDR) mode.
+	var authorizedNetworks []googleSQLAuthorizedNetwork
+	for i, ip := range mysql.SecurityIPs {
+		if !IsIPAddress(ip) && !IsCIDR(ip) {
+			return nil, "", fmt.Errorf("illegal security ip format: %s", ip)
+		}
+
+		authorizedNetworks = append(authorizedNetworks, googleSQLAuthorizedNetwork{
+			Name:  fmt.Sprintf("%s-%d", mysql.DatabaseName, i),
+			Value: ip,
+		})
+	}
+
+	ipConfiguration := googleSQLIPConfiguration{
+		IPv4Enabled:    !mysql.PrivateRouting || IsPublicAccessible(mysql.SecurityIPs),
+		PrivateNetwork: mysql.SubnetID,
+	}
+	if ipConfiguration.IPv4Enabled {
+		ipConfiguration.AuthorizedNetworks = authorizedNetworks
+	}
+
+	availabilityType := googleSQLZonalAvailability
+	if mysql.Category == googleSQLHighAvailability {
+		availabilityType = googleSQLRegionalAvailability
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":                mysql.DatabaseName,
+		"database_version":    "MYSQL_" + strings.ReplaceAll(mysql.Version, ".", "_"),
+		"region":              region,
+		"deletion_protection": mysql.DeletionProtection,
+		"settings": []googleSQLSettings{
+			{
+				Tier:             mysql.InstanceType,
+				DiskSize:         mysql.Size,
+				AvailabilityType: availabilityType,
+				IPConfiguration: []googleSQLIPConfiguration{
+					ipConfiguration,
+				},
+				DeletionProtectionEnabled: mysql.DeletionProtection,
+			},
+		},
+	}
+
+	id, err := module.TerraformResourceID(googleProviderCfg, googleSQLDatabaseInstance, mysql.DatabaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	googleProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(googleProviderCfg, googleSQLDatabaseInstance, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateGoogleSQLDatabase generates google_sql_database resource
+// for the GCP provided MySQL database instance.
+func (mysql *MySQL) generateGoogleSQLDatabase(googleProviderCfg module.ProviderConfig,
+	region, dbInstanceID string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"name":     mysql.generateLogicalDBName(),
+		"instance": module.KusionPathDependency(dbInstanceID, "name"),
+	}
+
+	id, err := module.TerraformResourceID(googleProviderCfg, googleSQLDatabase, mysql.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	googleProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(googleProviderCfg, googleSQLDatabase, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateGoogleSQLUser generates google_sql_user resource
+// for the GCP provided MySQL database instance.
+func (mysql *MySQL) generateGoogleSQLUser(googleProviderCfg module.ProviderConfig,
+	region, randomPasswordID, dbInstanceID string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"name":     mysql.Username,
+		"host":     "%",
+		"instance": module.KusionPathDependency(dbInstanceID, "name"),
+		"password": module.KusionPathDependency(randomPasswordID, "result"),
+	}
+
+	id, err := module.TerraformResourceID(googleProviderCfg, googleSQLUser, mysql.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	googleProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(googleProviderCfg, googleSQLUser, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestMySQLModule_GenerateGCPResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:           "cloud",
+		Version:        "8.0",
+		DatabaseName:   "test-database",
+		Username:       defaultUsername,
+		Category:       defaultCategory,
+		SecurityIPs:    defaultSecurityIPs,
+		PrivateRouting: false,
+		Size:           defaultSize,
+		InstanceType:   "db-f1-micro",
+	}
+
+	mockey.PatchConvey("set gcp region env", t, func() {
+		mockey.Mock(os.Getenv).Return("test-region").Build()
+
+		resources, patchers, err := mysql.GenerateGCPResources(r)
+
+		assert.Equal(t, 5, len(resources))
+		assert.NotNil(t, patchers)
+		assert.NoError(t, err)
+	})
+}
+
+func TestMySQLModule_GenerateGoogleSQLDatabaseInstance(t *testing.T) {
+	t.Run("private routing without network", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       defaultUsername,
+			SecurityIPs:    defaultSecurityIPs,
+			PrivateRouting: true,
+			Size:           defaultSize,
+			InstanceType:   "db-f1-micro",
+		}
+
+		res, id, err := mysql.generateGoogleSQLDatabaseInstance(defaultGoogleProviderCfg, "test-region")
+
+		assert.Nil(t, res)
+		assert.Equal(t, id, "")
+		assert.ErrorIs(t, err, ErrEmptyGCPNetworkForPrivateIP)
+	})
+
+	t.Run("illegal security ip", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       defaultUsername,
+			SecurityIPs:    []string{"illegal-ip"},
+			PrivateRouting: false,
+			Size:           defaultSize,
+			InstanceType:   "db-f1-micro",
+		}
+
+		res, id, err := mysql.generateGoogleSQLDatabaseInstance(defaultGoogleProviderCfg, "test-region")
+
+		assert.Nil(t, res)
+		assert.Equal(t, id, "")
+		assert.ErrorContains(t, err, "illegal security ip format")
+	})
+
+	t.Run("private routing with network", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:           "cloud",
+			Version:        "8.0",
+			DatabaseName:   "test-database",
+			Username:       defaultUsername,
+			SecurityIPs:    []string{"172.16.0.0/24"},
+			PrivateRouting: true,
+			Size:           defaultSize,
+			InstanceType:   "db-f1-micro",
+			SubnetID:       "projects/test-project/global/networks/test-network",
+		}
+
+		res, id, err := mysql.generateGoogleSQLDatabaseInstance(defaultGoogleProviderCfg, "test-region")
+
+		assert.NotNil(t, res)
+		assert.NotEqual(t, id, "")
+		assert.NoError(t, err)
+		assert.Equal(t, false, res.Attributes["deletion_protection"])
+	})
+
+	t.Run("deletion protection", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:               "cloud",
+			Version:            "8.0",
+			DatabaseName:       "test-database",
+			Username:           defaultUsername,
+			SecurityIPs:        defaultSecurityIPs,
+			PrivateRouting:     false,
+			Size:               defaultSize,
+			InstanceType:       "db-f1-micro",
+			DeletionProtection: true,
+		}
+
+		res, _, err := mysql.generateGoogleSQLDatabaseInstance(defaultGoogleProviderCfg, "test-region")
+
+		assert.NoError(t, err)
+		assert.Equal(t, true, res.Attributes["deletion_protection"])
+		assert.Equal(t, true, res.Attributes["settings"].([]googleSQLSettings)[0].DeletionProtectionEnabled)
+	})
+}
+
+func TestMySQLModule_GenerateGoogleSQLDatabase(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+	}
+
+	res, err := mysql.generateGoogleSQLDatabase(defaultGoogleProviderCfg, "test-region", "db_instance_id")
+
+	assert.NotNil(t, res)
+	assert.NoError(t, err)
+}
+
+func TestMySQLModule_GenerateGoogleSQLUser(t *testing.T) {
+	mysql := &MySQL{
+		Type:         "cloud",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+	}
+
+	res, err := mysql.generateGoogleSQLUser(defaultGoogleProviderCfg, "test-region",
+		"random_password_id", "db_instance_id")
+
+	assert.NotNil(t, res)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidInitScript = errors.New("invalid init script in mysql module config")
+
+var (
+	defaultInitScriptName = "init"
+	initScriptExt         = ".sql"
+)
+
+var initScriptNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// InitScript describes a SQL script run on the first boot of the locally deployed MySQL instance,
+// which is either written inline or read from a file.
+type InitScript struct {
+	// The name of the script, which defaults to the base name of the file.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The inline SQL statements of the script.
+	SQL string `json:"sql,omitempty" yaml:"sql,omitempty"`
+	// The path of the file containing the SQL statements, relative to the working directory of Kusion.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// parseInitScripts parses the init scripts declared in the devConfig.
+func parseInitScripts(config any) ([]InitScript, error) {
+	items, ok := config.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: initScripts should be a list", ErrInvalidInitScript)
+	}
+
+	scripts := make([]InitScript, 0, len(items))
+	for _, item := range items {
+		scriptMap, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: init script should be a map", ErrInvalidInitScript)
+		}
+
+		var script InitScript
+		if err := parseConfigBlock(scriptMap, ErrInvalidInitScript, "", map[string]configValueParser{
+			"name": configValue(&script.Name, assertConfigString),
+			"sql":  configValue(&script.SQL, assertConfigString),
+			"file": configValue(&script.File, assertConfigString),
+		}); err != nil {
+			return nil, err
+		}
+
+		scripts = append(scripts, script)
+	}
+
+	return scripts, nil
+}
+
+// validateInitScripts validates whether each init script has either the inline SQL or the file, and
+// whether the script names are valid and unique.
+func (mysql *MySQL) validateInitScripts() error {
+	names := make(map[string]bool)
+	for _, script := range mysql.InitScripts {
+		if (script.SQL == "") == (script.File == "") {
+			return fmt.Errorf("%w: exactly one of sql and file should be specified", ErrInvalidInitScript)
+		}
+
+		name := script.generateName()
+		if !initScriptNameRegexp.MatchString(name) {
+			return fmt.Errorf("%w: invalid script name %q", ErrInvalidInitScript, name)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: duplicate script name %q", ErrInvalidInitScript, name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// generateName generates the name of the init script without the extension.
+func (script InitScript) generateName() string {
+	name := script.Name
+	if name == "" && script.File != "" {
+		name = filepath.Base(script.File)
+	}
+	if name == "" {
+		name = defaultInitScriptName
+	}
+
+	return strings.TrimSuffix(name, initScriptExt)
+}
+
+// generateInitScriptsData generates the data of the init scripts keyed by the file names, which are
+// prefixed with the index to keep the declared order when executed by the image entrypoint.
+func (mysql *MySQL) generateInitScriptsData() (map[string]string, error) {
+	data := make(map[string]string, len(mysql.InitScripts))
+	for i, script := range mysql.InitScripts {
+		content := script.SQL
+		if script.File != "" {
+			bytes, err := os.ReadFile(script.File)
+			if err != nil {
+				return nil, fmt.Errorf("%w: failed to read file %s: %v", ErrInvalidInitScript, script.File, err)
+			}
+			content = string(bytes)
+		}
+
+		data[fmt.Sprintf("%02d-%s%s", i, script.generateName(), initScriptExt)] = content
+	}
+
+	return data, nil
+}
+
+// generateLocalInitScriptsConfigMap generates the Kubernetes ConfigMap resource holding the init
+// scripts of the local MySQL instance.
+func (mysql *MySQL) generateLocalInitScriptsConfigMap(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	data, err := mysql.generateInitScriptsData()
+	if err != nil {
+		return nil, err
+	}
+
+	configMap := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + localInitScriptsSuffix,
+			Namespace: request.Project,
+		},
+		Data: data,
+	}
+
+	resourceID := module.KubernetesResourceID(configMap.TypeMeta, configMap.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"kusionstack.io/kusion-module-framework/pkg/module"
 
@@ -18,22 +18,60 @@ import (
 func (mysql *MySQL) GenerateLocalResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
 	var resources []kusionapiv1.Resource
 
-	// Build Kubernetes Secret for the random password of the local MySQL instance.
-	password := mysql.generateLocalPassword(request)
-	localSecret, err := mysql.generateLocalSecret(request, password)
+	// Build random_password resource for the local MySQL instance.
+	randomPasswordRes, randomPasswordID, err := mysql.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build random_password resources for the application users of the local MySQL instance.
+	userPasswordResources, userPasswordIDs, err := mysql.generateUserRandomPasswords()
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, userPasswordResources...)
+
+	var userPasswords []string
+	for _, id := range userPasswordIDs {
+		userPasswords = append(userPasswords, module.KusionPathDependency(id, "result"))
+	}
+
+	// Build Kubernetes Secret for the random passwords of the local MySQL instance.
+	password := module.KusionPathDependency(randomPasswordID, "result")
+	localSecret, err := mysql.generateLocalSecret(request, password, userPasswords)
 	if err != nil {
 		return nil, nil, err
 	}
 	resources = append(resources, *localSecret)
 
-	// Build Kubernetes Deployment for the local MySQL instance.
-	localDeployment, err := mysql.generateLocalDeployment(request)
+	// Build Kubernetes ConfigMap for the init scripts of the local MySQL instance if declared.
+	if len(mysql.InitScripts) > 0 {
+		localInitScripts, err := mysql.generateLocalInitScriptsConfigMap(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *localInitScripts)
+	}
+
+	// Build Kubernetes ConfigMap for the option file with the engine parameters if declared.
+	if len(mysql.Parameters) > 0 {
+		localConfig, err := mysql.generateLocalConfigMap(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *localConfig)
+	}
+
+	// Build Kubernetes StatefulSet for the local MySQL instance.
+	localStatefulSet, err := mysql.generateLocalStatefulSet(request)
 	if err != nil {
 		return nil, nil, err
 	}
-	resources = append(resources, *localDeployment)
+	resources = append(resources, *localStatefulSet)
 
-	// Build Kubernetes Persistent Volume Claim for the lcoal MySQL instance.
+	// Build Kubernetes Persistent Volume Claim for the local MySQL instance, which keeps the name
+	// of the claim used by the former Deployment so that the existing data survives the upgrade.
 	localPVC, err := mysql.generateLocalPVC(request)
 	if err != nil {
 		return nil, nil, err
@@ -47,9 +85,56 @@ func (mysql *MySQL) GenerateLocalResources(request *module.GeneratorRequest) ([]
 	}
 	resources = append(resources, *localSvc)
 
+	// Build Kubernetes CronJob dumping the local MySQL instance on schedule if declared.
+	if mysql.Backup != nil {
+		backupResources, err := mysql.generateLocalBackupResources(request, hostAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, backupResources...)
+	}
+
+	// Build Kubernetes StatefulSet and Service for the binlog replicas of the local MySQL instance if declared.
+	var readHostAddresses []string
+	if mysql.Replicas > 0 {
+		replicaResources, readHostAddress, err := mysql.generateLocalReplicaResources(request, hostAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, replicaResources...)
+		readHostAddresses = append(readHostAddresses, readHostAddress)
+	}
+
+	// Build Kubernetes Deployment and Service for the connection pooler of the local MySQL instance if declared,
+	// through which the workload connects to the instance.
+	dbHostAddress := hostAddress
+	if mysql.Pooler != nil {
+		poolerResources, poolerHostAddress, err := mysql.generateLocalPoolerResources(request, hostAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, poolerResources...)
+		dbHostAddress = poolerHostAddress
+	}
+
+	// Build Kubernetes NetworkPolicy restricting the connections to the local MySQL instance if enabled.
+	if mysql.NetworkPolicy != nil && mysql.NetworkPolicy.Enabled {
+		networkPolicy, err := mysql.generateLocalNetworkPolicy(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *networkPolicy)
+	}
+
+	// Inject the credentials of the first application user into the workload if declared.
+	username := mysql.Username
+	if len(mysql.Users) > 0 {
+		username, password = mysql.Users[0].Name, userPasswords[0]
+	}
+
 	// Build Kubernetes Secret with the hostAddress, username and password of the local MySQL instance,
 	// and inject the credentials as the environment variable patcher.
-	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, mysql.Username, password)
+	dbSecret, patcher, err := mysql.GenerateDBSecret(request, dbHostAddress, username, password, readHostAddresses)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -59,10 +144,23 @@ func (mysql *MySQL) GenerateLocalResources(request *module.GeneratorRequest) ([]
 }
 
 // generateLocalSecret generates the Kubernetes Secret resource for the local MySQL instance.
-func (mysql *MySQL) generateLocalSecret(request *module.GeneratorRequest, password string) (*kusionapiv1.Resource, error) {
-	// Set the password string.
+func (mysql *MySQL) generateLocalSecret(request *module.GeneratorRequest, password string, userPasswords []string) (
+	*kusionapiv1.Resource, error,
+) {
+	return mysql.generateCredentialsSecret(request, mysql.DatabaseName+localSecretSuffix, password, userPasswords)
+}
+
+// generateCredentialsSecret generates the Kubernetes Secret resource holding the passwords of the administrator
+// and the application users of the MySQL instance.
+func (mysql *MySQL) generateCredentialsSecret(request *module.GeneratorRequest, name, password string,
+	userPasswords []string,
+) (*kusionapiv1.Resource, error) {
+	// Set the password strings of the administrator and the application users.
 	data := make(map[string]string)
 	data["password"] = password
+	for i, userPassword := range userPasswords {
+		data[generateUserPasswordKey(mysql.Users[i])] = userPassword
+	}
 
 	// Construct the Kubernetes Secret resource.
 	secret := &v1.Secret{
@@ -71,7 +169,7 @@ func (mysql *MySQL) generateLocalSecret(request *module.GeneratorRequest, passwo
 			APIVersion: v1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      mysql.DatabaseName + localSecretSuffix,
+			Name:      name,
 			Namespace: request.Project,
 		},
 		StringData: data,
@@ -86,39 +184,44 @@ func (mysql *MySQL) generateLocalSecret(request *module.GeneratorRequest, passwo
 	return resource, nil
 }
 
-// generateLocalDeployment generates the Kubernetes Deployment resource for the local MySQL instance.
-func (mysql *MySQL) generateLocalDeployment(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+// generateLocalStatefulSet generates the Kubernetes StatefulSet resource for the local MySQL instance.
+func (mysql *MySQL) generateLocalStatefulSet(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
 	// Prepare the Pod Spec for the local MySQL instance.
 	podSpec, err := mysql.generateLocalPodSpec(request)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 
-	// Create the Kubernetes Deployment for the local MySQL instance.
-	deployment := &appsv1.Deployment{
+	// Create the Kubernetes StatefulSet for the local MySQL instance, which only runs
+	// a single pod with the persistent volume claim mounted.
+	replicas := int32(1)
+	statefulSet := &appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
-			Kind:       "Deployment",
+			Kind:       "StatefulSet",
 			APIVersion: appsv1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      mysql.DatabaseName + localDeploymentSuffix,
+			Name:      mysql.DatabaseName + localStatefulSetSuffix,
 			Namespace: request.Project,
 		},
-		Spec: appsv1.DeploymentSpec{
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: mysql.DatabaseName + localServiceSuffix,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: mysql.generateLocalMatchLabels(),
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: mysql.generateLocalMatchLabels(),
+					Labels:      mysql.generateLocalMatchLabels(),
+					Annotations: mysql.generateLocalPodAnnotations(),
 				},
 				Spec: podSpec,
 			},
 		},
 	}
 
-	resourceID := module.KubernetesResourceID(deployment.TypeMeta, deployment.ObjectMeta)
-	resource, err := module.WrapK8sResourceToKusionResource(resourceID, deployment)
+	resourceID := module.KubernetesResourceID(statefulSet.TypeMeta, statefulSet.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, statefulSet)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +243,14 @@ func (mysql *MySQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1.PodSpec
 	ports := []v1.ContainerPort{
 		{
 			Name:          portName,
-			ContainerPort: int32(3306),
+			ContainerPort: int32(dbPort),
+		},
+	}
+
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      mysql.DatabaseName,
+			MountPath: "/var/lib/mysql",
 		},
 	}
 
@@ -154,16 +264,42 @@ func (mysql *MySQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1.PodSpec
 			},
 		},
 	}
-	volumeMounts := []v1.VolumeMount{
+
+	// The init scripts are mounted into the directory executed by the image entrypoint, which only
+	// runs them on the first boot with an empty data directory.
+	if len(mysql.InitScripts) > 0 {
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      localInitScriptsVolume,
+			MountPath: localInitScriptsPath,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, v1.Volume{
+			Name: localInitScriptsVolume,
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: mysql.DatabaseName + localInitScriptsSuffix,
+					},
+				},
+			},
+		})
+	}
+
+	// The option file with the engine parameters is read by mysqld on every start.
+	if len(mysql.Parameters) > 0 {
+		configVolume, configVolumeMount := mysql.generateLocalConfigVolume()
+		volumeMounts = append(volumeMounts, configVolumeMount)
+		volumes = append(volumes, configVolume)
+	}
+
+	env := []v1.EnvVar{
 		{
-			Name:      mysql.DatabaseName,
-			MountPath: "/var/lib/mysql",
+			Name:  "MYSQL_DATABASE",
+			Value: mysql.generateLogicalDBName(),
 		},
 	}
-
-	var env []v1.EnvVar
 	if mysql.Username != "root" {
-		env = []v1.EnvVar{
+		env = append(env, []v1.EnvVar{
 			{
 				Name:  "MYSQL_USER",
 				Value: mysql.Username,
@@ -179,9 +315,9 @@ func (mysql *MySQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1.PodSpec
 					},
 				},
 			},
-		}
+		}...)
 	} else {
-		env = []v1.EnvVar{
+		env = append(env, []v1.EnvVar{
 			{
 				Name: "MYSQL_ROOT_PASSWORD",
 				ValueFrom: &v1.EnvVarSource{
@@ -193,26 +329,146 @@ func (mysql *MySQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1.PodSpec
 					},
 				},
 			},
-		}
+		}...)
 	}
 
+	for i, user := range mysql.Users {
+		env = append(env, v1.EnvVar{
+			Name: generateUserPasswordEnv(i),
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secretName,
+					},
+					Key: generateUserPasswordKey(user),
+				},
+			},
+		})
+	}
+
+	resources, err := mysql.generateLocalResourceRequirements()
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+
+	// The local MySQL instance is regarded as ready only when it accepts connections
+	// via the TCP port, and the startup probe allows a long initialization on first boot.
 	podSpec := v1.PodSpec{
 		Containers: []v1.Container{
 			{
 				Name:         mysql.DatabaseName,
 				Image:        image,
+				Command:      []string{"sh", "-c", mysql.generateLocalEntrypoint()},
 				Env:          env,
 				Ports:        ports,
 				VolumeMounts: volumeMounts,
+				Resources:    resources,
+				ReadinessProbe: &v1.Probe{
+					ProbeHandler:     mysql.generateLocalProbeHandler(),
+					PeriodSeconds:    5,
+					TimeoutSeconds:   5,
+					FailureThreshold: 3,
+				},
+				LivenessProbe: &v1.Probe{
+					ProbeHandler:     mysql.generateLocalProbeHandler(),
+					PeriodSeconds:    10,
+					TimeoutSeconds:   5,
+					FailureThreshold: 6,
+				},
+				StartupProbe: &v1.Probe{
+					ProbeHandler:     mysql.generateLocalProbeHandler(),
+					PeriodSeconds:    10,
+					TimeoutSeconds:   5,
+					FailureThreshold: 30,
+				},
 			},
 		},
 		Volumes: volumes,
 	}
 
+	// The exporter sidecar exposes the metrics of the local MySQL instance if declared.
+	if mysql.Exporter != nil {
+		podSpec.Containers = append(podSpec.Containers, mysql.generateLocalExporterContainer())
+	}
+
 	return podSpec, nil
 }
 
+// generateLocalEntrypoint generates the entrypoint script of the local MySQL instance. The password
+// of an initialized instance is reset with the init file on every start, so that the rotated password
+// takes effect once the pod is recreated.
+func (mysql *MySQL) generateLocalEntrypoint() string {
+	passwordEnv, hosts := "MYSQL_PASSWORD", []string{"%"}
+	if mysql.Username == "root" {
+		passwordEnv, hosts = "MYSQL_ROOT_PASSWORD", []string{"%", "localhost"}
+	}
+
+	var statements []string
+	for _, host := range hosts {
+		statements = append(statements, fmt.Sprintf(
+			`printf "ALTER USER IF EXISTS '%%s'@'%%s' IDENTIFIED BY '%%s';\n" %q %q "$%s" >> %s`,
+			mysql.Username, host, passwordEnv, localInitFile,
+		))
+	}
+
+	script := fmt.Sprintf(`rm -f %s
+if [ -d /var/lib/mysql/mysql ]; then
+  %s
+fi
+`, localInitFile, strings.Join(statements, "\n  "))
+
+	// The logical databases, application users and grants are applied on every start including
+	// the first boot, with the passwords expanded from the environment variables.
+	if userStatements := mysql.generateUserStatements(); len(userStatements) > 0 {
+		script += fmt.Sprintf("cat >> %s <<EOSQL\n%s\nEOSQL\n", localInitFile, strings.Join(userStatements, "\n"))
+	}
+
+	// The binlog replicas replicate from the instance with the GTID based auto positioning.
+	var flags string
+	if mysql.Replicas > 0 {
+		flags = mysql.generateLocalReplicationFlags("1") + " "
+	}
+
+	return script + fmt.Sprintf(`if [ -s %s ]; then
+  set -- --init-file=%s
+fi
+exec docker-entrypoint.sh mysqld %s"$@"`, localInitFile, localInitFile, flags)
+}
+
+// generateLocalProbeHandler generates the probe handler checking whether the local MySQL instance
+// is alive and accepts connections.
+func (mysql *MySQL) generateLocalProbeHandler() v1.ProbeHandler {
+	return v1.ProbeHandler{
+		Exec: &v1.ExecAction{
+			Command: []string{"sh", "-c", "mysqladmin ping -h 127.0.0.1 --silent"},
+		},
+	}
+}
+
+// generateLocalResourceRequirements generates the cpu and memory requests of the local MySQL instance.
+func (mysql *MySQL) generateLocalResourceRequirements() (v1.ResourceRequirements, error) {
+	cpu, err := resource.ParseQuantity(mysql.CPU)
+	if err != nil {
+		return v1.ResourceRequirements{}, fmt.Errorf("invalid cpu request %q of the local mysql instance: %v", mysql.CPU, err)
+	}
+
+	memory, err := resource.ParseQuantity(mysql.Memory)
+	if err != nil {
+		return v1.ResourceRequirements{}, fmt.Errorf("invalid memory request %q of the local mysql instance: %v", mysql.Memory, err)
+	}
+
+	return v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    cpu,
+			v1.ResourceMemory: memory,
+		},
+	}, nil
+}
+
 // generateLocalPVC generates the Kubernetes Persistent Volume Claim resource for the local MySQL instance.
+// The StatefulSet mounts this claim instead of claiming the volume from a volumeClaimTemplate, whose
+// claims are named after the StatefulSet and the ordinal, so that the local instances deployed by the
+// former Deployment keep the existing claim and data on upgrade.
 func (mysql *MySQL) generateLocalPVC(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
 	// Create the Kubernetes PVC with the storage size of `mysql.Size`.
 	pvc := &v1.PersistentVolumeClaim{
@@ -225,16 +481,7 @@ func (mysql *MySQL) generateLocalPVC(request *module.GeneratorRequest) (*kusiona
 			Namespace: request.Project,
 			Labels:    mysql.generateLocalMatchLabels(),
 		},
-		Spec: v1.PersistentVolumeClaimSpec{
-			AccessModes: []v1.PersistentVolumeAccessMode{
-				v1.ReadWriteOnce,
-			},
-			Resources: v1.VolumeResourceRequirements{
-				Requests: map[v1.ResourceName]resource.Quantity{
-					v1.ResourceStorage: resource.MustParse(strconv.Itoa(mysql.Size) + "Gi"),
-				},
-			},
-		},
+		Spec: mysql.generateLocalPVCSpec(),
 	}
 
 	resourceID := module.KubernetesResourceID(pvc.TypeMeta, pvc.ObjectMeta)
@@ -246,6 +493,27 @@ func (mysql *MySQL) generateLocalPVC(request *module.GeneratorRequest) (*kusiona
 	return resource, nil
 }
 
+// generateLocalPVCSpec generates the Kubernetes Persistent Volume Claim spec of the local MySQL instance
+// and its read replicas, with the storage size of `mysql.Size` and the storage class if declared.
+func (mysql *MySQL) generateLocalPVCSpec() v1.PersistentVolumeClaimSpec {
+	spec := v1.PersistentVolumeClaimSpec{
+		AccessModes: []v1.PersistentVolumeAccessMode{
+			v1.ReadWriteOnce,
+		},
+		Resources: v1.VolumeResourceRequirements{
+			Requests: map[v1.ResourceName]resource.Quantity{
+				v1.ResourceStorage: resource.MustParse(strconv.Itoa(mysql.Size) + "Gi"),
+			},
+		},
+	}
+
+	if mysql.StorageClass != "" {
+		spec.StorageClassName = &mysql.StorageClass
+	}
+
+	return spec
+}
+
 // generateLocalService generates the Kubernetes Service resource for the local MySQL instance.
 func (mysql *MySQL) generateLocalService(request *module.GeneratorRequest) (*kusionapiv1.Resource, string, error) {
 	// Prepare the service port for the local MySQL instance.
@@ -283,10 +551,16 @@ func (mysql *MySQL) generateLocalService(request *module.GeneratorRequest) (*kus
 func (mysql *MySQL) generateLocalSvcPort() []v1.ServicePort {
 	svcPort := []v1.ServicePort{
 		{
-			Port: int32(3306),
+			Port: int32(dbPort),
 		},
 	}
 
+	// The ports of the Service are named once the metrics port of the exporter sidecar is exposed.
+	if mysql.Exporter != nil {
+		svcPort[0].Name = dbEngine
+		svcPort = append(svcPort, mysql.generateExporterSvcPort())
+	}
+
 	return svcPort
 }
 
@@ -296,13 +570,3 @@ func (mysql *MySQL) generateLocalMatchLabels() map[string]string {
 		"accessory": mysql.DatabaseName,
 	}
 }
-
-// generateLocalPassword generates a fixed password string with the specified length for the local MySQL instance.
-func (mysql *MySQL) generateLocalPassword(request *module.GeneratorRequest) string {
-	hashInput := request.Project + request.Stack + request.App + mysql.DatabaseName
-	hash := md5.Sum([]byte(hashInput))
-
-	hashString := hex.EncodeToString(hash[:])
-
-	return hashString[:16]
-}
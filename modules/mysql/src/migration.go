@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidMigrationConfig = errors.New("invalid migration config in mysql module config")
+	ErrEmptyMigrationImage    = errors.New("empty image of the migration in mysql module config")
+	ErrUnsupportedMigration   = errors.New("unsupported migration tool for mysql")
+)
+
+const (
+	FlywayTool        = "flyway"
+	LiquibaseTool     = "liquibase"
+	GolangMigrateTool = "golang-migrate"
+)
+
+var (
+	migrationJobSuffix      = "-db-migration"
+	migrationBackoffLimit   = int32(2)
+	migrateDatabaseURLEnv   = "MIGRATE_DATABASE_URL"
+	defaultMigrationsPath   = "/migrations"
+	migrationSpecHashLength = 8
+	migrationContainerName  = "migration"
+)
+
+// Migration describes the Kubernetes Job migrating the schema of the MySQL database with the
+// credentials injected into the workload.
+type Migration struct {
+	// The preset of the migration tool, which can be "flyway", "liquibase" or "golang-migrate".
+	Tool string `json:"tool,omitempty" yaml:"tool,omitempty"`
+	// The image containing the migration tool and the migration files.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The command of the migration container, which overrides the one of the tool preset.
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"`
+	// The arguments of the migration container, which override the ones of the tool preset.
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+// parseMigration parses the migration block of the devConfig.
+func parseMigration(config any) (*Migration, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidMigrationConfig, config)
+	}
+
+	migration := &Migration{}
+	if err := parseConfigBlock(configMap, ErrInvalidMigrationConfig, "", map[string]configValueParser{
+		"tool":    configValue(&migration.Tool, assertConfigString),
+		"image":   configValue(&migration.Image, assertConfigString),
+		"command": configValue(&migration.Command, toConfigStringSlice),
+		"args":    configValue(&migration.Args, toConfigStringSlice),
+	}); err != nil {
+		return nil, err
+	}
+
+	return migration, nil
+}
+
+// validateMigration validates whether the image and the tool of the migration are specified and supported.
+func (mysql *MySQL) validateMigration() error {
+	if mysql.Migration == nil {
+		return nil
+	}
+
+	if mysql.Migration.Image == "" {
+		return ErrEmptyMigrationImage
+	}
+
+	switch mysql.Migration.Tool {
+	case "", FlywayTool, LiquibaseTool, GolangMigrateTool:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedMigration, mysql.Migration.Tool)
+	}
+}
+
+// generateMigrationJob generates the Kubernetes Job resource running the schema migration with the
+// credentials in the database secret, whose resource is declared as the dependency of the Job. The Job
+// name is suffixed with the hash of the migration spec, so that a new Job is created once the spec
+// changes instead of updating the immutable pod template. The workload is not blocked by the Job.
+func (mysql *MySQL) generateMigrationJob(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	secretName := mysql.DatabaseName + dbResSuffix
+
+	specHash, err := mysql.generateMigrationSpecHash()
+	if err != nil {
+		return nil, err
+	}
+
+	command, args := mysql.generateMigrationCommand()
+	container := v1.Container{
+		Name:    migrationContainerName,
+		Image:   mysql.Migration.Image,
+		Command: command,
+		Args:    args,
+		Env:     mysql.generateMigrationEnv(secretName),
+	}
+
+	backoffLimit := migrationBackoffLimit
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + migrationJobSuffix + "-" + specHash,
+			Namespace: request.Project,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      mysql.generateClientMatchLabels(),
+					Annotations: mysql.generatePasswordRotationAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers:    []v1.Container{container},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(job.TypeMeta, job.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, job)
+	if err != nil {
+		return nil, err
+	}
+	resource.DependsOn = []string{mysql.generateDBSecretID(request)}
+
+	return resource, nil
+}
+
+// generateMigrationSpecHash generates the short hash of the migration spec.
+func (mysql *MySQL) generateMigrationSpecHash() (string, error) {
+	spec, err := json.Marshal(mysql.Migration)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(spec)
+
+	return hex.EncodeToString(sum[:])[:migrationSpecHashLength], nil
+}
+
+// generateMigrationCommand generates the command and arguments of the migration container, which
+// default to the ones of the tool preset.
+func (mysql *MySQL) generateMigrationCommand() ([]string, []string) {
+	var command, args []string
+	switch mysql.Migration.Tool {
+	case FlywayTool:
+		command, args = []string{"flyway"}, []string{"migrate"}
+	case LiquibaseTool:
+		command, args = []string{"liquibase"}, []string{"update"}
+	case GolangMigrateTool:
+		command = []string{"migrate"}
+		args = []string{"-path", defaultMigrationsPath, "-database", "$(" + migrateDatabaseURLEnv + ")", "up"}
+	}
+
+	if len(mysql.Migration.Command) > 0 {
+		command = mysql.Migration.Command
+	}
+	if len(mysql.Migration.Args) > 0 {
+		args = mysql.Migration.Args
+	}
+
+	return command, args
+}
+
+// generateMigrationEnv generates the environment variables of the migration container, composed of
+// the credentials in the database secret and the connection configs of the tool preset.
+func (mysql *MySQL) generateMigrationEnv(secretName string) []v1.EnvVar {
+	credentials := []struct {
+		name string
+		key  string
+	}{
+		{name: dbHostAddressEnv, key: "hostAddress"},
+		{name: dbPortEnv, key: "port"},
+		{name: dbDatabaseEnv, key: "database"},
+		{name: dbUsernameEnv, key: "username"},
+		{name: dbPasswordEnv, key: "password"},
+	}
+
+	var env []v1.EnvVar
+	for _, credential := range credentials {
+		env = append(env, v1.EnvVar{
+			Name: credential.name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: mysql.generateSecretKeySelector(secretName, credential.key),
+			},
+		})
+	}
+
+	// The connection configs are composed of the credential environment variables above, which
+	// should be placed after them for the dependent variable expansion.
+	host, port, database := "$("+dbHostAddressEnv+")", "$("+dbPortEnv+")", "$("+dbDatabaseEnv+")"
+	username, password := "$("+dbUsernameEnv+")", "$("+dbPasswordEnv+")"
+	jdbcURL := fmt.Sprintf("jdbc:mysql://%s:%s/%s", host, port, database)
+
+	switch mysql.Migration.Tool {
+	case FlywayTool:
+		env = append(env, []v1.EnvVar{
+			{Name: "FLYWAY_URL", Value: jdbcURL},
+			{Name: "FLYWAY_USER", Value: username},
+			{Name: "FLYWAY_PASSWORD", Value: password},
+		}...)
+	case LiquibaseTool:
+		env = append(env, []v1.EnvVar{
+			{Name: "LIQUIBASE_COMMAND_URL", Value: jdbcURL},
+			{Name: "LIQUIBASE_COMMAND_USERNAME", Value: username},
+			{Name: "LIQUIBASE_COMMAND_PASSWORD", Value: password},
+		}...)
+	case GolangMigrateTool:
+		env = append(env, v1.EnvVar{
+			Name:  migrateDatabaseURLEnv,
+			Value: fmt.Sprintf("mysql://%s:%s@tcp(%s:%s)/%s", username, password, host, port, database),
+		})
+	}
+
+	return env
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -19,28 +22,43 @@ import (
 )
 
 const (
-	CloudDBType = "cloud"
-	LocalDBType = "local"
+	CloudDBType    = "cloud"
+	LocalDBType    = "local"
+	ExternalDBType = "external"
+	OperatorDBType = "operator"
 )
 
 const (
 	dbEngine         = "mysql"
 	dbResSuffix      = "-mysql"
+	dbPort           = 3306
 	dbHostAddressEnv = "KUSION_DB_HOST"
 	dbUsernameEnv    = "KUSION_DB_USERNAME"
 	dbPasswordEnv    = "KUSION_DB_PASSWORD"
+	dbPortEnv        = "KUSION_DB_PORT"
+	dbDatabaseEnv    = "KUSION_DB_DATABASE"
+	dbReadHostEnv    = "KUSION_DB_READ_HOST"
+
+	passwordRotationAnnotation = "kusionstack.io/password-rotation"
 )
 
 var (
-	ErrEmptyInstanceTypeForCloudDB = errors.New("empty instance type for cloud managed mysql instance")
-	ErrEmptyCloudProviderType      = errors.New("empty cloud provider type in mysql module config")
+	ErrEmptyInstanceTypeForCloudDB  = errors.New("empty instance type for cloud managed mysql instance")
+	ErrEmptyCloudProviderType       = errors.New("empty cloud provider type in mysql module config")
+	ErrInvalidBackupRetentionPeriod = errors.New("backup retention period of mysql instance should be between 0 and 35 days")
+	ErrInvalidReplicas              = errors.New("replicas of mysql instance should be between 0 and 5")
+	ErrUnsupportedReplicas          = errors.New("read replicas are not supported for the mysql instance of this cloud provider")
 )
 
 var (
-	localDeploymentSuffix = "-db-local-deployment"
-	localSecretSuffix     = "-db-local-secret"
-	localPVCSuffix        = "-db-local-pvc"
-	localServiceSuffix    = "-db-local-service"
+	localStatefulSetSuffix = "-db-local-statefulset"
+	localSecretSuffix      = "-db-local-secret"
+	localPVCSuffix         = "-db-local-pvc"
+	localServiceSuffix     = "-db-local-service"
+	localInitFile          = "/tmp/kusion-init.sql"
+	localInitScriptsSuffix = "-db-local-init-scripts"
+	localInitScriptsPath   = "/docker-entrypoint-initdb.d"
+	localInitScriptsVolume = "init-scripts"
 )
 
 var (
@@ -49,6 +67,14 @@ var (
 	defaultSecurityIPs    []string = []string{"0.0.0.0/0"}
 	defaultPrivateRouting bool     = true
 	defaultSize           int      = 10
+	defaultCPU            string   = "500m"
+	defaultMemory         string   = "512Mi"
+
+	defaultMultiAZ                 bool = false
+	defaultBackupRetentionPeriod   int  = 7
+	defaultDeletionProtection      bool = true
+	defaultSkipFinalSnapshot       bool = false
+	defaultAutoMinorVersionUpgrade bool = true
 )
 
 var defaultRandomProviderCfg = module.ProviderConfig{
@@ -56,7 +82,11 @@ var defaultRandomProviderCfg = module.ProviderConfig{
 	Version: "3.6.0",
 }
 
-var randomPassword = "random_password"
+var (
+	randomPassword         = "random_password"
+	randomID               = "random_id"
+	passwordRotationKeeper = "rotation"
+)
 
 // MySQL describes the attributes to locally deploy or create a cloud provider
 // managed MySQL database instance for the workload.
@@ -82,6 +112,96 @@ type MySQL struct {
 	PrivateRouting bool `json:"privateRouting,omitempty" yaml:"privateRouting,omitempty"`
 	// The specified name of the MySQL database instance.
 	DatabaseName string `json:"databaseName,omitempty" yaml:"databaseName,omitempty"`
+	// The resource group that the Azure MySQL flexible server will be created in.
+	ResourceGroup string `json:"resourceGroup,omitempty" yaml:"resourceGroup,omitempty"`
+	// The private DNS zone ID of the Azure MySQL flexible server in the delegated subnet.
+	PrivateDNSZoneID string `json:"privateDNSZoneID,omitempty" yaml:"privateDNSZoneID,omitempty"`
+	// Whether the AWS RDS MySQL instance is deployed in multiple availability zones.
+	MultiAZ bool `json:"multiAZ,omitempty" yaml:"multiAZ,omitempty"`
+	// The days to retain the automated backups of the AWS RDS MySQL instance.
+	BackupRetentionPeriod int `json:"backupRetentionPeriod,omitempty" yaml:"backupRetentionPeriod,omitempty"`
+	// The daily time range in UTC during which the automated backups are created, such as "03:00-04:00".
+	BackupWindow string `json:"backupWindow,omitempty" yaml:"backupWindow,omitempty"`
+	// The weekly time range in UTC during which the maintenance can occur, such as "sun:05:00-sun:06:00".
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty" yaml:"maintenanceWindow,omitempty"`
+	// Whether the cloud provided MySQL instance is protected from being deleted, which is the deletion
+	// protection of AWS and GCP, and the management lock of the flexible server for Azure.
+	DeletionProtection bool `json:"deletionProtection,omitempty" yaml:"deletionProtection,omitempty"`
+	// Whether to skip creating the final snapshot before the AWS RDS MySQL instance is deleted.
+	SkipFinalSnapshot bool `json:"skipFinalSnapshot,omitempty" yaml:"skipFinalSnapshot,omitempty"`
+	// The storage type of the AWS or Alicloud RDS MySQL instance, such as "gp3" for AWS and "cloud_essd" for Alicloud.
+	StorageType string `json:"storageType,omitempty" yaml:"storageType,omitempty"`
+	// The provisioned IOPS of the AWS RDS MySQL instance.
+	Iops int `json:"iops,omitempty" yaml:"iops,omitempty"`
+	// Whether the minor engine upgrades are applied automatically to the AWS RDS MySQL instance.
+	AutoMinorVersionUpgrade bool `json:"autoMinorVersionUpgrade,omitempty" yaml:"autoMinorVersionUpgrade,omitempty"`
+	// The charge type of the Alicloud RDS MySQL instance, which can be "Prepaid" or "Postpaid".
+	ChargeType string `json:"chargeType,omitempty" yaml:"chargeType,omitempty"`
+	// The subscription period in months of the prepaid Alicloud RDS MySQL instance.
+	Period int `json:"period,omitempty" yaml:"period,omitempty"`
+	// The zones of the Alicloud RDS MySQL instance, the first of which is the primary zone and the
+	// others are the secondary zones of the multi-zone instance.
+	Zones []string `json:"zones,omitempty" yaml:"zones,omitempty"`
+	// The scaling config of the Alicloud serverless RDS MySQL instance.
+	Serverless *AlicloudServerless `json:"serverless,omitempty" yaml:"serverless,omitempty"`
+	// The engine parameters of the MySQL instance, such as "max_connections" and "innodb_buffer_pool_size".
+	Parameters map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// The AWS Aurora MySQL cluster created instead of the single AWS RDS MySQL instance.
+	Aurora *AuroraCluster `json:"aurora,omitempty" yaml:"aurora,omitempty"`
+	// The cpu request of the locally deployed MySQL instance.
+	CPU string `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	// The memory request of the locally deployed MySQL instance.
+	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
+	// The storage class of the persistent volume for the locally deployed MySQL instance.
+	StorageClass string `json:"storageClass,omitempty" yaml:"storageClass,omitempty"`
+	// The rotation trigger of the MySQL password, changing it regenerates the password.
+	PasswordRotation string `json:"passwordRotation,omitempty" yaml:"passwordRotation,omitempty"`
+	// The connection string of the MySQL database injected into the workload.
+	ConnectionURL *ConnectionURL `json:"connectionURL,omitempty" yaml:"connectionURL,omitempty"`
+	// The logical databases created in the MySQL instance, the first of which is for the workload to connect with.
+	Databases []string `json:"databases,omitempty" yaml:"databases,omitempty"`
+	// The application users with the least privileges created in the MySQL instance, the credentials
+	// of the first of which are injected into the workload.
+	Users []DatabaseUser `json:"users,omitempty" yaml:"users,omitempty"`
+	// The SQL scripts run on the first boot of the locally deployed MySQL instance.
+	InitScripts []InitScript `json:"initScripts,omitempty" yaml:"initScripts,omitempty"`
+	// The number of the read replicas of the MySQL instance.
+	Replicas int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	// The existing MySQL database managed outside of Kusion for the external type.
+	External *ExternalDatabase `json:"external,omitempty" yaml:"external,omitempty"`
+	// The authentication of the workload connecting to the MySQL instance, which can be "password" or
+	// "iam" for the IAM database authentication of the AWS provided instance.
+	Auth string `json:"auth,omitempty" yaml:"auth,omitempty"`
+	// The ARN of the IAM OIDC provider of the EKS cluster trusted by the IAM role for the IAM database authentication.
+	OIDCProviderARN string `json:"oidcProviderARN,omitempty" yaml:"oidcProviderARN,omitempty"`
+	// The dedicated ServiceAccount the workload runs as, which is created and annotated with the IAM role
+	// for the IAM database authentication.
+	ServiceAccount string `json:"serviceAccount,omitempty" yaml:"serviceAccount,omitempty"`
+	// The delivery of the generated password to the workload, which is synced from the secret manager
+	// of the cloud provider with the ExternalSecret instead of being stored in the Kubernetes Secret.
+	CredentialDelivery *CredentialDelivery `json:"credentialDelivery,omitempty" yaml:"credentialDelivery,omitempty"`
+	// The TLS connections enforced by the cloud provided MySQL instance.
+	TLS *TLS `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// The snapshot or the point in time of the source instance the cloud provided MySQL instance is restored from.
+	RestoreFrom *RestoreFrom `json:"restoreFrom,omitempty" yaml:"restoreFrom,omitempty"`
+	// The scheduled backups of the local MySQL instance.
+	Backup *Backup `json:"backup,omitempty" yaml:"backup,omitempty"`
+	// The connection pooler deployed in front of the local MySQL instance.
+	Pooler *Pooler `json:"pooler,omitempty" yaml:"pooler,omitempty"`
+	// The InnoDB Cluster managed by the MySQL Operator for the operator type.
+	Operator *Operator `json:"operator,omitempty" yaml:"operator,omitempty"`
+	// The exporter exposing the metrics of the MySQL instance to Prometheus.
+	Exporter *Exporter `json:"exporter,omitempty" yaml:"exporter,omitempty"`
+	// The NetworkPolicy isolating the local MySQL instance.
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty" yaml:"networkPolicy,omitempty"`
+	// The Kubernetes Job migrating the schema of the MySQL database.
+	Migration *Migration `json:"migration,omitempty" yaml:"migration,omitempty"`
+
+	// The warnings of the ignored keys in the devConfig and platformConfig.
+	warnings []string
+	// Whether the default logical database is not created by the backend, without any databases or
+	// application users declared for the Job to create it.
+	noDefaultDatabase bool
 }
 
 func (mysql *MySQL) Generate(ctx context.Context, request *module.GeneratorRequest) (response *module.GeneratorResponse, err error) {
@@ -111,6 +231,9 @@ func (mysql *MySQL) Generate(ctx context.Context, request *module.GeneratorReque
 	if err != nil {
 		return nil, err
 	}
+	for _, warning := range mysql.warnings {
+		logger.Info("Ignoring the module config", "warning", warning)
+	}
 
 	// Set the database name.
 	if mysql.DatabaseName == "" {
@@ -124,6 +247,10 @@ func (mysql *MySQL) Generate(ctx context.Context, request *module.GeneratorReque
 	switch strings.ToLower(mysql.Type) {
 	case LocalDBType:
 		resources, patcher, err = mysql.GenerateLocalResources(request)
+	case ExternalDBType:
+		resources, patcher, err = mysql.GenerateExternalResources(request)
+	case OperatorDBType:
+		resources, patcher, err = mysql.GenerateOperatorResources(request)
 	case CloudDBType:
 		providerType, err = GetCloudProviderType(request.PlatformConfig)
 		if err != nil {
@@ -141,12 +268,53 @@ func (mysql *MySQL) Generate(ctx context.Context, request *module.GeneratorReque
 			if err != nil {
 				return nil, err
 			}
+		case "gcp":
+			resources, patcher, err = mysql.GenerateGCPResources(request)
+			if err != nil {
+				return nil, err
+			}
+		case "azure":
+			resources, patcher, err = mysql.GenerateAzureResources(request)
+			if err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("unsupported cloud provider type: %s", providerType)
 		}
 	default:
 		return nil, fmt.Errorf("unsupported mysql type: %s", mysql.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Build Kubernetes Secret with the CA bundle of the cloud provider if declared.
+	if mysql.hasCABundle() {
+		caSecret, err := mysql.generateCASecret(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *caSecret)
+	}
+
+	// Build ServiceMonitor scraping the metrics exposed by the exporter, along with the standalone
+	// exporter for the cloud provided instance, if declared.
+	if mysql.Exporter != nil {
+		exporterResources, err := mysql.generateExporterResources(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, exporterResources...)
+	}
+
+	// Build Kubernetes Job for the schema migration with the database credentials if declared.
+	if mysql.Migration != nil {
+		migrationJob, err := mysql.generateMigrationJob(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *migrationJob)
+	}
 
 	return &module.GeneratorResponse{
 		Resources: resources,
@@ -157,83 +325,122 @@ func (mysql *MySQL) Generate(ctx context.Context, request *module.GeneratorReque
 // GetCompleteConfig combines the configs in devModuleConfig and platformModuleConfig to form a complete
 // configuration for the MySQL instance.
 func (mysql *MySQL) GetCompleteConfig(devConfig kusionapiv1.Accessory, platformConfig kusionapiv1.GenericConfig) error {
-	// Set the default values for MySQL instance if platformConfig not exists.
-	if platformConfig == nil {
-		mysql.Username = defaultUsername
-		mysql.Category = defaultCategory
-		mysql.SecurityIPs = defaultSecurityIPs
-		mysql.PrivateRouting = defaultPrivateRouting
-		mysql.Size = defaultSize
-	}
-
-	// Get the type and version of the MySQL instance in devConfig.
-	if mysqlType, ok := devConfig["type"]; ok {
-		mysql.Type = mysqlType.(string)
-	}
-	if mysqlVersion, ok := devConfig["version"]; ok {
-		mysql.Version = mysqlVersion.(string)
+	// Set the default values for MySQL instance, which are overridden by the configs below.
+	mysql.Username = defaultUsername
+	mysql.Category = defaultCategory
+	mysql.SecurityIPs = defaultSecurityIPs
+	mysql.PrivateRouting = defaultPrivateRouting
+	mysql.Size = defaultSize
+	mysql.CPU = defaultCPU
+	mysql.Memory = defaultMemory
+	mysql.MultiAZ = defaultMultiAZ
+	mysql.BackupRetentionPeriod = defaultBackupRetentionPeriod
+	mysql.DeletionProtection = defaultDeletionProtection
+	mysql.SkipFinalSnapshot = defaultSkipFinalSnapshot
+	mysql.AutoMinorVersionUpgrade = defaultAutoMinorVersionUpgrade
+
+	// Get the type, version, logical databases, application users, init scripts and schema
+	// migration of the MySQL instance in devConfig.
+	mysql.warnings = nil
+	if err := mysql.decodeConfig(devConfig, devConfigSource); err != nil {
+		return err
 	}
 
-	// Get the other configs of the MySQL instance in platformConfig,
-	// and use the default values if some of them don't exist.
-	if username, ok := platformConfig["username"]; ok {
-		mysql.Username = username.(string)
-	} else {
-		mysql.Username = defaultUsername
+	// The Azure provided MySQL instance uses the other default administrator login than the reserved
+	// default username, which is overridden by the one declared in the platformConfig.
+	if strings.EqualFold(mysql.Type, CloudDBType) {
+		if cloudType, err := GetCloudProviderType(platformConfig); err == nil && strings.EqualFold(cloudType, "azure") {
+			mysql.Username = defaultAzureAdministratorLogin
+		}
 	}
 
-	if category, ok := platformConfig["category"]; ok {
-		mysql.Category = category.(string)
-	} else {
-		mysql.Category = defaultCategory
+	// Get the other configs of the MySQL instance in platformConfig.
+	if err := mysql.decodeConfig(platformConfig, platformConfigSource); err != nil {
+		return err
 	}
 
-	if securityIPs, ok := platformConfig["securityIPs"]; ok {
-		mysql.SecurityIPs = securityIPs.([]string)
-	} else {
-		mysql.SecurityIPs = defaultSecurityIPs
+	// The InnoDB Cluster is deployed with the default settings if not declared by the platform.
+	if strings.EqualFold(mysql.Type, OperatorDBType) && mysql.Operator == nil {
+		mysql.Operator = defaultOperator()
 	}
 
-	if privateRouting, ok := platformConfig["privateRouting"]; ok {
-		mysql.PrivateRouting = privateRouting.(bool)
-	} else {
-		mysql.PrivateRouting = defaultPrivateRouting
+	// The local MySQL instance is isolated by the NetworkPolicy if not declared by the platform.
+	if strings.EqualFold(mysql.Type, LocalDBType) && mysql.NetworkPolicy == nil {
+		mysql.NetworkPolicy = &NetworkPolicy{Enabled: true}
 	}
 
-	if size, ok := platformConfig["size"]; ok {
-		mysql.Size = size.(int)
-	} else {
-		mysql.Size = defaultSize
-	}
+	return mysql.Validate()
+}
 
-	if instanceType, ok := platformConfig["instanceType"]; ok {
-		mysql.InstanceType = instanceType.(string)
-	}
+// GenerateDBSecret generates Kubernetes Secret resource to store the host address, port, database,
+// username and password of the MySQL database instance, along with the host addresses of the read
+// replicas if any.
+func (mysql *MySQL) GenerateDBSecret(request *module.GeneratorRequest, hostAddress, username, password string,
+	readHostAddresses []string,
+) (
+	*kusionapiv1.Resource, *kusionapiv1.Patcher, error,
+) {
+	return mysql.generateDBSecret(request, hostAddress, username, password, readHostAddresses,
+		mysql.generateRemoteSecretRef(request))
+}
 
-	if subnetID, ok := platformConfig["subnetID"]; ok {
-		mysql.SubnetID = subnetID.(string)
+// generateDBSecretID generates the ID of the resource emitting the database secret of GenerateDBSecret,
+// which is the ExternalSecret syncing the secret for the external secret delivery.
+func (mysql *MySQL) generateDBSecretID(request *module.GeneratorRequest) string {
+	typeMeta := metav1.TypeMeta{
+		Kind:       "Secret",
+		APIVersion: v1.SchemeGroupVersion.String(),
 	}
-
-	if databaseName, ok := platformConfig["databaseName"]; ok {
-		mysql.DatabaseName = databaseName.(string)
+	if mysql.isExternalSecretDelivery() {
+		typeMeta = metav1.TypeMeta{
+			Kind:       externalSecretKind,
+			APIVersion: externalSecretAPIVersion,
+		}
 	}
 
-	return mysql.Validate()
+	return module.KubernetesResourceID(typeMeta, metav1.ObjectMeta{
+		Name:      mysql.DatabaseName + dbResSuffix,
+		Namespace: request.Project,
+	})
 }
 
-// GenerateDBSecret generates Kubernetes Secret resource to store the host address, username
-// and password of the local MySQL database instance.
-func (mysql *MySQL) GenerateDBSecret(request *module.GeneratorRequest, hostAddress, username, password string) (
+// generateDBSecret generates the Kubernetes Secret resource of GenerateDBSecret, which is synced by the
+// ExternalSecret with the password referred to by ref in the secret manager for the external secret
+// delivery.
+func (mysql *MySQL) generateDBSecret(request *module.GeneratorRequest, hostAddress, username, password string,
+	readHostAddresses []string, ref remoteSecretRef,
+) (
 	*kusionapiv1.Resource, *kusionapiv1.Patcher, error,
 ) {
-	// Create the data map of Kubernetes Secret storing the database host address, username
-	// and password.
+	// Create the data map of Kubernetes Secret storing the database host address, port, database,
+	// username and password.
 	data := make(map[string]string)
 	data["hostAddress"] = hostAddress
+	data["port"] = strconv.Itoa(mysql.generatePort())
+	data["database"] = mysql.generateLogicalDBName()
 	data["username"] = username
 	data["password"] = password
+	for i, readHostAddress := range readHostAddresses {
+		data[generateReadHostAddressKey(i)] = readHostAddress
+	}
+
+	if mysql.TLS != nil {
+		data["sslMode"] = mysql.generateSSLMode()
+	}
+
+	// The workload connects with the IAM authentication token generated on its own instead of the password.
+	if mysql.isIAMAuth() {
+		delete(data, "password")
+	}
+
+	// The credentials referenced from the existing secrets of the external database are not stored.
+	for _, key := range []string{"username", "password"} {
+		if _, ok := mysql.generateExternalSecretRef(key); ok {
+			delete(data, key)
+		}
+	}
 
-	// Create the Kubernetes Secret.
+	// Create the Kubernetes Secret, or the ExternalSecret syncing the password into it.
 	secret := &v1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Secret",
@@ -246,17 +453,25 @@ func (mysql *MySQL) GenerateDBSecret(request *module.GeneratorRequest, hostAddre
 		StringData: data,
 	}
 
-	resourceID := module.KubernetesResourceID(secret.TypeMeta, secret.ObjectMeta)
-	resource, err := module.WrapK8sResourceToKusionResource(resourceID, secret)
+	var resource *kusionapiv1.Resource
+	var err error
+	if mysql.isExternalSecretDelivery() {
+		resource, err = mysql.generateExternalSecret(request, secret.Name, data, ref)
+	} else {
+		resourceID := module.KubernetesResourceID(secret.TypeMeta, secret.ObjectMeta)
+		resource, err = module.WrapK8sResourceToKusionResource(resourceID, secret)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Inject the database credentials into the workload as the environment variables with
 	// Kusion resource patcher.
-	hostAddressKey := dbHostAddressEnv + "_" + strings.ToUpper(strings.ReplaceAll(mysql.DatabaseName, "-", "_"))
-	usernameKey := dbUsernameEnv + "_" + strings.ToUpper(strings.ReplaceAll(mysql.DatabaseName, "-", "_"))
-	passwordKey := dbPasswordEnv + "_" + strings.ToUpper(strings.ReplaceAll(mysql.DatabaseName, "-", "_"))
+	hostAddressKey := dbHostAddressEnv + "_" + mysql.generateEnvSuffix()
+	usernameKey := dbUsernameEnv + "_" + mysql.generateEnvSuffix()
+	passwordKey := dbPasswordEnv + "_" + mysql.generateEnvSuffix()
+	portKey := dbPortEnv + "_" + mysql.generateEnvSuffix()
+	databaseKey := dbDatabaseEnv + "_" + mysql.generateEnvSuffix()
 
 	envVars := []v1.EnvVar{
 		{
@@ -272,48 +487,136 @@ func (mysql *MySQL) GenerateDBSecret(request *module.GeneratorRequest, hostAddre
 		},
 		{
 			Name: usernameKey,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: mysql.generateSecretKeySelector(secret.Name, "username"),
+			},
+		},
+		{
+			Name: passwordKey,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: mysql.generateSecretKeySelector(secret.Name, "password"),
+			},
+		},
+		{
+			Name: portKey,
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
 						Name: secret.Name,
 					},
-					Key: "username",
+					Key: "port",
 				},
 			},
 		},
 		{
-			Name: passwordKey,
+			Name: databaseKey,
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
 						Name: secret.Name,
 					},
-					Key: "password",
+					Key: "database",
 				},
 			},
 		},
 	}
 
+	if mysql.isIAMAuth() {
+		envVars = slices.DeleteFunc(envVars, func(envVar v1.EnvVar) bool {
+			return envVar.Name == passwordKey
+		})
+	}
+
+	// Inject the host addresses of the read replicas for the workload to split the read traffic.
+	for i := range readHostAddresses {
+		envVars = append(envVars, v1.EnvVar{
+			Name: mysql.generateReadHostEnv(i),
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secret.Name,
+					},
+					Key: generateReadHostAddressKey(i),
+				},
+			},
+		})
+	}
+
+	// Inject the ssl mode hint and the CA bundle for the TLS connections.
+	envVars = append(envVars, mysql.generateTLSEnvs(secret.Name)...)
+
+	// Compose the connection string with the credential environment variables above, which
+	// should be placed after them for the dependent variable expansion.
+	if mysql.ConnectionURL != nil {
+		envVars = append(envVars, mysql.generateConnectionURLEnv(hostAddressKey, usernameKey, passwordKey))
+	}
+
+	// Roll the workload together with the database secret once the password is rotated.
 	patcher := &kusionapiv1.Patcher{
-		Environments: envVars,
+		Environments:   envVars,
+		PodAnnotations: mysql.generatePasswordRotationAnnotations(),
 	}
 
 	return resource, patcher, nil
 }
 
+// generateEnvSuffix generates the suffix of the environment variable names injected into the workload.
+func (mysql *MySQL) generateEnvSuffix() string {
+	return strings.ToUpper(strings.ReplaceAll(mysql.DatabaseName, "-", "_"))
+}
+
+// generateLogicalDBName generates the name of the logical database for the workload to connect with,
+// which is the first declared database or the instance name with dashes replaced by underscores. The
+// workload connects without the logical database if the default one is not created by the backend.
+func (mysql *MySQL) generateLogicalDBName() string {
+	if len(mysql.Databases) > 0 {
+		return mysql.Databases[0]
+	}
+
+	if mysql.noDefaultDatabase {
+		return ""
+	}
+
+	return strings.ReplaceAll(mysql.DatabaseName, "-", "_")
+}
+
+// generatePasswordRotationAnnotations generates the pod annotations carrying the password rotation
+// trigger, which restart the pods using the password when the trigger changes.
+func (mysql *MySQL) generatePasswordRotationAnnotations() map[string]string {
+	if mysql.PasswordRotation == "" {
+		return nil
+	}
+
+	return map[string]string{
+		passwordRotationAnnotation: mysql.PasswordRotation,
+	}
+}
+
 // GenerateTFRandomPassword generates the terraform random_password resource as the password
-// of the cloud provided MySQL database instance.
+// of the MySQL database instance.
 func (mysql *MySQL) GenerateTFRandomPassword(request *module.GeneratorRequest) (*kusionapiv1.Resource, string, error) {
+	return mysql.generateTFRandomPassword(mysql.DatabaseName + dbResSuffix)
+}
+
+// generateTFRandomPassword generates the terraform random_password resource with the specified name.
+func (mysql *MySQL) generateTFRandomPassword(name string) (*kusionapiv1.Resource, string, error) {
 	resAttrs := map[string]any{
 		"length":           16,
 		"special":          true,
 		"override_special": "_",
 	}
 
+	// A new password will be generated once the rotation trigger changes.
+	if mysql.PasswordRotation != "" {
+		resAttrs["keepers"] = map[string]string{
+			passwordRotationKeeper: mysql.PasswordRotation,
+		}
+	}
+
 	// Set the random_password provider with the default provider config.
 	randomPasswordProvider := defaultRandomProviderCfg
 
-	id, err := module.TerraformResourceID(randomPasswordProvider, randomPassword, mysql.DatabaseName+dbResSuffix)
+	id, err := module.TerraformResourceID(randomPasswordProvider, randomPassword, name)
 	if err != nil {
 		return nil, "", err
 	}
@@ -328,11 +631,81 @@ func (mysql *MySQL) GenerateTFRandomPassword(request *module.GeneratorRequest) (
 
 // Validate validates whether the input of a MySQL database instance is valid.
 func (mysql *MySQL) Validate() error {
-	if mysql.Type == CloudDBType && mysql.InstanceType == "" {
+	// The instance type is fixed for the Aurora Serverless v2 instances.
+	isServerlessV2 := mysql.Aurora != nil && mysql.Aurora.ServerlessV2 != nil
+	if mysql.Type == CloudDBType && mysql.InstanceType == "" && !isServerlessV2 {
 		return ErrEmptyInstanceTypeForCloudDB
 	}
 
-	return nil
+	if mysql.BackupRetentionPeriod < 0 || mysql.BackupRetentionPeriod > 35 {
+		return ErrInvalidBackupRetentionPeriod
+	}
+
+	if mysql.Replicas < 0 || mysql.Replicas > maxReplicas {
+		return ErrInvalidReplicas
+	}
+
+	if err := mysql.validateExternalDatabase(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateAuth(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateCredentialDelivery(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateTLS(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateRestoreFrom(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateBackup(); err != nil {
+		return err
+	}
+
+	if err := mysql.validatePooler(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateOperator(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateExporter(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateNetworkPolicy(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateParameters(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateAuroraCluster(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateDatabaseUsers(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateInitScripts(); err != nil {
+		return err
+	}
+
+	if err := mysql.validateMigration(); err != nil {
+		return err
+	}
+
+	return mysql.validateConnectionURL()
 }
 
 // GenerateDefaultMySQLName generates the default name of the MySQL instance.
@@ -349,7 +722,13 @@ func GetCloudProviderType(platformConfig kusionapiv1.GenericConfig) (string, err
 	}
 
 	if cloud, ok := platformConfig["cloud"]; ok {
-		return cloud.(string), nil
+		cloudType, ok := toConfigString(cloud)
+		if !ok {
+			return "", fmt.Errorf("%w: cloud in %s should be a string but got %T",
+				ErrInvalidModuleConfig, platformConfigSource, cloud)
+		}
+
+		return cloudType, nil
 	}
 
 	return "", ErrEmptyCloudProviderType
@@ -388,6 +767,28 @@ func IsCIDR(cidrStr string) bool {
 	return err == nil
 }
 
+// IPRange returns the first and the last IPv4 address of the input ip address
+// or CIDR record.
+func IPRange(ipStr string) (string, string, error) {
+	if IsIPAddress(ipStr) {
+		return ipStr, ipStr, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(ipStr)
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", "", fmt.Errorf("illegal security ip format: %s", ipStr)
+	}
+
+	start := binary.BigEndian.Uint32(ipNet.IP.To4())
+	end := start | ^binary.BigEndian.Uint32(net.IP(ipNet.Mask).To4())
+
+	startIP, endIP := make(net.IP, net.IPv4len), make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(startIP, start)
+	binary.BigEndian.PutUint32(endIP, end)
+
+	return startIP.String(), endIP.String(), nil
+}
+
 func main() {
 	server.Start(&MySQL{})
 }
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/bytedance/mockey"
@@ -18,14 +20,20 @@ func TestMySQLModule_Generator(t *testing.T) {
 	// Set provider envs.
 	originAWSRegion := os.Getenv("AWS_REGION")
 	originAlicloudRegion := os.Getenv("ALICLOUD_REGION")
+	originGCPRegion := os.Getenv("GOOGLE_REGION")
+	originAzureRegion := os.Getenv("AZURE_REGION")
 
 	defer func() {
 		os.Setenv("AWS_REGION", originAWSRegion)
 		os.Setenv("ALICLOUD_REGION", originAlicloudRegion)
+		os.Setenv("GOOGLE_REGION", originGCPRegion)
+		os.Setenv("AZURE_REGION", originAzureRegion)
 	}()
 
 	os.Setenv("AWS_REGION", "us-east-1")
 	os.Setenv("ALICLOUD_REGION", "cn-beijing")
+	os.Setenv("GOOGLE_REGION", "us-central1")
+	os.Setenv("AZURE_REGION", "eastus")
 
 	// TODO: set env for AWS & Alicloud Region.
 	r := &module.GeneratorRequest{
@@ -85,6 +93,36 @@ func TestMySQLModule_Generator(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "Generate GCP MySQL Cloud SQL",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":    "cloud",
+				"version": "8.0",
+			},
+			platformConfig: kusionapiv1.GenericConfig{
+				"cloud":          "gcp",
+				"size":           20,
+				"instanceType":   "db-f1-micro",
+				"privateRouting": false,
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "Generate Azure MySQL flexible server",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":    "cloud",
+				"version": "8.0",
+			},
+			platformConfig: kusionapiv1.GenericConfig{
+				"cloud":          "azure",
+				"size":           20,
+				"username":       "kusion_admin",
+				"instanceType":   "B_Standard_B1s",
+				"privateRouting": false,
+				"resourceGroup":  "test-resource-group",
+			},
+			expectedErr: nil,
+		},
 		{
 			name: "Unsupported MySQL type",
 			devModuleConfig: kusionapiv1.Accessory{
@@ -150,42 +188,123 @@ func TestMySQLModule_GetCompleteConfig(t *testing.T) {
 			devModuleConfig: kusionapiv1.Accessory{
 				"type":    "local",
 				"version": "8.0",
+				"initScripts": []any{
+					map[string]any{"name": "schema", "sql": "CREATE TABLE t (id INT);"},
+				},
+				"migration": map[string]any{
+					"tool":  "flyway",
+					"image": "test-migration:v1",
+				},
 			},
 			platformConfig: nil,
 			expectedMySQL: &MySQL{
-				Type:           "local",
-				Version:        "8.0",
-				Username:       defaultUsername,
-				Category:       defaultCategory,
-				SecurityIPs:    defaultSecurityIPs,
-				PrivateRouting: defaultPrivateRouting,
-				Size:           defaultSize,
+				Type:                    "local",
+				Version:                 "8.0",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          defaultPrivateRouting,
+				Size:                    defaultSize,
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 defaultMultiAZ,
+				BackupRetentionPeriod:   defaultBackupRetentionPeriod,
+				DeletionProtection:      defaultDeletionProtection,
+				SkipFinalSnapshot:       defaultSkipFinalSnapshot,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				InitScripts:             []InitScript{{Name: "schema", SQL: "CREATE TABLE t (id INT);"}},
+				NetworkPolicy:           &NetworkPolicy{Enabled: true},
+				Migration:               &Migration{Tool: FlywayTool, Image: "test-migration:v1"},
 			},
 		},
 		{
-			name: "Default config with specified platform config",
+			name: "Operator type with default cluster settings",
 			devModuleConfig: kusionapiv1.Accessory{
-				"type":    "cloud",
+				"type":    "operator",
 				"version": "8.0",
 			},
+			platformConfig: nil,
+			expectedMySQL: &MySQL{
+				Type:                    "operator",
+				Version:                 "8.0",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          defaultPrivateRouting,
+				Size:                    defaultSize,
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 defaultMultiAZ,
+				BackupRetentionPeriod:   defaultBackupRetentionPeriod,
+				DeletionProtection:      defaultDeletionProtection,
+				SkipFinalSnapshot:       defaultSkipFinalSnapshot,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				Operator:                defaultOperator(),
+			},
+		},
+		{
+			name: "Default config with specified platform config",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":      "cloud",
+				"version":   "8.0",
+				"databases": []any{"orders"},
+				"users": []any{
+					map[string]any{
+						"name": "app",
+						"grants": []any{
+							map[string]any{"database": "orders", "privileges": []any{"select"}},
+						},
+					},
+				},
+			},
 			platformConfig: kusionapiv1.GenericConfig{
-				"size":           100,
-				"privateRouting": true,
-				"instanceType":   "test-instance-type",
-				"subnetID":       "test-subnet-id",
-				"databaseName":   "test-database",
+				"size":                  100,
+				"privateRouting":        true,
+				"instanceType":          "test-instance-type",
+				"subnetID":              "test-subnet-id",
+				"databaseName":          "test-database",
+				"passwordRotation":      "2024-06-01",
+				"multiAZ":               true,
+				"backupRetentionPeriod": 14,
+				"backupWindow":          "03:00-04:00",
+				"deletionProtection":    false,
+				"skipFinalSnapshot":     true,
+				"storageType":           "io1",
+				"iops":                  3000,
+				"connectionURL": map[string]any{
+					"format": "dsn",
+				},
 			},
 			expectedMySQL: &MySQL{
-				Type:           "cloud",
-				Version:        "8.0",
-				Username:       defaultUsername,
-				Category:       defaultCategory,
-				SecurityIPs:    defaultSecurityIPs,
-				PrivateRouting: true,
-				Size:           100,
-				InstanceType:   "test-instance-type",
-				SubnetID:       "test-subnet-id",
-				DatabaseName:   "test-database",
+				Type:                    "cloud",
+				Version:                 "8.0",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          true,
+				Size:                    100,
+				InstanceType:            "test-instance-type",
+				SubnetID:                "test-subnet-id",
+				DatabaseName:            "test-database",
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 true,
+				BackupRetentionPeriod:   14,
+				BackupWindow:            "03:00-04:00",
+				DeletionProtection:      false,
+				SkipFinalSnapshot:       true,
+				StorageType:             "io1",
+				Iops:                    3000,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				PasswordRotation:        "2024-06-01",
+				ConnectionURL: &ConnectionURL{
+					Format:    DSNFormat,
+					EnvPrefix: defaultConnectionURLEnvPrefix,
+				},
+				Databases: []string{"orders"},
+				Users: []DatabaseUser{
+					{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT"}}}},
+				},
 			},
 		},
 	}
@@ -235,6 +354,8 @@ func TestMySQLModule_GenerateDBSecret(t *testing.T) {
 		},
 		StringData: map[string]string{
 			"hostAddress": "test-host-address",
+			"port":        "3306",
+			"database":    "test_database",
 			"username":    "test-username",
 			"password":    "test-password",
 		},
@@ -281,10 +402,32 @@ func TestMySQLModule_GenerateDBSecret(t *testing.T) {
 					},
 				},
 			},
+			{
+				Name: "KUSION_DB_PORT_TEST_DATABASE",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: "test-database-mysql",
+						},
+						Key: "port",
+					},
+				},
+			},
+			{
+				Name: "KUSION_DB_DATABASE_TEST_DATABASE",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: "test-database-mysql",
+						},
+						Key: "database",
+					},
+				},
+			},
 		},
 	}
 
-	actualResource, actualPatcher, err := mysql.GenerateDBSecret(r, hostAddress, username, password)
+	actualResource, actualPatcher, err := mysql.GenerateDBSecret(r, hostAddress, username, password, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, expectedResource, actualResource)
@@ -351,11 +494,40 @@ func TestMySQLModule_GenerateTFRandomPassword(t *testing.T) {
 		res, id, err := mysql.GenerateTFRandomPassword(r)
 
 		assert.NotNil(t, res)
+		assert.NotContains(t, res.Attributes, "keepers")
+		assert.NotEqual(t, id, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("successfully generate random_password resource with rotation trigger", func(t *testing.T) {
+		rotatedMySQL := *mysql
+		rotatedMySQL.PasswordRotation = "2024-06-01"
+
+		res, id, err := rotatedMySQL.GenerateTFRandomPassword(r)
+
+		assert.NotNil(t, res)
+		assert.Equal(t, map[string]string{"rotation": "2024-06-01"}, res.Attributes["keepers"])
 		assert.NotEqual(t, id, "")
 		assert.NoError(t, err)
 	})
 }
 
+func TestMySQLModule_GeneratePasswordRotationAnnotations(t *testing.T) {
+	t.Run("empty rotation trigger", func(t *testing.T) {
+		mysql := &MySQL{}
+
+		assert.Nil(t, mysql.generatePasswordRotationAnnotations())
+	})
+
+	t.Run("specified rotation trigger", func(t *testing.T) {
+		mysql := &MySQL{PasswordRotation: "2024-06-01"}
+
+		assert.Equal(t, map[string]string{
+			"kusionstack.io/password-rotation": "2024-06-01",
+		}, mysql.generatePasswordRotationAnnotations())
+	})
+}
+
 func TestMySQLModule_Validate(t *testing.T) {
 	t.Run("cloud db with empty instanceType", func(t *testing.T) {
 		mysql := &MySQL{
@@ -368,6 +540,31 @@ func TestMySQLModule_Validate(t *testing.T) {
 		assert.ErrorContains(t, err, ErrEmptyInstanceTypeForCloudDB.Error())
 	})
 
+	t.Run("invalid backup retention period", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:                  "cloud",
+			Version:               "8.0",
+			InstanceType:          "test-instance-type",
+			BackupRetentionPeriod: 36,
+		}
+
+		err := mysql.Validate()
+
+		assert.ErrorIs(t, err, ErrInvalidBackupRetentionPeriod)
+	})
+
+	t.Run("too many replicas", func(t *testing.T) {
+		mysql := &MySQL{
+			Type:     "local",
+			Version:  "8.0",
+			Replicas: 6,
+		}
+
+		err := mysql.Validate()
+
+		assert.ErrorIs(t, err, ErrInvalidReplicas)
+	})
+
 	t.Run("valid mysql config", func(t *testing.T) {
 		mysql := &MySQL{
 			Type:         "cloud",
@@ -381,6 +578,48 @@ func TestMySQLModule_Validate(t *testing.T) {
 	})
 }
 
+func TestIPRange(t *testing.T) {
+	testcases := []struct {
+		name          string
+		ip            string
+		expectedStart string
+		expectedEnd   string
+		expectedErr   bool
+	}{
+		{
+			name:          "IP Address",
+			ip:            "172.16.0.1",
+			expectedStart: "172.16.0.1",
+			expectedEnd:   "172.16.0.1",
+		},
+		{
+			name:          "Public CIDR",
+			ip:            "0.0.0.0/0",
+			expectedStart: "0.0.0.0",
+			expectedEnd:   "255.255.255.255",
+		},
+		{
+			name:          "Private CIDR",
+			ip:            "172.16.0.0/24",
+			expectedStart: "172.16.0.0",
+			expectedEnd:   "172.16.0.255",
+		},
+		{
+			name:        "Illegal IP",
+			ip:          "illegal-ip",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		start, end, err := IPRange(tc.ip)
+
+		assert.Equal(t, tc.expectedStart, start)
+		assert.Equal(t, tc.expectedEnd, end)
+		assert.Equal(t, tc.expectedErr, err != nil)
+	}
+}
+
 func TestIsPublicAccessible(t *testing.T) {
 	testcases := []struct {
 		name        string
@@ -416,3 +655,31 @@ func TestIsPublicAccessible(t *testing.T) {
 		assert.Equal(t, tc.expected, actual)
 	}
 }
+
+// assertResolvableKusionPaths asserts that the implicit dependencies in the attributes of the resources
+// are the whole string values, since Kusion does not resolve the ones embedded in the other strings,
+// e.g. the marshalled JSON documents.
+func assertResolvableKusionPaths(t *testing.T, resources []kusionapiv1.Resource) {
+	t.Helper()
+
+	var walk func(path string, value any)
+	walk = func(path string, value any) {
+		switch v := value.(type) {
+		case string:
+			assert.False(t, strings.Index(v, "$kusion_path.") > 0,
+				"unresolvable kusion path embedded in %s: %s", path, v)
+		case map[string]any:
+			for key, item := range v {
+				walk(path+"."+key, item)
+			}
+		case []any:
+			for i, item := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		}
+	}
+
+	for _, resource := range resources {
+		walk(resource.ID, resource.Attributes)
+	}
+}
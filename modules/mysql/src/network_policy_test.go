@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseNetworkPolicy(t *testing.T) {
+	networkPolicy, err := parseNetworkPolicy(false)
+	assert.NoError(t, err)
+	assert.Equal(t, &NetworkPolicy{Enabled: false}, networkPolicy)
+
+	networkPolicy, err = parseNetworkPolicy(map[string]any{
+		"allowFrom": []any{
+			map[string]any{"app.kubernetes.io/name": "admin-console"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &NetworkPolicy{
+		Enabled:   true,
+		AllowFrom: []map[string]string{{"app.kubernetes.io/name": "admin-console"}},
+	}, networkPolicy)
+
+	_, err = parseNetworkPolicy("deny")
+	assert.ErrorIs(t, err, ErrInvalidNetworkPolicyConfig)
+
+	_, err = parseNetworkPolicy(map[string]any{"allowFrom": map[string]any{"app": "admin-console"}})
+	assert.ErrorIs(t, err, ErrInvalidNetworkPolicyConfig)
+
+	_, err = parseNetworkPolicy(map[string]any{"namespaces": []any{"monitoring"}})
+	assert.ErrorIs(t, err, ErrInvalidNetworkPolicyConfig)
+}
+
+func TestMySQLModule_ValidateNetworkPolicy(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name:    "network policy of local database",
+			mysql:   &MySQL{Type: "local", NetworkPolicy: &NetworkPolicy{Enabled: true}},
+			success: true,
+		},
+		{
+			name:    "disabled network policy of cloud database",
+			mysql:   &MySQL{Type: "cloud", NetworkPolicy: &NetworkPolicy{Enabled: false}},
+			success: true,
+		},
+		{
+			name:    "network policy of cloud database",
+			mysql:   &MySQL{Type: "cloud", NetworkPolicy: &NetworkPolicy{Enabled: true}},
+			success: false,
+		},
+		{
+			name: "empty labels in allowFrom",
+			mysql: &MySQL{
+				Type:          "local",
+				NetworkPolicy: &NetworkPolicy{Enabled: true, AllowFrom: []map[string]string{{}}},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateNetworkPolicy()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidNetworkPolicyConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateLocalNetworkPolicy(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		App:     "test-app",
+	}
+
+	mysql := &MySQL{
+		Type:         "local",
+		DatabaseName: "test-database",
+		Replicas:     1,
+		Pooler:       &Pooler{},
+		Exporter:     &Exporter{},
+		NetworkPolicy: &NetworkPolicy{
+			Enabled:   true,
+			AllowFrom: []map[string]string{{"app.kubernetes.io/name": "admin-console"}},
+		},
+	}
+
+	res, err := mysql.generateLocalNetworkPolicy(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "networking.k8s.io/v1:NetworkPolicy:test-project:test-database-db-network-policy", res.ID)
+	spec := res.Attributes["spec"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"matchExpressions": []any{
+			map[string]any{
+				"key":      "accessory",
+				"operator": "In",
+				"values":   []any{"test-database", "test-database-replica", "test-database-db-pooler"},
+			},
+		},
+	}, spec["podSelector"])
+	assert.Equal(t, []any{"Ingress"}, spec["policyTypes"])
+
+	ingress := spec["ingress"].([]any)
+	assert.Equal(t, 2, len(ingress))
+	assert.Equal(t, map[string]any{
+		"ports": []any{map[string]any{"protocol": "TCP", "port": int64(3306)}},
+		"from": []any{
+			map[string]any{"podSelector": map[string]any{"matchLabels": map[string]any{
+				"app.kubernetes.io/part-of": "test-project",
+				"app.kubernetes.io/name":    "test-app",
+			}}},
+			map[string]any{"podSelector": map[string]any{"matchLabels": map[string]any{"accessory": "test-database-db-client"}}},
+			map[string]any{"podSelector": map[string]any{"matchLabels": map[string]any{"accessory": "test-database-replica"}}},
+			map[string]any{"podSelector": map[string]any{"matchLabels": map[string]any{"accessory": "test-database-db-pooler"}}},
+			map[string]any{"podSelector": map[string]any{"matchLabels": map[string]any{"app.kubernetes.io/name": "admin-console"}}},
+		},
+	}, ingress[0])
+	assert.Equal(t, map[string]any{
+		"ports": []any{map[string]any{"protocol": "TCP", "port": "metrics"}},
+	}, ingress[1])
+}
+
+func TestMySQLModule_GenerateLocalResourcesWithNetworkPolicy(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:          "local",
+		Version:       "8.0",
+		DatabaseName:  "test-database",
+		Username:      defaultUsername,
+		Size:          defaultSize,
+		CPU:           defaultCPU,
+		Memory:        defaultMemory,
+		NetworkPolicy: &NetworkPolicy{Enabled: true},
+	}
+
+	resources, _, err := mysql.GenerateLocalResources(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, len(resources))
+	assert.Equal(t, "networking.k8s.io/v1:NetworkPolicy:test-project:test-database-db-network-policy", resources[5].ID)
+
+	mysql.NetworkPolicy.Enabled = false
+	resources, _, err = mysql.GenerateLocalResources(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(resources))
+}
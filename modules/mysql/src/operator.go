@@ -0,0 +1,401 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidOperatorConfig = errors.New("invalid operator config in mysql module config")
+
+var (
+	innoDBClusterAPIVersion = "mysql.oracle.com/v2"
+	innoDBClusterKind       = "InnoDBCluster"
+
+	operatorSecretSuffix      = "-db-operator-secret"
+	operatorInitJobSuffix     = "-db-operator-init"
+	operatorInitContainerName = "init"
+	operatorInitBackoffLimit  = int32(1)
+	operatorInitRetryInterval = 10
+	operatorInitTimeout       = int64(1800)
+	operatorRootHost          = "%"
+	operatorBackupProfile     = "s3-dump"
+	operatorBackupSchedule    = "scheduled-dump"
+	operatorS3Profile         = "default"
+
+	defaultOperatorInstances       = 3
+	defaultOperatorRouterInstances = 1
+	maxOperatorInstances           = 9
+)
+
+// Operator describes the InnoDB Cluster managed by the MySQL Operator for Kubernetes for the operator
+// type, which replicates the MySQL instance with the group replication and routes the connections of
+// the workload to the primary through the MySQL Router.
+type Operator struct {
+	// The number of the MySQL instances in the InnoDB Cluster.
+	Instances int `json:"instances,omitempty" yaml:"instances,omitempty"`
+	// The number of the MySQL Router instances.
+	RouterInstances int `json:"routerInstances,omitempty" yaml:"routerInstances,omitempty"`
+	// The scheduled dumps of the InnoDB Cluster uploaded to the S3 compatible object storage.
+	Backup *OperatorBackup `json:"backup,omitempty" yaml:"backup,omitempty"`
+}
+
+// OperatorBackup describes the scheduled dumps of the InnoDB Cluster, which are kept in the bucket
+// until deleted by its lifecycle rules.
+type OperatorBackup struct {
+	// The schedule of the dumps in the cron format, such as "0 3 * * *".
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	// The S3 compatible object storage the dumps are uploaded to, whose credentials secret holds the
+	// "credentials" and "config" files of the AWS CLI as read by the MySQL Operator.
+	S3 *BackupS3 `json:"s3,omitempty" yaml:"s3,omitempty"`
+}
+
+// defaultOperator returns the InnoDB Cluster settings used when the operator block is not declared.
+func defaultOperator() *Operator {
+	return &Operator{
+		Instances:       defaultOperatorInstances,
+		RouterInstances: defaultOperatorRouterInstances,
+	}
+}
+
+// parseOperator parses the operator block of the platform config.
+func parseOperator(config any) (*Operator, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidOperatorConfig, config)
+	}
+
+	operator := defaultOperator()
+	if err := parseConfigBlock(configMap, ErrInvalidOperatorConfig, "", map[string]configValueParser{
+		"instances":       configValue(&operator.Instances, toConfigInt),
+		"routerInstances": configValue(&operator.RouterInstances, toConfigInt),
+		"backup": func(value any) (err error) {
+			operator.Backup, err = parseOperatorBackup(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return operator, nil
+}
+
+// parseOperatorBackup parses the backup block of the operator.
+func parseOperatorBackup(config any) (*OperatorBackup, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map of backup but got %T", ErrInvalidOperatorConfig, config)
+	}
+
+	backup := &OperatorBackup{}
+	if err := parseConfigBlock(configMap, ErrInvalidOperatorConfig, "backup.", map[string]configValueParser{
+		"schedule": configValue(&backup.Schedule, toConfigString),
+		"s3": func(value any) (err error) {
+			backup.S3, err = parseBackupS3(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// validateOperator validates whether the InnoDB Cluster is declared with the operator type, and whether
+// the instances and the scheduled dumps are valid. The logical database is created by the init Job, so
+// the configs provisioning the other objects inside the instance are not supported.
+func (mysql *MySQL) validateOperator() error {
+	operator := mysql.Operator
+	if operator == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(mysql.Type, OperatorDBType) {
+		return fmt.Errorf("%w: only supported by the %s type", ErrInvalidOperatorConfig, OperatorDBType)
+	}
+
+	if operator.Instances < 1 || operator.Instances > maxOperatorInstances {
+		return fmt.Errorf("%w: instances should be between 1 and %d", ErrInvalidOperatorConfig, maxOperatorInstances)
+	}
+
+	if operator.RouterInstances < 1 {
+		return fmt.Errorf("%w: routerInstances should be positive", ErrInvalidOperatorConfig)
+	}
+
+	if len(mysql.Databases) > 1 || len(mysql.Users) > 0 || len(mysql.InitScripts) > 0 || mysql.Replicas > 0 {
+		return fmt.Errorf("%w: databases, users, initScripts and replicas are not supported by the %s type",
+			ErrInvalidOperatorConfig, OperatorDBType)
+	}
+
+	if backup := operator.Backup; backup != nil {
+		if !isValidBackupSchedule(backup.Schedule) {
+			return fmt.Errorf("%w: invalid backup schedule %q", ErrInvalidOperatorConfig, backup.Schedule)
+		}
+		if backup.S3 == nil || backup.S3.Bucket == "" || backup.S3.CredentialsSecret == "" {
+			return fmt.Errorf("%w: bucket and credentialsSecret of backup s3 should be specified", ErrInvalidOperatorConfig)
+		}
+		if backup.S3.Region != "" {
+			return fmt.Errorf("%w: region of backup s3 should be declared in the config file of the credentials secret",
+				ErrInvalidOperatorConfig)
+		}
+	}
+
+	return nil
+}
+
+// GenerateOperatorResources generates the InnoDBCluster resource along with the Kubernetes Secret of
+// its root credentials and the Job creating the logical database, and injects the credentials into
+// the workload with the host address of the Service created by the MySQL Operator.
+func (mysql *MySQL) GenerateOperatorResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
+	var resources []kusionapiv1.Resource
+
+	// Build random_password resource for the root user of the InnoDB Cluster.
+	randomPasswordRes, randomPasswordID, err := mysql.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build Kubernetes Secret for the root credentials read by the MySQL Operator.
+	password := module.KusionPathDependency(randomPasswordID, "result")
+	operatorSecret, err := mysql.generateOperatorSecret(request, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *operatorSecret)
+
+	// Build InnoDBCluster resource reconciled by the MySQL Operator.
+	cluster, err := mysql.generateInnoDBCluster(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *cluster)
+
+	// Build Kubernetes Job creating the logical database once the InnoDB Cluster is ready.
+	hostAddress := mysql.DatabaseName
+	initJob, err := mysql.generateOperatorInitJob(request, hostAddress, []string{cluster.ID, operatorSecret.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *initJob)
+
+	// Build Kubernetes Secret with the hostAddress, username and password of the InnoDB Cluster,
+	// and inject the credentials as the environment variable patcher.
+	dbSecret, patcher, err := mysql.GenerateDBSecret(request, hostAddress, mysql.Username, password, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *dbSecret)
+
+	return resources, patcher, nil
+}
+
+// generateOperatorSecret generates the Kubernetes Secret resource holding the root credentials of
+// the InnoDB Cluster.
+func (mysql *MySQL) generateOperatorSecret(request *module.GeneratorRequest, password string) (*kusionapiv1.Resource, error) {
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + operatorSecretSuffix,
+			Namespace: request.Project,
+		},
+		StringData: map[string]string{
+			"rootUser":     mysql.Username,
+			"rootHost":     operatorRootHost,
+			"rootPassword": password,
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(secret.TypeMeta, secret.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateInnoDBCluster generates the InnoDBCluster resource with the instances, the storage, the
+// resource requests and the engine parameters of the MySQL instance. The version is pinned only if
+// declared with the patch version, and defaults to the one of the MySQL Operator otherwise.
+func (mysql *MySQL) generateInnoDBCluster(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	volumeClaimTemplate := map[string]any{
+		"accessModes": []any{string(v1.ReadWriteOnce)},
+		"resources": map[string]any{
+			"requests": map[string]any{
+				"storage": fmt.Sprintf("%dGi", mysql.Size),
+			},
+		},
+	}
+	if mysql.StorageClass != "" {
+		volumeClaimTemplate["storageClassName"] = mysql.StorageClass
+	}
+
+	spec := map[string]any{
+		"secretName":       mysql.DatabaseName + operatorSecretSuffix,
+		"tlsUseSelfSigned": true,
+		"instances":        int64(mysql.Operator.Instances),
+		"router": map[string]any{
+			"instances": int64(mysql.Operator.RouterInstances),
+		},
+		"datadirVolumeClaimTemplate": volumeClaimTemplate,
+		"podSpec": map[string]any{
+			"containers": []any{
+				map[string]any{
+					"name": "mysql",
+					"resources": map[string]any{
+						"requests": map[string]any{
+							"cpu":    mysql.CPU,
+							"memory": mysql.Memory,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if len(strings.Split(mysql.Version, ".")) == 3 {
+		spec["version"] = mysql.Version
+	}
+
+	if len(mysql.Parameters) > 0 {
+		spec["mycnf"] = mysql.generateLocalConfig()
+	}
+
+	if backup := mysql.Operator.Backup; backup != nil {
+		s3 := map[string]any{
+			"bucketName": backup.S3.Bucket,
+			"prefix":     mysql.generateOperatorBackupPrefix(),
+			"config":     backup.S3.CredentialsSecret,
+			"profile":    operatorS3Profile,
+		}
+		if backup.S3.Endpoint != "" {
+			s3["endpoint"] = backup.S3.Endpoint
+		}
+
+		spec["backupProfiles"] = []any{
+			map[string]any{
+				"name": operatorBackupProfile,
+				"dumpInstance": map[string]any{
+					"storage": map[string]any{
+						"s3": s3,
+					},
+				},
+			},
+		}
+		spec["backupSchedules"] = []any{
+			map[string]any{
+				"name":              operatorBackupSchedule,
+				"schedule":          backup.Schedule,
+				"backupProfileName": operatorBackupProfile,
+				"enabled":           true,
+			},
+		}
+	}
+
+	cluster := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": innoDBClusterAPIVersion,
+			"kind":       innoDBClusterKind,
+			"metadata": map[string]any{
+				"name":      mysql.DatabaseName,
+				"namespace": request.Project,
+			},
+			"spec": spec,
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(metav1.TypeMeta{
+		Kind:       innoDBClusterKind,
+		APIVersion: innoDBClusterAPIVersion,
+	}, metav1.ObjectMeta{
+		Name:      mysql.DatabaseName,
+		Namespace: request.Project,
+	})
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateOperatorBackupPrefix generates the key prefix of the dumps, which defaults to the name of
+// the instance.
+func (mysql *MySQL) generateOperatorBackupPrefix() string {
+	if prefix := mysql.Operator.Backup.S3.Prefix; prefix != "" {
+		return prefix
+	}
+
+	return mysql.DatabaseName
+}
+
+// generateOperatorInitJob generates the Kubernetes Job resource creating the logical database in the
+// InnoDB Cluster after the resources of dependsOn are created, which retries until the MySQL Router
+// accepts the connections and fails once the timeout is exceeded.
+func (mysql *MySQL) generateOperatorInitJob(request *module.GeneratorRequest, hostAddress string,
+	dependsOn []string,
+) (*kusionapiv1.Resource, error) {
+	script := fmt.Sprintf("until mysql --host=%s --port=%d --user=%s -e 'CREATE DATABASE IF NOT EXISTS `%s`'; "+
+		"do sleep %d; done", hostAddress, dbPort, mysql.Username, mysql.generateLogicalDBName(), operatorInitRetryInterval)
+
+	backoffLimit, activeDeadlineSeconds := operatorInitBackoffLimit, operatorInitTimeout
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + operatorInitJobSuffix,
+			Namespace: request.Project,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:    operatorInitContainerName,
+							Image:   dbEngine + ":" + mysql.Version,
+							Command: []string{"sh", "-c", script},
+							Env: []v1.EnvVar{
+								{
+									Name: "MYSQL_PWD",
+									ValueFrom: &v1.EnvVarSource{
+										SecretKeyRef: &v1.SecretKeySelector{
+											LocalObjectReference: v1.LocalObjectReference{
+												Name: mysql.DatabaseName + operatorSecretSuffix,
+											},
+											Key: "rootPassword",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(job.TypeMeta, job.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, job)
+	if err != nil {
+		return nil, err
+	}
+	resource.DependsOn = dependsOn
+
+	return resource, nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseOperator(t *testing.T) {
+	operator, err := parseOperator(map[string]any{
+		"instances": "5",
+		"backup": map[string]any{
+			"schedule": "0 3 * * *",
+			"s3": map[string]any{
+				"bucket":            "backups",
+				"credentialsSecret": "s3-config",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Operator{
+		Instances:       5,
+		RouterInstances: 1,
+		Backup: &OperatorBackup{
+			Schedule: "0 3 * * *",
+			S3:       &BackupS3{Bucket: "backups", CredentialsSecret: "s3-config"},
+		},
+	}, operator)
+
+	_, err = parseOperator(true)
+	assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+
+	_, err = parseOperator(map[string]any{"kind": "PerconaXtraDBCluster"})
+	assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+
+	_, err = parseOperator(map[string]any{"backup": map[string]any{"retention": 7}})
+	assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+}
+
+func TestMySQLModule_ValidateOperator(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name:    "no operator",
+			mysql:   &MySQL{Type: "local"},
+			success: true,
+		},
+		{
+			name: "valid operator",
+			mysql: &MySQL{
+				Type:      "operator",
+				Databases: []string{"orders"},
+				Operator: &Operator{
+					Instances:       3,
+					RouterInstances: 1,
+					Backup: &OperatorBackup{
+						Schedule: "@daily",
+						S3:       &BackupS3{Bucket: "backups", CredentialsSecret: "s3-config"},
+					},
+				},
+			},
+			success: true,
+		},
+		{
+			name: "operator of local database",
+			mysql: &MySQL{
+				Type:     "local",
+				Operator: defaultOperator(),
+			},
+			success: false,
+		},
+		{
+			name: "too many instances",
+			mysql: &MySQL{
+				Type:     "operator",
+				Operator: &Operator{Instances: 10, RouterInstances: 1},
+			},
+			success: false,
+		},
+		{
+			name: "operator with users",
+			mysql: &MySQL{
+				Type:      "operator",
+				Databases: []string{"orders"},
+				Users:     []DatabaseUser{{Name: "app"}},
+				Operator:  defaultOperator(),
+			},
+			success: false,
+		},
+		{
+			name: "backup without s3",
+			mysql: &MySQL{
+				Type: "operator",
+				Operator: &Operator{
+					Instances:       3,
+					RouterInstances: 1,
+					Backup:          &OperatorBackup{Schedule: "0 3 * * *"},
+				},
+			},
+			success: false,
+		},
+		{
+			name: "backup with s3 region",
+			mysql: &MySQL{
+				Type: "operator",
+				Operator: &Operator{
+					Instances:       3,
+					RouterInstances: 1,
+					Backup: &OperatorBackup{
+						Schedule: "0 3 * * *",
+						S3:       &BackupS3{Bucket: "backups", Region: "us-east-1", CredentialsSecret: "s3-config"},
+					},
+				},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validateOperator()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateOperatorResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:         "operator",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         defaultSize,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Operator:     defaultOperator(),
+	}
+
+	resources, patcher, err := mysql.GenerateOperatorResources(r)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, patcher)
+	assert.Equal(t, 5, len(resources))
+	assert.Equal(t, "v1:Secret:test-project:test-database-db-operator-secret", resources[1].ID)
+	assert.Equal(t, "mysql.oracle.com/v2:InnoDBCluster:test-project:test-database", resources[2].ID)
+	assert.Equal(t, "batch/v1:Job:test-project:test-database-db-operator-init", resources[3].ID)
+	assert.Equal(t, []string{resources[2].ID, resources[1].ID}, resources[3].DependsOn)
+
+	password := module.KusionPathDependency(resources[0].ID, "result")
+	assert.Equal(t, password, resources[1].Attributes["stringData"].(map[string]any)["rootPassword"])
+
+	// The workload connects to the Service of the MySQL Router created by the operator.
+	dbSecretData := resources[4].Attributes["stringData"].(map[string]interface{})
+	assert.Equal(t, "test-database", dbSecretData["hostAddress"])
+	assert.Equal(t, "root", dbSecretData["username"])
+	assert.Equal(t, password, dbSecretData["password"])
+}
+
+func TestMySQLModule_GenerateInnoDBCluster(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	mysql := &MySQL{
+		Type:         "operator",
+		Version:      "8.0.36",
+		DatabaseName: "test-database",
+		Size:         20,
+		CPU:          "1",
+		Memory:       "2Gi",
+		StorageClass: "standard",
+		Parameters:   map[string]string{"max_connections": "500"},
+		Operator: &Operator{
+			Instances:       3,
+			RouterInstances: 2,
+			Backup: &OperatorBackup{
+				Schedule: "0 3 * * *",
+				S3: &BackupS3{
+					Endpoint:          "https://minio.example.com",
+					Bucket:            "backups",
+					CredentialsSecret: "s3-config",
+				},
+			},
+		},
+	}
+
+	res, err := mysql.generateInnoDBCluster(r)
+
+	assert.NoError(t, err)
+	spec := res.Attributes["spec"].(map[string]any)
+	assert.Equal(t, "test-database-db-operator-secret", spec["secretName"])
+	assert.Equal(t, int64(3), spec["instances"])
+	assert.Equal(t, map[string]any{"instances": int64(2)}, spec["router"])
+	assert.Equal(t, "8.0.36", spec["version"])
+	assert.Equal(t, "[mysqld]\nmax_connections = 500\n", spec["mycnf"])
+	assert.Equal(t, map[string]any{
+		"accessModes":      []any{"ReadWriteOnce"},
+		"resources":        map[string]any{"requests": map[string]any{"storage": "20Gi"}},
+		"storageClassName": "standard",
+	}, spec["datadirVolumeClaimTemplate"])
+	assert.Equal(t, []any{
+		map[string]any{
+			"name": "s3-dump",
+			"dumpInstance": map[string]any{
+				"storage": map[string]any{
+					"s3": map[string]any{
+						"bucketName": "backups",
+						"prefix":     "test-database",
+						"config":     "s3-config",
+						"profile":    "default",
+						"endpoint":   "https://minio.example.com",
+					},
+				},
+			},
+		},
+	}, spec["backupProfiles"])
+	assert.Equal(t, "0 3 * * *", spec["backupSchedules"].([]any)[0].(map[string]any)["schedule"])
+}
+
+func TestMySQLModule_GenerateOperatorInitJob(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	mysql := &MySQL{
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Databases:    []string{"orders"},
+	}
+
+	res, err := mysql.generateOperatorInitJob(r, "test-database", []string{"innodb_cluster_id", "operator_secret_id"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"innodb_cluster_id", "operator_secret_id"}, res.DependsOn)
+	spec := res.Attributes["spec"].(map[string]any)
+	assert.Equal(t, operatorInitTimeout, spec["activeDeadlineSeconds"])
+	podSpec := res.Attributes["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+	container := podSpec["containers"].([]any)[0].(map[string]any)
+	assert.Equal(t, "mysql:8.0", container["image"])
+	assert.Equal(t, []any{"sh", "-c", "until mysql --host=test-database --port=3306 --user=root " +
+		"-e 'CREATE DATABASE IF NOT EXISTS `orders`'; do sleep 10; done"}, container["command"])
+	assert.Equal(t, map[string]any{"name": "test-database-db-operator-secret", "key": "rootPassword"},
+		container["env"].([]any)[0].(map[string]any)["valueFrom"].(map[string]any)["secretKeyRef"])
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidParameters     = errors.New("invalid parameters in mysql module config")
+	ErrUnsupportedParameters = errors.New("engine parameters are not supported for the mysql instance of this cloud provider")
+)
+
+var (
+	localConfigSuffix        = "-db-local-config"
+	localConfigVolume        = "config"
+	localConfigFile          = "my.cnf"
+	localConfigPath          = "/etc/mysql/conf.d/" + localConfigFile
+	configChecksumAnnotation = "kusionstack.io/config-checksum"
+)
+
+var parameterNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Parameter describes an engine parameter of the MySQL instance.
+type Parameter struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// parseParameters parses the parameters block of the platform config, with the values of the
+// numbers and bools converted into strings.
+func parseParameters(config any) (map[string]string, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidParameters, config)
+	}
+
+	parameters := make(map[string]string, len(configMap))
+	for name, value := range configMap {
+		str, ok := toConfigString(value)
+		if !ok {
+			b, isBool := value.(bool)
+			if !isBool {
+				return nil, fmt.Errorf("%w: value of %s should be a string, number or bool", ErrInvalidParameters, name)
+			}
+			str = fmt.Sprint(b)
+		}
+		parameters[name] = str
+	}
+
+	return parameters, nil
+}
+
+// validateParameters validates whether the parameter names are valid and the values are in a single line.
+func (mysql *MySQL) validateParameters() error {
+	for name, value := range mysql.Parameters {
+		if !parameterNameRegexp.MatchString(name) {
+			return fmt.Errorf("%w: invalid parameter name %q", ErrInvalidParameters, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: value of %s should be in a single line", ErrInvalidParameters, name)
+		}
+	}
+
+	return nil
+}
+
+// generateParameters generates the engine parameters sorted by the names.
+func (mysql *MySQL) generateParameters() []Parameter {
+	return sortParameters(mysql.Parameters)
+}
+
+// sortParameters converts the engine parameters into the list sorted by the names.
+func sortParameters(values map[string]string) []Parameter {
+	parameters := make([]Parameter, 0, len(values))
+	for name, value := range values {
+		parameters = append(parameters, Parameter{Name: name, Value: value})
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		return parameters[i].Name < parameters[j].Name
+	})
+
+	return parameters
+}
+
+// generateParameterGroupFamily generates the family of the AWS parameter group from the major and
+// minor version of the engine, such as "mysql8.0".
+func (mysql *MySQL) generateParameterGroupFamily(engine string) string {
+	parts := strings.SplitN(mysql.Version, ".", 3)
+	if len(parts) < 2 {
+		return engine + mysql.Version
+	}
+
+	return engine + parts[0] + "." + parts[1]
+}
+
+// generateLocalConfig generates the option file of the local MySQL instance with the engine parameters.
+func (mysql *MySQL) generateLocalConfig() string {
+	var sb strings.Builder
+	sb.WriteString("[mysqld]\n")
+	for _, parameter := range mysql.generateParameters() {
+		sb.WriteString(fmt.Sprintf("%s = %s\n", parameter.Name, parameter.Value))
+	}
+
+	return sb.String()
+}
+
+// generateLocalConfigMap generates the Kubernetes ConfigMap resource holding the option file of
+// the local MySQL instance.
+func (mysql *MySQL) generateLocalConfigMap(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	configMap := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + localConfigSuffix,
+			Namespace: request.Project,
+		},
+		Data: map[string]string{
+			localConfigFile: mysql.generateLocalConfig(),
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(configMap.TypeMeta, configMap.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateLocalConfigVolume generates the volume and the volume mount of the option file, which is
+// mounted as a single file to keep the other option files shipped with the image.
+func (mysql *MySQL) generateLocalConfigVolume() (v1.Volume, v1.VolumeMount) {
+	volume := v1.Volume{
+		Name: localConfigVolume,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: mysql.DatabaseName + localConfigSuffix,
+				},
+			},
+		},
+	}
+	volumeMount := v1.VolumeMount{
+		Name:      localConfigVolume,
+		MountPath: localConfigPath,
+		SubPath:   localConfigFile,
+		ReadOnly:  true,
+	}
+
+	return volume, volumeMount
+}
+
+// generateLocalPodAnnotations generates the pod annotations of the local MySQL instance, which carry
+// the checksum of the option file for the pods to be recreated once the parameters change, as the
+// file mounted with the sub path is not updated in place.
+func (mysql *MySQL) generateLocalPodAnnotations() map[string]string {
+	annotations := mysql.generatePasswordRotationAnnotations()
+	if len(mysql.Parameters) == 0 {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	sum := sha256.Sum256([]byte(mysql.generateLocalConfig()))
+	annotations[configChecksumAnnotation] = hex.EncodeToString(sum[:])
+
+	return annotations
+}
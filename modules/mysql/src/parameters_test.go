@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseParameters(t *testing.T) {
+	parameters, err := parseParameters(map[string]any{
+		"max_connections":         float64(500),
+		"innodb_buffer_pool_size": "268435456",
+		"slow_query_log":          true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"max_connections":         "500",
+		"innodb_buffer_pool_size": "268435456",
+		"slow_query_log":          "true",
+	}, parameters)
+
+	_, err = parseParameters([]any{"max_connections"})
+	assert.ErrorIs(t, err, ErrInvalidParameters)
+
+	_, err = parseParameters(map[string]any{"sql_mode": []any{"STRICT_TRANS_TABLES"}})
+	assert.ErrorIs(t, err, ErrInvalidParameters)
+}
+
+func TestMySQLModule_ValidateParameters(t *testing.T) {
+	mysql := &MySQL{
+		Parameters: map[string]string{"max_connections": "500"},
+	}
+	assert.NoError(t, mysql.validateParameters())
+
+	mysql.Parameters = map[string]string{"max connections": "500"}
+	assert.ErrorIs(t, mysql.validateParameters(), ErrInvalidParameters)
+
+	mysql.Parameters = map[string]string{"max_connections": "500\n[client]"}
+	assert.ErrorIs(t, mysql.validateParameters(), ErrInvalidParameters)
+}
+
+func TestMySQLModule_GenerateParameterGroupFamily(t *testing.T) {
+	mysql := &MySQL{Version: "8.0.35"}
+	assert.Equal(t, "mysql8.0", mysql.generateParameterGroupFamily("mysql"))
+
+	mysql.Version = "8.0.mysql_aurora.3.05.2"
+	assert.Equal(t, "aurora-mysql8.0", mysql.generateParameterGroupFamily("aurora-mysql"))
+}
+
+func TestMySQLModule_GenerateLocalConfigMap(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		DatabaseName: "test-database",
+		Parameters: map[string]string{
+			"max_connections":         "500",
+			"innodb_buffer_pool_size": "268435456",
+		},
+	}
+
+	res, err := mysql.generateLocalConfigMap(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1:ConfigMap:test-project:test-database-db-local-config", res.ID)
+	assert.Equal(t, map[string]any{
+		"my.cnf": "[mysqld]\ninnodb_buffer_pool_size = 268435456\nmax_connections = 500\n",
+	}, res.Attributes["data"])
+}
+
+func TestMySQLModule_GenerateLocalPodAnnotations(t *testing.T) {
+	mysql := &MySQL{
+		PasswordRotation: "2024-01",
+	}
+	assert.Equal(t, map[string]string{passwordRotationAnnotation: "2024-01"}, mysql.generateLocalPodAnnotations())
+
+	mysql.Parameters = map[string]string{"max_connections": "500"}
+	annotations := mysql.generateLocalPodAnnotations()
+	assert.Len(t, annotations[configChecksumAnnotation], 64)
+
+	mysql.Parameters = map[string]string{"max_connections": "1000"}
+	assert.NotEqual(t, annotations[configChecksumAnnotation], mysql.generateLocalPodAnnotations()[configChecksumAnnotation])
+}
+
+func TestMySQLModule_GenerateLocalPodSpecWithParameters(t *testing.T) {
+	mysql := &MySQL{
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Parameters:   map[string]string{"max_connections": "500"},
+	}
+
+	podSpec, err := mysql.generateLocalPodSpec(nil)
+
+	assert.NoError(t, err)
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      "config",
+		MountPath: "/etc/mysql/conf.d/my.cnf",
+		SubPath:   "my.cnf",
+		ReadOnly:  true,
+	})
+	assert.Equal(t, "test-database-db-local-config", podSpec.Volumes[1].ConfigMap.Name)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidPoolerConfig = errors.New("invalid pooler config in mysql module config")
+	ErrUnsupportedPooler   = errors.New("pooler is not supported for the mysql instance of this cloud provider")
+)
+
+var (
+	poolerSuffix        = "-db-pooler"
+	poolerServiceSuffix = "-db-pooler-service"
+	poolerContainerName = "pooler"
+	poolerConfigFile    = "/tmp/proxysql.cnf"
+
+	defaultPoolerImage                = "proxysql/proxysql:2.6.3"
+	defaultPoolerReplicas             = 1
+	defaultPoolerPoolSize             = 20
+	defaultPoolerMaxClientConnections = 1000
+	minAWSDBProxySubnets              = 2
+)
+
+// Pooler describes the connection pooler deployed in front of the MySQL instance, which multiplexes the
+// connections of the workload replicas onto a limited number of server connections. It is ProxySQL for
+// the local instance and AWS RDS Proxy for the AWS provided instance, which authenticates the workload
+// with the credentials written into AWS Secrets Manager by the module.
+type Pooler struct {
+	// The number of the pooler replicas.
+	Replicas int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	// The maximum number of the server connections from the pooler to the MySQL instance.
+	PoolSize int `json:"poolSize,omitempty" yaml:"poolSize,omitempty"`
+	// The maximum number of the client connections to the pooler.
+	MaxClientConnections int `json:"maxClientConnections,omitempty" yaml:"maxClientConnections,omitempty"`
+	// The image of the pooler.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The subnet IDs in at least two availability zones the AWS RDS Proxy is deployed in.
+	SubnetIDs []string `json:"subnetIDs,omitempty" yaml:"subnetIDs,omitempty"`
+	// The maximum percentage of the max_connections of the AWS provided instance used by the AWS RDS Proxy.
+	MaxConnectionsPercent int `json:"maxConnectionsPercent,omitempty" yaml:"maxConnectionsPercent,omitempty"`
+}
+
+// parsePooler parses the pooler of the platform config, which is either a bool enabling the pooler
+// with the default settings or a block with the settings.
+func parsePooler(config any) (*Pooler, error) {
+	pooler := &Pooler{
+		Replicas:             defaultPoolerReplicas,
+		PoolSize:             defaultPoolerPoolSize,
+		MaxClientConnections: defaultPoolerMaxClientConnections,
+		Image:                defaultPoolerImage,
+	}
+
+	if enabled, ok := toConfigBool(config); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return pooler, nil
+	}
+
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a bool or map but got %T", ErrInvalidPoolerConfig, config)
+	}
+
+	if err := parseConfigBlock(configMap, ErrInvalidPoolerConfig, "", map[string]configValueParser{
+		"replicas":              configValue(&pooler.Replicas, toConfigInt),
+		"poolSize":              configValue(&pooler.PoolSize, toConfigInt),
+		"maxClientConnections":  configValue(&pooler.MaxClientConnections, toConfigInt),
+		"image":                 configValue(&pooler.Image, toConfigString),
+		"subnetIDs":             configValue(&pooler.SubnetIDs, toConfigStringSlice),
+		"maxConnectionsPercent": configValue(&pooler.MaxConnectionsPercent, toConfigInt),
+	}); err != nil {
+		return nil, err
+	}
+
+	return pooler, nil
+}
+
+// validatePooler validates whether the pooler is deployed in front of the local MySQL instance with
+// the positive replicas and connection limits, or in front of the cloud provided MySQL instance in the
+// subnets of at least two availability zones.
+func (mysql *MySQL) validatePooler() error {
+	pooler := mysql.Pooler
+	if pooler == nil {
+		return nil
+	}
+
+	if strings.EqualFold(mysql.Type, CloudDBType) {
+		return mysql.validateCloudPooler()
+	}
+
+	if !strings.EqualFold(mysql.Type, LocalDBType) {
+		return fmt.Errorf("%w: only supported by the %s and %s type", ErrInvalidPoolerConfig, LocalDBType, CloudDBType)
+	}
+
+	if pooler.Replicas <= 0 || pooler.PoolSize <= 0 || pooler.MaxClientConnections <= 0 {
+		return fmt.Errorf("%w: replicas, poolSize and maxClientConnections should be positive", ErrInvalidPoolerConfig)
+	}
+
+	if pooler.Image == "" {
+		return fmt.Errorf("%w: empty image", ErrInvalidPoolerConfig)
+	}
+
+	return nil
+}
+
+// validateCloudPooler validates whether AWS RDS Proxy is deployed in the subnets of at least two
+// availability zones, which authenticates the workload with the password instead of the IAM database
+// authentication tokens.
+func (mysql *MySQL) validateCloudPooler() error {
+	pooler := mysql.Pooler
+	if len(pooler.SubnetIDs) < minAWSDBProxySubnets {
+		return fmt.Errorf("%w: at least %d subnetIDs should be declared", ErrInvalidPoolerConfig, minAWSDBProxySubnets)
+	}
+
+	if pooler.MaxConnectionsPercent < 0 || pooler.MaxConnectionsPercent > 100 {
+		return fmt.Errorf("%w: maxConnectionsPercent should be between 1 and 100", ErrInvalidPoolerConfig)
+	}
+
+	if mysql.isIAMAuth() {
+		return fmt.Errorf("%w: %s auth is not supported", ErrInvalidPoolerConfig, IAMAuth)
+	}
+
+	return nil
+}
+
+// generateLocalPoolerResources generates the Kubernetes Deployment and Service of the pooler in front
+// of the local MySQL instance, and returns the host address of the pooler for the workload.
+func (mysql *MySQL) generateLocalPoolerResources(request *module.GeneratorRequest, hostAddress string) (
+	[]kusionapiv1.Resource, string, error,
+) {
+	poolerDeployment, err := mysql.generateLocalPoolerDeployment(request, hostAddress)
+	if err != nil {
+		return nil, "", err
+	}
+
+	poolerService, poolerHostAddress, err := mysql.generateLocalPoolerService(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []kusionapiv1.Resource{*poolerDeployment, *poolerService}, poolerHostAddress, nil
+}
+
+// generateLocalPoolerDeployment generates the Kubernetes Deployment of the pooler, which authenticates
+// the workload with the credentials in the database secret.
+func (mysql *MySQL) generateLocalPoolerDeployment(request *module.GeneratorRequest, hostAddress string) (
+	*kusionapiv1.Resource, error,
+) {
+	secretName := mysql.DatabaseName + dbResSuffix
+	probe := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			TCPSocket: &v1.TCPSocketAction{
+				Port: intstr.FromInt32(int32(dbPort)),
+			},
+		},
+		PeriodSeconds:  5,
+		TimeoutSeconds: 5,
+	}
+
+	container := v1.Container{
+		Name:    poolerContainerName,
+		Image:   mysql.Pooler.Image,
+		Command: []string{"sh", "-c", mysql.generateLocalPoolerEntrypoint(hostAddress)},
+		Env: []v1.EnvVar{
+			{
+				Name: "DB_USERNAME",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: mysql.generateSecretKeySelector(secretName, "username"),
+				},
+			},
+			{
+				Name: "DB_PASSWORD",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: mysql.generateSecretKeySelector(secretName, "password"),
+				},
+			},
+		},
+		Ports: []v1.ContainerPort{
+			{
+				ContainerPort: int32(dbPort),
+			},
+		},
+		ReadinessProbe: probe,
+		LivenessProbe:  probe,
+	}
+
+	replicas := int32(mysql.Pooler.Replicas)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + poolerSuffix,
+			Namespace: request.Project,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: mysql.generatePoolerMatchLabels(),
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      mysql.generatePoolerMatchLabels(),
+					Annotations: mysql.generatePasswordRotationAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{container},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(deployment.TypeMeta, deployment.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateLocalPoolerEntrypoint generates the entrypoint script of ProxySQL, which writes the config
+// file with the credentials expanded from the environment variables, since ProxySQL reads them from
+// the config file only. The admin interface is only exposed to the pod itself.
+func (mysql *MySQL) generateLocalPoolerEntrypoint(hostAddress string) string {
+	config := strings.Join([]string{
+		`datadir="/var/lib/proxysql"`,
+		`admin_variables={ mysql_ifaces="127.0.0.1:6032" }`,
+		fmt.Sprintf(`mysql_variables={ interfaces="0.0.0.0:%d" max_connections=%d monitor_enabled=false server_version="%s" }`,
+			dbPort, mysql.Pooler.MaxClientConnections, mysql.Version),
+		fmt.Sprintf(`mysql_servers=({ address="%s" port=%d hostgroup=0 max_connections=%d })`,
+			hostAddress, dbPort, mysql.Pooler.PoolSize),
+		`mysql_users=({ username="${DB_USERNAME}" password="${DB_PASSWORD}" default_hostgroup=0 })`,
+	}, "\n")
+
+	return fmt.Sprintf("cat > %s <<EOF\n%s\nEOF\nexec proxysql -f --initial -c %s", poolerConfigFile, config,
+		poolerConfigFile)
+}
+
+// generateLocalPoolerService generates the Kubernetes Service of the pooler.
+func (mysql *MySQL) generateLocalPoolerService(request *module.GeneratorRequest) (*kusionapiv1.Resource, string, error) {
+	svcName := mysql.DatabaseName + poolerServiceSuffix
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svcName,
+			Namespace: request.Project,
+			Labels:    mysql.generatePoolerMatchLabels(),
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Port: int32(dbPort),
+				},
+			},
+			Selector: mysql.generatePoolerMatchLabels(),
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(service.TypeMeta, service.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, service)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, svcName, nil
+}
+
+// generatePoolerMatchLabels generates the match labels of the pooler, which differ from the ones of
+// the local MySQL instance to keep the pooler out of the instance Service.
+func (mysql *MySQL) generatePoolerMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": mysql.DatabaseName + poolerSuffix,
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParsePooler(t *testing.T) {
+	pooler, err := parsePooler(true)
+	assert.NoError(t, err)
+	assert.Equal(t, &Pooler{
+		Replicas:             1,
+		PoolSize:             20,
+		MaxClientConnections: 1000,
+		Image:                "proxysql/proxysql:2.6.3",
+	}, pooler)
+
+	pooler, err = parsePooler("false")
+	assert.NoError(t, err)
+	assert.Nil(t, pooler)
+
+	pooler, err = parsePooler(map[string]any{"replicas": 2, "poolSize": "50"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Pooler{
+		Replicas:             2,
+		PoolSize:             50,
+		MaxClientConnections: 1000,
+		Image:                "proxysql/proxysql:2.6.3",
+	}, pooler)
+
+	pooler, err = parsePooler(map[string]any{"subnetIDs": []any{"subnet-a", "subnet-b"}, "maxConnectionsPercent": 80})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, pooler.SubnetIDs)
+	assert.Equal(t, 80, pooler.MaxConnectionsPercent)
+
+	_, err = parsePooler(1)
+	assert.ErrorIs(t, err, ErrInvalidPoolerConfig)
+
+	_, err = parsePooler(map[string]any{"engine": "rdsProxy"})
+	assert.ErrorIs(t, err, ErrInvalidPoolerConfig)
+}
+
+func TestMySQLModule_ValidatePooler(t *testing.T) {
+	testcases := []struct {
+		name    string
+		mysql   *MySQL
+		success bool
+	}{
+		{
+			name:    "no pooler",
+			mysql:   &MySQL{Type: "cloud"},
+			success: true,
+		},
+		{
+			name: "pooler of local database",
+			mysql: &MySQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000, Image: "proxysql/proxysql:2.6.3"},
+			},
+			success: true,
+		},
+		{
+			name: "pooler of cloud database",
+			mysql: &MySQL{
+				Type:   "cloud",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a", "subnet-b"}, MaxConnectionsPercent: 80},
+			},
+			success: true,
+		},
+		{
+			name: "pooler of cloud database in single subnet",
+			mysql: &MySQL{
+				Type:   "cloud",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a"}},
+			},
+			success: false,
+		},
+		{
+			name: "pooler of cloud database with invalid max connections percent",
+			mysql: &MySQL{
+				Type:   "cloud",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a", "subnet-b"}, MaxConnectionsPercent: 120},
+			},
+			success: false,
+		},
+		{
+			name: "pooler of cloud database with iam auth",
+			mysql: &MySQL{
+				Type:   "cloud",
+				Auth:   "iam",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a", "subnet-b"}},
+			},
+			success: false,
+		},
+		{
+			name: "invalid pool size",
+			mysql: &MySQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, MaxClientConnections: 1000, Image: "proxysql/proxysql:2.6.3"},
+			},
+			success: false,
+		},
+		{
+			name: "empty image",
+			mysql: &MySQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.mysql.validatePooler()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidPoolerConfig)
+			}
+		})
+	}
+}
+
+func TestMySQLModule_GenerateLocalPoolerResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	mysql := &MySQL{
+		Type:         "local",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Pooler:       &Pooler{Replicas: 2, PoolSize: 20, MaxClientConnections: 1000, Image: "proxysql/proxysql:2.6.3"},
+	}
+
+	resources, hostAddress, err := mysql.generateLocalPoolerResources(r, "test-database-db-local-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-database-db-pooler-service", hostAddress)
+	assert.Equal(t, 2, len(resources))
+	assert.Equal(t, "apps/v1:Deployment:test-project:test-database-db-pooler", resources[0].ID)
+	assert.Equal(t, "v1:Service:test-project:test-database-db-pooler-service", resources[1].ID)
+
+	spec := resources[0].Attributes["spec"].(map[string]any)
+	assert.Equal(t, int64(2), spec["replicas"])
+	assert.Equal(t, map[string]any{"accessory": "test-database-db-pooler"},
+		spec["selector"].(map[string]any)["matchLabels"])
+	assert.Equal(t, map[string]any{"accessory": "test-database-db-pooler"},
+		resources[1].Attributes["spec"].(map[string]any)["selector"])
+
+	container := spec["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+	assert.Equal(t, "proxysql/proxysql:2.6.3", container["image"])
+	assert.Equal(t, "test-database-mysql",
+		container["env"].([]any)[1].(map[string]any)["valueFrom"].(map[string]any)["secretKeyRef"].(map[string]any)["name"])
+}
+
+func TestMySQLModule_GenerateLocalPoolerEntrypoint(t *testing.T) {
+	mysql := &MySQL{
+		Version: "8.0",
+		Pooler:  &Pooler{PoolSize: 20, MaxClientConnections: 1000},
+	}
+
+	entrypoint := mysql.generateLocalPoolerEntrypoint("test-database-db-local-service")
+
+	assert.Contains(t, entrypoint, `mysql_variables={ interfaces="0.0.0.0:3306" max_connections=1000 `+
+		`monitor_enabled=false server_version="8.0" }`)
+	assert.Contains(t, entrypoint, `mysql_servers=({ address="test-database-db-local-service" port=3306 `+
+		`hostgroup=0 max_connections=20 })`)
+	assert.Contains(t, entrypoint, `mysql_users=({ username="${DB_USERNAME}" password="${DB_PASSWORD}" `)
+	assert.Contains(t, entrypoint, "exec proxysql -f --initial -c /tmp/proxysql.cnf")
+}
+
+func TestMySQLModule_GenerateLocalResourcesWithPooler(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:         "local",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         defaultSize,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Pooler:       &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000, Image: "proxysql/proxysql:2.6.3"},
+	}
+
+	resources, _, err := mysql.GenerateLocalResources(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(resources))
+
+	// The workload connects to the instance through the pooler.
+	dbSecretData := resources[7].Attributes["stringData"].(map[string]interface{})
+	assert.Equal(t, "test-database-db-pooler-service", dbSecretData["hostAddress"])
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	maxReplicas         = 5
+	replicaSuffix       = "-replica"
+	readHostAddressKey  = "readHostAddress"
+	localSourcePassword = "KUSION_SOURCE_PASSWORD"
+	localReplicaBaseID  = 100
+)
+
+// generateReadHostAddressKey generates the key of the read replica host address in the database secret.
+func generateReadHostAddressKey(index int) string {
+	if index == 0 {
+		return readHostAddressKey
+	}
+
+	return readHostAddressKey + strconv.Itoa(index)
+}
+
+// generateReadHostEnv generates the name of the environment variable holding the read replica host
+// address, which is suffixed with the index for the replicas other than the first one.
+func (mysql *MySQL) generateReadHostEnv(index int) string {
+	name := dbReadHostEnv + "_" + mysql.generateEnvSuffix()
+	if index == 0 {
+		return name
+	}
+
+	return name + "_" + strconv.Itoa(index)
+}
+
+// generateReplicaName generates the name of the read replica with the index.
+func (mysql *MySQL) generateReplicaName(index int) string {
+	return fmt.Sprintf("%s%s-%d", mysql.DatabaseName, replicaSuffix, index)
+}
+
+// generateLocalReplicationFlags generates the mysqld flags enabling the GTID based replication, with
+// the server ID evaluated by the shell.
+func (mysql *MySQL) generateLocalReplicationFlags(serverID string) string {
+	return fmt.Sprintf("--server-id=%s --log-bin=mysql-bin --gtid-mode=ON --enforce-gtid-consistency=ON", serverID)
+}
+
+// generateLocalReplicaResources generates the Kubernetes StatefulSet and headless Service of the
+// binlog replicas of the local MySQL instance, and returns the Service name as the read host address.
+func (mysql *MySQL) generateLocalReplicaResources(request *module.GeneratorRequest, sourceHostAddress string) (
+	[]kusionapiv1.Resource, string, error,
+) {
+	name := mysql.DatabaseName + replicaSuffix
+	labels := mysql.generateLocalReplicaMatchLabels()
+
+	podSpec, err := mysql.generateLocalReplicaPodSpec(sourceHostAddress)
+	if err != nil {
+		return nil, "", err
+	}
+
+	replicas := int32(mysql.Replicas)
+	statefulSet := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + localStatefulSetSuffix,
+			Namespace: request.Project,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: name + localServiceSuffix,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: mysql.generateLocalPodAnnotations(),
+				},
+				Spec: podSpec,
+			},
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   mysql.DatabaseName,
+						Labels: labels,
+					},
+					Spec: mysql.generateLocalPVCSpec(),
+				},
+			},
+		},
+	}
+
+	statefulSetID := module.KubernetesResourceID(statefulSet.TypeMeta, statefulSet.ObjectMeta)
+	statefulSetRes, err := module.WrapK8sResourceToKusionResource(statefulSetID, statefulSet)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The headless Service resolves to all the replica pods for the workload to spread the reads.
+	svcName := name + localServiceSuffix
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svcName,
+			Namespace: request.Project,
+			Labels:    labels,
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "None",
+			Ports:     mysql.generateLocalSvcPort(),
+			Selector:  labels,
+		},
+	}
+
+	serviceID := module.KubernetesResourceID(service.TypeMeta, service.ObjectMeta)
+	serviceRes, err := module.WrapK8sResourceToKusionResource(serviceID, service)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []kusionapiv1.Resource{*statefulSetRes, *serviceRes}, svcName, nil
+}
+
+// generateLocalReplicaPodSpec generates the Kubernetes PodSpec of the binlog replicas of the local
+// MySQL instance, which replicate from the source with the administrator account.
+func (mysql *MySQL) generateLocalReplicaPodSpec(sourceHostAddress string) (v1.PodSpec, error) {
+	secretName := mysql.DatabaseName + localSecretSuffix
+	passwordEnvSource := &v1.EnvVarSource{
+		SecretKeyRef: &v1.SecretKeySelector{
+			LocalObjectReference: v1.LocalObjectReference{
+				Name: secretName,
+			},
+			Key: "password",
+		},
+	}
+
+	resources, err := mysql.generateLocalResourceRequirements()
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+
+	// The server ID of each replica is derived from the ordinal of the pod, and the replicas are
+	// read only for the users other than the replication thread.
+	entrypoint := fmt.Sprintf(`exec docker-entrypoint.sh mysqld %s --read-only`,
+		mysql.generateLocalReplicationFlags(fmt.Sprintf(`$((${HOSTNAME##*-} + %d))`, localReplicaBaseID)))
+
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      mysql.DatabaseName,
+			MountPath: "/var/lib/mysql",
+		},
+	}
+
+	// The replicas share the option file with the engine parameters of the source.
+	var volumes []v1.Volume
+	if len(mysql.Parameters) > 0 {
+		configVolume, configVolumeMount := mysql.generateLocalConfigVolume()
+		volumeMounts = append(volumeMounts, configVolumeMount)
+		volumes = append(volumes, configVolume)
+	}
+
+	podSpec := v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name:    mysql.DatabaseName + replicaSuffix,
+				Image:   dbEngine + ":" + mysql.Version,
+				Command: []string{"sh", "-c", entrypoint},
+				Env: []v1.EnvVar{
+					{Name: "MYSQL_ROOT_PASSWORD", ValueFrom: passwordEnvSource},
+					{Name: localSourcePassword, ValueFrom: passwordEnvSource},
+				},
+				Ports: []v1.ContainerPort{
+					{
+						ContainerPort: int32(dbPort),
+					},
+				},
+				VolumeMounts: volumeMounts,
+				Resources:    resources,
+				Lifecycle: &v1.Lifecycle{
+					PostStart: &v1.LifecycleHandler{
+						Exec: &v1.ExecAction{
+							Command: []string{"sh", "-c", mysql.generateLocalReplicationScript(sourceHostAddress)},
+						},
+					},
+				},
+				ReadinessProbe: &v1.Probe{
+					ProbeHandler:     mysql.generateLocalProbeHandler(),
+					PeriodSeconds:    5,
+					TimeoutSeconds:   5,
+					FailureThreshold: 3,
+				},
+				StartupProbe: &v1.Probe{
+					ProbeHandler:     mysql.generateLocalProbeHandler(),
+					PeriodSeconds:    10,
+					TimeoutSeconds:   5,
+					FailureThreshold: 30,
+				},
+			},
+		},
+		Volumes: volumes,
+	}
+
+	return podSpec, nil
+}
+
+// generateLocalReplicationScript generates the script pointing the replica to the source once the
+// replica accepts connections, which is run after every start for the rotated password to take effect.
+func (mysql *MySQL) generateLocalReplicationScript(sourceHostAddress string) string {
+	statements := []string{
+		"STOP REPLICA;",
+		fmt.Sprintf(`CHANGE REPLICATION SOURCE TO SOURCE_HOST='%s', SOURCE_PORT=%d, SOURCE_USER='%s', `+
+			`SOURCE_PASSWORD='$%s', SOURCE_AUTO_POSITION=1, GET_SOURCE_PUBLIC_KEY=1;`,
+			sourceHostAddress, dbPort, mysql.Username, localSourcePassword),
+		"START REPLICA;",
+	}
+
+	// The replication statements before MySQL 8.0.23 use the legacy terms.
+	if strings.HasPrefix(mysql.Version, "5.") {
+		statements = []string{
+			"STOP SLAVE;",
+			fmt.Sprintf(`CHANGE MASTER TO MASTER_HOST='%s', MASTER_PORT=%d, MASTER_USER='%s', `+
+				`MASTER_PASSWORD='$%s', MASTER_AUTO_POSITION=1;`,
+				sourceHostAddress, dbPort, mysql.Username, localSourcePassword),
+			"START SLAVE;",
+		}
+	}
+
+	return fmt.Sprintf(`until mysqladmin ping -h 127.0.0.1 --silent; do sleep 1; done
+mysql -h 127.0.0.1 -uroot -p"$MYSQL_ROOT_PASSWORD" <<EOSQL
+%s
+EOSQL`, strings.Join(statements, "\n"))
+}
+
+// generateLocalReplicaMatchLabels generates the match labels of the replicas of the local MySQL instance,
+// which are distinct from the ones of the source for the Services to select separately.
+func (mysql *MySQL) generateLocalReplicaMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": mysql.DatabaseName + replicaSuffix,
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestMySQLModule_GenerateReadHostEnv(t *testing.T) {
+	mysql := &MySQL{
+		DatabaseName: "test-database",
+	}
+
+	assert.Equal(t, "readHostAddress", generateReadHostAddressKey(0))
+	assert.Equal(t, "readHostAddress1", generateReadHostAddressKey(1))
+	assert.Equal(t, "KUSION_DB_READ_HOST_TEST_DATABASE", mysql.generateReadHostEnv(0))
+	assert.Equal(t, "KUSION_DB_READ_HOST_TEST_DATABASE_1", mysql.generateReadHostEnv(1))
+	assert.Equal(t, "test-database-replica-1", mysql.generateReplicaName(1))
+}
+
+func TestMySQLModule_GenerateDBSecretWithReadHosts(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:         "local",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+	}
+
+	res, patcher, err := mysql.GenerateDBSecret(r, "test-host-address", "test-username", "test-password",
+		[]string{"test-read-host-address-0", "test-read-host-address-1"})
+	assert.NoError(t, err)
+
+	stringData := res.Attributes["stringData"].(map[string]any)
+	assert.Equal(t, "test-read-host-address-0", stringData["readHostAddress"])
+	assert.Equal(t, "test-read-host-address-1", stringData["readHostAddress1"])
+
+	envs := make(map[string]string)
+	for _, env := range patcher.Environments {
+		if env.ValueFrom != nil {
+			envs[env.Name] = env.ValueFrom.SecretKeyRef.Key
+		}
+	}
+	assert.Equal(t, "readHostAddress", envs["KUSION_DB_READ_HOST_TEST_DATABASE"])
+	assert.Equal(t, "readHostAddress1", envs["KUSION_DB_READ_HOST_TEST_DATABASE_1"])
+}
+
+func TestMySQLModule_GenerateLocalReplicaResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	mysql := &MySQL{
+		Type:         "local",
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     "root",
+		Size:         10,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Replicas:     2,
+	}
+
+	resources, readHostAddress, err := mysql.generateLocalReplicaResources(r, "test-database-db-local-service")
+	assert.NoError(t, err)
+	assert.Len(t, resources, 2)
+	assert.Equal(t, "test-database-replica-db-local-service", readHostAddress)
+	assert.Equal(t, "apps/v1:StatefulSet:test-project:test-database-replica-db-local-statefulset", resources[0].ID)
+	assert.Equal(t, "v1:Service:test-project:test-database-replica-db-local-service", resources[1].ID)
+}
+
+func TestMySQLModule_GenerateLocalReplicationScript(t *testing.T) {
+	mysql := &MySQL{
+		Version:  "8.0",
+		Username: "root",
+	}
+
+	script := mysql.generateLocalReplicationScript("test-host-address")
+	assert.Contains(t, script, "CHANGE REPLICATION SOURCE TO SOURCE_HOST='test-host-address', SOURCE_PORT=3306")
+	assert.Contains(t, script, "START REPLICA;")
+
+	mysql.Version = "5.7"
+	script = mysql.generateLocalReplicationScript("test-host-address")
+	assert.Contains(t, script, "CHANGE MASTER TO MASTER_HOST='test-host-address', MASTER_PORT=3306")
+	assert.True(t, strings.HasSuffix(script, "START SLAVE;\nEOSQL"))
+}
+
+func TestMySQLModule_GenerateLocalReplicaPodSpec(t *testing.T) {
+	mysql := &MySQL{
+		Version:      "8.0",
+		DatabaseName: "test-database",
+		Username:     "root",
+		Size:         10,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+	}
+
+	podSpec, err := mysql.generateLocalReplicaPodSpec("test-host-address")
+	assert.NoError(t, err)
+	assert.Len(t, podSpec.Containers, 1)
+	assert.Contains(t, podSpec.Containers[0].Command[2], "--server-id=$((${HOSTNAME##*-} + 100))")
+	assert.Contains(t, podSpec.Containers[0].Command[2], "--read-only")
+	assert.Equal(t, []v1.EnvVar{
+		{
+			Name: "MYSQL_ROOT_PASSWORD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "test-database-db-local-secret"},
+					Key:                  "password",
+				},
+			},
+		},
+		{
+			Name: "KUSION_SOURCE_PASSWORD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "test-database-db-local-secret"},
+					Key:                  "password",
+				},
+			},
+		},
+	}, podSpec.Containers[0].Env)
+}
+
+func TestMySQLModule_GenerateLocalEntrypointWithReplicas(t *testing.T) {
+	mysql := &MySQL{
+		Version:  "8.0",
+		Username: "root",
+	}
+	assert.True(t, strings.HasSuffix(mysql.generateLocalEntrypoint(), `exec docker-entrypoint.sh mysqld "$@"`))
+
+	mysql.Replicas = 1
+	assert.True(t, strings.HasSuffix(mysql.generateLocalEntrypoint(),
+		`exec docker-entrypoint.sh mysqld --server-id=1 --log-bin=mysql-bin --gtid-mode=ON --enforce-gtid-consistency=ON "$@"`))
+}
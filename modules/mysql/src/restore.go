@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRestoreFrom     = errors.New("invalid restoreFrom in mysql module config")
+	ErrUnsupportedRestoreFrom = errors.New("restoring is not supported for the mysql instance of this cloud provider")
+)
+
+// RestoreFrom describes the source the cloud provided MySQL instance is restored from, which is either
+// a snapshot or a point in time of the source instance.
+type RestoreFrom struct {
+	// The identifier of the snapshot to restore from, which is the DB snapshot identifier or ARN for AWS
+	// and the backup set ID of the source instance for Alicloud.
+	Snapshot string `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+	// The identifier of the source instance, or the source cluster for AWS Aurora.
+	SourceInstance string `json:"sourceInstance,omitempty" yaml:"sourceInstance,omitempty"`
+	// The point in time in UTC to restore to, in the format of RFC 3339 such as "2024-06-01T08:00:00Z".
+	RestoreTime string `json:"restoreTime,omitempty" yaml:"restoreTime,omitempty"`
+	// Whether to restore to the latest restorable time of the source instance.
+	UseLatestRestorableTime bool `json:"useLatestRestorableTime,omitempty" yaml:"useLatestRestorableTime,omitempty"`
+}
+
+// parseRestoreFrom parses the restoreFrom block of the devConfig.
+func parseRestoreFrom(config any) (*RestoreFrom, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidRestoreFrom, config)
+	}
+
+	restoreFrom := &RestoreFrom{}
+	if err := parseConfigBlock(configMap, ErrInvalidRestoreFrom, "", map[string]configValueParser{
+		"snapshot":                configValue(&restoreFrom.Snapshot, toConfigString),
+		"sourceInstance":          configValue(&restoreFrom.SourceInstance, toConfigString),
+		"restoreTime":             configValue(&restoreFrom.RestoreTime, toConfigString),
+		"useLatestRestorableTime": configValue(&restoreFrom.UseLatestRestorableTime, toConfigBool),
+	}); err != nil {
+		return nil, err
+	}
+
+	return restoreFrom, nil
+}
+
+// isPointInTimeRestore returns whether the MySQL instance is restored to a point in time of the
+// source instance instead of from a snapshot.
+func (restoreFrom *RestoreFrom) isPointInTimeRestore() bool {
+	return restoreFrom.RestoreTime != "" || restoreFrom.UseLatestRestorableTime
+}
+
+// validateRestoreFrom validates whether the MySQL instance is restored either from a snapshot or to
+// a point in time of the source instance, which is only supported by the cloud provided instance.
+func (mysql *MySQL) validateRestoreFrom() error {
+	restoreFrom := mysql.RestoreFrom
+	if restoreFrom == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(mysql.Type, CloudDBType) {
+		return fmt.Errorf("%w: only supported by the %s type", ErrInvalidRestoreFrom, CloudDBType)
+	}
+
+	// The restored instance keeps the databases of the source instead of the one named after the
+	// instance, so the database for the workload to connect with should be declared explicitly.
+	if len(mysql.Databases) == 0 {
+		return fmt.Errorf("%w: databases should be declared with the one of the source to connect with first",
+			ErrInvalidRestoreFrom)
+	}
+
+	if restoreFrom.Snapshot == "" && !restoreFrom.isPointInTimeRestore() {
+		return fmt.Errorf("%w: either snapshot or the point in time to restore to should be specified", ErrInvalidRestoreFrom)
+	}
+
+	if restoreFrom.Snapshot != "" && restoreFrom.isPointInTimeRestore() {
+		return fmt.Errorf("%w: snapshot cannot be specified with the point in time to restore to", ErrInvalidRestoreFrom)
+	}
+
+	if restoreFrom.isPointInTimeRestore() {
+		if restoreFrom.SourceInstance == "" {
+			return fmt.Errorf("%w: source instance should be specified for the point-in-time restore", ErrInvalidRestoreFrom)
+		}
+		if restoreFrom.RestoreTime != "" && restoreFrom.UseLatestRestorableTime {
+			return fmt.Errorf("%w: restore time cannot be specified with useLatestRestorableTime", ErrInvalidRestoreFrom)
+		}
+	}
+
+	if restoreFrom.RestoreTime != "" {
+		if _, err := time.Parse(time.RFC3339, restoreFrom.RestoreTime); err != nil {
+			return fmt.Errorf("%w: restore time %q should be in the format of RFC 3339", ErrInvalidRestoreFrom,
+				restoreFrom.RestoreTime)
+		}
+	}
+
+	return nil
+}
+
+// generateRestoreTime generates the point in time to restore to in UTC, which is accepted by the cloud
+// providers in the format of "2006-01-02T15:04:05Z".
+func (restoreFrom *RestoreFrom) generateRestoreTime() string {
+	restoreTime, err := time.Parse(time.RFC3339, restoreFrom.RestoreTime)
+	if err != nil {
+		return restoreFrom.RestoreTime
+	}
+
+	return restoreTime.UTC().Format("2006-01-02T15:04:05Z")
+}
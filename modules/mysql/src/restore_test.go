@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRestoreFrom(t *testing.T) {
+	t.Run("restore from snapshot", func(t *testing.T) {
+		actual, err := parseRestoreFrom(map[string]any{
+			"snapshot": "test-snapshot",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &RestoreFrom{Snapshot: "test-snapshot"}, actual)
+	})
+
+	t.Run("restore to point in time", func(t *testing.T) {
+		actual, err := parseRestoreFrom(map[string]any{
+			"sourceInstance":          "test-source",
+			"useLatestRestorableTime": "true",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &RestoreFrom{
+			SourceInstance:          "test-source",
+			UseLatestRestorableTime: true,
+		}, actual)
+	})
+
+	t.Run("invalid restoreFrom", func(t *testing.T) {
+		_, err := parseRestoreFrom("test-snapshot")
+		assert.ErrorIs(t, err, ErrInvalidRestoreFrom)
+
+		_, err = parseRestoreFrom(map[string]any{"useLatestRestorableTime": "latest"})
+		assert.ErrorIs(t, err, ErrInvalidRestoreFrom)
+
+		_, err = parseRestoreFrom(map[string]any{"unknown": "value"})
+		assert.ErrorIs(t, err, ErrInvalidRestoreFrom)
+	})
+}
+
+func TestMySQLModule_ValidateRestoreFrom(t *testing.T) {
+	testcases := []struct {
+		name        string
+		mysqlType   string
+		databases   []string
+		restoreFrom *RestoreFrom
+		expectedErr error
+	}{
+		{
+			name:      "without restoreFrom",
+			mysqlType: "local",
+		},
+		{
+			name:        "restore from snapshot",
+			mysqlType:   "cloud",
+			databases:   []string{"orders"},
+			restoreFrom: &RestoreFrom{Snapshot: "test-snapshot"},
+		},
+		{
+			name:        "restore without databases",
+			mysqlType:   "cloud",
+			restoreFrom: &RestoreFrom{Snapshot: "test-snapshot"},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+		{
+			name:        "restore to point in time",
+			mysqlType:   "cloud",
+			databases:   []string{"orders"},
+			restoreFrom: &RestoreFrom{SourceInstance: "test-source", RestoreTime: "2024-06-01T16:00:00+08:00"},
+		},
+		{
+			name:        "restore to latest restorable time",
+			mysqlType:   "cloud",
+			databases:   []string{"orders"},
+			restoreFrom: &RestoreFrom{SourceInstance: "test-source", UseLatestRestorableTime: true},
+		},
+		{
+			name:        "restore local instance",
+			mysqlType:   "local",
+			restoreFrom: &RestoreFrom{Snapshot: "test-snapshot"},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+		{
+			name:        "empty restoreFrom",
+			mysqlType:   "cloud",
+			restoreFrom: &RestoreFrom{SourceInstance: "test-source"},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+		{
+			name:        "snapshot with point in time",
+			mysqlType:   "cloud",
+			restoreFrom: &RestoreFrom{Snapshot: "test-snapshot", SourceInstance: "test-source", UseLatestRestorableTime: true},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+		{
+			name:        "point in time without source instance",
+			mysqlType:   "cloud",
+			restoreFrom: &RestoreFrom{UseLatestRestorableTime: true},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+		{
+			name:      "restore time with latest restorable time",
+			mysqlType: "cloud",
+			restoreFrom: &RestoreFrom{
+				SourceInstance:          "test-source",
+				RestoreTime:             "2024-06-01T08:00:00Z",
+				UseLatestRestorableTime: true,
+			},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+		{
+			name:        "invalid restore time",
+			mysqlType:   "cloud",
+			restoreFrom: &RestoreFrom{SourceInstance: "test-source", RestoreTime: "2024-06-01 08:00:00"},
+			expectedErr: ErrInvalidRestoreFrom,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mysql := &MySQL{Type: tc.mysqlType, Databases: tc.databases, RestoreFrom: tc.restoreFrom}
+
+			err := mysql.validateRestoreFrom()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRestoreFrom_GenerateRestoreTime(t *testing.T) {
+	restoreFrom := &RestoreFrom{RestoreTime: "2024-06-01T16:00:00+08:00"}
+	assert.Equal(t, "2024-06-01T08:00:00Z", restoreFrom.generateRestoreTime())
+
+	restoreFrom.RestoreTime = "2024-06-01T08:00:00.5Z"
+	assert.Equal(t, "2024-06-01T08:00:00Z", restoreFrom.generateRestoreTime())
+}
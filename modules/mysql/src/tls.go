@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidTLSConfig = errors.New("invalid tls config in mysql module config")
+	ErrUnsupportedTLS   = errors.New("enforced tls is not supported for the mysql instance of this cloud provider")
+)
+
+var (
+	dbCACertEnv     = "KUSION_DB_CA_CERT"
+	dbCACertFileEnv = "KUSION_DB_CA_CERT_FILE"
+	dbSSLModeEnv    = "KUSION_DB_SSL_MODE"
+
+	tlsCASuffix   = "-db-ca"
+	tlsCAFile     = "ca.pem"
+	tlsCAMountDir = "/etc/kusion/db-ca"
+
+	// The mysql client ssl modes of the connections without and with the server certificate verified.
+	tlsRequiredSSLMode = "REQUIRED"
+	tlsVerifySSLMode   = "VERIFY_CA"
+
+	awsTLSParameter   = "require_secure_transport"
+	alicloudSSLAction = "Open"
+)
+
+// TLS describes the TLS connections enforced by the cloud provided MySQL instance, along with the CA
+// bundle of the cloud provider for the workload to verify the server certificate.
+//
+// The CA bundle is stored in the Kubernetes Secret "<databaseName>-db-ca", which the workload mounts at
+// "/etc/kusion/db-ca/<databaseName>" with the dirs of the container, i.e.
+// "/etc/kusion/db-ca/<databaseName>": "secret://<databaseName>-db-ca". The ssl mode hint and the
+// connection string verify the server certificate with the CA bundle file there.
+type TLS struct {
+	// The PEM encoded CA bundle of the cloud provider.
+	CABundle string `json:"caBundle,omitempty" yaml:"caBundle,omitempty"`
+	// The path of the file containing the CA bundle, relative to the working directory of Kusion.
+	CABundleFile string `json:"caBundleFile,omitempty" yaml:"caBundleFile,omitempty"`
+}
+
+// parseTLS parses the tls of the platform config, which is either a bool enforcing the TLS connections
+// or a block with the CA bundle.
+func parseTLS(config any) (*TLS, error) {
+	if enabled, ok := toConfigBool(config); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return &TLS{}, nil
+	}
+
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a bool or map but got %T", ErrInvalidTLSConfig, config)
+	}
+
+	tls := &TLS{}
+	if err := parseConfigBlock(configMap, ErrInvalidTLSConfig, "", map[string]configValueParser{
+		"caBundle":     configValue(&tls.CABundle, toConfigString),
+		"caBundleFile": configValue(&tls.CABundleFile, toConfigString),
+	}); err != nil {
+		return nil, err
+	}
+
+	return tls, nil
+}
+
+// validateTLS validates whether the TLS connections are enforced on the cloud provided MySQL instance,
+// with the CA bundle declared at most once.
+func (mysql *MySQL) validateTLS() error {
+	if mysql.TLS == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(mysql.Type, CloudDBType) {
+		return fmt.Errorf("%w: only supported by the %s type", ErrInvalidTLSConfig, CloudDBType)
+	}
+
+	if mysql.TLS.CABundle != "" && mysql.TLS.CABundleFile != "" {
+		return fmt.Errorf("%w: caBundle and caBundleFile cannot be specified together", ErrInvalidTLSConfig)
+	}
+
+	return nil
+}
+
+// hasCABundle returns whether the CA bundle is declared for the workload to verify the server certificate.
+func (mysql *MySQL) hasCABundle() bool {
+	return mysql.TLS != nil && (mysql.TLS.CABundle != "" || mysql.TLS.CABundleFile != "")
+}
+
+// generateAWSParameters generates the engine parameters of the AWS provided MySQL instance sorted by
+// the names, along with the one rejecting the insecure connections if the TLS connections are enforced,
+// which takes precedence over the one in the engine parameters.
+func (mysql *MySQL) generateAWSParameters() []Parameter {
+	if mysql.TLS == nil {
+		return mysql.generateParameters()
+	}
+
+	values := make(map[string]string, len(mysql.Parameters)+1)
+	for name, value := range mysql.Parameters {
+		values[name] = value
+	}
+	values[awsTLSParameter] = "1"
+
+	return sortParameters(values)
+}
+
+// generateSSLMode generates the ssl mode hint of the connections, which verifies the server certificate
+// only if the CA bundle is declared.
+func (mysql *MySQL) generateSSLMode() string {
+	if mysql.hasCABundle() {
+		return tlsVerifySSLMode
+	}
+
+	return tlsRequiredSSLMode
+}
+
+// generateTLSParams generates the parameters of the connection string in the specified format enabling
+// the TLS connections, which verify the server certificate only with the mounted CA bundle file.
+func (mysql *MySQL) generateTLSParams(format string) map[string]string {
+	if mysql.TLS == nil {
+		return nil
+	}
+
+	switch format {
+	case JDBCFormat:
+		// MySQL Connector/J only reads the CA from the Java trust store instead of the PEM file.
+		return map[string]string{"sslMode": tlsRequiredSSLMode}
+	case DSNFormat:
+		// The go mysql driver only verifies the server certificate with the TLS config registered by the
+		// workload, which loads the mounted CA bundle file on its own.
+		return map[string]string{"tls": "skip-verify"}
+	default:
+		if mysql.hasCABundle() {
+			return map[string]string{"ssl-mode": tlsVerifySSLMode, "ssl-ca": mysql.generateCAFilePath()}
+		}
+		return map[string]string{"ssl-mode": tlsRequiredSSLMode}
+	}
+}
+
+// generateCAMountPath generates the path the workload mounts the Secret holding the CA bundle at.
+func (mysql *MySQL) generateCAMountPath() string {
+	return tlsCAMountDir + "/" + mysql.DatabaseName
+}
+
+// generateCAFilePath generates the path of the CA bundle file mounted into the workload.
+func (mysql *MySQL) generateCAFilePath() string {
+	return mysql.generateCAMountPath() + "/" + tlsCAFile
+}
+
+// generateCABundle generates the CA bundle declared inline or read from the file.
+func (mysql *MySQL) generateCABundle() (string, error) {
+	if mysql.TLS.CABundleFile == "" {
+		return mysql.TLS.CABundle, nil
+	}
+
+	bytes, err := os.ReadFile(mysql.TLS.CABundleFile)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read file %s: %v", ErrInvalidTLSConfig, mysql.TLS.CABundleFile, err)
+	}
+
+	return string(bytes), nil
+}
+
+// generateCASecret generates the Kubernetes Secret resource holding the CA bundle of the cloud provider,
+// which is mounted into the workload as the file.
+func (mysql *MySQL) generateCASecret(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	caBundle, err := mysql.generateCABundle()
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mysql.DatabaseName + tlsCASuffix,
+			Namespace: request.Project,
+		},
+		StringData: map[string]string{
+			tlsCAFile: caBundle,
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(secret.TypeMeta, secret.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateTLSEnvs generates the environment variables of the ssl mode hint in the database secret, along
+// with the CA bundle in the Secret and the path of the mounted CA bundle file.
+func (mysql *MySQL) generateTLSEnvs(secretName string) []v1.EnvVar {
+	if mysql.TLS == nil {
+		return nil
+	}
+
+	envVars := []v1.EnvVar{
+		{
+			Name: dbSSLModeEnv + "_" + mysql.generateEnvSuffix(),
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: mysql.generateSecretKeySelector(secretName, "sslMode"),
+			},
+		},
+	}
+
+	if mysql.hasCABundle() {
+		envVars = append(envVars,
+			v1.EnvVar{
+				Name: dbCACertEnv + "_" + mysql.generateEnvSuffix(),
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: mysql.DatabaseName + tlsCASuffix,
+						},
+						Key: tlsCAFile,
+					},
+				},
+			},
+			v1.EnvVar{
+				Name:  dbCACertFileEnv + "_" + mysql.generateEnvSuffix(),
+				Value: mysql.generateCAFilePath(),
+			},
+		)
+	}
+
+	return envVars
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidAuroraConfig = errors.New("invalid aurora config in postgres module config")
+
+var (
+	awsRDSCluster         = "aws_rds_cluster"
+	awsRDSClusterInstance = "aws_rds_cluster_instance"
+	awsRDSClusterParams   = "aws_rds_cluster_parameter_group"
+	auroraEngine          = "aurora-postgresql"
+
+	auroraCopyOnWriteRestoreType  = "copy-on-write"
+	auroraFullCopyRestoreType     = "full-copy"
+	auroraServerlessInstanceClass = "db.serverless"
+	auroraInstanceSuffix          = "-instance"
+	defaultAuroraInstances        = 1
+	maxAuroraInstances            = 16
+)
+
+// AuroraCluster describes the AWS Aurora PostgreSQL cluster created instead of the single RDS instance,
+// which consists of a writer instance and the reader instances.
+type AuroraCluster struct {
+	// The engine version of Aurora PostgreSQL of the same major version as the PostgreSQL version, such as
+	// "15.4", which defaults to the PostgreSQL version.
+	EngineVersion string `json:"engineVersion,omitempty" yaml:"engineVersion,omitempty"`
+	// The number of the instances in the cluster, the first of which is the writer.
+	Instances int `json:"instances,omitempty" yaml:"instances,omitempty"`
+	// The scaling configuration of the Aurora Serverless v2 instances.
+	ServerlessV2 *AuroraServerlessV2 `json:"serverlessV2,omitempty" yaml:"serverlessV2,omitempty"`
+}
+
+// AuroraServerlessV2 describes the capacity range in Aurora capacity units (ACUs) of the Aurora
+// Serverless v2 instances.
+type AuroraServerlessV2 struct {
+	// The minimum capacity of each instance in the cluster.
+	MinCapacity float64 `json:"minCapacity,omitempty" yaml:"minCapacity,omitempty"`
+	// The maximum capacity of each instance in the cluster.
+	MaxCapacity float64 `json:"maxCapacity,omitempty" yaml:"maxCapacity,omitempty"`
+}
+
+type awsServerlessV2ScalingConfiguration struct {
+	MinCapacity float64 `yaml:"min_capacity" json:"min_capacity"`
+	MaxCapacity float64 `yaml:"max_capacity" json:"max_capacity"`
+}
+
+type awsClusterRestoreToPointInTime struct {
+	SourceClusterIdentifier string `yaml:"source_cluster_identifier" json:"source_cluster_identifier"`
+	RestoreType             string `yaml:"restore_type" json:"restore_type"`
+	RestoreToTime           string `yaml:"restore_to_time,omitempty" json:"restore_to_time,omitempty"`
+	UseLatestRestorableTime bool   `yaml:"use_latest_restorable_time,omitempty" json:"use_latest_restorable_time,omitempty"`
+}
+
+// parseAuroraCluster parses the aurora block of the platform config.
+func parseAuroraCluster(config any) (*AuroraCluster, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidAuroraConfig, config)
+	}
+
+	aurora := &AuroraCluster{
+		Instances: defaultAuroraInstances,
+	}
+	if err := parseConfigBlock(configMap, ErrInvalidAuroraConfig, "", map[string]configValueParser{
+		"engineVersion": configValue(&aurora.EngineVersion, assertConfigString),
+		"instances":     configValue(&aurora.Instances, toConfigInt),
+		"serverlessV2": func(value any) (err error) {
+			aurora.ServerlessV2, err = parseAuroraServerlessV2(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return aurora, nil
+}
+
+// parseAuroraServerlessV2 parses the serverlessV2 block in the aurora block of the platform config.
+func parseAuroraServerlessV2(config any) (*AuroraServerlessV2, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map of serverlessV2 but got %T", ErrInvalidAuroraConfig, config)
+	}
+
+	serverlessV2 := &AuroraServerlessV2{}
+	if err := parseConfigBlock(configMap, ErrInvalidAuroraConfig, "serverlessV2.", map[string]configValueParser{
+		"minCapacity": configValue(&serverlessV2.MinCapacity, toConfigFloat),
+		"maxCapacity": configValue(&serverlessV2.MaxCapacity, toConfigFloat),
+	}); err != nil {
+		return nil, err
+	}
+
+	return serverlessV2, nil
+}
+
+// validateAuroraCluster validates whether the engine version, the instance number and the serverless
+// capacity range of the Aurora cluster are valid.
+func (postgres *PostgreSQL) validateAuroraCluster() error {
+	if postgres.Aurora == nil {
+		return nil
+	}
+
+	// The engine versions of Aurora PostgreSQL consist of the major and the minor versions, so the major
+	// PostgreSQL version such as "15" should be completed by the platform.
+	engineVersion := postgres.generateAuroraEngineVersion()
+	major, minor, _ := strings.Cut(engineVersion, ".")
+	if major == "" || minor == "" {
+		return fmt.Errorf("%w: engineVersion should be an Aurora PostgreSQL version such as 15.4 but got %q",
+			ErrInvalidAuroraConfig, engineVersion)
+	}
+	if versionMajor, _, _ := strings.Cut(postgres.Version, "."); versionMajor != major {
+		return fmt.Errorf("%w: engineVersion %s is not compatible with PostgreSQL %s", ErrInvalidAuroraConfig,
+			engineVersion, postgres.Version)
+	}
+
+	if postgres.Aurora.Instances < 1 || postgres.Aurora.Instances > maxAuroraInstances {
+		return fmt.Errorf("%w: instances should be between 1 and %d", ErrInvalidAuroraConfig, maxAuroraInstances)
+	}
+
+	// The reader instances of the Aurora cluster serve the reads instead of the read replicas.
+	if postgres.Replicas > 0 {
+		return fmt.Errorf("%w: replicas are not supported, use the instances of the cluster instead", ErrInvalidAuroraConfig)
+	}
+
+	if serverlessV2 := postgres.Aurora.ServerlessV2; serverlessV2 != nil {
+		if serverlessV2.MinCapacity < 0 || serverlessV2.MaxCapacity <= 0 || serverlessV2.MinCapacity > serverlessV2.MaxCapacity {
+			return fmt.Errorf("%w: invalid serverless v2 capacity range [%v, %v]", ErrInvalidAuroraConfig,
+				serverlessV2.MinCapacity, serverlessV2.MaxCapacity)
+		}
+	}
+
+	return nil
+}
+
+// generateAuroraEngineVersion generates the engine version of the Aurora cluster, which is the PostgreSQL
+// version unless declared by the platform.
+func (postgres *PostgreSQL) generateAuroraEngineVersion() string {
+	if postgres.Aurora.EngineVersion != "" {
+		return postgres.Aurora.EngineVersion
+	}
+
+	return postgres.Version
+}
+
+// generateAuroraInstanceClass generates the instance class of the Aurora cluster instances, which is
+// fixed for the Aurora Serverless v2 instances.
+func (postgres *PostgreSQL) generateAuroraInstanceClass() string {
+	if postgres.Aurora.ServerlessV2 != nil {
+		return auroraServerlessInstanceClass
+	}
+
+	return postgres.InstanceType
+}
+
+// generateAWSAuroraResources generates the AWS Aurora PostgreSQL cluster with the cluster instances, and
+// injects the writer and reader endpoints of the cluster into the workload.
+func (postgres *PostgreSQL) generateAWSAuroraResources(request *module.GeneratorRequest, awsProviderCfg module.ProviderConfig, region string) (
+	[]kusionapiv1.Resource, *kusionapiv1.Patcher, error,
+) {
+	var resources []kusionapiv1.Resource
+
+	// Build random_password resource.
+	randomPasswordRes, randomPasswordID, err := postgres.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build aws_security_group resource.
+	awsSecurityGroupRes, awsSecurityGroupID, err := postgres.generateAWSSecurityGroup(awsProviderCfg, region)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *awsSecurityGroupRes)
+
+	// Build aws_rds_cluster_parameter_group resource with the engine parameters if declared.
+	if len(postgres.generateAWSParameters()) > 0 {
+		awsRDSClusterParamsRes, err := postgres.generateAWSRDSClusterParameterGroup(awsProviderCfg, region)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsRDSClusterParamsRes)
+	}
+
+	// Build random_id resource as the identifier of the final snapshot unless it is skipped.
+	var finalSnapshotID string
+	if !postgres.SkipFinalSnapshot {
+		finalSnapshotRes, id, err := postgres.generateAWSFinalSnapshotID()
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *finalSnapshotRes)
+		finalSnapshotID = id
+	}
+
+	// Build aws_rds_cluster resource.
+	awsRDSClusterRes, awsRDSClusterID, err := postgres.generateAWSRDSCluster(awsProviderCfg, region, randomPasswordID,
+		awsSecurityGroupID, finalSnapshotID)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *awsRDSClusterRes)
+
+	// Build aws_rds_cluster_instance resources, the cluster accepts connections once the instances are available.
+	var awsRDSClusterInstanceIDs []string
+	for i := 0; i < postgres.Aurora.Instances; i++ {
+		awsRDSClusterInstanceRes, awsRDSClusterInstanceID, err := postgres.generateAWSRDSClusterInstance(awsProviderCfg, region, awsRDSClusterID, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsRDSClusterInstanceRes)
+		awsRDSClusterInstanceIDs = append(awsRDSClusterInstanceIDs, awsRDSClusterInstanceID)
+	}
+
+	hostAddress := module.KusionPathDependency(awsRDSClusterID, "endpoint")
+	readHostAddresses := []string{module.KusionPathDependency(awsRDSClusterID, "reader_endpoint")}
+
+	// Build the logical databases, application users and grants inside the AWS Aurora PostgreSQL cluster.
+	dependsOn := append([]string{awsRDSClusterID}, awsRDSClusterInstanceIDs...)
+	dbUserResources, username, password, err := postgres.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), dependsOn)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// The default logical database is only created by the Job along with the declared databases,
+	// extensions and application users, since declaring it on the cluster forces the existing one to be replaced.
+	postgres.noDefaultDatabase = len(dbUserResources) == 0
+
+	// Write the credentials into AWS Secrets Manager for the ExternalSecret to sync them into the cluster,
+	// and for AWS RDS Proxy to authenticate the workload with.
+	if postgres.isExternalSecretDelivery() || postgres.Pooler != nil {
+		awsCredentialsStackRes, err := postgres.generateAWSCredentialsStack(request, awsProviderCfg, region, username, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsCredentialsStackRes)
+
+		// Build AWS RDS Proxy in front of the instance for the workload to connect to if declared.
+		if postgres.Pooler != nil {
+			poolerResources, poolerHostAddress, err := postgres.generateAWSDBProxyResources(awsProviderCfg, region,
+				postgres.generateRemoteSecretName(request), awsCredentialsStackRes.ID, awsSecurityGroupID, "db_cluster_identifier",
+				module.KusionPathDependency(awsRDSClusterID, "cluster_identifier"), dependsOn)
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, poolerResources...)
+			hostAddress = poolerHostAddress
+		}
+	}
+
+	// Build Kubernetes Secret with the writer and reader endpoints, username and password of the AWS Aurora
+	// PostgreSQL cluster, and inject the credentials as the environment variable patcher.
+	dbSecret, patcher, err := postgres.generateDBSecret(request, hostAddress, username, password, readHostAddresses,
+		postgres.generateAWSRemoteSecretRef(request))
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *dbSecret)
+
+	// Build the IAM role assumed by the workload with the annotated ServiceAccount for the IAM database
+	// authentication, and inject the region for the workload to generate the authentication tokens.
+	if postgres.isIAMAuth() {
+		iamResources, iamEnvVars, err := postgres.generateAWSIAMAuthResources(request, awsProviderCfg, region, username,
+			awsRDSClusterID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, iamResources...)
+		patcher.Environments = append(patcher.Environments, iamEnvVars...)
+	}
+
+	return resources, patcher, nil
+}
+
+// generateAWSRDSCluster generates aws_rds_cluster resource for the AWS Aurora PostgreSQL cluster.
+func (postgres *PostgreSQL) generateAWSRDSCluster(awsProviderCfg module.ProviderConfig, region, randomPasswordID, awsSecurityGroupID,
+	finalSnapshotID string,
+) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"backup_retention_period": postgres.BackupRetentionPeriod,
+		"cluster_identifier":      postgres.DatabaseName,
+		"deletion_protection":     postgres.DeletionProtection,
+		"engine":                  auroraEngine,
+		"engine_version":          postgres.generateAuroraEngineVersion(),
+		"master_password":         module.KusionPathDependency(randomPasswordID, "result"),
+		"master_username":         postgres.Username,
+		"skip_final_snapshot":     postgres.SkipFinalSnapshot,
+		"vpc_security_group_ids": []string{
+			module.KusionPathDependency(awsSecurityGroupID, "id"),
+		},
+	}
+
+	// A final snapshot is created before the cluster is deleted unless it is skipped explicitly.
+	if !postgres.SkipFinalSnapshot {
+		resAttrs["final_snapshot_identifier"] = module.KusionPathDependency(finalSnapshotID, "hex")
+	}
+
+	if postgres.BackupWindow != "" {
+		resAttrs["preferred_backup_window"] = postgres.BackupWindow
+	}
+
+	if postgres.MaintenanceWindow != "" {
+		resAttrs["preferred_maintenance_window"] = postgres.MaintenanceWindow
+	}
+
+	if postgres.SubnetID != "" {
+		resAttrs["db_subnet_group_name"] = postgres.SubnetID
+	}
+
+	if postgres.isIAMAuth() {
+		resAttrs["iam_database_authentication_enabled"] = true
+	}
+
+	if len(postgres.generateAWSParameters()) > 0 {
+		paramsID, err := module.TerraformResourceID(awsProviderCfg, awsRDSClusterParams, postgres.DatabaseName+awsParameterGroupSuffix)
+		if err != nil {
+			return nil, "", err
+		}
+		resAttrs["db_cluster_parameter_group_name"] = module.KusionPathDependency(paramsID, "name")
+	}
+
+	// The restored cluster inherits the master username and the databases from the source. The
+	// restore to the latest restorable time clones the source cluster with copy-on-write, which shares
+	// the storage until the data diverges, while the restore to a timestamp fully copies the data.
+	if restoreFrom := postgres.RestoreFrom; restoreFrom != nil {
+		delete(resAttrs, "master_username")
+
+		if restoreFrom.Snapshot != "" {
+			resAttrs["snapshot_identifier"] = restoreFrom.Snapshot
+		} else {
+			restoreToPointInTime := awsClusterRestoreToPointInTime{
+				SourceClusterIdentifier: restoreFrom.SourceInstance,
+				RestoreType:             auroraCopyOnWriteRestoreType,
+				UseLatestRestorableTime: restoreFrom.UseLatestRestorableTime,
+			}
+			if restoreFrom.RestoreTime != "" {
+				restoreToPointInTime.RestoreType = auroraFullCopyRestoreType
+				restoreToPointInTime.RestoreToTime = restoreFrom.generateRestoreTime()
+			}
+			resAttrs["restore_to_point_in_time"] = []awsClusterRestoreToPointInTime{restoreToPointInTime}
+		}
+	}
+
+	if serverlessV2 := postgres.Aurora.ServerlessV2; serverlessV2 != nil {
+		resAttrs["serverlessv2_scaling_configuration"] = []awsServerlessV2ScalingConfiguration{
+			{
+				MinCapacity: serverlessV2.MinCapacity,
+				MaxCapacity: serverlessV2.MaxCapacity,
+			},
+		}
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsRDSCluster, postgres.DatabaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsRDSCluster, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSRDSClusterInstance generates aws_rds_cluster_instance resource for the member of the AWS
+// Aurora PostgreSQL cluster with the index.
+func (postgres *PostgreSQL) generateAWSRDSClusterInstance(awsProviderCfg module.ProviderConfig, region, awsRDSClusterID string, index int) (*kusionapiv1.Resource, string, error) {
+	name := fmt.Sprintf("%s%s-%d", postgres.DatabaseName, auroraInstanceSuffix, index)
+	resAttrs := map[string]interface{}{
+		"auto_minor_version_upgrade": postgres.AutoMinorVersionUpgrade,
+		"cluster_identifier":         module.KusionPathDependency(awsRDSClusterID, "id"),
+		"engine":                     auroraEngine,
+		"engine_version":             postgres.generateAuroraEngineVersion(),
+		"identifier":                 name,
+		"instance_class":             postgres.generateAuroraInstanceClass(),
+		"publicly_accessible":        IsPublicAccessible(postgres.SecurityIPs),
+	}
+
+	if postgres.SubnetID != "" {
+		resAttrs["db_subnet_group_name"] = postgres.SubnetID
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsRDSClusterInstance, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsRDSClusterInstance, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSRDSClusterParameterGroup generates aws_rds_cluster_parameter_group resource with the
+// engine parameters for the AWS Aurora PostgreSQL cluster.
+func (postgres *PostgreSQL) generateAWSRDSClusterParameterGroup(awsProviderCfg module.ProviderConfig, region string) (*kusionapiv1.Resource, error) {
+	var parameters []awsDBParameter
+	for _, parameter := range postgres.generateAWSParameters() {
+		parameters = append(parameters, awsDBParameter{
+			Name:        parameter.Name,
+			Value:       parameter.Value,
+			ApplyMethod: awsParameterApplyMethod,
+		})
+	}
+
+	resAttrs := map[string]interface{}{
+		"family":    postgres.generateParameterGroupFamily(auroraEngine),
+		"name":      postgres.DatabaseName + awsParameterGroupSuffix,
+		"parameter": parameters,
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsRDSClusterParams, postgres.DatabaseName+awsParameterGroupSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsRDSClusterParams, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
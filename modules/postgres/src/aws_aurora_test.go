@@ -0,0 +1,289 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseAuroraCluster(t *testing.T) {
+	t.Run("default instances", func(t *testing.T) {
+		aurora, err := parseAuroraCluster(map[string]any{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &AuroraCluster{Instances: defaultAuroraInstances}, aurora)
+	})
+
+	t.Run("serverless v2 cluster", func(t *testing.T) {
+		aurora, err := parseAuroraCluster(map[string]any{
+			"engineVersion": "15.4",
+			"instances":     "2",
+			"serverlessV2": map[string]any{
+				"minCapacity": 0.5,
+				"maxCapacity": 4,
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &AuroraCluster{
+			EngineVersion: "15.4",
+			Instances:     2,
+			ServerlessV2: &AuroraServerlessV2{
+				MinCapacity: 0.5,
+				MaxCapacity: 4,
+			},
+		}, aurora)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := parseAuroraCluster(map[string]any{"members": 2})
+
+		assert.ErrorIs(t, err, ErrInvalidAuroraConfig)
+	})
+}
+
+func TestPostgreSQLModule_ValidateAuroraCluster(t *testing.T) {
+	testcases := []struct {
+		name     string
+		postgres *PostgreSQL
+		success  bool
+	}{
+		{
+			name: "valid aurora cluster",
+			postgres: &PostgreSQL{
+				Version: "15",
+				Aurora:  &AuroraCluster{EngineVersion: "15.4", Instances: 2},
+			},
+			success: true,
+		},
+		{
+			name: "aurora engine version as postgres version",
+			postgres: &PostgreSQL{
+				Version: "15.4",
+				Aurora:  &AuroraCluster{Instances: 2},
+			},
+			success: true,
+		},
+		{
+			name: "missing aurora engine version",
+			postgres: &PostgreSQL{
+				Version: "15",
+				Aurora:  &AuroraCluster{Instances: 2},
+			},
+			success: false,
+		},
+		{
+			name: "incompatible aurora engine version",
+			postgres: &PostgreSQL{
+				Version: "14.0",
+				Aurora:  &AuroraCluster{EngineVersion: "15.4", Instances: 2},
+			},
+			success: false,
+		},
+		{
+			name: "too many instances",
+			postgres: &PostgreSQL{
+				Version: "15.4",
+				Aurora:  &AuroraCluster{Instances: 17},
+			},
+			success: false,
+		},
+		{
+			name: "aurora cluster with replicas",
+			postgres: &PostgreSQL{
+				Version:  "15.4",
+				Replicas: 1,
+				Aurora:   &AuroraCluster{Instances: 1},
+			},
+			success: false,
+		},
+		{
+			name: "invalid serverless v2 capacity range",
+			postgres: &PostgreSQL{
+				Version: "15.4",
+				Aurora: &AuroraCluster{
+					Instances:    1,
+					ServerlessV2: &AuroraServerlessV2{MinCapacity: 8, MaxCapacity: 4},
+				},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.postgres.validateAuroraCluster()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidAuroraConfig)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateAWSAuroraResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "cloud",
+		Version:      "15.4",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		SecurityIPs:  defaultSecurityIPs,
+		InstanceType: "db.r6g.large",
+		Aurora:       &AuroraCluster{Instances: 2},
+	}
+
+	resources, patcher, err := postgres.generateAWSAuroraResources(r, defaultAWSProviderCfg, "test-region")
+
+	assert.NoError(t, err)
+	// random_password, aws_security_group, random_id, aws_rds_cluster, 2 aws_rds_cluster_instance and the secret.
+	assert.Equal(t, 7, len(resources))
+	assert.NotNil(t, patcher)
+
+	assert.Equal(t, module.KusionPathDependency(resources[2].ID, "hex"), resources[3].Attributes["final_snapshot_identifier"])
+
+	stringData := resources[6].Attributes["stringData"].(map[string]any)
+	assert.Equal(t, module.KusionPathDependency(resources[3].ID, "endpoint"), stringData["hostAddress"])
+	assert.Equal(t, module.KusionPathDependency(resources[3].ID, "reader_endpoint"), stringData["readHostAddress"])
+}
+
+func TestPostgreSQLModule_GenerateAWSRDSCluster(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:                  "cloud",
+		Version:               "15",
+		DatabaseName:          "test-database",
+		Username:              defaultUsername,
+		BackupRetentionPeriod: defaultBackupRetentionPeriod,
+		DeletionProtection:    defaultDeletionProtection,
+		Aurora: &AuroraCluster{
+			EngineVersion: "15.4",
+			Instances:     1,
+			ServerlessV2:  &AuroraServerlessV2{MinCapacity: 0.5, MaxCapacity: 4},
+		},
+	}
+
+	res, id, err := postgres.generateAWSRDSCluster(defaultAWSProviderCfg, "test-region",
+		"random_password_id", "aws_security_group_id", "random_id_id")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", id)
+	assert.Equal(t, "aurora-postgresql", res.Attributes["engine"])
+	assert.Equal(t, "15.4", res.Attributes["engine_version"])
+	assert.Equal(t, module.KusionPathDependency("random_id_id", "hex"), res.Attributes["final_snapshot_identifier"])
+	assert.Equal(t, []awsServerlessV2ScalingConfiguration{
+		{MinCapacity: 0.5, MaxCapacity: 4},
+	}, res.Attributes["serverlessv2_scaling_configuration"])
+}
+
+func TestPostgreSQLModule_GenerateAWSRDSClusterWithRestoreFrom(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:         "cloud",
+		Version:      "15.4",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Aurora:       &AuroraCluster{Instances: 1},
+		RestoreFrom:  &RestoreFrom{SourceInstance: "test-source", UseLatestRestorableTime: true},
+	}
+
+	res, _, err := postgres.generateAWSRDSCluster(defaultAWSProviderCfg, "test-region",
+		"random_password_id", "aws_security_group_id", "random_id_id")
+
+	assert.NoError(t, err)
+	assert.NotContains(t, res.Attributes, "master_username")
+	assert.NotContains(t, res.Attributes, "database_name")
+	assert.Equal(t, []awsClusterRestoreToPointInTime{
+		{
+			SourceClusterIdentifier: "test-source",
+			RestoreType:             "copy-on-write",
+			UseLatestRestorableTime: true,
+		},
+	}, res.Attributes["restore_to_point_in_time"])
+
+	postgres.RestoreFrom = &RestoreFrom{Snapshot: "test-cluster-snapshot"}
+	res, _, err = postgres.generateAWSRDSCluster(defaultAWSProviderCfg, "test-region",
+		"random_password_id", "aws_security_group_id", "random_id_id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-cluster-snapshot", res.Attributes["snapshot_identifier"])
+	assert.NotContains(t, res.Attributes, "restore_to_point_in_time")
+}
+
+func TestPostgreSQLModule_GenerateAWSRDSClusterWithRestoreTime(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:         "cloud",
+		Version:      "15.4",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Aurora:       &AuroraCluster{Instances: 1},
+		RestoreFrom:  &RestoreFrom{SourceInstance: "test-source", RestoreTime: "2024-06-01T16:00:00+08:00"},
+	}
+
+	res, _, err := postgres.generateAWSRDSCluster(defaultAWSProviderCfg, "test-region",
+		"random_password_id", "aws_security_group_id", "random_id_id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []awsClusterRestoreToPointInTime{
+		{
+			SourceClusterIdentifier: "test-source",
+			RestoreType:             "full-copy",
+			RestoreToTime:           "2024-06-01T08:00:00Z",
+		},
+	}, res.Attributes["restore_to_point_in_time"])
+}
+
+func TestPostgreSQLModule_GenerateAWSRDSClusterInstance(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:         "cloud",
+		Version:      "15.4",
+		DatabaseName: "test-database",
+		SecurityIPs:  defaultSecurityIPs,
+		InstanceType: "db.r6g.large",
+		Aurora: &AuroraCluster{
+			Instances:    1,
+			ServerlessV2: &AuroraServerlessV2{MinCapacity: 0.5, MaxCapacity: 4},
+		},
+	}
+
+	res, id, err := postgres.generateAWSRDSClusterInstance(defaultAWSProviderCfg, "test-region", "aws_rds_cluster_id", 0)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", id)
+	assert.Equal(t, "test-database-instance-0", res.Attributes["identifier"])
+	assert.Equal(t, "db.serverless", res.Attributes["instance_class"])
+	assert.Equal(t, module.KusionPathDependency("aws_rds_cluster_id", "id"), res.Attributes["cluster_identifier"])
+}
+
+func TestPostgreSQLModule_GenerateAWSRDSClusterParameterGroup(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:         "cloud",
+		Version:      "15.4",
+		DatabaseName: "test-database",
+		Aurora:       &AuroraCluster{Instances: 1},
+		Parameters: map[string]string{
+			"max_connections": "500",
+		},
+	}
+
+	res, err := postgres.generateAWSRDSClusterParameterGroup(defaultAWSProviderCfg, "test-region")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "aurora-postgresql15", res.Attributes["family"])
+
+	cluster, _, err := postgres.generateAWSRDSCluster(defaultAWSProviderCfg, "test-region",
+		"random_password_id", "aws_security_group_id", "random_id_id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, module.KusionPathDependency(res.ID, "name"), cluster.Attributes["db_cluster_parameter_group_name"])
+}
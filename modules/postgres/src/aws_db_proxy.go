@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	awsDBProxy                   = "aws_db_proxy"
+	awsDBProxyDefaultTargetGroup = "aws_db_proxy_default_target_group"
+	awsDBProxyTarget             = "aws_db_proxy_target"
+
+	awsDBProxyEngineFamily  = "POSTGRESQL"
+	awsDBProxyAuthScheme    = "SECRETS"
+	awsDBProxyIAMAuth       = "DISABLED"
+	awsDBProxyRoleSuffix    = "-db-proxy"
+	awsDBProxyPolicySuffix  = "-db-proxy-secrets"
+	awsRDSServicePrincipal  = "rds.amazonaws.com"
+	awsSecretsManagerAction = "secretsmanager:GetSecretValue"
+
+	awsPartitionPrefixes = map[string]string{
+		"cn-":     "aws-cn",
+		"us-gov-": "aws-us-gov",
+	}
+)
+
+type awsDBProxyAuth struct {
+	AuthScheme string `yaml:"auth_scheme" json:"auth_scheme"`
+	IAMAuth    string `yaml:"iam_auth" json:"iam_auth"`
+	SecretARN  string `yaml:"secret_arn" json:"secret_arn"`
+}
+
+type awsDBProxyConnectionPoolConfig struct {
+	MaxConnectionsPercent int `yaml:"max_connections_percent" json:"max_connections_percent"`
+}
+
+// generateAWSDBProxyResources generates AWS RDS Proxy in front of the AWS provided PostgreSQL instance or
+// Aurora cluster, along with the IAM role allowed to read the credentials of the workload from the
+// secret named secretName in AWS Secrets Manager, which is created by the stack of awsCredentialsStackID.
+// The target is declared by the attribute, which is "db_instance_identifier" of the instance or
+// "db_cluster_identifier" of the cluster, with its identifier. It returns the endpoint of the proxy as
+// the host address for the workload.
+func (postgres *PostgreSQL) generateAWSDBProxyResources(awsProviderCfg module.ProviderConfig, region, secretName,
+	awsCredentialsStackID, awsSecurityGroupID, targetAttr, targetIdentifier string, dependsOn []string,
+) ([]kusionapiv1.Resource, string, error) {
+	secretARN := module.KusionPathDependency(awsCredentialsStackID, awsCredentialsSecretARNAttr)
+
+	awsIAMRoleRes, awsIAMRoleID, err := postgres.generateAWSDBProxyRole(awsProviderCfg, region)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsIAMRolePolicyRes, err := postgres.generateAWSDBProxyRolePolicy(awsProviderCfg, region, awsIAMRoleID, secretName,
+		awsCredentialsStackID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsDBProxyRes, awsDBProxyID, err := postgres.generateAWSDBProxy(awsProviderCfg, region, awsIAMRoleID,
+		awsSecurityGroupID, secretARN, awsIAMRolePolicyRes.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsDBProxyTargetGroupRes, awsDBProxyTargetGroupID, err := postgres.generateAWSDBProxyDefaultTargetGroup(awsProviderCfg,
+		region, awsDBProxyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsDBProxyTargetRes, err := postgres.generateAWSDBProxyTarget(awsProviderCfg, region, awsDBProxyID,
+		awsDBProxyTargetGroupID, targetAttr, targetIdentifier, dependsOn)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resources := []kusionapiv1.Resource{
+		*awsIAMRoleRes, *awsIAMRolePolicyRes, *awsDBProxyRes, *awsDBProxyTargetGroupRes, *awsDBProxyTargetRes,
+	}
+
+	return resources, module.KusionPathDependency(awsDBProxyID, "endpoint"), nil
+}
+
+// generateAWSDBProxyRole generates aws_iam_role resource assumed by AWS RDS Proxy.
+func (postgres *PostgreSQL) generateAWSDBProxyRole(awsProviderCfg module.ProviderConfig, region string) (
+	*kusionapiv1.Resource, string, error,
+) {
+	assumeRolePolicy, err := json.Marshal(awsIAMPolicyDocument{
+		Version: awsIAMPolicyVersion,
+		Statement: []awsIAMPolicyStatement{
+			{
+				Effect:    "Allow",
+				Principal: map[string]string{"Service": awsRDSServicePrincipal},
+				Action:    "sts:AssumeRole",
+			},
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":               postgres.DatabaseName + awsDBProxyRoleSuffix,
+		"assume_role_policy": string(assumeRolePolicy),
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsIAMRole, postgres.DatabaseName+awsDBProxyRoleSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsIAMRole, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSDBProxyRolePolicy generates aws_iam_role_policy resource allowing AWS RDS Proxy to read
+// the secret holding the credentials of the workload only, once the secret is created by the stack.
+// The ARN of the secret is not known until it is created, and Kusion can not resolve it inside the
+// marshalled policy, so the secret is matched by its name known at generate time instead, followed by
+// the random suffix appended by AWS Secrets Manager.
+func (postgres *PostgreSQL) generateAWSDBProxyRolePolicy(awsProviderCfg module.ProviderConfig, region, awsIAMRoleID,
+	secretName, awsCredentialsStackID string,
+) (*kusionapiv1.Resource, error) {
+	policy, err := json.Marshal(awsIAMPolicyDocument{
+		Version: awsIAMPolicyVersion,
+		Statement: []awsIAMPolicyStatement{
+			{
+				Effect:   "Allow",
+				Action:   awsSecretsManagerAction,
+				Resource: fmt.Sprintf("arn:%s:secretsmanager:%s:*:secret:%s-*", awsPartition(region), region, secretName),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":   postgres.DatabaseName + awsDBProxyPolicySuffix,
+		"role":   module.KusionPathDependency(awsIAMRoleID, "id"),
+		"policy": string(policy),
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsIAMRolePolicy, postgres.DatabaseName+awsDBProxyPolicySuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsIAMRolePolicy, id, resAttrs,
+		[]string{awsCredentialsStackID})
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// awsPartition returns the AWS partition of the region, which is "aws" for the standard regions.
+func awsPartition(region string) string {
+	for prefix, partition := range awsPartitionPrefixes {
+		if strings.HasPrefix(region, prefix) {
+			return partition
+		}
+	}
+
+	return "aws"
+}
+
+// generateAWSDBProxy generates aws_db_proxy resource authenticating the workload with the credentials
+// in the secret, which is created once the role is allowed to read the secret.
+func (postgres *PostgreSQL) generateAWSDBProxy(awsProviderCfg module.ProviderConfig, region, awsIAMRoleID, awsSecurityGroupID,
+	secretARN, awsIAMRolePolicyID string,
+) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"name":          postgres.DatabaseName + poolerSuffix,
+		"engine_family": awsDBProxyEngineFamily,
+		"role_arn":      module.KusionPathDependency(awsIAMRoleID, "arn"),
+		"require_tls":   postgres.TLS != nil,
+		"auth": []awsDBProxyAuth{
+			{
+				AuthScheme: awsDBProxyAuthScheme,
+				IAMAuth:    awsDBProxyIAMAuth,
+				SecretARN:  secretARN,
+			},
+		},
+		"vpc_subnet_ids": postgres.Pooler.SubnetIDs,
+		"vpc_security_group_ids": []string{
+			module.KusionPathDependency(awsSecurityGroupID, "id"),
+		},
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBProxy, postgres.DatabaseName+poolerSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBProxy, id, resAttrs,
+		[]string{awsIAMRolePolicyID})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSDBProxyDefaultTargetGroup generates aws_db_proxy_default_target_group resource with the
+// connection pool limit of AWS RDS Proxy if declared.
+func (postgres *PostgreSQL) generateAWSDBProxyDefaultTargetGroup(awsProviderCfg module.ProviderConfig, region,
+	awsDBProxyID string,
+) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"db_proxy_name": module.KusionPathDependency(awsDBProxyID, "name"),
+	}
+
+	if postgres.Pooler.MaxConnectionsPercent > 0 {
+		resAttrs["connection_pool_config"] = []awsDBProxyConnectionPoolConfig{
+			{
+				MaxConnectionsPercent: postgres.Pooler.MaxConnectionsPercent,
+			},
+		}
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBProxyDefaultTargetGroup, postgres.DatabaseName+poolerSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBProxyDefaultTargetGroup, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSDBProxyTarget generates aws_db_proxy_target resource registering the AWS provided PostgreSQL
+// instance or Aurora cluster into the default target group, once it is available.
+func (postgres *PostgreSQL) generateAWSDBProxyTarget(awsProviderCfg module.ProviderConfig, region, awsDBProxyID,
+	awsDBProxyTargetGroupID, targetAttr, targetIdentifier string, dependsOn []string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"db_proxy_name":     module.KusionPathDependency(awsDBProxyID, "name"),
+		"target_group_name": module.KusionPathDependency(awsDBProxyTargetGroupID, "name"),
+		targetAttr:          targetIdentifier,
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBProxyTarget, postgres.DatabaseName+poolerSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBProxyTarget, id, resAttrs, dependsOn)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidAuthConfig  = errors.New("invalid auth config in postgres module config")
+	ErrUnsupportedIAMAuth = errors.New("iam database authentication is only supported for the aws provided postgres instance")
+)
+
+const (
+	PasswordAuth = "password"
+	IAMAuth      = "iam"
+)
+
+var (
+	awsIAMRole       = "aws_iam_role"
+	awsIAMRolePolicy = "aws_iam_role_policy"
+	awsIAMDBRole     = "rds_iam"
+
+	awsIAMRoleSuffix       = "-iam-auth"
+	awsIAMPolicySuffix     = "-rds-connect"
+	awsIAMPolicyVersion    = "2012-10-17"
+	awsRoleARNAnnotation   = "eks.amazonaws.com/role-arn"
+	defaultServiceAccount  = "default"
+	dbRegionEnv            = "KUSION_DB_REGION"
+	awsOIDCProviderPrefix  = "oidc-provider/"
+	awsOIDCAudience        = "sts.amazonaws.com"
+	awsServiceAccountScope = "system:serviceaccount"
+)
+
+type awsIAMPolicyDocument struct {
+	Version   string                  `json:"Version"`
+	Statement []awsIAMPolicyStatement `json:"Statement"`
+}
+
+type awsIAMPolicyStatement struct {
+	Effect    string                       `json:"Effect"`
+	Principal map[string]string            `json:"Principal,omitempty"`
+	Action    string                       `json:"Action"`
+	Resource  string                       `json:"Resource,omitempty"`
+	Condition map[string]map[string]string `json:"Condition,omitempty"`
+}
+
+// awsOIDCProvider describes the IAM OIDC provider of the EKS cluster trusted by the IAM role.
+type awsOIDCProvider struct {
+	ARN       string
+	Partition string
+	AccountID string
+	Issuer    string
+}
+
+// isIAMAuth returns whether the workload connects to the PostgreSQL instance with the IAM database
+// authentication tokens instead of the password.
+func (postgres *PostgreSQL) isIAMAuth() bool {
+	return strings.EqualFold(postgres.Auth, IAMAuth)
+}
+
+// parseOIDCProviderARN parses the ARN of the IAM OIDC provider in the format of
+// "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE".
+func parseOIDCProviderARN(arn string) (*awsOIDCProvider, bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || parts[4] == "" ||
+		!strings.HasPrefix(parts[5], awsOIDCProviderPrefix) {
+		return nil, false
+	}
+
+	issuer := strings.TrimPrefix(parts[5], awsOIDCProviderPrefix)
+	if issuer == "" {
+		return nil, false
+	}
+
+	return &awsOIDCProvider{
+		ARN:       arn,
+		Partition: parts[1],
+		AccountID: parts[4],
+		Issuer:    issuer,
+	}, true
+}
+
+// validateAuth validates whether the IAM database authentication is declared for the cloud provided
+// PostgreSQL instance, with the application user to connect as and the OIDC provider to trust. The
+// credentials injected elsewhere other than the workload are not supported without the password.
+func (postgres *PostgreSQL) validateAuth() error {
+	if postgres.Auth == "" || strings.EqualFold(postgres.Auth, PasswordAuth) {
+		return nil
+	}
+
+	if !postgres.isIAMAuth() {
+		return fmt.Errorf("%w: unsupported auth %q, which should be %s or %s", ErrInvalidAuthConfig,
+			postgres.Auth, PasswordAuth, IAMAuth)
+	}
+
+	if !strings.EqualFold(postgres.Type, CloudDBType) {
+		return fmt.Errorf("%w: %s auth is only supported by the %s type", ErrInvalidAuthConfig, IAMAuth, CloudDBType)
+	}
+
+	if len(postgres.Users) == 0 {
+		return fmt.Errorf("%w: an application user should be declared to connect with %s auth", ErrInvalidAuthConfig, IAMAuth)
+	}
+
+	if _, ok := parseOIDCProviderARN(postgres.OIDCProviderARN); !ok {
+		return fmt.Errorf("%w: invalid oidc provider arn %q", ErrInvalidAuthConfig, postgres.OIDCProviderARN)
+	}
+
+	// The ServiceAccount is created and annotated with the IAM role by the module, so it should be
+	// dedicated to the workload instead of the default one shared by all the pods in the namespace.
+	if postgres.ServiceAccount == "" || postgres.ServiceAccount == defaultServiceAccount {
+		return fmt.Errorf("%w: a dedicated serviceAccount other than %q should be declared for %s auth",
+			ErrInvalidAuthConfig, defaultServiceAccount, IAMAuth)
+	}
+
+	if postgres.ConnectionURL != nil || postgres.Migration != nil {
+		return fmt.Errorf("%w: connectionURL and migration require the password, which is not supported by %s auth",
+			ErrInvalidAuthConfig, IAMAuth)
+	}
+
+	// The Patcher of the module can only patch the environment variables, labels and annotations of
+	// the workload, so the pods can not be bound to the annotated ServiceAccount and would never be
+	// injected with the web identity token to assume the IAM role.
+	return fmt.Errorf("%w: the workload can not be bound to the serviceAccount %q by the module, which is "+
+		"required by %s auth", ErrInvalidAuthConfig, postgres.ServiceAccount, IAMAuth)
+}
+
+// generateAWSIAMAuthResources generates the IAM role allowed to connect to the AWS provided PostgreSQL
+// instance as the application user, and the ServiceAccount annotated for the workload to assume the
+// role with IRSA. The policy of the role depends on the instance or the cluster of the given Kusion
+// resource ID. It returns the environment variable of the region for the workload to generate the
+// authentication tokens.
+func (postgres *PostgreSQL) generateAWSIAMAuthResources(request *module.GeneratorRequest, awsProviderCfg module.ProviderConfig,
+	region, username, awsDBResID string,
+) ([]kusionapiv1.Resource, []v1.EnvVar, error) {
+	oidcProvider, ok := parseOIDCProviderARN(postgres.OIDCProviderARN)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: invalid oidc provider arn %q", ErrInvalidAuthConfig, postgres.OIDCProviderARN)
+	}
+
+	awsIAMRoleRes, awsIAMRoleID, err := postgres.generateAWSIAMRole(awsProviderCfg, region, request.Project, oidcProvider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	awsIAMRolePolicyRes, err := postgres.generateAWSIAMRolePolicy(awsProviderCfg, region, username, awsIAMRoleID,
+		awsDBResID, oidcProvider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceAccountRes, err := postgres.generateIAMServiceAccount(request, awsIAMRoleID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envVars := []v1.EnvVar{
+		{
+			Name:  dbRegionEnv + "_" + postgres.generateEnvSuffix(),
+			Value: region,
+		},
+	}
+
+	return []kusionapiv1.Resource{*awsIAMRoleRes, *awsIAMRolePolicyRes, *serviceAccountRes}, envVars, nil
+}
+
+// generateAWSIAMRole generates aws_iam_role resource trusting the ServiceAccount of the workload
+// through the OIDC provider of the EKS cluster.
+func (postgres *PostgreSQL) generateAWSIAMRole(awsProviderCfg module.ProviderConfig, region, namespace string,
+	oidcProvider *awsOIDCProvider,
+) (*kusionapiv1.Resource, string, error) {
+	assumeRolePolicy, err := json.Marshal(awsIAMPolicyDocument{
+		Version: awsIAMPolicyVersion,
+		Statement: []awsIAMPolicyStatement{
+			{
+				Effect:    "Allow",
+				Principal: map[string]string{"Federated": oidcProvider.ARN},
+				Action:    "sts:AssumeRoleWithWebIdentity",
+				Condition: map[string]map[string]string{
+					"StringEquals": {
+						oidcProvider.Issuer + ":aud": awsOIDCAudience,
+						oidcProvider.Issuer + ":sub": fmt.Sprintf("%s:%s:%s", awsServiceAccountScope, namespace,
+							postgres.ServiceAccount),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":               postgres.DatabaseName + awsIAMRoleSuffix,
+		"assume_role_policy": string(assumeRolePolicy),
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsIAMRole, postgres.DatabaseName+awsIAMRoleSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsIAMRole, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSIAMRolePolicy generates aws_iam_role_policy resource granting rds-db:connect as the
+// application user of the AWS provided PostgreSQL instance. The resource ID of the instance is only known
+// after it is created and can not be resolved by Kusion inside the policy document, so the policy is
+// scoped by the account, the region and the username known at generate time instead, which also
+// covers the other instances with the same user in them.
+func (postgres *PostgreSQL) generateAWSIAMRolePolicy(awsProviderCfg module.ProviderConfig, region, username, awsIAMRoleID,
+	awsDBResID string, oidcProvider *awsOIDCProvider,
+) (*kusionapiv1.Resource, error) {
+	policy, err := json.Marshal(awsIAMPolicyDocument{
+		Version: awsIAMPolicyVersion,
+		Statement: []awsIAMPolicyStatement{
+			{
+				Effect: "Allow",
+				Action: "rds-db:connect",
+				Resource: fmt.Sprintf("arn:%s:rds-db:%s:%s:dbuser:*/%s", oidcProvider.Partition, region,
+					oidcProvider.AccountID, username),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":   postgres.DatabaseName + awsIAMPolicySuffix,
+		"role":   module.KusionPathDependency(awsIAMRoleID, "id"),
+		"policy": string(policy),
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsIAMRolePolicy, postgres.DatabaseName+awsIAMPolicySuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsIAMRolePolicy, id, resAttrs, []string{awsDBResID})
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateIAMServiceAccount generates the Kubernetes ServiceAccount annotated with the IAM role, whose
+// pods are injected with the web identity token by the EKS pod identity webhook.
+func (postgres *PostgreSQL) generateIAMServiceAccount(request *module.GeneratorRequest, awsIAMRoleID string) (*kusionapiv1.Resource, error) {
+	serviceAccount := &v1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ServiceAccount",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.ServiceAccount,
+			Namespace: request.Project,
+			Annotations: map[string]string{
+				awsRoleARNAnnotation: module.KusionPathDependency(awsIAMRoleID, "arn"),
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(serviceAccount.TypeMeta, serviceAccount.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
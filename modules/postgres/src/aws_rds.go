@@ -15,6 +15,13 @@ var (
 	awsRegionEnv     = "AWS_REGION"
 	awsSecurityGroup = "aws_security_group"
 	awsDBInstance    = "aws_db_instance"
+
+	awsDBParameterGroup     = "aws_db_parameter_group"
+	awsParameterGroupSuffix = "-parameter-group"
+	awsParameterApplyMethod = "pending-reboot"
+
+	awsFinalSnapshotSuffix     = "-final-snapshot"
+	awsFinalSnapshotByteLength = 4
 )
 
 var defaultAWSProviderCfg = module.ProviderConfig{
@@ -22,6 +29,18 @@ var defaultAWSProviderCfg = module.ProviderConfig{
 	Version: "5.0.1",
 }
 
+type awsDBParameter struct {
+	Name        string `yaml:"name" json:"name"`
+	Value       string `yaml:"value" json:"value"`
+	ApplyMethod string `yaml:"apply_method" json:"apply_method"`
+}
+
+type awsRestoreToPointInTime struct {
+	SourceDBInstanceIdentifier string `yaml:"source_db_instance_identifier" json:"source_db_instance_identifier"`
+	RestoreTime                string `yaml:"restore_time,omitempty" json:"restore_time,omitempty"`
+	UseLatestRestorableTime    bool   `yaml:"use_latest_restorable_time,omitempty" json:"use_latest_restorable_time,omitempty"`
+}
+
 type awsSecurityGroupTraffic struct {
 	CidrBlocks     []string `yaml:"cidr_blocks" json:"cidr_blocks"`
 	Description    string   `yaml:"description" json:"description"`
@@ -50,6 +69,17 @@ func (postgres *PostgreSQL) GenerateAWSResources(request *module.GeneratorReques
 		return nil, nil, ErrEmptyAWSProviderRegion
 	}
 
+	// The AWS snapshots are identified by themselves without the source instance.
+	if postgres.RestoreFrom != nil && postgres.RestoreFrom.Snapshot != "" && postgres.RestoreFrom.SourceInstance != "" {
+		return nil, nil, fmt.Errorf("%w: sourceInstance should not be specified with the snapshot for aws",
+			ErrInvalidRestoreFrom)
+	}
+
+	// Build the AWS Aurora PostgreSQL cluster instead of the single instance if declared.
+	if postgres.Aurora != nil {
+		return postgres.generateAWSAuroraResources(request, awsProviderCfg, region)
+	}
+
 	// Build random_password resource.
 	randomPasswordRes, randomPasswordID, err := postgres.GenerateTFRandomPassword(request)
 	if err != nil {
@@ -64,24 +94,103 @@ func (postgres *PostgreSQL) GenerateAWSResources(request *module.GeneratorReques
 	}
 	resources = append(resources, *awsSecurityGroupRes)
 
+	// Build aws_db_parameter_group resource with the engine parameters if declared, which reject the
+	// insecure connections if the TLS connections are enforced.
+	if len(postgres.generateAWSParameters()) > 0 {
+		awsDBParameterGroupRes, _, err := postgres.generateAWSDBParameterGroup(awsProviderCfg, region)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsDBParameterGroupRes)
+	}
+
+	// Build random_id resource as the identifier of the final snapshot unless it is skipped.
+	var finalSnapshotID string
+	if !postgres.SkipFinalSnapshot {
+		finalSnapshotRes, id, err := postgres.generateAWSFinalSnapshotID()
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *finalSnapshotRes)
+		finalSnapshotID = id
+	}
+
 	// Build aws_db_instance resource.
-	awsDBInstance, awsDBInstanceID, err := postgres.generateAWSDBInstance(awsProviderCfg, region, randomPasswordID, awsSecurityGroupID)
+	awsDBInstance, awsDBInstanceID, err := postgres.generateAWSDBInstance(awsProviderCfg, region, randomPasswordID,
+		awsSecurityGroupID, finalSnapshotID)
 	if err != nil {
 		return nil, nil, err
 	}
 	resources = append(resources, *awsDBInstance)
 
 	hostAddress := module.KusionPathDependency(awsDBInstanceID, "address")
-	password := module.KusionPathDependency(randomPasswordID, "result")
+
+	// Build aws_db_instance resources of the read replicas replicating from the source instance.
+	var readHostAddresses []string
+	for i := 0; i < postgres.Replicas; i++ {
+		awsDBReplica, awsDBReplicaID, err := postgres.generateAWSDBReplica(awsProviderCfg, region, awsDBInstanceID, awsSecurityGroupID, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsDBReplica)
+		readHostAddresses = append(readHostAddresses, module.KusionPathDependency(awsDBReplicaID, "address"))
+	}
+
+	// Build the logical databases, application users and grants inside the AWS provided PostgreSQL instance.
+	dbUserResources, username, password, err := postgres.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), []string{awsDBInstanceID})
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// The default logical database is only created by the Job along with the declared databases,
+	// extensions and application users, since declaring it on the instance forces the existing one to be replaced.
+	postgres.noDefaultDatabase = len(dbUserResources) == 0
+
+	// Write the credentials into AWS Secrets Manager for the ExternalSecret to sync them into the cluster,
+	// and for AWS RDS Proxy to authenticate the workload with.
+	if postgres.isExternalSecretDelivery() || postgres.Pooler != nil {
+		awsCredentialsStackRes, err := postgres.generateAWSCredentialsStack(request, awsProviderCfg, region, username, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *awsCredentialsStackRes)
+
+		// Build AWS RDS Proxy in front of the instance for the workload to connect to if declared.
+		if postgres.Pooler != nil {
+			poolerResources, poolerHostAddress, err := postgres.generateAWSDBProxyResources(awsProviderCfg, region,
+				postgres.generateRemoteSecretName(request), awsCredentialsStackRes.ID, awsSecurityGroupID, "db_instance_identifier",
+				module.KusionPathDependency(awsDBInstanceID, "identifier"), []string{awsDBInstanceID})
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, poolerResources...)
+			hostAddress = poolerHostAddress
+		}
+	}
 
 	// Build Kubernetes Secret with the hostAddress, username and password of the AWS provided PostgreSQL instance,
 	// and inject the credentials as the environment variable patcher.
-	dbSecret, patcher, err := postgres.GenerateDBSecret(request, hostAddress, postgres.Username, password)
+	dbSecret, patcher, err := postgres.generateDBSecret(request, hostAddress, username, password, readHostAddresses,
+		postgres.generateAWSRemoteSecretRef(request))
 	if err != nil {
 		return nil, nil, err
 	}
 	resources = append(resources, *dbSecret)
 
+	// Build the IAM role assumed by the workload with the annotated ServiceAccount for the IAM database
+	// authentication, and inject the region for the workload to generate the authentication tokens.
+	if postgres.isIAMAuth() {
+		iamResources, iamEnvVars, err := postgres.generateAWSIAMAuthResources(request, awsProviderCfg, region, username,
+			awsDBInstanceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, iamResources...)
+		patcher.Environments = append(patcher.Environments, iamEnvVars...)
+	}
+
 	return resources, patcher, nil
 }
 
@@ -108,12 +217,23 @@ func (postgres *PostgreSQL) generateAWSSecurityGroup(awsProviderCfg module.Provi
 			{
 				CidrBlocks: postgres.SecurityIPs,
 				Protocol:   "tcp",
-				FromPort:   5432,
-				ToPort:     5432,
+				FromPort:   dbPort,
+				ToPort:     dbPort,
 			},
 		},
 	}
 
+	// AWS RDS Proxy shares the security group with the instance, which connects to the instance within it.
+	if postgres.Pooler != nil {
+		resAttrs["ingress"] = append(resAttrs["ingress"].([]awsSecurityGroupTraffic), awsSecurityGroupTraffic{
+			Description: "AWS RDS Proxy",
+			Protocol:    "tcp",
+			FromPort:    dbPort,
+			ToPort:      dbPort,
+			Self:        true,
+		})
+	}
+
 	id, err := module.TerraformResourceID(awsProviderCfg, awsSecurityGroup, postgres.DatabaseName+dbResSuffix)
 	if err != nil {
 		return nil, "", err
@@ -128,27 +248,116 @@ func (postgres *PostgreSQL) generateAWSSecurityGroup(awsProviderCfg module.Provi
 	return resource, id, nil
 }
 
+// generateAWSFinalSnapshotID generates the terraform random_id resource as the identifier of the final
+// snapshot of the AWS provided PostgreSQL instance or Aurora cluster, which is suffixed with the random bytes
+// so that it does not collide with the final snapshot of the former instance with the same identifier.
+// The identifier is regenerated once the instance is replaced by the other identifier or restore source.
+func (postgres *PostgreSQL) generateAWSFinalSnapshotID() (*kusionapiv1.Resource, string, error) {
+	keepers := map[string]string{
+		"identifier": postgres.DatabaseName,
+	}
+	if postgres.RestoreFrom != nil {
+		keepers["restore_snapshot"] = postgres.RestoreFrom.Snapshot
+		keepers["restore_source_instance"] = postgres.RestoreFrom.SourceInstance
+	}
+
+	resAttrs := map[string]any{
+		"byte_length": awsFinalSnapshotByteLength,
+		"prefix":      postgres.DatabaseName + awsFinalSnapshotSuffix + "-",
+		"keepers":     keepers,
+	}
+
+	// Set the random_id provider with the default provider config.
+	randomIDProvider := defaultRandomProviderCfg
+
+	id, err := module.TerraformResourceID(randomIDProvider, randomID, postgres.DatabaseName+awsFinalSnapshotSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resource, err := module.WrapTFResourceToKusionResource(randomIDProvider, randomID, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
 // generateAWSDBInstance generates aws_db_instance resource for the AWS provided PostgreSQL database instance.
-func (postgres *PostgreSQL) generateAWSDBInstance(awsProviderCfg module.ProviderConfig, region, randomPasswordID, awsSecurityGroupID string) (*kusionapiv1.Resource, string, error) {
+func (postgres *PostgreSQL) generateAWSDBInstance(awsProviderCfg module.ProviderConfig, region, randomPasswordID, awsSecurityGroupID,
+	finalSnapshotID string,
+) (*kusionapiv1.Resource, string, error) {
 	resAttrs := map[string]interface{}{
-		"allocated_storage":   postgres.Size,
-		"engine":              dbEngine,
-		"engine_version":      postgres.Version,
-		"identifier":          postgres.DatabaseName,
-		"instance_class":      postgres.InstanceType,
-		"password":            module.KusionPathDependency(randomPasswordID, "result"),
-		"publicly_accessible": IsPublicAccessible(postgres.SecurityIPs),
-		"skip_final_snapshot": true,
-		"username":            postgres.Username,
+		"allocated_storage":          postgres.Size,
+		"auto_minor_version_upgrade": postgres.AutoMinorVersionUpgrade,
+		"backup_retention_period":    postgres.BackupRetentionPeriod,
+		"deletion_protection":        postgres.DeletionProtection,
+		"engine":                     dbEngine,
+		"engine_version":             postgres.Version,
+		"identifier":                 postgres.DatabaseName,
+		"instance_class":             postgres.InstanceType,
+		"multi_az":                   postgres.MultiAZ,
+		"password":                   module.KusionPathDependency(randomPasswordID, "result"),
+		"publicly_accessible":        IsPublicAccessible(postgres.SecurityIPs),
+		"skip_final_snapshot":        postgres.SkipFinalSnapshot,
+		"username":                   postgres.Username,
 		"vpc_security_group_ids": []string{
 			module.KusionPathDependency(awsSecurityGroupID, "id"),
 		},
 	}
 
+	// A final snapshot is created before the instance is deleted unless it is skipped explicitly.
+	if !postgres.SkipFinalSnapshot {
+		resAttrs["final_snapshot_identifier"] = module.KusionPathDependency(finalSnapshotID, "hex")
+	}
+
+	if postgres.BackupWindow != "" {
+		resAttrs["backup_window"] = postgres.BackupWindow
+	}
+
+	if postgres.MaintenanceWindow != "" {
+		resAttrs["maintenance_window"] = postgres.MaintenanceWindow
+	}
+
+	if postgres.StorageType != "" {
+		resAttrs["storage_type"] = postgres.StorageType
+	}
+
+	if postgres.Iops > 0 {
+		resAttrs["iops"] = postgres.Iops
+	}
+
 	if postgres.SubnetID != "" {
 		resAttrs["db_subnet_group_name"] = postgres.SubnetID
 	}
 
+	if postgres.isIAMAuth() {
+		resAttrs["iam_database_authentication_enabled"] = true
+	}
+
+	// The restored instance inherits the master username and the databases from the source, while
+	// the password is reset to the generated one.
+	if restoreFrom := postgres.RestoreFrom; restoreFrom != nil {
+		delete(resAttrs, "username")
+
+		if restoreFrom.Snapshot != "" {
+			resAttrs["snapshot_identifier"] = restoreFrom.Snapshot
+		} else {
+			restoreToPointInTime := awsRestoreToPointInTime{
+				SourceDBInstanceIdentifier: restoreFrom.SourceInstance,
+				UseLatestRestorableTime:    restoreFrom.UseLatestRestorableTime,
+			}
+			if restoreFrom.RestoreTime != "" {
+				restoreToPointInTime.RestoreTime = restoreFrom.generateRestoreTime()
+			}
+			resAttrs["restore_to_point_in_time"] = []awsRestoreToPointInTime{restoreToPointInTime}
+		}
+	}
+
+	if err := postgres.setAWSDBParameterGroupName(awsProviderCfg, resAttrs); err != nil {
+		return nil, "", err
+	}
+
 	id, err := module.TerraformResourceID(awsProviderCfg, awsDBInstance, postgres.DatabaseName)
 	if err != nil {
 		return nil, "", err
@@ -162,3 +371,90 @@ func (postgres *PostgreSQL) generateAWSDBInstance(awsProviderCfg module.Provider
 
 	return resource, id, nil
 }
+
+// generateAWSDBReplica generates aws_db_instance resource for the read replica of the AWS provided PostgreSQL
+// database instance, which inherits the engine, storage and credentials from the source instance.
+func (postgres *PostgreSQL) generateAWSDBReplica(awsProviderCfg module.ProviderConfig, region, awsDBInstanceID, awsSecurityGroupID string, index int) (*kusionapiv1.Resource, string, error) {
+	resAttrs := map[string]interface{}{
+		"auto_minor_version_upgrade": postgres.AutoMinorVersionUpgrade,
+		"deletion_protection":        postgres.DeletionProtection,
+		"identifier":                 postgres.generateReplicaName(index),
+		"instance_class":             postgres.InstanceType,
+		"publicly_accessible":        IsPublicAccessible(postgres.SecurityIPs),
+		"replicate_source_db":        module.KusionPathDependency(awsDBInstanceID, "identifier"),
+		"skip_final_snapshot":        true,
+		"vpc_security_group_ids": []string{
+			module.KusionPathDependency(awsSecurityGroupID, "id"),
+		},
+	}
+
+	if postgres.isIAMAuth() {
+		resAttrs["iam_database_authentication_enabled"] = true
+	}
+
+	if err := postgres.setAWSDBParameterGroupName(awsProviderCfg, resAttrs); err != nil {
+		return nil, "", err
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBInstance, postgres.generateReplicaName(index))
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBInstance, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAWSDBParameterGroup generates aws_db_parameter_group resource with the engine parameters
+// for the AWS provided PostgreSQL database instance. The parameters are applied after the reboot of the
+// instance, which is accepted by both the static and dynamic parameters.
+func (postgres *PostgreSQL) generateAWSDBParameterGroup(awsProviderCfg module.ProviderConfig, region string) (*kusionapiv1.Resource, string, error) {
+	var parameters []awsDBParameter
+	for _, parameter := range postgres.generateAWSParameters() {
+		parameters = append(parameters, awsDBParameter{
+			Name:        parameter.Name,
+			Value:       parameter.Value,
+			ApplyMethod: awsParameterApplyMethod,
+		})
+	}
+
+	resAttrs := map[string]interface{}{
+		"family":    postgres.generateParameterGroupFamily(dbEngine),
+		"name":      postgres.DatabaseName + awsParameterGroupSuffix,
+		"parameter": parameters,
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBParameterGroup, postgres.DatabaseName+awsParameterGroupSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsDBParameterGroup, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// setAWSDBParameterGroupName sets the name of the parameter group as the dependency of the AWS
+// provided PostgreSQL database instance if the engine parameters are declared.
+func (postgres *PostgreSQL) setAWSDBParameterGroupName(awsProviderCfg module.ProviderConfig, resAttrs map[string]interface{}) error {
+	if len(postgres.generateAWSParameters()) == 0 {
+		return nil
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsDBParameterGroup, postgres.DatabaseName+awsParameterGroupSuffix)
+	if err != nil {
+		return err
+	}
+	resAttrs["parameter_group_name"] = module.KusionPathDependency(id, "name")
+
+	return nil
+}
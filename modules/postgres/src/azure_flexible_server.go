@@ -0,0 +1,328 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrEmptyAzureProviderLocation       = errors.New("empty azure provider location")
+	ErrEmptyAzureResourceGroup          = errors.New("empty resource group for azure postgresql flexible server")
+	ErrEmptyAzureSubnetForPrivateAccess = errors.New("empty subnetID for azure postgresql flexible server with private routing")
+	ErrReservedAzureAdministratorLogin  = errors.New("reserved administrator login for azure postgresql flexible server")
+)
+
+var (
+	azureLocationEnv                      = "AZURE_REGION"
+	azurePostgreSQLFlexibleServer         = "azurerm_postgresql_flexible_server"
+	azurePostgreSQLFlexibleServerFirewall = "azurerm_postgresql_flexible_server_firewall_rule"
+	azurePostgreSQLFlexibleDatabase       = "azurerm_postgresql_flexible_server_database"
+	azureManagementLock                   = "azurerm_management_lock"
+
+	azureDeletionLockSuffix = "-deletion-lock"
+	azureDeletionLockLevel  = "CanNotDelete"
+)
+
+// azureReservedAdministratorLogins are the administrator logins not allowed by
+// the Azure Database flexible server.
+var azureReservedAdministratorLogins = []string{
+	"azure_superuser", "azure_pg_admin", "admin", "administrator", "root", "guest", "public",
+}
+
+// azurePostgreSQLStorageMB are the storage sizes in MB accepted by the Azure PostgreSQL
+// flexible server.
+var azurePostgreSQLStorageMB = []int{
+	32768, 65536, 131072, 262144, 524288, 1048576, 2097152, 4193280, 4194304, 8388608, 16777216, 33553408,
+}
+
+var defaultAzureProviderCfg = module.ProviderConfig{
+	Source:  "hashicorp/azurerm",
+	Version: "3.116.0",
+}
+
+// GenerateAzureResources generates the Azure provided PostgreSQL flexible server.
+func (postgres *PostgreSQL) GenerateAzureResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
+	var resources []kusionapiv1.Resource
+
+	// The read replicas of the Azure provided PostgreSQL instance are not supported yet.
+	if postgres.Replicas > 0 {
+		return nil, nil, ErrUnsupportedReplicas
+	}
+
+	// The engine parameters of the Azure provided PostgreSQL instance are not supported yet.
+	if len(postgres.Parameters) > 0 {
+		return nil, nil, ErrUnsupportedParameters
+	}
+
+	// The extensions of the Azure provided PostgreSQL instance are not supported yet, which should be
+	// allow-listed with the azure.extensions server parameter before being created.
+	if len(postgres.Extensions) > 0 {
+		return nil, nil, ErrUnsupportedExtensions
+	}
+
+	// Restoring the Azure provided PostgreSQL instance is not supported yet.
+	if postgres.RestoreFrom != nil {
+		return nil, nil, ErrUnsupportedRestoreFrom
+	}
+
+	// Enforcing the TLS connections to the Azure provided PostgreSQL instance is not supported yet.
+	if postgres.TLS != nil {
+		return nil, nil, ErrUnsupportedTLS
+	}
+
+	// The IAM database authentication is only supported by the AWS provided PostgreSQL instance.
+	if postgres.isIAMAuth() {
+		return nil, nil, ErrUnsupportedIAMAuth
+	}
+
+	// The pooler is only supported by the local and the AWS provided PostgreSQL instance yet.
+	if postgres.Pooler != nil {
+		return nil, nil, ErrUnsupportedPooler
+	}
+
+	// Delivering the password of the Azure provided PostgreSQL instance with the ExternalSecret is not supported yet.
+	if postgres.isExternalSecretDelivery() {
+		return nil, nil, ErrUnsupportedCredentialDelivery
+	}
+
+	// Set the Azure provider with the default provider config.
+	azureProviderCfg := defaultAzureProviderCfg
+
+	// Get the Azure location of the flexible server, which should not be empty.
+	var location string
+	if location = module.TerraformProviderRegion(azureProviderCfg); location == "" {
+		location = os.Getenv(azureLocationEnv)
+	}
+	if location == "" {
+		return nil, nil, ErrEmptyAzureProviderLocation
+	}
+
+	if postgres.ResourceGroup == "" {
+		return nil, nil, ErrEmptyAzureResourceGroup
+	}
+
+	// Build random_password resource.
+	randomPasswordRes, randomPasswordID, err := postgres.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build azurerm_postgresql_flexible_server resource.
+	azureFlexibleServerRes, azureFlexibleServerID, err := postgres.generateAzureFlexibleServer(
+		azureProviderCfg, location, randomPasswordID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *azureFlexibleServerRes)
+
+	// Build azurerm_postgresql_flexible_server_database resource.
+	azureFlexibleDatabaseRes, err := postgres.generateAzureFlexibleDatabase(azureProviderCfg, azureFlexibleServerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *azureFlexibleDatabaseRes)
+	dependsOn := []string{azureFlexibleServerID, azureFlexibleDatabaseRes.ID}
+
+	// Build azurerm_postgresql_flexible_server_firewall_rule resources for the flexible server
+	// with public access, the server in the delegated subnet is only accessible inside the
+	// virtual network.
+	if !postgres.PrivateRouting {
+		azureFirewallRules, err := postgres.generateAzureFirewallRules(azureProviderCfg, azureFlexibleServerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, azureFirewallRules...)
+		for _, rule := range azureFirewallRules {
+			dependsOn = append(dependsOn, rule.ID)
+		}
+	}
+
+	// Build azurerm_management_lock resource protecting the flexible server from being deleted if declared.
+	if postgres.DeletionProtection {
+		azureDeletionLockRes, err := postgres.generateAzureDeletionLock(azureProviderCfg, azureFlexibleServerID, dependsOn)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *azureDeletionLockRes)
+	}
+
+	hostAddress := module.KusionPathDependency(azureFlexibleServerID, "fqdn")
+
+	// Build the logical databases, application users and grants inside the Azure provided PostgreSQL instance.
+	dbUserResources, username, password, err := postgres.GenerateDBUsers(request, hostAddress,
+		module.KusionPathDependency(randomPasswordID, "result"), dependsOn)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, dbUserResources...)
+
+	// Build Kubernetes Secret with the hostAddress, username and password of the Azure provided PostgreSQL instance,
+	// and inject the credentials as the environment variable patcher.
+	dbSecret, patcher, err := postgres.GenerateDBSecret(request, hostAddress, username, password, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *dbSecret)
+
+	return resources, patcher, nil
+}
+
+// generateAzureFlexibleServer generates azurerm_postgresql_flexible_server resource
+// for the Azure provided PostgreSQL database instance.
+func (postgres *PostgreSQL) generateAzureFlexibleServer(azureProviderCfg module.ProviderConfig,
+	location, randomPasswordID string,
+) (*kusionapiv1.Resource, string, error) {
+	for _, login := range azureReservedAdministratorLogins {
+		if postgres.Username == login {
+			return nil, "", fmt.Errorf("%w: %s", ErrReservedAzureAdministratorLogin, postgres.Username)
+		}
+	}
+
+	// The delegated subnet is required for the workload to connect with the flexible
+	// server via the private network.
+	if postgres.PrivateRouting && postgres.SubnetID == "" {
+		return nil, "", ErrEmptyAzureSubnetForPrivateAccess
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":                   postgres.DatabaseName,
+		"resource_group_name":    postgres.ResourceGroup,
+		"location":               location,
+		"administrator_login":    postgres.Username,
+		"administrator_password": module.KusionPathDependency(randomPasswordID, "result"),
+		"sku_name":               postgres.InstanceType,
+		"version":                strings.Split(postgres.Version, ".")[0],
+		"storage_mb":             azurePostgreSQLStorageSize(postgres.Size),
+	}
+
+	if postgres.PrivateRouting {
+		resAttrs["delegated_subnet_id"] = postgres.SubnetID
+		if postgres.PrivateDNSZoneID != "" {
+			resAttrs["private_dns_zone_id"] = postgres.PrivateDNSZoneID
+		}
+	}
+
+	id, err := module.TerraformResourceID(azureProviderCfg, azurePostgreSQLFlexibleServer, postgres.DatabaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azurePostgreSQLFlexibleServer, id, resAttrs, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, id, nil
+}
+
+// generateAzureFlexibleDatabase generates azurerm_postgresql_flexible_server_database resource
+// for the Azure provided PostgreSQL database instance.
+func (postgres *PostgreSQL) generateAzureFlexibleDatabase(azureProviderCfg module.ProviderConfig,
+	flexibleServerID string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"name":      postgres.generateLogicalDBName(),
+		"server_id": module.KusionPathDependency(flexibleServerID, "id"),
+		"charset":   "UTF8",
+		"collation": "en_US.utf8",
+	}
+
+	id, err := module.TerraformResourceID(azureProviderCfg, azurePostgreSQLFlexibleDatabase, postgres.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azurePostgreSQLFlexibleDatabase, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateAzureDeletionLock generates azurerm_management_lock resource protecting the Azure provided
+// PostgreSQL flexible server and its child resources from being deleted, as the flexible server has no
+// deletion protection on its own. The lock depends on the child resources for it to be removed first
+// once the flexible server is destroyed by Kusion.
+func (postgres *PostgreSQL) generateAzureDeletionLock(azureProviderCfg module.ProviderConfig,
+	flexibleServerID string, dependsOn []string,
+) (*kusionapiv1.Resource, error) {
+	resAttrs := map[string]interface{}{
+		"name":       postgres.DatabaseName + azureDeletionLockSuffix,
+		"scope":      module.KusionPathDependency(flexibleServerID, "id"),
+		"lock_level": azureDeletionLockLevel,
+		"notes":      fmt.Sprintf("The postgres instance %s is protected from being deleted", postgres.DatabaseName),
+	}
+
+	id, err := module.TerraformResourceID(azureProviderCfg, azureManagementLock, postgres.DatabaseName+azureDeletionLockSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azureManagementLock, id, resAttrs,
+		append([]string(nil), dependsOn...))
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateAzureFirewallRules generates azurerm_postgresql_flexible_server_firewall_rule resources
+// for the Azure provided PostgreSQL database instance according to the securityIPs.
+func (postgres *PostgreSQL) generateAzureFirewallRules(azureProviderCfg module.ProviderConfig,
+	flexibleServerID string,
+) ([]kusionapiv1.Resource, error) {
+	var resources []kusionapiv1.Resource
+
+	azureProviderCfg.ProviderMeta = map[string]any{"features": map[string]any{}}
+	for i, ip := range postgres.SecurityIPs {
+		startIP, endIP, err := IPRange(ip)
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("%s-%d", postgres.DatabaseName, i)
+		resAttrs := map[string]interface{}{
+			"name":             name,
+			"server_id":        module.KusionPathDependency(flexibleServerID, "id"),
+			"start_ip_address": startIP,
+			"end_ip_address":   endIP,
+		}
+
+		id, err := module.TerraformResourceID(azureProviderCfg, azurePostgreSQLFlexibleServerFirewall, name)
+		if err != nil {
+			return nil, err
+		}
+
+		resource, err := module.WrapTFResourceToKusionResource(azureProviderCfg, azurePostgreSQLFlexibleServerFirewall, id, resAttrs, nil)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *resource)
+	}
+
+	return resources, nil
+}
+
+// azurePostgreSQLStorageSize returns the smallest storage size in MB accepted by the Azure
+// PostgreSQL flexible server that is not less than the specified size in GB.
+func azurePostgreSQLStorageSize(size int) int {
+	for _, storageMB := range azurePostgreSQLStorageMB {
+		if storageMB >= size*1024 {
+			return storageMB
+		}
+	}
+
+	return azurePostgreSQLStorageMB[len(azurePostgreSQLStorageMB)-1]
+}
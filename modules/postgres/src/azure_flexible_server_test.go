@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestPostgreSQLModule_GenerateAzureResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	t.Run("empty resource group", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:           "cloud",
+			Version:        "14.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			SecurityIPs:    defaultSecurityIPs,
+			PrivateRouting: false,
+			Size:           defaultSize,
+			InstanceType:   "B_Standard_B1ms",
+		}
+
+		mockey.PatchConvey("set azure region env", t, func() {
+			mockey.Mock(os.Getenv).Return("test-region").Build()
+
+			resources, patchers, err := postgres.GenerateAzureResources(r)
+
+			assert.Nil(t, resources)
+			assert.Nil(t, patchers)
+			assert.ErrorIs(t, err, ErrEmptyAzureResourceGroup)
+		})
+	})
+
+	t.Run("public access with firewall rules", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:           "cloud",
+			Version:        "14.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			SecurityIPs:    []string{"0.0.0.0/0", "172.16.0.1"},
+			PrivateRouting: false,
+			Size:           defaultSize,
+			InstanceType:   "B_Standard_B1ms",
+			ResourceGroup:  "test-resource-group",
+		}
+
+		mockey.PatchConvey("set azure region env", t, func() {
+			mockey.Mock(os.Getenv).Return("test-region").Build()
+
+			resources, patchers, err := postgres.GenerateAzureResources(r)
+
+			assert.Equal(t, 6, len(resources))
+			assert.NotNil(t, patchers)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestPostgreSQLModule_GenerateAzureFlexibleServer(t *testing.T) {
+	t.Run("reserved administrator login", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:          "cloud",
+			Version:       "14.0",
+			DatabaseName:  "test-database",
+			Username:      "azure_superuser",
+			InstanceType:  "B_Standard_B1ms",
+			ResourceGroup: "test-resource-group",
+		}
+
+		res, id, err := postgres.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.Nil(t, res)
+		assert.Equal(t, id, "")
+		assert.ErrorIs(t, err, ErrReservedAzureAdministratorLogin)
+	})
+
+	t.Run("private routing without delegated subnet", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:           "cloud",
+			Version:        "14.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			PrivateRouting: true,
+			InstanceType:   "B_Standard_B1ms",
+			ResourceGroup:  "test-resource-group",
+		}
+
+		res, id, err := postgres.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.Nil(t, res)
+		assert.Equal(t, id, "")
+		assert.ErrorIs(t, err, ErrEmptyAzureSubnetForPrivateAccess)
+	})
+
+	t.Run("private routing with delegated subnet", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:           "cloud",
+			Version:        "14.0",
+			DatabaseName:   "test-database",
+			Username:       "kusion_admin",
+			PrivateRouting: true,
+			Size:           defaultSize,
+			InstanceType:   "B_Standard_B1ms",
+			SubnetID:       "test-subnet-id",
+			ResourceGroup:  "test-resource-group",
+		}
+
+		res, id, err := postgres.generateAzureFlexibleServer(defaultAzureProviderCfg, "test-region", "random_password_id")
+
+		assert.NotNil(t, res)
+		assert.NotEqual(t, id, "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestPostgreSQLModule_GenerateAzureFirewallRules(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:          "cloud",
+		Version:       "14.0",
+		DatabaseName:  "test-database",
+		Username:      "kusion_admin",
+		SecurityIPs:   []string{"172.16.0.0/24", "illegal-ip"},
+		ResourceGroup: "test-resource-group",
+	}
+
+	res, err := postgres.generateAzureFirewallRules(defaultAzureProviderCfg, "flexible_server_id")
+
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, "illegal security ip format")
+}
+
+func TestAzurePostgreSQLStorageSize(t *testing.T) {
+	assert.Equal(t, 32768, azurePostgreSQLStorageSize(10))
+	assert.Equal(t, 65536, azurePostgreSQLStorageSize(50))
+	assert.Equal(t, 33553408, azurePostgreSQLStorageSize(100000))
+}
+
+func TestPostgreSQLModule_GenerateAzureDeletionLock(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:               "cloud",
+		Version:            "8.0",
+		DatabaseName:       "test-database",
+		ResourceGroup:      "test-resource-group",
+		DeletionProtection: true,
+	}
+
+	res, err := postgres.generateAzureDeletionLock(defaultAzureProviderCfg, "flexible_server_id",
+		[]string{"flexible_server_id", "flexible_database_id"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hashicorp:azurerm:azurerm_management_lock:test-database-deletion-lock", res.ID)
+	assert.Equal(t, module.KusionPathDependency("flexible_server_id", "id"), res.Attributes["scope"])
+	assert.Equal(t, "CanNotDelete", res.Attributes["lock_level"])
+	assert.Equal(t, []string{"flexible_server_id", "flexible_database_id"}, res.DependsOn)
+}
+
+func TestPostgreSQLModule_GenerateAzureFlexibleDatabase(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:          "cloud",
+		Version:       "8.0",
+		DatabaseName:  "test-database",
+		ResourceGroup: "test-resource-group",
+	}
+
+	res, err := postgres.generateAzureFlexibleDatabase(defaultAzureProviderCfg, "flexible_server_id")
+
+	assert.NotNil(t, res)
+	assert.Equal(t, "test_database", res.Attributes["name"])
+	assert.NoError(t, err)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestPostgreSQLModule_DecodeConfig(t *testing.T) {
+	t.Run("coerce yaml decoded values", func(t *testing.T) {
+		postgres := &PostgreSQL{}
+
+		err := postgres.decodeConfig(map[string]any{
+			"cloud":              "aws",
+			"size":               float64(20),
+			"securityIPs":        []any{"10.0.0.0/16"},
+			"privateRouting":     "false",
+			"passwordRotation":   float64(2024),
+			"deletionProtection": true,
+		}, platformConfigSource)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 20, postgres.Size)
+		assert.Equal(t, []string{"10.0.0.0/16"}, postgres.SecurityIPs)
+		assert.Equal(t, false, postgres.PrivateRouting)
+		assert.Equal(t, "2024", postgres.PasswordRotation)
+		assert.Equal(t, true, postgres.DeletionProtection)
+	})
+
+	t.Run("decode aurora cluster in platform config", func(t *testing.T) {
+		postgres := &PostgreSQL{}
+
+		err := postgres.decodeConfig(map[string]any{
+			"cloud": "aws",
+			"aurora": map[string]any{
+				"instances": float64(3),
+				"serverlessV2": map[string]any{
+					"minCapacity": "0.5",
+					"maxCapacity": 16,
+				},
+			},
+		}, platformConfigSource)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &AuroraCluster{
+			Instances:    3,
+			ServerlessV2: &AuroraServerlessV2{MinCapacity: 0.5, MaxCapacity: 16},
+		}, postgres.Aurora)
+	})
+
+	t.Run("decode dev config with reserved keys", func(t *testing.T) {
+		postgres := &PostgreSQL{}
+
+		err := postgres.decodeConfig(map[string]any{
+			"_type":     "postgres.PostgreSQL",
+			"type":      "local",
+			"version":   "14.0",
+			"databases": []any{"orders"},
+		}, devConfigSource)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "local", postgres.Type)
+		assert.Equal(t, "14.0", postgres.Version)
+		assert.Equal(t, []string{"orders"}, postgres.Databases)
+	})
+
+	warningTestcases := []struct {
+		name            string
+		config          map[string]any
+		source          string
+		expectedWarning string
+	}{
+		{
+			name:            "unknown key",
+			config:          map[string]any{"sise": 20},
+			source:          platformConfigSource,
+			expectedWarning: `unknown key "sise" in platformConfig is ignored`,
+		},
+		{
+			name:            "dev config key in platform config",
+			config:          map[string]any{"version": "14.0"},
+			source:          platformConfigSource,
+			expectedWarning: `key "version" should not be declared in platformConfig and is ignored`,
+		},
+		{
+			name:            "platform config key in dev config",
+			config:          map[string]any{"size": 20},
+			source:          devConfigSource,
+			expectedWarning: `key "size" should not be declared in devConfig and is ignored`,
+		},
+	}
+
+	for _, tc := range warningTestcases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := &PostgreSQL{}
+
+			err := postgres.decodeConfig(tc.config, tc.source)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{tc.expectedWarning}, postgres.warnings)
+			assert.Equal(t, &PostgreSQL{warnings: postgres.warnings}, postgres)
+		})
+	}
+
+	testcases := []struct {
+		name          string
+		config        map[string]any
+		source        string
+		expectedError string
+	}{
+		{
+			name:          "non-integral size",
+			config:        map[string]any{"size": 20.5},
+			source:        platformConfigSource,
+			expectedError: "size in platformConfig should be an integer but got float64 20.5",
+		},
+		{
+			name:          "invalid bool",
+			config:        map[string]any{"privateRouting": "maybe"},
+			source:        platformConfigSource,
+			expectedError: "privateRouting in platformConfig should be a bool but got string maybe",
+		},
+		{
+			name:          "invalid string list",
+			config:        map[string]any{"securityIPs": "0.0.0.0/0"},
+			source:        platformConfigSource,
+			expectedError: "securityIPs in platformConfig should be a list of strings but got string",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := &PostgreSQL{}
+
+			err := postgres.decodeConfig(tc.config, tc.source)
+
+			assert.ErrorIs(t, err, ErrInvalidModuleConfig)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestParseConfigBlock(t *testing.T) {
+	errInvalidBlock := errors.New("invalid block")
+
+	parse := func(configMap map[string]any) (*BackupS3, error) {
+		s3 := &BackupS3{}
+		err := parseConfigBlock(configMap, errInvalidBlock, "s3.", map[string]configValueParser{
+			"bucket": configValue(&s3.Bucket, assertConfigString),
+			"prefix": func(value any) error {
+				return errors.New("unexpected prefix")
+			},
+		})
+
+		return s3, err
+	}
+
+	t.Run("parse keys", func(t *testing.T) {
+		s3, err := parse(map[string]any{"bucket": "backups"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &BackupS3{Bucket: "backups"}, s3)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := parse(map[string]any{"bucket": "backups", "region": "us-east-1"})
+
+		assert.ErrorIs(t, err, errInvalidBlock)
+		assert.ErrorContains(t, err, "unknown key s3.region")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := parse(map[string]any{"bucket": 1})
+
+		assert.ErrorIs(t, err, errInvalidBlock)
+		assert.ErrorContains(t, err, "invalid value of s3.bucket")
+	})
+
+	t.Run("error of the parser", func(t *testing.T) {
+		_, err := parse(map[string]any{"prefix": "postgres"})
+
+		assert.NotErrorIs(t, err, errInvalidBlock)
+		assert.EqualError(t, err, "unexpected prefix")
+	})
+}
+
+func TestPostgreSQLModule_GetCompleteConfigWithYAMLValues(t *testing.T) {
+	postgres := &PostgreSQL{}
+
+	err := postgres.GetCompleteConfig(kusionapiv1.Accessory{
+		"type":    "cloud",
+		"version": "14.0",
+	}, kusionapiv1.GenericConfig{
+		"cloud":                 "aws",
+		"instanceType":          "db.t3.micro",
+		"size":                  float64(50),
+		"backupRetentionPeriod": float64(14),
+		"securityIPs":           []any{"10.0.0.0/16"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 50, postgres.Size)
+	assert.Equal(t, 14, postgres.BackupRetentionPeriod)
+	assert.Equal(t, []string{"10.0.0.0/16"}, postgres.SecurityIPs)
+	assert.Equal(t, defaultUsername, postgres.Username)
+	assert.Equal(t, defaultDeletionProtection, postgres.DeletionProtection)
+}
+
+func TestGetCloudProviderTypeWithInvalidValue(t *testing.T) {
+	_, err := GetCloudProviderType(kusionapiv1.GenericConfig{"cloud": []any{"aws"}})
+
+	assert.ErrorIs(t, err, ErrInvalidModuleConfig)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+var (
+	ErrInvalidConnectionURLConfig     = errors.New("invalid connectionURL config in postgres module config")
+	ErrUnsupportedConnectionURLFormat = errors.New("unsupported connection url format for postgres")
+)
+
+const (
+	URLFormat  = "url"
+	JDBCFormat = "jdbc"
+	DSNFormat  = "dsn"
+)
+
+var defaultConnectionURLEnvPrefix = "KUSION_DB_URL"
+
+// ConnectionURL describes the connection string of the PostgreSQL database injected into the workload
+// as an environment variable. The credentials are spliced into the connection string by Kubernetes
+// without being escaped, so it is only composed of the credentials generated by the module, which
+// are the usernames of the SQL identifiers and the passwords of the letters, digits and underscores.
+type ConnectionURL struct {
+	// The format of the connection string, which can be "url", "jdbc" or "dsn".
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// The name of the environment variable, which takes precedence over the envPrefix.
+	EnvName string `json:"envName,omitempty" yaml:"envName,omitempty"`
+	// The prefix of the environment variable name, which is suffixed with the database name.
+	EnvPrefix string `json:"envPrefix,omitempty" yaml:"envPrefix,omitempty"`
+	// The extra parameters appended to the connection string.
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// parseConnectionURL parses the connectionURL block of the platform config.
+func parseConnectionURL(config any) (*ConnectionURL, error) {
+	var configMap map[string]any
+	switch c := config.(type) {
+	case map[string]any:
+		configMap = c
+	case map[string]string:
+		configMap = make(map[string]any, len(c))
+		for k, v := range c {
+			configMap[k] = v
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidConnectionURLConfig, config)
+	}
+
+	connectionURL := &ConnectionURL{
+		Format:    URLFormat,
+		EnvPrefix: defaultConnectionURLEnvPrefix,
+	}
+
+	if err := parseConfigBlock(configMap, ErrInvalidConnectionURLConfig, "", map[string]configValueParser{
+		"format":    configValue(&connectionURL.Format, assertConfigString),
+		"envName":   configValue(&connectionURL.EnvName, assertConfigString),
+		"envPrefix": configValue(&connectionURL.EnvPrefix, assertConfigString),
+		"params": func(value any) (err error) {
+			connectionURL.Params, err = connectionURLParams(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return connectionURL, nil
+}
+
+// connectionURLParams returns the params of the connectionURL block, whose values are
+// converted into strings.
+func connectionURLParams(value any) (map[string]string, error) {
+	switch p := value.(type) {
+	case map[string]string:
+		return p, nil
+	case map[string]any:
+		params := make(map[string]string, len(p))
+		for k, v := range p {
+			params[k] = fmt.Sprint(v)
+		}
+
+		return params, nil
+	default:
+		return nil, fmt.Errorf("%w: params should be a map", ErrInvalidConnectionURLConfig)
+	}
+}
+
+// validateConnectionURL validates whether the format of the connection string is supported, and whether
+// the credentials of the workload are generated by the module to be spliced into the connection string
+// without escaping.
+func (postgres *PostgreSQL) validateConnectionURL() error {
+	if postgres.ConnectionURL == nil {
+		return nil
+	}
+
+	switch postgres.ConnectionURL.Format {
+	case URLFormat, JDBCFormat, DSNFormat:
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedConnectionURLFormat, postgres.ConnectionURL.Format)
+	}
+
+	if strings.EqualFold(postgres.Type, ExternalDBType) {
+		return fmt.Errorf("%w: the credentials of the external database can not be escaped in the connection string",
+			ErrInvalidConnectionURLConfig)
+	}
+
+	// The workload connects with the first application user if declared, whose name is a SQL identifier
+	// and whose password is generated.
+	if len(postgres.Users) > 0 {
+		return nil
+	}
+
+	if !sqlIdentifierRegexp.MatchString(postgres.Username) {
+		return fmt.Errorf("%w: username %q can not be escaped in the connection string", ErrInvalidConnectionURLConfig,
+			postgres.Username)
+	}
+
+	return nil
+}
+
+// generateConnectionURLEnv generates the environment variable of the connection string, which is
+// composed of the host address, username and password environment variables defined before it.
+func (postgres *PostgreSQL) generateConnectionURLEnv(hostAddressKey, usernameKey, passwordKey string) v1.EnvVar {
+	name := postgres.ConnectionURL.EnvName
+	if name == "" {
+		name = postgres.ConnectionURL.EnvPrefix + "_" + postgres.generateEnvSuffix()
+	}
+
+	return v1.EnvVar{
+		Name: name,
+		Value: postgres.generateConnectionURL(
+			"$("+hostAddressKey+")", "$("+usernameKey+")", "$("+passwordKey+")",
+		),
+	}
+}
+
+// generateConnectionURL generates the connection string of the PostgreSQL database in the specified format.
+func (postgres *PostgreSQL) generateConnectionURL(hostAddress, username, password string) string {
+	// The TLS parameters are overridden by the ones declared explicitly.
+	values := postgres.generateTLSParams(postgres.ConnectionURL.Format)
+	if values == nil {
+		values = make(map[string]string, len(postgres.ConnectionURL.Params))
+	}
+	for key, value := range postgres.ConnectionURL.Params {
+		values[key] = value
+	}
+
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		params = append(params, key+"="+values[key])
+	}
+
+	address := fmt.Sprintf("%s:%d", hostAddress, postgres.generatePort())
+	database := postgres.generateLogicalDBName()
+
+	switch postgres.ConnectionURL.Format {
+	case JDBCFormat:
+		params = append([]string{"user=" + username, "password=" + password}, params...)
+		return fmt.Sprintf("jdbc:postgresql://%s/%s?%s", address, database, strings.Join(params, "&"))
+	case DSNFormat:
+		// The keyword/value connection string of libpq.
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
+			hostAddress, postgres.generatePort(), username, password, database)
+		if len(params) > 0 {
+			dsn += " " + strings.Join(params, " ")
+		}
+		return dsn
+	default:
+		url := fmt.Sprintf("postgres://%s:%s@%s/%s", username, password, address, database)
+		if len(params) > 0 {
+			url += "?" + strings.Join(params, "&")
+		}
+		return url
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConnectionURL(t *testing.T) {
+	t.Run("default connection url config", func(t *testing.T) {
+		actual, err := parseConnectionURL(map[string]any{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ConnectionURL{
+			Format:    URLFormat,
+			EnvPrefix: defaultConnectionURLEnvPrefix,
+		}, actual)
+	})
+
+	t.Run("specified connection url config", func(t *testing.T) {
+		actual, err := parseConnectionURL(map[string]any{
+			"format":  "jdbc",
+			"envName": "DATABASE_URL",
+			"params": map[string]any{
+				"ssl":            true,
+				"connectTimeout": 10,
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ConnectionURL{
+			Format:    JDBCFormat,
+			EnvName:   "DATABASE_URL",
+			EnvPrefix: defaultConnectionURLEnvPrefix,
+			Params: map[string]string{
+				"ssl":            "true",
+				"connectTimeout": "10",
+			},
+		}, actual)
+	})
+
+	t.Run("invalid connection url config", func(t *testing.T) {
+		_, err := parseConnectionURL("jdbc")
+		assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+
+		_, err = parseConnectionURL(map[string]any{"format": 1})
+		assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+
+		_, err = parseConnectionURL(map[string]any{"unknown": "value"})
+		assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+	})
+}
+
+func TestPostgreSQLModule_ValidateConnectionURL(t *testing.T) {
+	postgres := &PostgreSQL{Username: defaultUsername}
+	assert.NoError(t, postgres.validateConnectionURL())
+
+	postgres.ConnectionURL = &ConnectionURL{Format: DSNFormat}
+	assert.NoError(t, postgres.validateConnectionURL())
+
+	postgres.ConnectionURL = &ConnectionURL{Format: "odbc"}
+	assert.ErrorIs(t, postgres.validateConnectionURL(), ErrUnsupportedConnectionURLFormat)
+
+	testcases := []struct {
+		name     string
+		postgres *PostgreSQL
+		success  bool
+	}{
+		{
+			name: "external database credentials",
+			postgres: &PostgreSQL{
+				Type:     ExternalDBType,
+				Username: defaultUsername,
+				External: &ExternalDatabase{Host: "test-host", Password: "secret://test-secret/password"},
+			},
+			success: false,
+		},
+		{
+			name: "username to be escaped",
+			postgres: &PostgreSQL{
+				Type:     CloudDBType,
+				Username: "app@example.com",
+			},
+			success: false,
+		},
+		{
+			name: "generated password of application user",
+			postgres: &PostgreSQL{
+				Type:               CloudDBType,
+				Username:           defaultUsername,
+				CredentialDelivery: &CredentialDelivery{Mode: ExternalSecretDelivery},
+				Databases:          []string{"orders"},
+				Users:              []DatabaseUser{{Name: "app"}},
+			},
+			success: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.postgres.ConnectionURL = &ConnectionURL{Format: URLFormat}
+
+			err := tc.postgres.validateConnectionURL()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidConnectionURLConfig)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateConnectionURLEnv(t *testing.T) {
+	testcases := []struct {
+		name          string
+		connectionURL *ConnectionURL
+		expectedName  string
+		expectedValue string
+	}{
+		{
+			name: "url format",
+			connectionURL: &ConnectionURL{
+				Format:    URLFormat,
+				EnvPrefix: defaultConnectionURLEnvPrefix,
+				Params:    map[string]string{"sslmode": "require"},
+			},
+			expectedName:  "KUSION_DB_URL_TEST_DATABASE",
+			expectedValue: "postgres://$(USER):$(PASS)@$(HOST):5432/test_database?sslmode=require",
+		},
+		{
+			name: "jdbc format",
+			connectionURL: &ConnectionURL{
+				Format:  JDBCFormat,
+				EnvName: "DATABASE_URL",
+				Params:  map[string]string{"ssl": "true", "connectTimeout": "10"},
+			},
+			expectedName:  "DATABASE_URL",
+			expectedValue: "jdbc:postgresql://$(HOST):5432/test_database?user=$(USER)&password=$(PASS)&connectTimeout=10&ssl=true",
+		},
+		{
+			name: "dsn format",
+			connectionURL: &ConnectionURL{
+				Format:    DSNFormat,
+				EnvPrefix: "PG_DSN",
+				Params:    map[string]string{"sslmode": "disable"},
+			},
+			expectedName:  "PG_DSN_TEST_DATABASE",
+			expectedValue: "host=$(HOST) port=5432 user=$(USER) password=$(PASS) dbname=test_database sslmode=disable",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := &PostgreSQL{
+				DatabaseName:  "test-database",
+				ConnectionURL: tc.connectionURL,
+			}
+
+			actual := postgres.generateConnectionURLEnv("HOST", "USER", "PASS")
+
+			assert.Equal(t, tc.expectedName, actual.Name)
+			assert.Equal(t, tc.expectedValue, actual.Value)
+		})
+	}
+}
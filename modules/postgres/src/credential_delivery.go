@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidCredentialDelivery     = errors.New("invalid credentialDelivery config in postgres module config")
+	ErrUnsupportedCredentialDelivery = errors.New("external secret delivery is not supported for the postgres instance of this cloud provider")
+)
+
+const (
+	SecretDelivery         = "secret"
+	ExternalSecretDelivery = "externalSecret"
+)
+
+const VaultBackend = "vault"
+
+var (
+	externalSecretAPIVersion = "external-secrets.io/v1beta1"
+	externalSecretKind       = "ExternalSecret"
+	externalSecretTemplate   = "{{ .password }}"
+
+	defaultSecretStoreKind = "ClusterSecretStore"
+	defaultRefreshInterval = "1h"
+
+	awsCloudFormationStack      = "aws_cloudformation_stack"
+	awsCloudFormationVersion    = "2010-09-09"
+	awsCredentialsStackSuffix   = "-db-credentials"
+	awsCredentialsSecretARNAttr = "outputs.SecretARN"
+	alicloudKMSSecret           = "alicloud_kms_secret"
+	defaultAlicloudKMSVersionID = "v1"
+)
+
+// CredentialDelivery describes how the generated password of the cloud provided PostgreSQL instance is
+// delivered to the workload. With the externalSecret mode, the password is written into the secret
+// manager of the cloud provider and synced into the Kubernetes Secret by the External Secrets Operator,
+// keeping it out of the Kusion spec.
+//
+// Vault is not supported as the secret manager. Its KV secrets only accept the JSON document, while
+// Kusion only resolves the generated password as a whole attribute value rather than inside the JSON.
+type CredentialDelivery struct {
+	// The delivery mode, which can be "secret" for the Kubernetes Secret or "externalSecret".
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// The secret manager of the externalSecret mode, which can only be empty for the one of the cloud
+	// provider, i.e. AWS Secrets Manager or Alicloud KMS secrets.
+	Backend string `json:"backend,omitempty" yaml:"backend,omitempty"`
+	// The name of the SecretStore of the External Secrets Operator reading the secret manager.
+	SecretStore string `json:"secretStore,omitempty" yaml:"secretStore,omitempty"`
+	// The kind of the SecretStore, which can be "SecretStore" or "ClusterSecretStore".
+	SecretStoreKind string `json:"secretStoreKind,omitempty" yaml:"secretStoreKind,omitempty"`
+	// The interval of syncing the password from the secret manager, such as "1h".
+	RefreshInterval string `json:"refreshInterval,omitempty" yaml:"refreshInterval,omitempty"`
+}
+
+// parseCredentialDelivery parses the credentialDelivery block of the platform config.
+func parseCredentialDelivery(config any) (*CredentialDelivery, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidCredentialDelivery, config)
+	}
+
+	delivery := &CredentialDelivery{
+		Mode:            SecretDelivery,
+		SecretStoreKind: defaultSecretStoreKind,
+		RefreshInterval: defaultRefreshInterval,
+	}
+	if err := parseConfigBlock(configMap, ErrInvalidCredentialDelivery, "", map[string]configValueParser{
+		"mode":            configValue(&delivery.Mode, toConfigString),
+		"backend":         configValue(&delivery.Backend, toConfigString),
+		"secretStore":     configValue(&delivery.SecretStore, toConfigString),
+		"secretStoreKind": configValue(&delivery.SecretStoreKind, toConfigString),
+		"refreshInterval": configValue(&delivery.RefreshInterval, toConfigString),
+	}); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// isExternalSecretDelivery returns whether the password is delivered with the ExternalSecret instead
+// of being stored in the Kubernetes Secret directly.
+func (postgres *PostgreSQL) isExternalSecretDelivery() bool {
+	return postgres.CredentialDelivery != nil && postgres.CredentialDelivery.Mode == ExternalSecretDelivery
+}
+
+// validateCredentialDelivery validates whether the external secret delivery is declared with the
+// SecretStore for the cloud provided PostgreSQL instance authenticated with the generated password.
+func (postgres *PostgreSQL) validateCredentialDelivery() error {
+	delivery := postgres.CredentialDelivery
+	if delivery == nil {
+		return nil
+	}
+
+	switch delivery.Mode {
+	case SecretDelivery:
+		return nil
+	case ExternalSecretDelivery:
+	default:
+		return fmt.Errorf("%w: unsupported mode %q, which should be %s or %s", ErrInvalidCredentialDelivery,
+			delivery.Mode, SecretDelivery, ExternalSecretDelivery)
+	}
+
+	if !strings.EqualFold(postgres.Type, CloudDBType) {
+		return fmt.Errorf("%w: %s mode is only supported by the %s type", ErrInvalidCredentialDelivery,
+			ExternalSecretDelivery, CloudDBType)
+	}
+
+	if postgres.isIAMAuth() {
+		return fmt.Errorf("%w: no password to deliver with %s auth", ErrInvalidCredentialDelivery, IAMAuth)
+	}
+
+	if delivery.SecretStore == "" {
+		return fmt.Errorf("%w: empty secretStore", ErrInvalidCredentialDelivery)
+	}
+
+	if delivery.Backend == VaultBackend {
+		return fmt.Errorf("%w: %s backend is not supported, whose kv secret can not be composed of the generated password",
+			ErrInvalidCredentialDelivery, VaultBackend)
+	}
+
+	if delivery.Backend != "" {
+		return fmt.Errorf("%w: unsupported backend %q, which should be empty for the secret manager of the cloud provider",
+			ErrInvalidCredentialDelivery, delivery.Backend)
+	}
+
+	if delivery.SecretStoreKind != "SecretStore" && delivery.SecretStoreKind != defaultSecretStoreKind {
+		return fmt.Errorf("%w: unsupported secretStoreKind %q", ErrInvalidCredentialDelivery, delivery.SecretStoreKind)
+	}
+
+	return nil
+}
+
+// remoteSecretRef refers to the password in the secret manager synced by the ExternalSecret.
+type remoteSecretRef struct {
+	// The key of the secret, which is the name, the path or the ARN of it.
+	Key string
+	// The property of the password in the structured secret, which is empty for the plain one.
+	Property string
+}
+
+// generateRemoteSecretName generates the name of the secret in the secret manager, which is scoped
+// by the project to avoid the conflicts of the instances with the same name.
+func (postgres *PostgreSQL) generateRemoteSecretName(request *module.GeneratorRequest) string {
+	return request.Project + "/" + postgres.DatabaseName + dbResSuffix
+}
+
+// generateRemoteSecretRef generates the reference to the password written into the secret manager by
+// the module, which is stored as the plain secret.
+func (postgres *PostgreSQL) generateRemoteSecretRef(request *module.GeneratorRequest) remoteSecretRef {
+	return remoteSecretRef{
+		Key: postgres.generateRemoteSecretName(request),
+	}
+}
+
+// generateAWSRemoteSecretRef generates the reference to the password in the credentials written into
+// AWS Secrets Manager, which are stored in the JSON format with the username and password.
+func (postgres *PostgreSQL) generateAWSRemoteSecretRef(request *module.GeneratorRequest) remoteSecretRef {
+	ref := postgres.generateRemoteSecretRef(request)
+	ref.Property = "password"
+
+	return ref
+}
+
+// generateAWSCredentialsStack generates aws_cloudformation_stack resource creating the secret in AWS
+// Secrets Manager, which holds the credentials of the AWS provided PostgreSQL instance in the JSON format
+// with the username and password read by both the ExternalSecret and AWS RDS Proxy. Kusion only
+// resolves the generated password as a whole attribute value, so it is passed as a parameter of the
+// stack and composed into the JSON by CloudFormation. The ARN of the secret is exported as the
+// SecretARN output of the stack.
+func (postgres *PostgreSQL) generateAWSCredentialsStack(request *module.GeneratorRequest, awsProviderCfg module.ProviderConfig,
+	region, username, password string,
+) (*kusionapiv1.Resource, error) {
+	template, err := json.Marshal(map[string]any{
+		"AWSTemplateFormatVersion": awsCloudFormationVersion,
+		"Parameters": map[string]any{
+			"Username": map[string]any{"Type": "String"},
+			"Password": map[string]any{"Type": "String", "NoEcho": true},
+		},
+		"Resources": map[string]any{
+			"Secret": map[string]any{
+				"Type": "AWS::SecretsManager::Secret",
+				"Properties": map[string]any{
+					"Name":        postgres.generateRemoteSecretName(request),
+					"Description": fmt.Sprintf("The credentials of the postgres instance %s", postgres.DatabaseName),
+					"SecretString": map[string]any{
+						"Fn::Sub": `{"username":"${Username}","password":"${Password}"}`,
+					},
+				},
+			},
+		},
+		"Outputs": map[string]any{
+			"SecretARN": map[string]any{
+				"Value": map[string]any{"Ref": "Secret"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resAttrs := map[string]interface{}{
+		"name":          postgres.DatabaseName + awsCredentialsStackSuffix,
+		"template_body": string(template),
+		"parameters": map[string]any{
+			"Username": username,
+			"Password": password,
+		},
+	}
+
+	id, err := module.TerraformResourceID(awsProviderCfg, awsCloudFormationStack, postgres.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	awsProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(awsProviderCfg, awsCloudFormationStack, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateAlicloudKMSSecret generates alicloud_kms_secret resource storing the password of the Alicloud
+// provided PostgreSQL instance, whose version changes along with the password rotation trigger.
+func (postgres *PostgreSQL) generateAlicloudKMSSecret(request *module.GeneratorRequest, alicloudProviderCfg module.ProviderConfig,
+	region, password string,
+) (*kusionapiv1.Resource, error) {
+	versionID := defaultAlicloudKMSVersionID
+	if postgres.PasswordRotation != "" {
+		versionID = postgres.PasswordRotation
+	}
+
+	resAttrs := map[string]interface{}{
+		"secret_name":                   postgres.generateRemoteSecretName(request),
+		"description":                   fmt.Sprintf("The password of the postgres instance %s", postgres.DatabaseName),
+		"secret_data":                   password,
+		"version_id":                    versionID,
+		"force_delete_without_recovery": !postgres.DeletionProtection,
+	}
+
+	id, err := module.TerraformResourceID(alicloudProviderCfg, alicloudKMSSecret, postgres.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	alicloudProviderCfg.ProviderMeta = map[string]any{"region": region}
+	resource, err := module.WrapTFResourceToKusionResource(alicloudProviderCfg, alicloudKMSSecret, id, resAttrs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExternalSecret generates the ExternalSecret resource syncing the password from the secret
+// manager into the Kubernetes Secret, which is rendered along with the other data so that the keys
+// referenced by the environment variables of the workload stay the same. The password rotation
+// trigger is annotated to sync the rotated password immediately.
+func (postgres *PostgreSQL) generateExternalSecret(request *module.GeneratorRequest, secretName string,
+	data map[string]string, ref remoteSecretRef,
+) (*kusionapiv1.Resource, error) {
+	remoteRef := map[string]any{
+		"key": ref.Key,
+	}
+	if ref.Property != "" {
+		remoteRef["property"] = ref.Property
+	}
+
+	templateData := make(map[string]any, len(data))
+	for key, value := range data {
+		templateData[key] = value
+	}
+	templateData["password"] = externalSecretTemplate
+
+	metadata := map[string]any{
+		"name":      secretName,
+		"namespace": request.Project,
+	}
+	if annotations := postgres.generatePasswordRotationAnnotations(); annotations != nil {
+		metadataAnnotations := make(map[string]any, len(annotations))
+		for key, value := range annotations {
+			metadataAnnotations[key] = value
+		}
+		metadata["annotations"] = metadataAnnotations
+	}
+
+	externalSecret := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": externalSecretAPIVersion,
+			"kind":       externalSecretKind,
+			"metadata":   metadata,
+			"spec": map[string]any{
+				"refreshInterval": postgres.CredentialDelivery.RefreshInterval,
+				"secretStoreRef": map[string]any{
+					"name": postgres.CredentialDelivery.SecretStore,
+					"kind": postgres.CredentialDelivery.SecretStoreKind,
+				},
+				"target": map[string]any{
+					"name":           secretName,
+					"creationPolicy": "Owner",
+					"template": map[string]any{
+						"engineVersion": "v2",
+						"data":          templateData,
+					},
+				},
+				"data": []any{
+					map[string]any{
+						"secretKey": "password",
+						"remoteRef": remoteRef,
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(metav1.TypeMeta{
+		Kind:       externalSecretKind,
+		APIVersion: externalSecretAPIVersion,
+	}, metav1.ObjectMeta{
+		Name:      secretName,
+		Namespace: request.Project,
+	})
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, externalSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
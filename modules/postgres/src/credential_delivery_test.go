@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseCredentialDelivery(t *testing.T) {
+	delivery, err := parseCredentialDelivery(map[string]any{
+		"mode":        "externalSecret",
+		"secretStore": "aws-secrets-manager",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &CredentialDelivery{
+		Mode:            "externalSecret",
+		SecretStore:     "aws-secrets-manager",
+		SecretStoreKind: "ClusterSecretStore",
+		RefreshInterval: "1h",
+	}, delivery)
+
+	delivery, err = parseCredentialDelivery(map[string]any{
+		"mode":        "externalSecret",
+		"backend":     "vault",
+		"secretStore": "vault",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "vault", delivery.Backend)
+
+	_, err = parseCredentialDelivery("externalSecret")
+	assert.ErrorIs(t, err, ErrInvalidCredentialDelivery)
+
+	_, err = parseCredentialDelivery(map[string]any{"store": "vault"})
+	assert.ErrorIs(t, err, ErrInvalidCredentialDelivery)
+}
+
+func TestPostgreSQLModule_ValidateCredentialDelivery(t *testing.T) {
+	testcases := []struct {
+		name     string
+		postgres *PostgreSQL
+		success  bool
+	}{
+		{
+			name:     "secret delivery by default",
+			postgres: &PostgreSQL{Type: "local"},
+			success:  true,
+		},
+		{
+			name: "valid external secret delivery",
+			postgres: &PostgreSQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "SecretStore",
+				},
+			},
+			success: true,
+		},
+		{
+			name: "unsupported mode",
+			postgres: &PostgreSQL{
+				Type:               "cloud",
+				CredentialDelivery: &CredentialDelivery{Mode: "vault"},
+			},
+			success: false,
+		},
+		{
+			name: "external secret delivery for local database",
+			postgres: &PostgreSQL{
+				Type: "local",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "external secret delivery with iam auth",
+			postgres: &PostgreSQL{
+				Type: "cloud",
+				Auth: "iam",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "empty secret store",
+			postgres: &PostgreSQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "vault backend",
+			postgres: &PostgreSQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					Backend:         "vault",
+					SecretStore:     "vault",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported backend",
+			postgres: &PostgreSQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					Backend:         "gcpSecretManager",
+					SecretStore:     "gcp-secret-manager",
+					SecretStoreKind: "ClusterSecretStore",
+				},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported secret store kind",
+			postgres: &PostgreSQL{
+				Type: "cloud",
+				CredentialDelivery: &CredentialDelivery{
+					Mode:            "externalSecret",
+					SecretStore:     "aws-secrets-manager",
+					SecretStoreKind: "Vault",
+				},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.postgres.validateCredentialDelivery()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidCredentialDelivery)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateAWSCredentialsStack(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+	}
+
+	password := module.KusionPathDependency("random_password_id", "result")
+	res, err := postgres.generateAWSCredentialsStack(r, defaultAWSProviderCfg, "test-region", defaultUsername, password)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hashicorp:aws:aws_cloudformation_stack:test-database", res.ID)
+	assert.Equal(t, "test-database-db-credentials", res.Attributes["name"])
+	// The password is passed as a whole parameter value for Kusion to resolve.
+	assert.Equal(t, map[string]any{"Username": "kusion_default", "Password": password}, res.Attributes["parameters"])
+	assertResolvableKusionPaths(t, []kusionapiv1.Resource{*res})
+
+	var template map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(res.Attributes["template_body"].(string)), &template))
+	secret := template["Resources"].(map[string]any)["Secret"].(map[string]any)
+	assert.Equal(t, "AWS::SecretsManager::Secret", secret["Type"])
+	assert.Equal(t, "test-project/test-database-postgres", secret["Properties"].(map[string]any)["Name"])
+	assert.Equal(t, map[string]any{"Value": map[string]any{"Ref": "Secret"}},
+		template["Outputs"].(map[string]any)["SecretARN"])
+	assert.Equal(t, remoteSecretRef{Key: "test-project/test-database-postgres", Property: "password"},
+		postgres.generateAWSRemoteSecretRef(r))
+}
+
+func TestPostgreSQLModule_GenerateAlicloudKMSSecret(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	postgres := &PostgreSQL{
+		DatabaseName:     "test-database",
+		PasswordRotation: "2024-06-01",
+	}
+
+	res, err := postgres.generateAlicloudKMSSecret(r, defaultAlicloudProviderCfg, "test-region",
+		module.KusionPathDependency("random_password_id", "result"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-project/test-database-postgres", res.Attributes["secret_name"])
+	assert.Equal(t, module.KusionPathDependency("random_password_id", "result"), res.Attributes["secret_data"])
+	assert.Equal(t, "2024-06-01", res.Attributes["version_id"])
+	assert.Equal(t, true, res.Attributes["force_delete_without_recovery"])
+}
+
+func TestPostgreSQLModule_GenerateDBSecretWithExternalSecret(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "cloud",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+	}
+
+	_, expectedPatcher, err := postgres.GenerateDBSecret(r, "test-host-address", "test-username", "test-password", nil)
+	assert.NoError(t, err)
+
+	postgres.CredentialDelivery = &CredentialDelivery{
+		Mode:            "externalSecret",
+		SecretStore:     "aws-secrets-manager",
+		SecretStoreKind: "ClusterSecretStore",
+		RefreshInterval: "1h",
+	}
+
+	res, patcher, err := postgres.GenerateDBSecret(r, "test-host-address", "test-username", "test-password", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "external-secrets.io/v1beta1:ExternalSecret:test-project:test-database-postgres", res.ID)
+	assert.Equal(t, expectedPatcher, patcher)
+
+	spec := res.Attributes["spec"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"name": "aws-secrets-manager",
+		"kind": "ClusterSecretStore",
+	}, spec["secretStoreRef"])
+	assert.Equal(t, []any{
+		map[string]any{
+			"secretKey": "password",
+			"remoteRef": map[string]any{"key": "test-project/test-database-postgres"},
+		},
+	}, spec["data"])
+
+	template := spec["target"].(map[string]any)["template"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"hostAddress": "test-host-address",
+		"port":        "5432",
+		"database":    "test_database",
+		"username":    "test-username",
+		"password":    "{{ .password }}",
+	}, template["data"])
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidDatabaseName     = errors.New("invalid logical database name in postgres module config")
+	ErrInvalidDatabaseUser     = errors.New("invalid database user in postgres module config")
+	ErrInvalidGrantPrivilege   = errors.New("invalid grant privilege in postgres module config")
+	ErrUndeclaredGrantDatabase = errors.New("grant on undeclared logical database in postgres module config")
+)
+
+var (
+	dbUsersJobSuffix         = "-db-users"
+	dbUsersSecretSuffix      = "-db-users-secret"
+	dbUsersContainerName     = "db-users"
+	dbUsersImage             = "postgres:16"
+	dbUsersMaintenanceDB     = "postgres"
+	dbUsersBackoffLimit      = int32(6)
+	userPasswordEnv          = "KUSION_USER_PASSWORD"
+	defaultSchema            = "public"
+	allPrivileges            = "ALL"
+	allPrivilegesFull        = "ALL PRIVILEGES"
+	connectPrivilege         = "CONNECT"
+	usagePrivilege           = "USAGE"
+	createPrivilege          = "CREATE"
+	databasePrivileges       = []string{"CONNECT", "CREATE", "TEMPORARY"}
+	tablePrivileges          = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+	databasePrivilegeAliases = map[string]string{"TEMP": "TEMPORARY"}
+)
+
+var (
+	sqlIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	sqlPrivilegeRegexp  = regexp.MustCompile(`^[A-Z]+( [A-Z]+)*$`)
+)
+
+// DatabaseUser describes an application user with the least privileges created in the
+// PostgreSQL instance.
+type DatabaseUser struct {
+	// The name of the application user.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The privileges granted to the application user on the logical databases.
+	Grants []DatabaseGrant `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// DatabaseGrant describes the privileges granted on a logical database.
+type DatabaseGrant struct {
+	// The name of the logical database.
+	Database string `json:"database,omitempty" yaml:"database,omitempty"`
+	// The privileges granted on the logical database, such as CONNECT and TEMPORARY, and on all the
+	// tables in its public schema, such as SELECT and INSERT.
+	Privileges []string `json:"privileges,omitempty" yaml:"privileges,omitempty"`
+}
+
+// parseDatabases parses the logical databases declared in the devConfig.
+func parseDatabases(config any) ([]string, error) {
+	databases, ok := toConfigStringSlice(config)
+	if !ok {
+		return nil, fmt.Errorf("%w: databases should be a list of strings", ErrInvalidDatabaseName)
+	}
+
+	return databases, nil
+}
+
+// parseDatabaseUsers parses the application users declared in the devConfig.
+func parseDatabaseUsers(config any) ([]DatabaseUser, error) {
+	items, ok := config.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: users should be a list", ErrInvalidDatabaseUser)
+	}
+
+	users := make([]DatabaseUser, 0, len(items))
+	for _, item := range items {
+		userMap, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: user should be a map", ErrInvalidDatabaseUser)
+		}
+
+		var user DatabaseUser
+		if user.Name, ok = userMap["name"].(string); !ok {
+			return nil, fmt.Errorf("%w: user name should be a string", ErrInvalidDatabaseUser)
+		}
+
+		grants, _ := userMap["grants"].([]any)
+		for _, g := range grants {
+			grantMap, ok := g.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: grant of user %s should be a map", ErrInvalidDatabaseUser, user.Name)
+			}
+
+			var grant DatabaseGrant
+			if grant.Database, ok = grantMap["database"].(string); !ok {
+				return nil, fmt.Errorf("%w: grant database of user %s should be a string", ErrInvalidDatabaseUser, user.Name)
+			}
+
+			privileges, ok := toConfigStringSlice(grantMap["privileges"])
+			if !ok {
+				return nil, fmt.Errorf("%w: grant privileges of user %s should be a list of strings",
+					ErrInvalidDatabaseUser, user.Name)
+			}
+			for _, privilege := range privileges {
+				grant.Privileges = append(grant.Privileges, strings.ToUpper(strings.TrimSpace(privilege)))
+			}
+
+			user.Grants = append(user.Grants, grant)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// validateDatabaseUsers validates whether the logical databases, application users and grants
+// are valid, which are restricted to plain SQL identifiers to be safely used in the statements.
+func (postgres *PostgreSQL) validateDatabaseUsers() error {
+	databases := make(map[string]bool)
+	for _, database := range postgres.Databases {
+		if !sqlIdentifierRegexp.MatchString(database) {
+			return fmt.Errorf("%w: %s", ErrInvalidDatabaseName, database)
+		}
+		databases[database] = true
+	}
+	databases[postgres.generateLogicalDBName()] = true
+
+	users := make(map[string]bool)
+	for _, user := range postgres.Users {
+		if !sqlIdentifierRegexp.MatchString(user.Name) || user.Name == postgres.Username || users[user.Name] {
+			return fmt.Errorf("%w: %s", ErrInvalidDatabaseUser, user.Name)
+		}
+		users[user.Name] = true
+
+		grants := make(map[string]bool)
+		for _, grant := range user.Grants {
+			if !databases[grant.Database] {
+				return fmt.Errorf("%w: %s", ErrUndeclaredGrantDatabase, grant.Database)
+			}
+			if grants[grant.Database] {
+				return fmt.Errorf("%w: duplicate grants of user %s on %s", ErrInvalidDatabaseUser, user.Name, grant.Database)
+			}
+			grants[grant.Database] = true
+
+			if len(grant.Privileges) == 0 {
+				return fmt.Errorf("%w: empty privileges of user %s on %s", ErrInvalidGrantPrivilege, user.Name, grant.Database)
+			}
+			for _, privilege := range grant.Privileges {
+				if !sqlPrivilegeRegexp.MatchString(privilege) || !isPostgreSQLPrivilege(privilege) {
+					return fmt.Errorf("%w: %s", ErrInvalidGrantPrivilege, privilege)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// generateLogicalDBNames generates the names of all the logical databases in the PostgreSQL instance.
+func (postgres *PostgreSQL) generateLogicalDBNames() []string {
+	if len(postgres.Databases) > 0 {
+		return postgres.Databases
+	}
+
+	return []string{postgres.generateLogicalDBName()}
+}
+
+// generateUserRandomPasswords generates the random_password resources of the application users,
+// and returns the IDs of them in the order of the users.
+func (postgres *PostgreSQL) generateUserRandomPasswords() ([]kusionapiv1.Resource, []string, error) {
+	var resources []kusionapiv1.Resource
+	var ids []string
+	for _, user := range postgres.Users {
+		resource, id, err := postgres.generateTFRandomPassword(postgres.DatabaseName + dbResSuffix + "-" + user.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *resource)
+		ids = append(ids, id)
+	}
+
+	return resources, ids, nil
+}
+
+// isPostgreSQLPrivilege returns whether the privilege can be granted on the logical database or
+// the tables in it.
+func isPostgreSQLPrivilege(privilege string) bool {
+	if privilege == allPrivileges || privilege == allPrivilegesFull {
+		return true
+	}
+	if _, ok := databasePrivilegeAliases[privilege]; ok {
+		return true
+	}
+
+	return slices.Contains(databasePrivileges, privilege) || slices.Contains(tablePrivileges, privilege)
+}
+
+// splitPrivileges splits the privileges of the grant into the ones on the logical database, on the
+// public schema and on the tables in it. CONNECT and USAGE are always granted for the application
+// user to access the tables, and ALL is expanded into the concrete privileges.
+func splitPrivileges(grant DatabaseGrant) ([]string, []string, []string) {
+	dbPrivileges := []string{connectPrivilege}
+	schemaPrivileges := []string{usagePrivilege}
+	var tblPrivileges []string
+
+	for _, privilege := range grant.Privileges {
+		if alias, ok := databasePrivilegeAliases[privilege]; ok {
+			privilege = alias
+		}
+
+		switch {
+		case privilege == allPrivileges || privilege == allPrivilegesFull:
+			dbPrivileges = append([]string{}, databasePrivileges...)
+			schemaPrivileges = []string{usagePrivilege, createPrivilege}
+			tblPrivileges = append([]string{}, tablePrivileges...)
+
+			return dbPrivileges, schemaPrivileges, tblPrivileges
+		case slices.Contains(databasePrivileges, privilege):
+			if !slices.Contains(dbPrivileges, privilege) {
+				dbPrivileges = append(dbPrivileges, privilege)
+			}
+		case !slices.Contains(tblPrivileges, privilege):
+			tblPrivileges = append(tblPrivileges, privilege)
+		}
+	}
+
+	return dbPrivileges, schemaPrivileges, tblPrivileges
+}
+
+// GenerateDBUsers generates the logical databases, extensions, application users and grants inside the
+// cloud provided PostgreSQL instance. They are applied by a Kubernetes Job connecting via the administrator
+// account from the cluster, which reaches the instance with private routing as the workload does. It returns
+// the credentials for the workload, which belong to the first application user if declared.
+func (postgres *PostgreSQL) GenerateDBUsers(request *module.GeneratorRequest, hostAddress, password string,
+	dependsOn []string,
+) ([]kusionapiv1.Resource, string, string, error) {
+	var resources []kusionapiv1.Resource
+	username := postgres.Username
+
+	if len(postgres.Databases) == 0 && len(postgres.Users) == 0 && len(postgres.Extensions) == 0 {
+		return nil, username, password, nil
+	}
+
+	// Build random_password resources for the application users, which are authenticated with the
+	// IAM authentication tokens instead for the IAM database authentication.
+	var userPasswords []string
+	if !postgres.isIAMAuth() {
+		passwordResources, ids, err := postgres.generateUserRandomPasswords()
+		if err != nil {
+			return nil, "", "", err
+		}
+		resources = append(resources, passwordResources...)
+
+		for _, id := range ids {
+			userPasswords = append(userPasswords, module.KusionPathDependency(id, "result"))
+		}
+	}
+
+	// Build Kubernetes Secret with the passwords of the administrator and the application users for the Job.
+	secretName := postgres.DatabaseName + dbUsersSecretSuffix
+	secret, err := postgres.generateCredentialsSecret(request, secretName, password, userPasswords)
+	if err != nil {
+		return nil, "", "", err
+	}
+	resources = append(resources, *secret)
+
+	// Build Kubernetes Job applying the statements after the instance and the Secret are created.
+	job, err := postgres.generateDBUsersJob(request, hostAddress, secretName, append(slices.Clone(dependsOn), secret.ID))
+	if err != nil {
+		return nil, "", "", err
+	}
+	resources = append(resources, *job)
+
+	if len(postgres.Users) > 0 {
+		username, password = postgres.Users[0].Name, ""
+		if len(userPasswords) > 0 {
+			password = userPasswords[0]
+		}
+	}
+
+	return resources, username, password, nil
+}
+
+// generateDBUsersJob generates the Kubernetes Job resource running the statements of the logical databases,
+// extensions, application users and grants with psql, with the passwords read from the Secret. It connects
+// to the maintenance database, which exists in the instances of all the cloud providers. The Job name is
+// suffixed with the hash of the statements and the password rotation trigger, so that a new Job is created
+// to apply them once they change instead of updating the immutable pod template.
+func (postgres *PostgreSQL) generateDBUsersJob(request *module.GeneratorRequest, hostAddress, secretName string,
+	dependsOn []string,
+) (*kusionapiv1.Resource, error) {
+	env := []v1.EnvVar{
+		{
+			Name:  dbHostAddressEnv,
+			Value: hostAddress,
+		},
+		{
+			Name:  "PGSSLMODE",
+			Value: "require",
+		},
+		{
+			Name: "PGPASSWORD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secretName,
+					},
+					Key: "password",
+				},
+			},
+		},
+	}
+	if !postgres.isIAMAuth() {
+		for i, user := range postgres.Users {
+			env = append(env, v1.EnvVar{
+				Name: generateUserPasswordEnv(i),
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: secretName,
+						},
+						Key: generateUserPasswordKey(user),
+					},
+				},
+			})
+		}
+	}
+
+	statements := append(postgres.generateUserStatements(), postgres.generateDatabaseExtensionStatements()...)
+	script := fmt.Sprintf("psql -v ON_ERROR_STOP=1 -h \"$%s\" -p %d -U %s -d %s <<EOSQL\n%s\nEOSQL",
+		dbHostAddressEnv, dbPort, postgres.Username, dbUsersMaintenanceDB, strings.Join(statements, "\n"))
+
+	sum := sha256.Sum256([]byte(dbUsersImage + "\n" + script + "\n" + postgres.PasswordRotation))
+	specHash := hex.EncodeToString(sum[:])[:migrationSpecHashLength]
+
+	backoffLimit := dbUsersBackoffLimit
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + dbUsersJobSuffix + "-" + specHash,
+			Namespace: request.Project,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: postgres.generateClientMatchLabels(),
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:    dbUsersContainerName,
+							Image:   dbUsersImage,
+							Command: []string{"sh", "-c", script},
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(job.TypeMeta, job.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, job)
+	if err != nil {
+		return nil, err
+	}
+	resource.DependsOn = dependsOn
+
+	return resource, nil
+}
+
+// generateUserPasswordKey generates the key of the application user password in the Kubernetes
+// Secret of the local PostgreSQL instance.
+func generateUserPasswordKey(user DatabaseUser) string {
+	return user.Name + "-password"
+}
+
+// generateUserPasswordEnv generates the name of the environment variable holding the password
+// of the application user in the local PostgreSQL instance.
+func generateUserPasswordEnv(index int) string {
+	return userPasswordEnv + "_" + strconv.Itoa(index)
+}
+
+// generateUserStatements generates the idempotent SQL statements creating the logical databases,
+// application users and grants in the PostgreSQL instance, which are run by psql in a shell heredoc
+// with the passwords expanded from the environment variables. The application users of the IAM
+// database authentication are granted the rds_iam role instead of the passwords. The logical databases
+// are also created for the extensions only, which are created in them.
+func (postgres *PostgreSQL) generateUserStatements() []string {
+	if len(postgres.Databases) == 0 && len(postgres.Users) == 0 && len(postgres.Extensions) == 0 {
+		return nil
+	}
+
+	var statements []string
+
+	for _, database := range postgres.generateLogicalDBNames() {
+		statements = append(statements, fmt.Sprintf(
+			`SELECT 'CREATE DATABASE "%s"' WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = '%s')\gexec`,
+			database, database))
+	}
+
+	for i, user := range postgres.Users {
+		statements = append(statements,
+			fmt.Sprintf(`DO \$\$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = '%s') THEN CREATE ROLE "%s"; END IF; END \$\$;`,
+				user.Name, user.Name),
+		)
+		if postgres.isIAMAuth() {
+			statements = append(statements,
+				fmt.Sprintf(`ALTER ROLE "%s" WITH LOGIN;`, user.Name),
+				fmt.Sprintf(`GRANT %s TO "%s";`, awsIAMDBRole, user.Name),
+			)
+		} else {
+			statements = append(statements,
+				fmt.Sprintf(`ALTER ROLE "%s" WITH LOGIN PASSWORD '${%s}';`, user.Name, generateUserPasswordEnv(i)))
+		}
+	}
+
+	// The grants on the schema and tables are applied after connecting to each logical database.
+	for _, user := range postgres.Users {
+		for _, grant := range user.Grants {
+			dbPrivileges, schemaPrivileges, tblPrivileges := splitPrivileges(grant)
+
+			statements = append(statements,
+				fmt.Sprintf(`GRANT %s ON DATABASE "%s" TO "%s";`, strings.Join(dbPrivileges, ", "), grant.Database, user.Name),
+				fmt.Sprintf(`\connect "%s"`, grant.Database),
+				fmt.Sprintf(`GRANT %s ON SCHEMA %s TO "%s";`, strings.Join(schemaPrivileges, ", "), defaultSchema, user.Name),
+			)
+			if len(tblPrivileges) > 0 {
+				statements = append(statements,
+					fmt.Sprintf(`GRANT %s ON ALL TABLES IN SCHEMA %s TO "%s";`,
+						strings.Join(tblPrivileges, ", "), defaultSchema, user.Name),
+					fmt.Sprintf(`ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT %s ON TABLES TO "%s";`,
+						defaultSchema, strings.Join(tblPrivileges, ", "), user.Name),
+				)
+			}
+		}
+	}
+
+	return statements
+}
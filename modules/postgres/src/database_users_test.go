@@ -0,0 +1,278 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseDatabaseUsers(t *testing.T) {
+	t.Run("successfully parse databases and users", func(t *testing.T) {
+		databases, err := parseDatabases([]any{"orders", "audit"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"orders", "audit"}, databases)
+
+		users, err := parseDatabaseUsers([]any{
+			map[string]any{
+				"name": "app",
+				"grants": []any{
+					map[string]any{
+						"database":   "orders",
+						"privileges": []any{"select", "insert"},
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []DatabaseUser{
+			{
+				Name: "app",
+				Grants: []DatabaseGrant{
+					{
+						Database:   "orders",
+						Privileges: []string{"SELECT", "INSERT"},
+					},
+				},
+			},
+		}, users)
+	})
+
+	t.Run("failed to parse databases and users", func(t *testing.T) {
+		_, err := parseDatabases("orders")
+		assert.ErrorIs(t, err, ErrInvalidDatabaseName)
+
+		_, err = parseDatabaseUsers([]any{map[string]any{"name": 1}})
+		assert.ErrorIs(t, err, ErrInvalidDatabaseUser)
+
+		_, err = parseDatabaseUsers([]any{
+			map[string]any{
+				"name":   "app",
+				"grants": []any{map[string]any{"database": "orders", "privileges": "ALL"}},
+			},
+		})
+		assert.ErrorIs(t, err, ErrInvalidDatabaseUser)
+	})
+}
+
+func TestPostgreSQLModule_ValidateDatabaseUsers(t *testing.T) {
+	testcases := []struct {
+		name        string
+		databases   []string
+		users       []DatabaseUser
+		expectedErr error
+	}{
+		{
+			name:      "valid databases and users",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT"}}}},
+			},
+		},
+		{
+			name:      "grant on the default logical database",
+			databases: nil,
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "test_database", Privileges: []string{"ALL PRIVILEGES"}}}},
+			},
+		},
+		{
+			name:        "invalid database name",
+			databases:   []string{"orders; DROP"},
+			expectedErr: ErrInvalidDatabaseName,
+		},
+		{
+			name:        "user conflicts with the administrator",
+			users:       []DatabaseUser{{Name: defaultUsername}},
+			expectedErr: ErrInvalidDatabaseUser,
+		},
+		{
+			name:      "grant on undeclared database",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "audit", Privileges: []string{"SELECT"}}}},
+			},
+			expectedErr: ErrUndeclaredGrantDatabase,
+		},
+		{
+			name:      "privilege not applicable to databases or tables",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"EXECUTE"}}}},
+			},
+			expectedErr: ErrInvalidGrantPrivilege,
+		},
+		{
+			name:      "invalid privilege",
+			databases: []string{"orders"},
+			users: []DatabaseUser{
+				{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT;"}}}},
+			},
+			expectedErr: ErrInvalidGrantPrivilege,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := &PostgreSQL{
+				DatabaseName: "test-database",
+				Username:     defaultUsername,
+				Databases:    tc.databases,
+				Users:        tc.users,
+			}
+
+			err := postgres.validateDatabaseUsers()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateDBUsers(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+	}
+	adminPassword := module.KusionPathDependency("random_password_id", "result")
+
+	t.Run("without declared databases and users", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+		}
+
+		resources, username, password, err := postgres.GenerateDBUsers(r, "test-host", adminPassword, nil)
+
+		assert.NoError(t, err)
+		assert.Nil(t, resources)
+		assert.Equal(t, defaultUsername, username)
+		assert.Equal(t, module.KusionPathDependency("random_password_id", "result"), password)
+	})
+
+	t.Run("with declared databases and users", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+			Databases:    []string{"orders", "audit"},
+			Users: []DatabaseUser{
+				{
+					Name: "app",
+					Grants: []DatabaseGrant{
+						{Database: "orders", Privileges: []string{"SELECT", "INSERT"}},
+						{Database: "audit", Privileges: []string{"INSERT"}},
+					},
+				},
+			},
+		}
+
+		resources, username, password, err := postgres.GenerateDBUsers(r, "test-host", adminPassword, []string{"db_instance_id"})
+
+		// random_password, the Secret and the Job.
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(resources))
+		assert.Equal(t, "app", username)
+		assert.Equal(t, module.KusionPathDependency(resources[0].ID, "result"), password)
+
+		secret := resources[1]
+		assert.Equal(t, "v1:Secret:test-project:test-database-db-users-secret", secret.ID)
+		assert.Equal(t, map[string]any{
+			"password":     module.KusionPathDependency("random_password_id", "result"),
+			"username":     defaultUsername,
+			"database":     "orders",
+			"app-password": module.KusionPathDependency(resources[0].ID, "result"),
+		}, secret.Attributes["stringData"])
+
+		// The Job connects to the instance from the cluster after the instance and the Secret are created.
+		job := resources[2]
+		assert.Contains(t, job.ID, "batch/v1:Job:test-project:test-database-db-users-")
+		assert.Equal(t, []string{"db_instance_id", secret.ID}, job.DependsOn)
+
+		container := job.Attributes["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+		assert.Equal(t, "postgres:16", container["image"])
+		script := container["command"].([]any)[2].(string)
+		assert.Contains(t, script, "psql -v ON_ERROR_STOP=1 -h \"$KUSION_DB_HOST\" -p 5432 -U kusion_default -d postgres <<EOSQL")
+		assert.Contains(t, script, "GRANT CONNECT ON DATABASE \"audit\" TO \"app\";")
+
+		env := container["env"].([]any)
+		assert.Equal(t, map[string]any{"name": "KUSION_DB_HOST", "value": "test-host"}, env[0])
+		assert.Equal(t, map[string]any{"name": "PGSSLMODE", "value": "require"}, env[1])
+		assert.Equal(t, "PGPASSWORD", env[2].(map[string]any)["name"])
+		assert.Equal(t, "KUSION_USER_PASSWORD_0", env[3].(map[string]any)["name"])
+
+		// A new Job is created once the statements change.
+		postgres.Databases = []string{"orders", "audit", "reports"}
+		changed, _, _, err := postgres.GenerateDBUsers(r, "test-host", adminPassword, []string{"db_instance_id"})
+		assert.NoError(t, err)
+		assert.NotEqual(t, job.ID, changed[2].ID)
+	})
+
+	t.Run("with iam auth", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			DatabaseName: "test-database",
+			Username:     defaultUsername,
+			Auth:         "iam",
+			Users:        []DatabaseUser{{Name: "app"}},
+		}
+
+		resources, username, password, err := postgres.GenerateDBUsers(r, "test-host", adminPassword, nil)
+
+		// The Secret and the Job without the random_password.
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(resources))
+		container := resources[1].Attributes["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+		assert.Contains(t, container["command"].([]any)[2].(string), "GRANT rds_iam TO \"app\";")
+		assert.Equal(t, "app", username)
+		assert.Equal(t, "", password)
+	})
+}
+
+func TestSplitPrivileges(t *testing.T) {
+	dbPrivileges, schemaPrivileges, tblPrivileges := splitPrivileges(DatabaseGrant{
+		Database:   "orders",
+		Privileges: []string{"TEMP", "SELECT"},
+	})
+	assert.Equal(t, []string{"CONNECT", "TEMPORARY"}, dbPrivileges)
+	assert.Equal(t, []string{"USAGE"}, schemaPrivileges)
+	assert.Equal(t, []string{"SELECT"}, tblPrivileges)
+
+	dbPrivileges, schemaPrivileges, tblPrivileges = splitPrivileges(DatabaseGrant{
+		Database:   "orders",
+		Privileges: []string{"ALL PRIVILEGES"},
+	})
+	assert.Equal(t, databasePrivileges, dbPrivileges)
+	assert.Equal(t, []string{"USAGE", "CREATE"}, schemaPrivileges)
+	assert.Equal(t, tablePrivileges, tblPrivileges)
+}
+
+func TestPostgreSQLModule_GenerateUserStatements(t *testing.T) {
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Databases:    []string{"orders"},
+		Users: []DatabaseUser{
+			{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT", "INSERT"}}}},
+		},
+	}
+
+	assert.Equal(t, []string{
+		`SELECT 'CREATE DATABASE "orders"' WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'orders')\gexec`,
+		`DO \$\$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'app') THEN CREATE ROLE "app"; END IF; END \$\$;`,
+		`ALTER ROLE "app" WITH LOGIN PASSWORD '${KUSION_USER_PASSWORD_0}';`,
+		`GRANT CONNECT ON DATABASE "orders" TO "app";`,
+		`\connect "orders"`,
+		`GRANT USAGE ON SCHEMA public TO "app";`,
+		`GRANT SELECT, INSERT ON ALL TABLES IN SCHEMA public TO "app";`,
+		`ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT ON TABLES TO "app";`,
+	}, postgres.generateUserStatements())
+
+	assert.Nil(t, (&PostgreSQL{DatabaseName: "test-database"}).generateUserStatements())
+
+	// The logical database is created for the extensions only.
+	assert.Equal(t, []string{
+		`SELECT 'CREATE DATABASE "test_database"' WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'test_database')\gexec`,
+	}, (&PostgreSQL{DatabaseName: "test-database", Extensions: []string{"pgvector"}}).generateUserStatements())
+}
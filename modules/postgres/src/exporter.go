@@ -0,0 +1,376 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidExporterConfig = errors.New("invalid exporter config in postgres module config")
+
+var (
+	exporterSuffix        = "-db-exporter"
+	exporterServiceSuffix = "-db-exporter-service"
+	exporterMonitorSuffix = "-db-metrics"
+	exporterContainerName = "exporter"
+	exporterPortName      = "metrics"
+	exporterPort          = 9187
+	exporterPath          = "/metrics"
+	exporterURIEnv        = "DATA_SOURCE_URI"
+	exporterUserEnv       = "DATA_SOURCE_USER"
+	exporterPasswordEnv   = "DATA_SOURCE_PASS"
+
+	serviceMonitorAPIVersion = "monitoring.coreos.com/v1"
+	serviceMonitorKind       = "ServiceMonitor"
+
+	defaultExporterImage    = "quay.io/prometheuscommunity/postgres-exporter:v0.15.0"
+	defaultExporterInterval = "30s"
+	defaultExporterTimeout  = "15s"
+)
+
+// Exporter describes the postgres_exporter exposing the metrics of the PostgreSQL instance, which are scraped
+// with the ServiceMonitor by the Prometheus Operator the same way as the monitoring module does. The
+// exporter runs as the sidecar of the local instance, or as the standalone Deployment connecting to
+// the cloud provided instance with the generated credentials.
+type Exporter struct {
+	// The image of postgres_exporter.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The interval of scraping the metrics, such as "30s".
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// The timeout of scraping the metrics, which should not be greater than the interval.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// parseExporter parses the exporter of the platform config, which is either a bool enabling the
+// exporter with the default settings or a block with the settings.
+func parseExporter(config any) (*Exporter, error) {
+	exporter := &Exporter{
+		Image:    defaultExporterImage,
+		Interval: defaultExporterInterval,
+		Timeout:  defaultExporterTimeout,
+	}
+
+	if enabled, ok := toConfigBool(config); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return exporter, nil
+	}
+
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a bool or map but got %T", ErrInvalidExporterConfig, config)
+	}
+
+	if err := parseConfigBlock(configMap, ErrInvalidExporterConfig, "", map[string]configValueParser{
+		"image":    configValue(&exporter.Image, toConfigString),
+		"interval": configValue(&exporter.Interval, toConfigString),
+		"timeout":  configValue(&exporter.Timeout, toConfigString),
+	}); err != nil {
+		return nil, err
+	}
+
+	return exporter, nil
+}
+
+// validateExporter validates whether the exporter is declared for the local or cloud provided PostgreSQL
+// instance authenticated with the password, and whether the scrape timeout is within the interval.
+func (postgres *PostgreSQL) validateExporter() error {
+	exporter := postgres.Exporter
+	if exporter == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(postgres.Type, LocalDBType) && !strings.EqualFold(postgres.Type, CloudDBType) {
+		return fmt.Errorf("%w: only supported by the %s and %s types", ErrInvalidExporterConfig, LocalDBType, CloudDBType)
+	}
+
+	if postgres.isIAMAuth() {
+		return fmt.Errorf("%w: not supported with %s auth", ErrInvalidExporterConfig, IAMAuth)
+	}
+
+	if exporter.Image == "" {
+		return fmt.Errorf("%w: empty image", ErrInvalidExporterConfig)
+	}
+
+	interval, err := time.ParseDuration(exporter.Interval)
+	if err != nil {
+		return fmt.Errorf("%w: invalid interval %q", ErrInvalidExporterConfig, exporter.Interval)
+	}
+
+	timeout, err := time.ParseDuration(exporter.Timeout)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timeout %q", ErrInvalidExporterConfig, exporter.Timeout)
+	}
+
+	if timeout > interval {
+		return fmt.Errorf("%w: timeout cannot be greater than interval", ErrInvalidExporterConfig)
+	}
+
+	return nil
+}
+
+// generateExporterResources generates the ServiceMonitor scraping the metrics of the PostgreSQL instance,
+// along with the standalone exporter Deployment and Service for the cloud provided instance. The
+// local instance exposes the metrics with the sidecar through the metrics port of its Service.
+func (postgres *PostgreSQL) generateExporterResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, error) {
+	var resources []kusionapiv1.Resource
+
+	selector := postgres.generateLocalMatchLabels()
+	if strings.EqualFold(postgres.Type, CloudDBType) {
+		exporterDeployment, err := postgres.generateExporterDeployment(request)
+		if err != nil {
+			return nil, err
+		}
+
+		exporterService, err := postgres.generateExporterService(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *exporterDeployment, *exporterService)
+
+		selector = postgres.generateExporterMatchLabels()
+	}
+
+	serviceMonitor, err := postgres.generateServiceMonitor(request, selector)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, *serviceMonitor)
+
+	return resources, nil
+}
+
+// generateLocalExporterContainer generates the exporter sidecar of the local PostgreSQL instance, which
+// connects to the instance via the loopback address with the credentials in the local secret.
+func (postgres *PostgreSQL) generateLocalExporterContainer() v1.Container {
+	secretName := postgres.DatabaseName + localSecretSuffix
+	env := []v1.EnvVar{
+		{
+			Name:  exporterURIEnv,
+			Value: fmt.Sprintf("127.0.0.1:%d/%s?sslmode=disable", dbPort, postgres.generateLogicalDBName()),
+		},
+		{
+			Name: exporterUserEnv,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secretName,
+					},
+					Key: "username",
+				},
+			},
+		},
+		{
+			Name: exporterPasswordEnv,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secretName,
+					},
+					Key: "password",
+				},
+			},
+		},
+	}
+
+	return postgres.generateExporterContainer(env)
+}
+
+// generateExporterContainer generates the exporter container with the connection environment variables.
+func (postgres *PostgreSQL) generateExporterContainer(env []v1.EnvVar) v1.Container {
+	probe := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{
+				Path: "/",
+				Port: intstr.FromString(exporterPortName),
+			},
+		},
+		PeriodSeconds:  10,
+		TimeoutSeconds: 5,
+	}
+
+	return v1.Container{
+		Name:  exporterContainerName,
+		Image: postgres.Exporter.Image,
+		Env:   env,
+		Ports: []v1.ContainerPort{
+			{
+				Name:          exporterPortName,
+				ContainerPort: int32(exporterPort),
+			},
+		},
+		ReadinessProbe: probe,
+		LivenessProbe:  probe,
+	}
+}
+
+// generateExporterDeployment generates the Kubernetes Deployment of the standalone exporter, which
+// connects to the cloud provided PostgreSQL instance with the credentials in the database secret.
+func (postgres *PostgreSQL) generateExporterDeployment(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	secretName := postgres.DatabaseName + dbResSuffix
+	credentials := []struct {
+		name string
+		key  string
+	}{
+		{name: dbHostAddressEnv, key: "hostAddress"},
+		{name: dbPortEnv, key: "port"},
+		{name: dbDatabaseEnv, key: "database"},
+		{name: exporterUserEnv, key: "username"},
+		{name: exporterPasswordEnv, key: "password"},
+	}
+
+	var env []v1.EnvVar
+	for _, credential := range credentials {
+		env = append(env, v1.EnvVar{
+			Name: credential.name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: postgres.generateSecretKeySelector(secretName, credential.key),
+			},
+		})
+	}
+
+	// The data source URI is composed of the credential environment variables above, which should be
+	// placed after them for the dependent variable expansion.
+	env = append(env, v1.EnvVar{
+		Name:  exporterURIEnv,
+		Value: fmt.Sprintf("$(%s):$(%s)/$(%s)?sslmode=require", dbHostAddressEnv, dbPortEnv, dbDatabaseEnv),
+	})
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + exporterSuffix,
+			Namespace: request.Project,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: postgres.generateExporterMatchLabels(),
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      postgres.generateExporterMatchLabels(),
+					Annotations: postgres.generatePasswordRotationAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{postgres.generateExporterContainer(env)},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(deployment.TypeMeta, deployment.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExporterService generates the Kubernetes Service of the standalone exporter.
+func (postgres *PostgreSQL) generateExporterService(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + exporterServiceSuffix,
+			Namespace: request.Project,
+			Labels:    postgres.generateExporterMatchLabels(),
+		},
+		Spec: v1.ServiceSpec{
+			Ports:    []v1.ServicePort{postgres.generateExporterSvcPort()},
+			Selector: postgres.generateExporterMatchLabels(),
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(service.TypeMeta, service.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, service)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExporterSvcPort generates the named metrics port of the Service exposing the exporter.
+func (postgres *PostgreSQL) generateExporterSvcPort() v1.ServicePort {
+	return v1.ServicePort{
+		Name:       exporterPortName,
+		Port:       int32(exporterPort),
+		TargetPort: intstr.FromString(exporterPortName),
+	}
+}
+
+// generateServiceMonitor generates the ServiceMonitor resource scraping the metrics port of the Service
+// selected by the labels.
+func (postgres *PostgreSQL) generateServiceMonitor(request *module.GeneratorRequest, selector map[string]string) (
+	*kusionapiv1.Resource, error,
+) {
+	matchLabels := make(map[string]any, len(selector))
+	for key, value := range selector {
+		matchLabels[key] = value
+	}
+
+	name := postgres.DatabaseName + exporterMonitorSuffix
+	serviceMonitor := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": serviceMonitorAPIVersion,
+			"kind":       serviceMonitorKind,
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": request.Project,
+			},
+			"spec": map[string]any{
+				"selector": map[string]any{
+					"matchLabels": matchLabels,
+				},
+				"endpoints": []any{
+					map[string]any{
+						"port":          exporterPortName,
+						"path":          exporterPath,
+						"interval":      postgres.Exporter.Interval,
+						"scrapeTimeout": postgres.Exporter.Timeout,
+					},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(metav1.TypeMeta{
+		Kind:       serviceMonitorKind,
+		APIVersion: serviceMonitorAPIVersion,
+	}, metav1.ObjectMeta{
+		Name:      name,
+		Namespace: request.Project,
+	})
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, serviceMonitor)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateExporterMatchLabels generates the match labels of the standalone exporter.
+func (postgres *PostgreSQL) generateExporterMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": postgres.DatabaseName + exporterSuffix,
+	}
+}
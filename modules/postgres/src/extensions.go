@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidExtensions     = errors.New("invalid extensions in postgres module config")
+	ErrUnsupportedExtensions = errors.New("extensions are not supported for the postgres instance of this cloud provider")
+)
+
+var (
+	sharedPreloadLibrariesParam  = "shared_preload_libraries"
+	localExtensionsInitScriptKey = "00-extensions" + initScriptExt
+)
+
+// extensionSpec describes how a supported extension is installed into the PostgreSQL instance.
+type extensionSpec struct {
+	// The name of the extension in the CREATE EXTENSION statement.
+	name string
+	// Whether the library of the extension should be loaded on the server start.
+	preload bool
+	// The format of the image of the local instance bundling the extension with the major version,
+	// which is empty for the contrib extensions bundled in the official image.
+	localImage string
+}
+
+// supportedExtensions are the extensions which can be declared in the devConfig, keyed by the names
+// the developers are familiar with.
+var supportedExtensions = map[string]extensionSpec{
+	"pgvector":           {name: "vector", localImage: "pgvector/pgvector:pg%s"},
+	"postgis":            {name: "postgis", localImage: "postgis/postgis:%s-3.4"},
+	"pg_trgm":            {name: "pg_trgm"},
+	"pg_stat_statements": {name: "pg_stat_statements", preload: true},
+	"btree_gin":          {name: "btree_gin"},
+	"btree_gist":         {name: "btree_gist"},
+	"citext":             {name: "citext"},
+	"cube":               {name: "cube"},
+	"fuzzystrmatch":      {name: "fuzzystrmatch"},
+	"hstore":             {name: "hstore"},
+	"ltree":              {name: "ltree"},
+	"pgcrypto":           {name: "pgcrypto"},
+	"tablefunc":          {name: "tablefunc"},
+	"unaccent":           {name: "unaccent"},
+	"uuid-ossp":          {name: "uuid-ossp"},
+}
+
+// validateExtensions validates whether the extensions are supported and declared for the local or cloud
+// provided PostgreSQL instance, and whether the local instance can be deployed with a single image
+// bundling all of them.
+func (postgres *PostgreSQL) validateExtensions() error {
+	if len(postgres.Extensions) == 0 {
+		return nil
+	}
+
+	if !strings.EqualFold(postgres.Type, LocalDBType) && !strings.EqualFold(postgres.Type, CloudDBType) {
+		return fmt.Errorf("%w: only supported by the %s and %s types", ErrInvalidExtensions, LocalDBType, CloudDBType)
+	}
+
+	var localImages []string
+	for i, extension := range postgres.Extensions {
+		spec, ok := supportedExtensions[extension]
+		if !ok {
+			return fmt.Errorf("%w: unsupported extension %q", ErrInvalidExtensions, extension)
+		}
+		if slices.Contains(postgres.Extensions[:i], extension) {
+			return fmt.Errorf("%w: duplicate extension %q", ErrInvalidExtensions, extension)
+		}
+		if spec.localImage != "" {
+			localImages = append(localImages, extension)
+		}
+	}
+
+	if strings.EqualFold(postgres.Type, LocalDBType) && len(localImages) > 1 {
+		return fmt.Errorf("%w: %s cannot be bundled in the same image of the local instance",
+			ErrInvalidExtensions, strings.Join(localImages, " and "))
+	}
+
+	return nil
+}
+
+// generateLocalImage generates the image of the local PostgreSQL instance, which is the one bundling
+// the declared extension if any, or the official image otherwise.
+func (postgres *PostgreSQL) generateLocalImage() string {
+	major := strings.Split(postgres.Version, ".")[0]
+	for _, extension := range postgres.Extensions {
+		if spec := supportedExtensions[extension]; spec.localImage != "" {
+			return fmt.Sprintf(spec.localImage, major)
+		}
+	}
+
+	return dbEngine + ":" + postgres.Version
+}
+
+// generateEngineParameters generates the engine parameters along with the shared_preload_libraries
+// parameter loading the libraries of the declared extensions on the server start, which are appended
+// to the ones in the engine parameters without modifying the declared parameters.
+func (postgres *PostgreSQL) generateEngineParameters() map[string]string {
+	var libraries []string
+	if value := postgres.Parameters[sharedPreloadLibrariesParam]; value != "" {
+		for _, library := range strings.Split(value, ",") {
+			libraries = append(libraries, strings.TrimSpace(library))
+		}
+	}
+
+	preloaded := len(libraries)
+	for _, extension := range postgres.Extensions {
+		spec := supportedExtensions[extension]
+		if spec.preload && !slices.Contains(libraries, spec.name) {
+			libraries = append(libraries, spec.name)
+		}
+	}
+	if len(libraries) == preloaded {
+		return postgres.Parameters
+	}
+
+	parameters := make(map[string]string, len(postgres.Parameters)+1)
+	for name, value := range postgres.Parameters {
+		parameters[name] = value
+	}
+	parameters[sharedPreloadLibrariesParam] = strings.Join(libraries, ",")
+
+	return parameters
+}
+
+// generateExtensionStatements generates the statements creating the extensions in the current database.
+func (postgres *PostgreSQL) generateExtensionStatements() []string {
+	statements := make([]string, 0, len(postgres.Extensions))
+	for _, extension := range postgres.Extensions {
+		statements = append(statements,
+			fmt.Sprintf(`CREATE EXTENSION IF NOT EXISTS "%s";`, supportedExtensions[extension].name))
+	}
+
+	return statements
+}
+
+// generateLocalExtensionInitScript generates the init script creating the extensions in the first logical
+// database, which is keyed to run before the declared init scripts for them to use the extensions.
+func (postgres *PostgreSQL) generateLocalExtensionInitScript() string {
+	return strings.Join(postgres.generateExtensionStatements(), "\n") + "\n"
+}
+
+// generateDatabaseExtensionStatements generates the statements creating the extensions in every logical
+// database, which are applied after the local instance starts or by the Job of the cloud provided instance.
+// They cover the logical databases created after the first boot and the extensions declared later.
+func (postgres *PostgreSQL) generateDatabaseExtensionStatements() []string {
+	if len(postgres.Extensions) == 0 {
+		return nil
+	}
+
+	var statements []string
+	for _, database := range postgres.generateLogicalDBNames() {
+		statements = append(statements, fmt.Sprintf(`\connect "%s"`, database))
+		statements = append(statements, postgres.generateExtensionStatements()...)
+	}
+
+	return statements
+}
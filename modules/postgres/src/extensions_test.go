@@ -0,0 +1,140 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestPostgreSQLModule_ValidateExtensions(t *testing.T) {
+	testcases := []struct {
+		name     string
+		postgres *PostgreSQL
+		success  bool
+	}{
+		{
+			name:     "extensions of local database",
+			postgres: &PostgreSQL{Type: "local", Extensions: []string{"pgvector", "pg_trgm"}},
+			success:  true,
+		},
+		{
+			name:     "extensions of cloud database",
+			postgres: &PostgreSQL{Type: "cloud", Extensions: []string{"pgvector", "postgis", "pg_trgm"}},
+			success:  true,
+		},
+		{
+			name:     "extensions of external database",
+			postgres: &PostgreSQL{Type: "external", Extensions: []string{"pgvector"}},
+			success:  false,
+		},
+		{
+			name:     "unsupported extension",
+			postgres: &PostgreSQL{Type: "local", Extensions: []string{"timescaledb"}},
+			success:  false,
+		},
+		{
+			name:     "duplicate extension",
+			postgres: &PostgreSQL{Type: "local", Extensions: []string{"pg_trgm", "pg_trgm"}},
+			success:  false,
+		},
+		{
+			name:     "extensions of different images for local database",
+			postgres: &PostgreSQL{Type: "local", Extensions: []string{"pgvector", "postgis"}},
+			success:  false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.postgres.validateExtensions()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidExtensions)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateLocalImage(t *testing.T) {
+	postgres := &PostgreSQL{Version: "16.2"}
+	assert.Equal(t, "postgres:16.2", postgres.generateLocalImage())
+
+	postgres.Extensions = []string{"pg_trgm", "pgvector"}
+	assert.Equal(t, "pgvector/pgvector:pg16", postgres.generateLocalImage())
+
+	postgres.Extensions = []string{"postgis"}
+	assert.Equal(t, "postgis/postgis:16-3.4", postgres.generateLocalImage())
+}
+
+func TestPostgreSQLModule_GenerateEngineParameters(t *testing.T) {
+	postgres := &PostgreSQL{Extensions: []string{"pgvector"}}
+	assert.Nil(t, postgres.generateEngineParameters())
+
+	postgres.Extensions = []string{"pgvector", "pg_stat_statements"}
+	assert.Equal(t, map[string]string{"shared_preload_libraries": "pg_stat_statements"}, postgres.generateEngineParameters())
+	assert.Nil(t, postgres.Parameters)
+
+	postgres.Parameters = map[string]string{"shared_preload_libraries": "auto_explain, pg_stat_statements"}
+	assert.Equal(t, "auto_explain, pg_stat_statements", postgres.generateEngineParameters()["shared_preload_libraries"])
+
+	// The declared parameters are kept as they are.
+	postgres.Parameters = map[string]string{"shared_preload_libraries": "auto_explain"}
+	assert.Equal(t, "auto_explain,pg_stat_statements", postgres.generateEngineParameters()["shared_preload_libraries"])
+	assert.Equal(t, "auto_explain", postgres.Parameters["shared_preload_libraries"])
+}
+
+func TestPostgreSQLModule_GenerateLocalExtensions(t *testing.T) {
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		Databases:    []string{"orders", "audit"},
+		Extensions:   []string{"pgvector", "uuid-ossp"},
+		InitScripts:  []InitScript{{Name: "schema", SQL: "CREATE TABLE items (embedding vector(3));"}},
+	}
+
+	// The extensions are created before the declared init scripts on the first boot.
+	data, err := postgres.generateInitScriptsData()
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"00-extensions.sql": "CREATE EXTENSION IF NOT EXISTS \"vector\";\nCREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";\n",
+		"01-schema.sql":     "CREATE TABLE items (embedding vector(3));",
+	}, data)
+
+	// The extensions are created in every logical database after the instance starts.
+	assert.Equal(t, []string{
+		`\connect "orders"`,
+		`CREATE EXTENSION IF NOT EXISTS "vector";`,
+		`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`,
+		`\connect "audit"`,
+		`CREATE EXTENSION IF NOT EXISTS "vector";`,
+		`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`,
+	}, postgres.generateDatabaseExtensionStatements())
+}
+
+func TestPostgreSQLModule_GenerateDBUsersWithExtensions(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+	}
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Databases:    []string{"orders", "audit"},
+		Extensions:   []string{"pgvector"},
+	}
+
+	resources, username, password, err := postgres.GenerateDBUsers(r, "test-host",
+		module.KusionPathDependency("random_password_id", "result"), []string{"db_instance_id"})
+
+	// The Secret and the Job creating the extensions in each logical database.
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resources))
+	container := resources[1].Attributes["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+	script := container["command"].([]any)[2].(string)
+	assert.Contains(t, script, "\\connect \"audit\"\nCREATE EXTENSION IF NOT EXISTS \"vector\";")
+	assert.Equal(t, defaultUsername, username)
+	assert.Equal(t, module.KusionPathDependency("random_password_id", "result"), password)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidExternalConfig = errors.New("invalid external config in postgres module config")
+
+var secretRefPrefix = "secret://"
+
+// ExternalDatabase describes the existing PostgreSQL database managed outside of Kusion, whose
+// credentials are injected into the workload without provisioning anything.
+type ExternalDatabase struct {
+	// The host address of the external PostgreSQL database.
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+	// The port of the external PostgreSQL database, which defaults to 5432.
+	Port int `json:"port,omitempty" yaml:"port,omitempty"`
+	// The username of the external PostgreSQL database, which is either a plain string or a reference
+	// to the key of an existing Kubernetes Secret in the format of "secret://name/key".
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	// The reference to the key of the existing Kubernetes Secret holding the password, in the
+	// format of "secret://name/key".
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// parseExternalDatabase parses the external block of the devConfig.
+func parseExternalDatabase(config any) (*ExternalDatabase, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidExternalConfig, config)
+	}
+
+	external := &ExternalDatabase{
+		Port: dbPort,
+	}
+	if err := parseConfigBlock(configMap, ErrInvalidExternalConfig, "", map[string]configValueParser{
+		"host":     configValue(&external.Host, toConfigString),
+		"port":     configValue(&external.Port, toConfigInt),
+		"username": configValue(&external.Username, toConfigString),
+		"password": configValue(&external.Password, toConfigString),
+	}); err != nil {
+		return nil, err
+	}
+
+	return external, nil
+}
+
+// parseSecretRef parses the reference to the key of the Kubernetes Secret in the format of
+// "secret://name/key".
+func parseSecretRef(ref string) (*v1.SecretKeySelector, bool) {
+	if !strings.HasPrefix(ref, secretRefPrefix) {
+		return nil, false
+	}
+
+	name, key, ok := strings.Cut(strings.TrimPrefix(ref, secretRefPrefix), "/")
+	if !ok || name == "" || key == "" || strings.Contains(key, "/") {
+		return nil, false
+	}
+
+	return &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{
+			Name: name,
+		},
+		Key: key,
+	}, true
+}
+
+// validateExternalDatabase validates whether the external database is declared with the external type,
+// and whether the host, port and credentials are valid. The external database is not provisioned, so
+// the configs creating anything inside it are not supported.
+func (postgres *PostgreSQL) validateExternalDatabase() error {
+	isExternal := strings.EqualFold(postgres.Type, ExternalDBType)
+	if postgres.External == nil {
+		if isExternal {
+			return fmt.Errorf("%w: external should be specified for the %s type", ErrInvalidExternalConfig, ExternalDBType)
+		}
+		return nil
+	}
+
+	if !isExternal {
+		return fmt.Errorf("%w: external is only supported by the %s type", ErrInvalidExternalConfig, ExternalDBType)
+	}
+
+	if postgres.External.Host == "" {
+		return fmt.Errorf("%w: empty host", ErrInvalidExternalConfig)
+	}
+
+	if postgres.External.Port <= 0 || postgres.External.Port > 65535 {
+		return fmt.Errorf("%w: invalid port %d", ErrInvalidExternalConfig, postgres.External.Port)
+	}
+
+	if postgres.External.Username == "" {
+		return fmt.Errorf("%w: empty username", ErrInvalidExternalConfig)
+	}
+	if strings.HasPrefix(postgres.External.Username, secretRefPrefix) {
+		if _, ok := parseSecretRef(postgres.External.Username); !ok {
+			return fmt.Errorf("%w: invalid username secret reference %q", ErrInvalidExternalConfig, postgres.External.Username)
+		}
+	}
+
+	if _, ok := parseSecretRef(postgres.External.Password); !ok {
+		return fmt.Errorf("%w: password should be a secret reference in the format of %sname/key",
+			ErrInvalidExternalConfig, secretRefPrefix)
+	}
+
+	if len(postgres.Databases) > 1 || len(postgres.Users) > 0 || len(postgres.InitScripts) > 0 || postgres.Replicas > 0 {
+		return fmt.Errorf("%w: databases, users, initScripts and replicas cannot be provisioned in the external database",
+			ErrInvalidExternalConfig)
+	}
+
+	return nil
+}
+
+// GenerateExternalResources generates the Kubernetes Secret with the host address, port and database
+// of the external PostgreSQL database, and injects them into the workload along with the credentials
+// referenced from the existing Kubernetes Secrets.
+func (postgres *PostgreSQL) GenerateExternalResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
+	// The username referenced from the existing secret is not stored in the database secret.
+	var username string
+	if _, ok := parseSecretRef(postgres.External.Username); !ok {
+		username = postgres.External.Username
+	}
+
+	dbSecret, patcher, err := postgres.GenerateDBSecret(request, postgres.External.Host, username, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []kusionapiv1.Resource{*dbSecret}, patcher, nil
+}
+
+// generateSecretKeySelector generates the selector of the key in the database secret, which is
+// replaced with the existing secret referenced by the credentials of the external database, or
+// with the secret generated by the operator.
+func (postgres *PostgreSQL) generateSecretKeySelector(secretName, key string) *v1.SecretKeySelector {
+	if ref, ok := postgres.generateExternalSecretRef(key); ok {
+		return ref
+	}
+
+	if ref, ok := postgres.generateOperatorSecretRef(key); ok {
+		return ref
+	}
+
+	return &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{
+			Name: secretName,
+		},
+		Key: key,
+	}
+}
+
+// generateExternalSecretRef generates the reference to the existing secret of the username or
+// password of the external database.
+func (postgres *PostgreSQL) generateExternalSecretRef(key string) (*v1.SecretKeySelector, bool) {
+	if postgres.External == nil {
+		return nil, false
+	}
+
+	switch key {
+	case "username":
+		return parseSecretRef(postgres.External.Username)
+	case "password":
+		return parseSecretRef(postgres.External.Password)
+	default:
+		return nil, false
+	}
+}
+
+// generatePort generates the port of the PostgreSQL database, which is customized by the external database.
+func (postgres *PostgreSQL) generatePort() int {
+	if postgres.External != nil {
+		return postgres.External.Port
+	}
+
+	return dbPort
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseExternalDatabase(t *testing.T) {
+	t.Run("default external port", func(t *testing.T) {
+		actual, err := parseExternalDatabase(map[string]any{
+			"host":     "postgres.example.com",
+			"username": "root",
+			"password": "secret://postgres-credentials/password",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ExternalDatabase{
+			Host:     "postgres.example.com",
+			Port:     5432,
+			Username: "root",
+			Password: "secret://postgres-credentials/password",
+		}, actual)
+	})
+
+	t.Run("specified external port", func(t *testing.T) {
+		actual, err := parseExternalDatabase(map[string]any{
+			"host": "postgres.example.com",
+			"port": "15432",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 15432, actual.Port)
+	})
+
+	t.Run("invalid external config", func(t *testing.T) {
+		_, err := parseExternalDatabase("postgres.example.com")
+		assert.ErrorIs(t, err, ErrInvalidExternalConfig)
+
+		_, err = parseExternalDatabase(map[string]any{"port": "port"})
+		assert.ErrorIs(t, err, ErrInvalidExternalConfig)
+
+		_, err = parseExternalDatabase(map[string]any{"unknown": "value"})
+		assert.ErrorIs(t, err, ErrInvalidExternalConfig)
+	})
+}
+
+func TestParseSecretRef(t *testing.T) {
+	ref, ok := parseSecretRef("secret://postgres-credentials/password")
+	assert.True(t, ok)
+	assert.Equal(t, "postgres-credentials", ref.Name)
+	assert.Equal(t, "password", ref.Key)
+
+	for _, invalid := range []string{"password", "secret://postgres-credentials", "secret:///password", "secret://a/b/c"} {
+		_, ok = parseSecretRef(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestPostgreSQLModule_ValidateExternalDatabase(t *testing.T) {
+	validExternal := func() *ExternalDatabase {
+		return &ExternalDatabase{
+			Host:     "postgres.example.com",
+			Port:     5432,
+			Username: "root",
+			Password: "secret://postgres-credentials/password",
+		}
+	}
+
+	testcases := []struct {
+		name        string
+		postgres    *PostgreSQL
+		expectedErr error
+	}{
+		{
+			name:     "local type without external",
+			postgres: &PostgreSQL{Type: "local"},
+		},
+		{
+			name:     "valid external database",
+			postgres: &PostgreSQL{Type: "external", External: validExternal(), Databases: []string{"app"}},
+		},
+		{
+			name: "username referenced from secret",
+			postgres: &PostgreSQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Username = "secret://postgres-credentials/username"
+				return external
+			}()},
+		},
+		{
+			name:        "external type without external",
+			postgres:    &PostgreSQL{Type: "external"},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name:        "external with local type",
+			postgres:    &PostgreSQL{Type: "local", External: validExternal()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "empty host",
+			postgres: &PostgreSQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Host = ""
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "invalid port",
+			postgres: &PostgreSQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Port = 65536
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "plain password",
+			postgres: &PostgreSQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Password = "password"
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name: "invalid username secret reference",
+			postgres: &PostgreSQL{Type: "external", External: func() *ExternalDatabase {
+				external := validExternal()
+				external.Username = "secret://postgres-credentials"
+				return external
+			}()},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name:        "replicas of external database",
+			postgres:    &PostgreSQL{Type: "external", External: validExternal(), Replicas: 1},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+		{
+			name:        "users of external database",
+			postgres:    &PostgreSQL{Type: "external", External: validExternal(), Users: []DatabaseUser{{Name: "app"}}},
+			expectedErr: ErrInvalidExternalConfig,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.postgres.validateExternalDatabase()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateExternalResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	t.Run("plain username", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:         "external",
+			DatabaseName: "test-database",
+			External: &ExternalDatabase{
+				Host:     "postgres.example.com",
+				Port:     15432,
+				Username: "root",
+				Password: "secret://postgres-credentials/password",
+			},
+		}
+
+		resources, patcher, err := postgres.GenerateExternalResources(r)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(resources))
+		assert.Equal(t, map[string]any{
+			"hostAddress": "postgres.example.com",
+			"port":        "15432",
+			"database":    "test_database",
+			"username":    "root",
+		}, resources[0].Attributes["stringData"])
+
+		refs := make(map[string]*v1.SecretKeySelector)
+		for _, env := range patcher.Environments {
+			refs[env.Name] = env.ValueFrom.SecretKeyRef
+		}
+		assert.Equal(t, "test-database-postgres", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Name)
+		assert.Equal(t, "username", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Key)
+		assert.Equal(t, "postgres-credentials", refs["KUSION_DB_PASSWORD_TEST_DATABASE"].Name)
+		assert.Equal(t, "password", refs["KUSION_DB_PASSWORD_TEST_DATABASE"].Key)
+	})
+
+	t.Run("username referenced from secret", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:         "external",
+			DatabaseName: "test-database",
+			External: &ExternalDatabase{
+				Host:     "postgres.example.com",
+				Port:     5432,
+				Username: "secret://postgres-credentials/user",
+				Password: "secret://postgres-credentials/password",
+			},
+		}
+
+		resources, patcher, err := postgres.GenerateExternalResources(r)
+		assert.NoError(t, err)
+		assert.NotContains(t, resources[0].Attributes["stringData"], "username")
+
+		refs := make(map[string]*v1.SecretKeySelector)
+		for _, env := range patcher.Environments {
+			refs[env.Name] = env.ValueFrom.SecretKeyRef
+		}
+		assert.Equal(t, "postgres-credentials", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Name)
+		assert.Equal(t, "user", refs["KUSION_DB_USERNAME_TEST_DATABASE"].Key)
+	})
+}
+
+func TestPostgreSQLModule_GenerateMigrationEnvWithExternalDatabase(t *testing.T) {
+	postgres := &PostgreSQL{
+		Migration: &Migration{Tool: GolangMigrateTool},
+		External: &ExternalDatabase{
+			Host:     "postgres.example.com",
+			Port:     5432,
+			Username: "root",
+			Password: "secret://postgres-credentials/password",
+		},
+	}
+
+	env := postgres.generateMigrationEnv("test-secret")
+
+	assert.Equal(t, dbPasswordEnv, env[4].Name)
+	assert.Equal(t, "postgres-credentials", env[4].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", env[4].ValueFrom.SecretKeyRef.Key)
+}
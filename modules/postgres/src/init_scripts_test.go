@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseInitScripts(t *testing.T) {
+	t.Run("successfully parse init scripts", func(t *testing.T) {
+		scripts, err := parseInitScripts([]any{
+			map[string]any{"name": "schema", "sql": "CREATE TABLE t (id INT);"},
+			map[string]any{"file": "seed.sql"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []InitScript{
+			{Name: "schema", SQL: "CREATE TABLE t (id INT);"},
+			{File: "seed.sql"},
+		}, scripts)
+	})
+
+	t.Run("failed to parse init scripts", func(t *testing.T) {
+		_, err := parseInitScripts("CREATE TABLE t (id INT);")
+		assert.ErrorIs(t, err, ErrInvalidInitScript)
+
+		_, err = parseInitScripts([]any{map[string]any{"path": "seed.sql"}})
+		assert.ErrorIs(t, err, ErrInvalidInitScript)
+	})
+}
+
+func TestPostgreSQLModule_ValidateInitScripts(t *testing.T) {
+	testcases := []struct {
+		name        string
+		initScripts []InitScript
+		expectedErr error
+	}{
+		{
+			name: "valid init scripts",
+			initScripts: []InitScript{
+				{Name: "schema", SQL: "CREATE TABLE t (id INT);"},
+				{File: "testdata/seed.sql"},
+			},
+		},
+		{
+			name:        "both sql and file",
+			initScripts: []InitScript{{SQL: "SELECT 1;", File: "seed.sql"}},
+			expectedErr: ErrInvalidInitScript,
+		},
+		{
+			name:        "neither sql nor file",
+			initScripts: []InitScript{{Name: "schema"}},
+			expectedErr: ErrInvalidInitScript,
+		},
+		{
+			name:        "invalid script name",
+			initScripts: []InitScript{{Name: "../schema", SQL: "SELECT 1;"}},
+			expectedErr: ErrInvalidInitScript,
+		},
+		{
+			name: "duplicate script names",
+			initScripts: []InitScript{
+				{Name: "seed", SQL: "SELECT 1;"},
+				{File: "testdata/seed.sql"},
+			},
+			expectedErr: ErrInvalidInitScript,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := &PostgreSQL{InitScripts: tc.initScripts}
+
+			err := postgres.validateInitScripts()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateLocalInitScriptsConfigMap(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	file := filepath.Join(t.TempDir(), "seed.sql")
+	assert.NoError(t, os.WriteFile(file, []byte("INSERT INTO t VALUES (1);"), 0o600))
+
+	t.Run("inline and file scripts", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			DatabaseName: "test-database",
+			InitScripts: []InitScript{
+				{Name: "schema", SQL: "CREATE TABLE t (id INT);"},
+				{File: file},
+			},
+		}
+
+		res, err := postgres.generateLocalInitScriptsConfigMap(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"00-schema.sql": "CREATE TABLE t (id INT);",
+			"01-seed.sql":   "INSERT INTO t VALUES (1);",
+		}, res.Attributes["data"])
+	})
+
+	t.Run("missing script file", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			DatabaseName: "test-database",
+			InitScripts:  []InitScript{{File: filepath.Join(t.TempDir(), "missing.sql")}},
+		}
+
+		_, err := postgres.generateLocalInitScriptsConfigMap(r)
+
+		assert.ErrorIs(t, err, ErrInvalidInitScript)
+	})
+}
+
+func TestPostgreSQLModule_GenerateLocalPodSpecWithInitScripts(t *testing.T) {
+	postgres := &PostgreSQL{
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		InitScripts:  []InitScript{{Name: "schema", SQL: "CREATE TABLE t (id INT);"}},
+	}
+
+	res, err := postgres.generateLocalPodSpec(nil)
+
+	assert.NoError(t, err)
+	assert.Contains(t, res.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      localInitScriptsVolume,
+		MountPath: localInitScriptsPath,
+		ReadOnly:  true,
+	})
+	assert.Equal(t, "test-database"+localInitScriptsSuffix, res.Volumes[1].ConfigMap.Name)
+}
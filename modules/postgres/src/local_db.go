@@ -1,9 +1,9 @@
 package main
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"kusionstack.io/kusion-module-framework/pkg/module"
 
@@ -18,22 +18,60 @@ import (
 func (postgres *PostgreSQL) GenerateLocalResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
 	var resources []kusionapiv1.Resource
 
-	// Build Kubernetes Secret for the random password of the local PostgreSQL instance.
-	password := postgres.generateLocalPassword(request)
-	localSecret, err := postgres.generateLocalSecret(request, password)
+	// Build random_password resource for the local PostgreSQL instance.
+	randomPasswordRes, randomPasswordID, err := postgres.GenerateTFRandomPassword(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *randomPasswordRes)
+
+	// Build random_password resources for the application users of the local PostgreSQL instance.
+	userPasswordResources, userPasswordIDs, err := postgres.generateUserRandomPasswords()
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, userPasswordResources...)
+
+	var userPasswords []string
+	for _, id := range userPasswordIDs {
+		userPasswords = append(userPasswords, module.KusionPathDependency(id, "result"))
+	}
+
+	// Build Kubernetes Secret for the random passwords of the local PostgreSQL instance.
+	password := module.KusionPathDependency(randomPasswordID, "result")
+	localSecret, err := postgres.generateLocalSecret(request, password, userPasswords)
 	if err != nil {
 		return nil, nil, err
 	}
 	resources = append(resources, *localSecret)
 
-	// Build Kubernetes Deployment for the local PostgreSQL instance.
-	localDeployment, err := postgres.generateLocalDeployment(request)
+	// Build Kubernetes ConfigMap for the init scripts of the local PostgreSQL instance if declared.
+	if postgres.hasLocalInitScripts() {
+		localInitScripts, err := postgres.generateLocalInitScriptsConfigMap(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *localInitScripts)
+	}
+
+	// Build Kubernetes ConfigMap for the configuration file with the engine parameters if declared.
+	if len(postgres.generateParameters()) > 0 {
+		localConfig, err := postgres.generateLocalConfigMap(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *localConfig)
+	}
+
+	// Build Kubernetes StatefulSet for the local PostgreSQL instance.
+	localStatefulSet, err := postgres.generateLocalStatefulSet(request)
 	if err != nil {
 		return nil, nil, err
 	}
-	resources = append(resources, *localDeployment)
+	resources = append(resources, *localStatefulSet)
 
-	// Build Kubernetes Persistent Volume Claim for the lcoal PostgreSQL instance.
+	// Build Kubernetes Persistent Volume Claim for the local PostgreSQL instance, which keeps the name
+	// of the claim used by the former Deployment so that the existing data survives the upgrade.
 	localPVC, err := postgres.generateLocalPVC(request)
 	if err != nil {
 		return nil, nil, err
@@ -47,9 +85,56 @@ func (postgres *PostgreSQL) GenerateLocalResources(request *module.GeneratorRequ
 	}
 	resources = append(resources, *localSvc)
 
+	// Build Kubernetes CronJob dumping the local PostgreSQL instance on schedule if declared.
+	if postgres.Backup != nil {
+		backupResources, err := postgres.generateLocalBackupResources(request, hostAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, backupResources...)
+	}
+
+	// Build Kubernetes StatefulSet and Service for the streaming replicas of the local PostgreSQL instance if declared.
+	var readHostAddresses []string
+	if postgres.Replicas > 0 {
+		replicaResources, readHostAddress, err := postgres.generateLocalReplicaResources(request, hostAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, replicaResources...)
+		readHostAddresses = append(readHostAddresses, readHostAddress)
+	}
+
+	// Build Kubernetes Deployment and Service for the connection pooler of the local PostgreSQL instance if declared,
+	// through which the workload connects to the instance.
+	dbHostAddress := hostAddress
+	if postgres.Pooler != nil {
+		poolerResources, poolerHostAddress, err := postgres.generateLocalPoolerResources(request, hostAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, poolerResources...)
+		dbHostAddress = poolerHostAddress
+	}
+
+	// Build Kubernetes NetworkPolicy restricting the connections to the local PostgreSQL instance if enabled.
+	if postgres.NetworkPolicy != nil && postgres.NetworkPolicy.Enabled {
+		networkPolicy, err := postgres.generateLocalNetworkPolicy(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *networkPolicy)
+	}
+
+	// Inject the credentials of the first application user into the workload if declared.
+	username := postgres.Username
+	if len(postgres.Users) > 0 {
+		username, password = postgres.Users[0].Name, userPasswords[0]
+	}
+
 	// Build Kubernetes Secret with the hostAddress, username and password of the local PostgreSQL instance,
 	// and inject the credentials as the environment variable patcher.
-	dbSecret, patcher, err := postgres.GenerateDBSecret(request, hostAddress, postgres.Username, password)
+	dbSecret, patcher, err := postgres.GenerateDBSecret(request, dbHostAddress, username, password, readHostAddresses)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -59,12 +144,25 @@ func (postgres *PostgreSQL) GenerateLocalResources(request *module.GeneratorRequ
 }
 
 // generateLocalSecret generates the Kubernetes Secret resource for the local PostgreSQL instance.
-func (postgres *PostgreSQL) generateLocalSecret(request *module.GeneratorRequest, password string) (*kusionapiv1.Resource, error) {
-	// Set the password string.
+func (postgres *PostgreSQL) generateLocalSecret(request *module.GeneratorRequest, password string, userPasswords []string) (
+	*kusionapiv1.Resource, error,
+) {
+	return postgres.generateCredentialsSecret(request, postgres.DatabaseName+localSecretSuffix, password, userPasswords)
+}
+
+// generateCredentialsSecret generates the Kubernetes Secret resource holding the credentials of the administrator
+// and the passwords of the application users of the PostgreSQL instance.
+func (postgres *PostgreSQL) generateCredentialsSecret(request *module.GeneratorRequest, name, password string,
+	userPasswords []string,
+) (*kusionapiv1.Resource, error) {
+	// Set the password strings of the administrator and the application users.
 	data := make(map[string]string)
 	data["password"] = password
 	data["username"] = postgres.Username
-	data["database"] = postgres.DatabaseName
+	data["database"] = postgres.generateLogicalDBName()
+	for i, userPassword := range userPasswords {
+		data[generateUserPasswordKey(postgres.Users[i])] = userPassword
+	}
 
 	// Construct the Kubernetes Secret resource.
 	secret := &v1.Secret{
@@ -73,7 +171,7 @@ func (postgres *PostgreSQL) generateLocalSecret(request *module.GeneratorRequest
 			APIVersion: v1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      postgres.DatabaseName + localSecretSuffix,
+			Name:      name,
 			Namespace: request.Project,
 		},
 		StringData: data,
@@ -88,39 +186,44 @@ func (postgres *PostgreSQL) generateLocalSecret(request *module.GeneratorRequest
 	return resource, nil
 }
 
-// generateLocalDeployment generates the Kubernetes Deployment resource for the local PostgreSQL instance.
-func (postgres *PostgreSQL) generateLocalDeployment(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+// generateLocalStatefulSet generates the Kubernetes StatefulSet resource for the local PostgreSQL instance.
+func (postgres *PostgreSQL) generateLocalStatefulSet(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
 	// Prepare the Pod Spec for the local PostgreSQL instance.
 	podSpec, err := postgres.generateLocalPodSpec(request)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 
-	// Create the Kubernetes Deployment for the local PostgreSQL instance.
-	deployment := &appsv1.Deployment{
+	// Create the Kubernetes StatefulSet for the local PostgreSQL instance, which only runs
+	// a single pod with the persistent volume claim mounted.
+	replicas := int32(1)
+	statefulSet := &appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
-			Kind:       "Deployment",
+			Kind:       "StatefulSet",
 			APIVersion: appsv1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      postgres.DatabaseName + localDeploymentSuffix,
+			Name:      postgres.DatabaseName + localStatefulSetSuffix,
 			Namespace: request.Project,
 		},
-		Spec: appsv1.DeploymentSpec{
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: postgres.DatabaseName + localServiceSuffix,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: postgres.generateLocalMatchLabels(),
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: postgres.generateLocalMatchLabels(),
+					Labels:      postgres.generateLocalMatchLabels(),
+					Annotations: postgres.generateLocalPodAnnotations(),
 				},
 				Spec: podSpec,
 			},
 		},
 	}
 
-	resourceID := module.KubernetesResourceID(deployment.TypeMeta, deployment.ObjectMeta)
-	resource, err := module.WrapK8sResourceToKusionResource(resourceID, deployment)
+	resourceID := module.KubernetesResourceID(statefulSet.TypeMeta, statefulSet.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, statefulSet)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +233,7 @@ func (postgres *PostgreSQL) generateLocalDeployment(request *module.GeneratorReq
 
 // generateLocalPodSpec generates the Kubernetes PodSpec for the local PostgreSQL instance.
 func (postgres *PostgreSQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1.PodSpec, error) {
-	image := dbEngine + ":" + postgres.Version
+	image := postgres.generateLocalImage()
 	secretName := postgres.DatabaseName + localSecretSuffix
 
 	var portName string
@@ -142,7 +245,14 @@ func (postgres *PostgreSQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1
 	ports := []v1.ContainerPort{
 		{
 			Name:          portName,
-			ContainerPort: int32(5432),
+			ContainerPort: int32(dbPort),
+		},
+	}
+
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      postgres.DatabaseName,
+			MountPath: "/var/lib/postgresql/data",
 		},
 	}
 
@@ -156,11 +266,38 @@ func (postgres *PostgreSQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1
 			},
 		},
 	}
-	volumeMounts := []v1.VolumeMount{
-		{
-			Name:      postgres.DatabaseName,
-			MountPath: "/var/lib/postgresql/data",
-		},
+
+	// The init scripts are mounted into the directory executed by the image entrypoint, which only
+	// runs them on the first boot with an empty data directory.
+	if postgres.hasLocalInitScripts() {
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      localInitScriptsVolume,
+			MountPath: localInitScriptsPath,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, v1.Volume{
+			Name: localInitScriptsVolume,
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: postgres.DatabaseName + localInitScriptsSuffix,
+					},
+				},
+			},
+		})
+	}
+
+	// The configuration file with the engine parameters is read by postgres on every start.
+	if len(postgres.generateParameters()) > 0 {
+		configVolume, configVolumeMount := postgres.generateLocalConfigVolume()
+		volumeMounts = append(volumeMounts, configVolumeMount)
+		volumes = append(volumes, configVolume)
+	}
+
+	// The image entrypoint runs postgres with the configuration file passed in the arguments.
+	var args []string
+	if len(postgres.generateParameters()) > 0 {
+		args = append([]string{dbEngine}, postgres.generateLocalConfigArgs()...)
 	}
 
 	env := []v1.EnvVar{
@@ -199,23 +336,136 @@ func (postgres *PostgreSQL) generateLocalPodSpec(_ *module.GeneratorRequest) (v1
 		},
 	}
 
+	for i, user := range postgres.Users {
+		env = append(env, v1.EnvVar{
+			Name: generateUserPasswordEnv(i),
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secretName,
+					},
+					Key: generateUserPasswordKey(user),
+				},
+			},
+		})
+	}
+
+	resources, err := postgres.generateLocalResourceRequirements()
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+
+	// The local PostgreSQL instance is regarded as ready only when it accepts connections
+	// via the TCP port, and the startup probe allows a long initialization on first boot.
 	podSpec := v1.PodSpec{
 		Containers: []v1.Container{
 			{
 				Name:         postgres.DatabaseName,
 				Image:        image,
+				Args:         args,
 				Env:          env,
 				Ports:        ports,
 				VolumeMounts: volumeMounts,
+				Lifecycle:    postgres.generateLocalLifecycle(),
+				Resources:    resources,
+				ReadinessProbe: &v1.Probe{
+					ProbeHandler:     postgres.generateLocalProbeHandler(),
+					PeriodSeconds:    5,
+					TimeoutSeconds:   5,
+					FailureThreshold: 3,
+				},
+				LivenessProbe: &v1.Probe{
+					ProbeHandler:     postgres.generateLocalProbeHandler(),
+					PeriodSeconds:    10,
+					TimeoutSeconds:   5,
+					FailureThreshold: 6,
+				},
+				StartupProbe: &v1.Probe{
+					ProbeHandler:     postgres.generateLocalProbeHandler(),
+					PeriodSeconds:    10,
+					TimeoutSeconds:   5,
+					FailureThreshold: 30,
+				},
 			},
 		},
 		Volumes: volumes,
 	}
 
+	// The exporter sidecar exposes the metrics of the local PostgreSQL instance if declared.
+	if postgres.Exporter != nil {
+		podSpec.Containers = append(podSpec.Containers, postgres.generateLocalExporterContainer())
+	}
+
 	return podSpec, nil
 }
 
+// generateLocalLifecycle generates the lifecycle hook of the local PostgreSQL instance. The password
+// of the initialized instance is only set on the first boot, so it is reset after every start for the
+// rotated password to take effect once the pod is recreated. The logical databases, application users
+// and grants are applied along with it, with the passwords expanded from the environment variables.
+func (postgres *PostgreSQL) generateLocalLifecycle() *v1.Lifecycle {
+	statements := append([]string{
+		`ALTER USER "$POSTGRES_USER" WITH PASSWORD '$POSTGRES_PASSWORD';`,
+	}, postgres.generateUserStatements()...)
+	statements = append(statements, postgres.generateDatabaseExtensionStatements()...)
+
+	// The streaming replicas connect to the instance with the replication protocol, which should be
+	// allowed explicitly in the client authentication config.
+	var hba string
+	if postgres.Replicas > 0 {
+		hba = fmt.Sprintf(`grep -qx '%[1]s' "$PGDATA/pg_hba.conf" || echo '%[1]s' >> "$PGDATA/pg_hba.conf"
+`, localReplicationHBA)
+		statements = append(statements, "SELECT pg_reload_conf();")
+	}
+
+	script := fmt.Sprintf(`until pg_isready -U "$POSTGRES_USER" -d "$POSTGRES_DB" -h 127.0.0.1; do sleep 1; done
+%spsql -v ON_ERROR_STOP=1 -U "$POSTGRES_USER" -d "$POSTGRES_DB" <<EOSQL
+%s
+EOSQL`, hba, strings.Join(statements, "\n"))
+
+	return &v1.Lifecycle{
+		PostStart: &v1.LifecycleHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{"sh", "-c", script},
+			},
+		},
+	}
+}
+
+// generateLocalProbeHandler generates the probe handler checking whether the local PostgreSQL instance
+// is alive and accepts connections.
+func (postgres *PostgreSQL) generateLocalProbeHandler() v1.ProbeHandler {
+	return v1.ProbeHandler{
+		Exec: &v1.ExecAction{
+			Command: []string{"sh", "-c", "pg_isready -U \"$POSTGRES_USER\" -d \"$POSTGRES_DB\" -h 127.0.0.1"},
+		},
+	}
+}
+
+// generateLocalResourceRequirements generates the cpu and memory requests of the local PostgreSQL instance.
+func (postgres *PostgreSQL) generateLocalResourceRequirements() (v1.ResourceRequirements, error) {
+	cpu, err := resource.ParseQuantity(postgres.CPU)
+	if err != nil {
+		return v1.ResourceRequirements{}, fmt.Errorf("invalid cpu request %q of the local postgres instance: %v", postgres.CPU, err)
+	}
+
+	memory, err := resource.ParseQuantity(postgres.Memory)
+	if err != nil {
+		return v1.ResourceRequirements{}, fmt.Errorf("invalid memory request %q of the local postgres instance: %v", postgres.Memory, err)
+	}
+
+	return v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    cpu,
+			v1.ResourceMemory: memory,
+		},
+	}, nil
+}
+
 // generateLocalPVC generates the Kubernetes Persistent Volume Claim resource for the local PostgreSQL instance.
+// The StatefulSet mounts this claim instead of claiming the volume from a volumeClaimTemplate, whose
+// claims are named after the StatefulSet and the ordinal, so that the local instances deployed by the
+// former Deployment keep the existing claim and data on upgrade.
 func (postgres *PostgreSQL) generateLocalPVC(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
 	// Create the Kubernetes PVC with the storage size of `postgres.Size`.
 	pvc := &v1.PersistentVolumeClaim{
@@ -228,16 +478,7 @@ func (postgres *PostgreSQL) generateLocalPVC(request *module.GeneratorRequest) (
 			Namespace: request.Project,
 			Labels:    postgres.generateLocalMatchLabels(),
 		},
-		Spec: v1.PersistentVolumeClaimSpec{
-			AccessModes: []v1.PersistentVolumeAccessMode{
-				v1.ReadWriteOnce,
-			},
-			Resources: v1.VolumeResourceRequirements{
-				Requests: map[v1.ResourceName]resource.Quantity{
-					v1.ResourceStorage: resource.MustParse(strconv.Itoa(postgres.Size) + "Gi"),
-				},
-			},
-		},
+		Spec: postgres.generateLocalPVCSpec(),
 	}
 
 	resourceID := module.KubernetesResourceID(pvc.TypeMeta, pvc.ObjectMeta)
@@ -249,6 +490,27 @@ func (postgres *PostgreSQL) generateLocalPVC(request *module.GeneratorRequest) (
 	return resource, nil
 }
 
+// generateLocalPVCSpec generates the Kubernetes Persistent Volume Claim spec of the local PostgreSQL instance
+// and its read replicas, with the storage size of `postgres.Size` and the storage class if declared.
+func (postgres *PostgreSQL) generateLocalPVCSpec() v1.PersistentVolumeClaimSpec {
+	spec := v1.PersistentVolumeClaimSpec{
+		AccessModes: []v1.PersistentVolumeAccessMode{
+			v1.ReadWriteOnce,
+		},
+		Resources: v1.VolumeResourceRequirements{
+			Requests: map[v1.ResourceName]resource.Quantity{
+				v1.ResourceStorage: resource.MustParse(strconv.Itoa(postgres.Size) + "Gi"),
+			},
+		},
+	}
+
+	if postgres.StorageClass != "" {
+		spec.StorageClassName = &postgres.StorageClass
+	}
+
+	return spec
+}
+
 // generateLocalService generates the Kubernetes Service resource for the local PostgreSQL instance.
 func (postgres *PostgreSQL) generateLocalService(request *module.GeneratorRequest) (*kusionapiv1.Resource, string, error) {
 	// Prepare the service port for the local PostgreSQL instance.
@@ -286,10 +548,16 @@ func (postgres *PostgreSQL) generateLocalService(request *module.GeneratorReques
 func (postgres *PostgreSQL) generateLocalSvcPort() []v1.ServicePort {
 	svcPort := []v1.ServicePort{
 		{
-			Port: int32(5432),
+			Port: int32(dbPort),
 		},
 	}
 
+	// The ports of the Service are named once the metrics port of the exporter sidecar is exposed.
+	if postgres.Exporter != nil {
+		svcPort[0].Name = dbEngine
+		svcPort = append(svcPort, postgres.generateExporterSvcPort())
+	}
+
 	return svcPort
 }
 
@@ -299,13 +567,3 @@ func (postgres *PostgreSQL) generateLocalMatchLabels() map[string]string {
 		"accessory": postgres.DatabaseName,
 	}
 }
-
-// generateLocalPassword generates a fixed password string with the specified length for the local PostgreSQL instance.
-func (postgres *PostgreSQL) generateLocalPassword(request *module.GeneratorRequest) string {
-	hashInput := request.Project + request.Stack + request.App + postgres.DatabaseName
-	hash := md5.Sum([]byte(hashInput))
-
-	hashString := hex.EncodeToString(hash[:])
-
-	return hashString[:16]
-}
@@ -28,15 +28,54 @@ func TestPostgreSQLModule_GenerateLocalResources(t *testing.T) {
 		SecurityIPs:    defaultSecurityIPs,
 		PrivateRouting: defaultPrivateRouting,
 		Size:           defaultSize,
+		CPU:            defaultCPU,
+		Memory:         defaultMemory,
 	}
 
 	resources, patchers, err := postgres.GenerateLocalResources(r)
 
-	assert.Equal(t, 5, len(resources))
+	assert.Equal(t, 6, len(resources))
 	assert.NotNil(t, patchers)
 	assert.NoError(t, err)
 }
 
+func TestPostgreSQLModule_GenerateLocalResourcesWithUsers(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "local",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         defaultSize,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Databases:    []string{"orders"},
+		Users: []DatabaseUser{
+			{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT"}}}},
+		},
+	}
+
+	resources, patchers, err := postgres.GenerateLocalResources(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, len(resources))
+	assert.NotNil(t, patchers)
+
+	// The workload secret holds the credentials of the application user.
+	dbSecretData := resources[6].Attributes["stringData"].(map[string]interface{})
+	assert.Equal(t, "app", dbSecretData["username"])
+	assert.Equal(t, module.KusionPathDependency(resources[1].ID, "result"), dbSecretData["password"])
+}
+
 func TestPostgreSQLModule_GenerateLocalSecret(t *testing.T) {
 	r := &module.GeneratorRequest{
 		Project: "test-project",
@@ -57,15 +96,17 @@ func TestPostgreSQLModule_GenerateLocalSecret(t *testing.T) {
 		SecurityIPs:    defaultSecurityIPs,
 		PrivateRouting: defaultPrivateRouting,
 		Size:           defaultSize,
+		CPU:            defaultCPU,
+		Memory:         defaultMemory,
 	}
 
-	res, err := postgres.generateLocalSecret(r, "123456")
+	res, err := postgres.generateLocalSecret(r, "123456", nil)
 
 	assert.NotNil(t, res)
 	assert.NoError(t, err)
 }
 
-func TestPostgreSQLModule_GenerateLocalDeployment(t *testing.T) {
+func TestPostgreSQLModule_GenerateLocalStatefulSet(t *testing.T) {
 	r := &module.GeneratorRequest{
 		Project: "test-project",
 		Stack:   "test-stack",
@@ -85,9 +126,11 @@ func TestPostgreSQLModule_GenerateLocalDeployment(t *testing.T) {
 		SecurityIPs:    defaultSecurityIPs,
 		PrivateRouting: defaultPrivateRouting,
 		Size:           defaultSize,
+		CPU:            defaultCPU,
+		Memory:         defaultMemory,
 	}
 
-	res, err := postgres.generateLocalDeployment(r)
+	res, err := postgres.generateLocalStatefulSet(r)
 
 	assert.NotNil(t, res)
 	assert.NoError(t, err)
@@ -113,14 +156,38 @@ func TestPostgreSQLModule_GenerateLocalPodSpec(t *testing.T) {
 		SecurityIPs:    defaultSecurityIPs,
 		PrivateRouting: defaultPrivateRouting,
 		Size:           defaultSize,
+		CPU:            defaultCPU,
+		Memory:         defaultMemory,
 	}
 
 	res, err := postgres.generateLocalPodSpec(r)
 
 	assert.NotNil(t, res)
+	assert.NotNil(t, res.Containers[0].ReadinessProbe)
+	assert.NotNil(t, res.Containers[0].LivenessProbe)
+	assert.NotNil(t, res.Containers[0].StartupProbe)
+	assert.NotNil(t, res.Containers[0].Lifecycle.PostStart)
 	assert.NoError(t, err)
 }
 
+func TestPostgreSQLModule_GenerateLocalPVCSpec(t *testing.T) {
+	postgres := &PostgreSQL{
+		Type:           "local",
+		Version:        "14.0",
+		DatabaseName:   "test-database",
+		Username:       defaultUsername,
+		Category:       defaultCategory,
+		SecurityIPs:    defaultSecurityIPs,
+		PrivateRouting: defaultPrivateRouting,
+		Size:           defaultSize,
+		StorageClass:   "test-storage-class",
+	}
+
+	spec := postgres.generateLocalPVCSpec()
+
+	assert.Equal(t, "test-storage-class", *spec.StorageClassName)
+}
+
 func TestPostgreSQLModule_GenerateLocalPVC(t *testing.T) {
 	r := &module.GeneratorRequest{
 		Project: "test-project",
@@ -133,20 +200,64 @@ func TestPostgreSQLModule_GenerateLocalPVC(t *testing.T) {
 	}
 
 	postgres := &PostgreSQL{
-		Type:           "local",
-		Version:        "14.0",
-		DatabaseName:   "test-database",
-		Username:       defaultUsername,
-		Category:       defaultCategory,
-		SecurityIPs:    defaultSecurityIPs,
-		PrivateRouting: defaultPrivateRouting,
-		Size:           defaultSize,
+		Type:         "local",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         defaultSize,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
 	}
 
-	res, err := postgres.generateLocalPVC(r)
-
-	assert.NotNil(t, res)
+	resources, _, err := postgres.GenerateLocalResources(r)
 	assert.NoError(t, err)
+
+	// The claim keeps the resource ID of the one used by the former Deployment, so that
+	// the existing local instances are upgraded to the StatefulSet without losing the data.
+	var pvc, statefulSet *kusionapiv1.Resource
+	for i := range resources {
+		switch resources[i].ID {
+		case "v1:PersistentVolumeClaim:test-project:test-database-db-local-pvc":
+			pvc = &resources[i]
+		case "apps/v1:StatefulSet:test-project:test-database-db-local-statefulset":
+			statefulSet = &resources[i]
+		}
+	}
+	assert.NotNil(t, pvc)
+	assert.NotNil(t, statefulSet)
+
+	spec := statefulSet.Attributes["spec"].(map[string]interface{})
+	assert.NotContains(t, spec, "volumeClaimTemplates")
+	podSpec := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	volume := podSpec["volumes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "test-database", volume["name"])
+	assert.Equal(t, "test-database-db-local-pvc", volume["persistentVolumeClaim"].(map[string]interface{})["claimName"])
+}
+
+func TestPostgreSQLModule_GenerateLocalResourceRequirements(t *testing.T) {
+	t.Run("valid cpu and memory requests", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			CPU:    defaultCPU,
+			Memory: defaultMemory,
+		}
+
+		res, err := postgres.generateLocalResourceRequirements()
+
+		assert.Equal(t, defaultCPU, res.Requests.Cpu().String())
+		assert.Equal(t, defaultMemory, res.Requests.Memory().String())
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid cpu request", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			CPU:    "invalid-cpu",
+			Memory: defaultMemory,
+		}
+
+		_, err := postgres.generateLocalResourceRequirements()
+
+		assert.ErrorContains(t, err, "invalid cpu request")
+	})
 }
 
 func TestPostgreSQLModule_GenerateLocalService(t *testing.T) {
@@ -169,6 +280,8 @@ func TestPostgreSQLModule_GenerateLocalService(t *testing.T) {
 		SecurityIPs:    defaultSecurityIPs,
 		PrivateRouting: defaultPrivateRouting,
 		Size:           defaultSize,
+		CPU:            defaultCPU,
+		Memory:         defaultMemory,
 	}
 
 	res, svcName, err := postgres.generateLocalService(r)
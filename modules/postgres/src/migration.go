@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidMigrationConfig = errors.New("invalid migration config in postgres module config")
+	ErrEmptyMigrationImage    = errors.New("empty image of the migration in postgres module config")
+	ErrUnsupportedMigration   = errors.New("unsupported migration tool for postgres")
+)
+
+const (
+	FlywayTool        = "flyway"
+	LiquibaseTool     = "liquibase"
+	GolangMigrateTool = "golang-migrate"
+)
+
+var (
+	migrationJobSuffix      = "-db-migration"
+	migrationBackoffLimit   = int32(2)
+	migrateDatabaseURLEnv   = "MIGRATE_DATABASE_URL"
+	defaultMigrationsPath   = "/migrations"
+	migrationSpecHashLength = 8
+	migrationContainerName  = "migration"
+)
+
+// Migration describes the Kubernetes Job migrating the schema of the PostgreSQL database with the
+// credentials injected into the workload.
+type Migration struct {
+	// The preset of the migration tool, which can be "flyway", "liquibase" or "golang-migrate".
+	Tool string `json:"tool,omitempty" yaml:"tool,omitempty"`
+	// The image containing the migration tool and the migration files.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The command of the migration container, which overrides the one of the tool preset.
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"`
+	// The arguments of the migration container, which override the ones of the tool preset.
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+// parseMigration parses the migration block of the devConfig.
+func parseMigration(config any) (*Migration, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidMigrationConfig, config)
+	}
+
+	migration := &Migration{}
+	if err := parseConfigBlock(configMap, ErrInvalidMigrationConfig, "", map[string]configValueParser{
+		"tool":    configValue(&migration.Tool, assertConfigString),
+		"image":   configValue(&migration.Image, assertConfigString),
+		"command": configValue(&migration.Command, toConfigStringSlice),
+		"args":    configValue(&migration.Args, toConfigStringSlice),
+	}); err != nil {
+		return nil, err
+	}
+
+	return migration, nil
+}
+
+// validateMigration validates whether the image and the tool of the migration are specified and supported.
+func (postgres *PostgreSQL) validateMigration() error {
+	if postgres.Migration == nil {
+		return nil
+	}
+
+	if postgres.Migration.Image == "" {
+		return ErrEmptyMigrationImage
+	}
+
+	switch postgres.Migration.Tool {
+	case "", FlywayTool, LiquibaseTool, GolangMigrateTool:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedMigration, postgres.Migration.Tool)
+	}
+}
+
+// generateMigrationJob generates the Kubernetes Job resource running the schema migration with the
+// credentials in the database secret, whose resource is declared as the dependency of the Job. The Job
+// name is suffixed with the hash of the migration spec, so that a new Job is created once the spec
+// changes instead of updating the immutable pod template. The workload is not blocked by the Job.
+func (postgres *PostgreSQL) generateMigrationJob(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	secretName := postgres.DatabaseName + dbResSuffix
+
+	specHash, err := postgres.generateMigrationSpecHash()
+	if err != nil {
+		return nil, err
+	}
+
+	command, args := postgres.generateMigrationCommand()
+	container := v1.Container{
+		Name:    migrationContainerName,
+		Image:   postgres.Migration.Image,
+		Command: command,
+		Args:    args,
+		Env:     postgres.generateMigrationEnv(secretName),
+	}
+
+	backoffLimit := migrationBackoffLimit
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + migrationJobSuffix + "-" + specHash,
+			Namespace: request.Project,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      postgres.generateClientMatchLabels(),
+					Annotations: postgres.generatePasswordRotationAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers:    []v1.Container{container},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(job.TypeMeta, job.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, job)
+	if err != nil {
+		return nil, err
+	}
+	resource.DependsOn = []string{postgres.generateDBSecretID(request)}
+
+	return resource, nil
+}
+
+// generateMigrationSpecHash generates the short hash of the migration spec.
+func (postgres *PostgreSQL) generateMigrationSpecHash() (string, error) {
+	spec, err := json.Marshal(postgres.Migration)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(spec)
+
+	return hex.EncodeToString(sum[:])[:migrationSpecHashLength], nil
+}
+
+// generateMigrationCommand generates the command and arguments of the migration container, which
+// default to the ones of the tool preset.
+func (postgres *PostgreSQL) generateMigrationCommand() ([]string, []string) {
+	var command, args []string
+	switch postgres.Migration.Tool {
+	case FlywayTool:
+		command, args = []string{"flyway"}, []string{"migrate"}
+	case LiquibaseTool:
+		command, args = []string{"liquibase"}, []string{"update"}
+	case GolangMigrateTool:
+		command = []string{"migrate"}
+		args = []string{"-path", defaultMigrationsPath, "-database", "$(" + migrateDatabaseURLEnv + ")", "up"}
+	}
+
+	if len(postgres.Migration.Command) > 0 {
+		command = postgres.Migration.Command
+	}
+	if len(postgres.Migration.Args) > 0 {
+		args = postgres.Migration.Args
+	}
+
+	return command, args
+}
+
+// generateMigrationEnv generates the environment variables of the migration container, composed of
+// the credentials in the database secret and the connection configs of the tool preset.
+func (postgres *PostgreSQL) generateMigrationEnv(secretName string) []v1.EnvVar {
+	credentials := []struct {
+		name string
+		key  string
+	}{
+		{name: dbHostAddressEnv, key: "hostAddress"},
+		{name: dbPortEnv, key: "port"},
+		{name: dbDatabaseEnv, key: "database"},
+		{name: dbUsernameEnv, key: "username"},
+		{name: dbPasswordEnv, key: "password"},
+	}
+
+	var env []v1.EnvVar
+	for _, credential := range credentials {
+		env = append(env, v1.EnvVar{
+			Name: credential.name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: postgres.generateSecretKeySelector(secretName, credential.key),
+			},
+		})
+	}
+
+	// The connection configs are composed of the credential environment variables above, which
+	// should be placed after them for the dependent variable expansion.
+	host, port, database := "$("+dbHostAddressEnv+")", "$("+dbPortEnv+")", "$("+dbDatabaseEnv+")"
+	username, password := "$("+dbUsernameEnv+")", "$("+dbPasswordEnv+")"
+	jdbcURL := fmt.Sprintf("jdbc:postgresql://%s:%s/%s", host, port, database)
+
+	switch postgres.Migration.Tool {
+	case FlywayTool:
+		env = append(env, []v1.EnvVar{
+			{Name: "FLYWAY_URL", Value: jdbcURL},
+			{Name: "FLYWAY_USER", Value: username},
+			{Name: "FLYWAY_PASSWORD", Value: password},
+		}...)
+	case LiquibaseTool:
+		env = append(env, []v1.EnvVar{
+			{Name: "LIQUIBASE_COMMAND_URL", Value: jdbcURL},
+			{Name: "LIQUIBASE_COMMAND_USERNAME", Value: username},
+			{Name: "LIQUIBASE_COMMAND_PASSWORD", Value: password},
+		}...)
+	case GolangMigrateTool:
+		env = append(env, v1.EnvVar{
+			Name: migrateDatabaseURLEnv,
+			Value: fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s", username, password, host, port, database,
+				postgres.generateMigrationSSLMode()),
+		})
+	}
+
+	return env
+}
+
+// generateMigrationSSLMode generates the sslmode of the migration connection, as the locally deployed
+// PostgreSQL instance is not configured with TLS while the cloud provided ones support it.
+func (postgres *PostgreSQL) generateMigrationSSLMode() string {
+	if postgres.Type == LocalDBType {
+		return "disable"
+	}
+
+	return "require"
+}
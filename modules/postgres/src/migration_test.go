@@ -0,0 +1,189 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseMigration(t *testing.T) {
+	t.Run("successfully parse migration", func(t *testing.T) {
+		migration, err := parseMigration(map[string]any{
+			"tool":  "flyway",
+			"image": "test-migration:v1",
+			"args":  []any{"migrate", "-outOfOrder=true"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Migration{
+			Tool:  FlywayTool,
+			Image: "test-migration:v1",
+			Args:  []string{"migrate", "-outOfOrder=true"},
+		}, migration)
+	})
+
+	t.Run("failed to parse migration", func(t *testing.T) {
+		_, err := parseMigration("flyway")
+		assert.ErrorIs(t, err, ErrInvalidMigrationConfig)
+
+		_, err = parseMigration(map[string]any{"image": "test-migration:v1", "env": "test"})
+		assert.ErrorIs(t, err, ErrInvalidMigrationConfig)
+
+		_, err = parseMigration(map[string]any{"command": "migrate"})
+		assert.ErrorIs(t, err, ErrInvalidMigrationConfig)
+	})
+}
+
+func TestPostgreSQLModule_ValidateMigration(t *testing.T) {
+	testcases := []struct {
+		name        string
+		migration   *Migration
+		expectedErr error
+	}{
+		{
+			name:      "no migration",
+			migration: nil,
+		},
+		{
+			name:      "migration with tool preset",
+			migration: &Migration{Tool: GolangMigrateTool, Image: "test-migration:v1"},
+		},
+		{
+			name:        "empty image",
+			migration:   &Migration{Tool: FlywayTool},
+			expectedErr: ErrEmptyMigrationImage,
+		},
+		{
+			name:        "unsupported tool",
+			migration:   &Migration{Tool: "alembic", Image: "test-migration:v1"},
+			expectedErr: ErrUnsupportedMigration,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := &PostgreSQL{Migration: tc.migration}
+
+			err := postgres.validateMigration()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateMigrationJob(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		Migration: &Migration{
+			Tool:  FlywayTool,
+			Image: "test-migration:v1",
+		},
+	}
+
+	res, err := postgres.generateMigrationJob(r)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(res.ID, "batch/v1:Job:test-project:test-database"+migrationJobSuffix+"-"))
+	assert.Equal(t, []string{"v1:Secret:test-project:test-database-postgres"}, res.DependsOn)
+
+	// A new Job is generated once the migration spec changes.
+	postgres.Migration.Image = "test-migration:v2"
+	newRes, err := postgres.generateMigrationJob(r)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, res.ID, newRes.ID)
+}
+
+func TestPostgreSQLModule_GenerateMigrationJobWithExternalSecret(t *testing.T) {
+	t.Setenv(awsRegionEnv, "test-region")
+
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+	}
+
+	postgres := &PostgreSQL{
+		Type:                  "cloud",
+		Version:               "14.0",
+		DatabaseName:          "test-database",
+		Username:              defaultUsername,
+		SecurityIPs:           defaultSecurityIPs,
+		Size:                  defaultSize,
+		InstanceType:          "db.t3.micro",
+		SkipFinalSnapshot:     true,
+		BackupRetentionPeriod: defaultBackupRetentionPeriod,
+		CredentialDelivery: &CredentialDelivery{
+			Mode:            "externalSecret",
+			SecretStore:     "aws-secrets-manager",
+			SecretStoreKind: "ClusterSecretStore",
+			RefreshInterval: "1h",
+		},
+		Migration: &Migration{
+			Tool:  FlywayTool,
+			Image: "test-migration:v1",
+		},
+	}
+
+	resources, _, err := postgres.GenerateAWSResources(r)
+	assert.NoError(t, err)
+
+	res, err := postgres.generateMigrationJob(r)
+	assert.NoError(t, err)
+
+	// The Job depends on the ExternalSecret emitted instead of the Secret.
+	assert.Equal(t, []string{"external-secrets.io/v1beta1:ExternalSecret:test-project:test-database-postgres"}, res.DependsOn)
+	var ids []string
+	for _, resource := range resources {
+		ids = append(ids, resource.ID)
+	}
+	assert.Contains(t, ids, res.DependsOn[0])
+}
+
+func TestPostgreSQLModule_GenerateMigrationCommand(t *testing.T) {
+	t.Run("tool preset", func(t *testing.T) {
+		postgres := &PostgreSQL{Migration: &Migration{Tool: GolangMigrateTool}}
+
+		command, args := postgres.generateMigrationCommand()
+
+		assert.Equal(t, []string{"migrate"}, command)
+		assert.Equal(t, []string{"-path", defaultMigrationsPath, "-database", "$(MIGRATE_DATABASE_URL)", "up"}, args)
+	})
+
+	t.Run("override tool preset", func(t *testing.T) {
+		postgres := &PostgreSQL{Migration: &Migration{Tool: FlywayTool, Args: []string{"repair"}}}
+
+		command, args := postgres.generateMigrationCommand()
+
+		assert.Equal(t, []string{"flyway"}, command)
+		assert.Equal(t, []string{"repair"}, args)
+	})
+}
+
+func TestPostgreSQLModule_GenerateMigrationEnv(t *testing.T) {
+	postgres := &PostgreSQL{Type: LocalDBType, Migration: &Migration{Tool: GolangMigrateTool}}
+
+	env := postgres.generateMigrationEnv("test-secret")
+
+	assert.Equal(t, 6, len(env))
+	assert.Equal(t, "test-secret", env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, migrateDatabaseURLEnv, env[5].Name)
+	assert.Equal(t, "postgres://$(KUSION_DB_USERNAME):$(KUSION_DB_PASSWORD)@$(KUSION_DB_HOST):$(KUSION_DB_PORT)/$(KUSION_DB_DATABASE)?sslmode=disable",
+		env[5].Value)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidNetworkPolicyConfig = errors.New("invalid network policy config in postgres module config")
+
+var (
+	networkPolicySuffix = "-db-network-policy"
+	clientSuffix        = "-db-client"
+)
+
+// NetworkPolicy describes the Kubernetes NetworkPolicy isolating the local PostgreSQL instance, which only
+// accepts the connections from the pods of the owning application, the pods deployed by the module
+// itself and the pods selected by the extra labels, the same way as the securityIPs restricts the
+// cloud provided instance. It is enabled by default for the local type.
+type NetworkPolicy struct {
+	// Whether to generate the NetworkPolicy.
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// The labels of the extra pods in the namespace allowed to connect to the PostgreSQL instance, each of
+	// which selects the pods carrying all the labels.
+	AllowFrom []map[string]string `json:"allowFrom,omitempty" yaml:"allowFrom,omitempty"`
+}
+
+// parseNetworkPolicy parses the network policy of the platform config, which is either a bool enabling
+// or disabling the NetworkPolicy or a block with the extra pod labels allowed.
+func parseNetworkPolicy(config any) (*NetworkPolicy, error) {
+	networkPolicy := &NetworkPolicy{
+		Enabled: true,
+	}
+
+	if enabled, ok := toConfigBool(config); ok {
+		networkPolicy.Enabled = enabled
+		return networkPolicy, nil
+	}
+
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a bool or map but got %T", ErrInvalidNetworkPolicyConfig, config)
+	}
+
+	if err := parseConfigBlock(configMap, ErrInvalidNetworkPolicyConfig, "", map[string]configValueParser{
+		"enabled":   configValue(&networkPolicy.Enabled, toConfigBool),
+		"allowFrom": configValue(&networkPolicy.AllowFrom, parseNetworkPolicyAllowFrom),
+	}); err != nil {
+		return nil, err
+	}
+
+	return networkPolicy, nil
+}
+
+// parseNetworkPolicyAllowFrom parses the list of the extra pod labels allowed by the NetworkPolicy.
+func parseNetworkPolicyAllowFrom(config any) ([]map[string]string, bool) {
+	items, ok := config.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	allowFrom := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		labelMap, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		labels := make(map[string]string, len(labelMap))
+		for key, value := range labelMap {
+			if labels[key], ok = toConfigString(value); !ok {
+				return nil, false
+			}
+		}
+		allowFrom = append(allowFrom, labels)
+	}
+
+	return allowFrom, true
+}
+
+// validateNetworkPolicy validates whether the enabled NetworkPolicy is declared for the local PostgreSQL
+// instance, and whether the extra pod labels are not empty.
+func (postgres *PostgreSQL) validateNetworkPolicy() error {
+	networkPolicy := postgres.NetworkPolicy
+	if networkPolicy == nil || !networkPolicy.Enabled {
+		return nil
+	}
+
+	if !strings.EqualFold(postgres.Type, LocalDBType) {
+		return fmt.Errorf("%w: only supported by the %s type", ErrInvalidNetworkPolicyConfig, LocalDBType)
+	}
+
+	for _, labels := range networkPolicy.AllowFrom {
+		if len(labels) == 0 {
+			return fmt.Errorf("%w: empty labels in allowFrom", ErrInvalidNetworkPolicyConfig)
+		}
+		for key, value := range labels {
+			if key == "" || value == "" {
+				return fmt.Errorf("%w: empty label key or value in allowFrom", ErrInvalidNetworkPolicyConfig)
+			}
+		}
+	}
+
+	return nil
+}
+
+// generateLocalNetworkPolicy generates the Kubernetes NetworkPolicy resource isolating the pods of the
+// local PostgreSQL instance, along with the ones of its replicas and pooler if declared. The PostgreSQL port is
+// only allowed from the pods of the owning application, the replicas, the pooler and the backup and
+// migration Jobs, along with the extra pods declared by the platform. The metrics port of the exporter
+// sidecar is left open for Prometheus, which is usually deployed in another namespace.
+func (postgres *PostgreSQL) generateLocalNetworkPolicy(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	targets := []string{postgres.generateLocalMatchLabels()["accessory"]}
+	if postgres.Replicas > 0 {
+		targets = append(targets, postgres.generateLocalReplicaMatchLabels()["accessory"])
+	}
+	if postgres.Pooler != nil {
+		targets = append(targets, postgres.generatePoolerMatchLabels()["accessory"])
+	}
+
+	sources := []map[string]string{
+		module.UniqueAppLabels(request.Project, request.App),
+		postgres.generateClientMatchLabels(),
+	}
+	if postgres.Replicas > 0 {
+		sources = append(sources, postgres.generateLocalReplicaMatchLabels())
+	}
+	if postgres.Pooler != nil {
+		sources = append(sources, postgres.generatePoolerMatchLabels())
+	}
+	sources = append(sources, postgres.NetworkPolicy.AllowFrom...)
+
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, labels := range sources {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+		})
+	}
+
+	protocol := v1.ProtocolTCP
+	dbPortValue := intstr.FromInt32(int32(dbPort))
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{
+					Protocol: &protocol,
+					Port:     &dbPortValue,
+				},
+			},
+			From: peers,
+		},
+	}
+
+	if postgres.Exporter != nil {
+		metricsPort := intstr.FromString(exporterPortName)
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{
+					Protocol: &protocol,
+					Port:     &metricsPort,
+				},
+			},
+		})
+	}
+
+	networkPolicy := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + networkPolicySuffix,
+			Namespace: request.Project,
+			Labels:    postgres.generateLocalMatchLabels(),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "accessory",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   targets,
+					},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+			},
+			Ingress: ingress,
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(networkPolicy.TypeMeta, networkPolicy.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, networkPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateClientMatchLabels generates the labels of the backup and migration Job pods connecting to
+// the PostgreSQL instance, which are allowed by the NetworkPolicy of the local instance.
+func (postgres *PostgreSQL) generateClientMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": postgres.DatabaseName + clientSuffix,
+	}
+}
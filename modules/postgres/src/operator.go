@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var ErrInvalidOperatorConfig = errors.New("invalid operator config in postgres module config")
+
+var (
+	cnpgAPIVersion          = "postgresql.cnpg.io/v1"
+	cnpgClusterKind         = "Cluster"
+	cnpgScheduledBackupKind = "ScheduledBackup"
+	cnpgImageRepository     = "ghcr.io/cloudnative-pg/postgresql"
+
+	operatorAppSecretSuffix    = "-app"
+	operatorRWServiceSuffix    = "-rw"
+	operatorROServiceSuffix    = "-ro"
+	operatorBackupSuffix       = "-db-operator-backup"
+	operatorAccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	operatorSecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+
+	defaultOperatorInstances = 3
+)
+
+// Operator describes the PostgreSQL cluster managed by the CloudNativePG operator for the operator type,
+// which replicates the instance with the streaming replication and fails over automatically.
+type Operator struct {
+	// The number of the PostgreSQL instances in the cluster, including the primary.
+	Instances int `json:"instances,omitempty" yaml:"instances,omitempty"`
+	// The image of the PostgreSQL instances, which defaults to the CloudNativePG image of the major version.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The continuous backups of the cluster archived to the S3 compatible object storage.
+	Backup *OperatorBackup `json:"backup,omitempty" yaml:"backup,omitempty"`
+}
+
+// OperatorBackup describes the base backups of the cluster taken on schedule, which are archived along
+// with the WAL files by Barman Cloud for the point-in-time recovery.
+type OperatorBackup struct {
+	// The schedule of the base backups in the cron format, such as "0 3 * * *".
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	// The days to keep the backups, after which they are deleted.
+	Retention int `json:"retention,omitempty" yaml:"retention,omitempty"`
+	// The S3 compatible object storage the backups are archived to.
+	S3 *BackupS3 `json:"s3,omitempty" yaml:"s3,omitempty"`
+}
+
+// defaultOperator returns the cluster settings used when the operator block is not declared.
+func defaultOperator() *Operator {
+	return &Operator{
+		Instances: defaultOperatorInstances,
+	}
+}
+
+// parseOperator parses the operator block of the platform config.
+func parseOperator(config any) (*Operator, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidOperatorConfig, config)
+	}
+
+	operator := defaultOperator()
+	if err := parseConfigBlock(configMap, ErrInvalidOperatorConfig, "", map[string]configValueParser{
+		"instances": configValue(&operator.Instances, toConfigInt),
+		"image":     configValue(&operator.Image, toConfigString),
+		"backup": func(value any) (err error) {
+			operator.Backup, err = parseOperatorBackup(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return operator, nil
+}
+
+// parseOperatorBackup parses the backup block of the operator.
+func parseOperatorBackup(config any) (*OperatorBackup, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map of backup but got %T", ErrInvalidOperatorConfig, config)
+	}
+
+	backup := &OperatorBackup{Retention: defaultBackupRetention}
+	if err := parseConfigBlock(configMap, ErrInvalidOperatorConfig, "backup.", map[string]configValueParser{
+		"schedule":  configValue(&backup.Schedule, toConfigString),
+		"retention": configValue(&backup.Retention, toConfigInt),
+		"s3": func(value any) (err error) {
+			backup.S3, err = parseBackupS3(value)
+			return err
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// validateOperator validates whether the cluster is declared with the operator type, and whether the
+// instances and the backups are valid. Only the logical database owned by the workload user is created
+// by the operator, so the configs provisioning the other objects inside the instance are not supported.
+func (postgres *PostgreSQL) validateOperator() error {
+	operator := postgres.Operator
+	if operator == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(postgres.Type, OperatorDBType) {
+		return fmt.Errorf("%w: only supported by the %s type", ErrInvalidOperatorConfig, OperatorDBType)
+	}
+
+	if operator.Instances < 1 {
+		return fmt.Errorf("%w: instances should be positive", ErrInvalidOperatorConfig)
+	}
+
+	if len(postgres.Databases) > 1 || len(postgres.Users) > 0 || len(postgres.InitScripts) > 0 || postgres.Replicas > 0 {
+		return fmt.Errorf("%w: databases, users, initScripts and replicas are not supported by the %s type",
+			ErrInvalidOperatorConfig, OperatorDBType)
+	}
+
+	if backup := operator.Backup; backup != nil {
+		if !isValidBackupSchedule(backup.Schedule) {
+			return fmt.Errorf("%w: invalid backup schedule %q", ErrInvalidOperatorConfig, backup.Schedule)
+		}
+		if backup.Retention <= 0 {
+			return fmt.Errorf("%w: backup retention should be positive", ErrInvalidOperatorConfig)
+		}
+		if backup.S3 == nil || backup.S3.Bucket == "" || backup.S3.CredentialsSecret == "" {
+			return fmt.Errorf("%w: bucket and credentialsSecret of backup s3 should be specified", ErrInvalidOperatorConfig)
+		}
+		if backup.S3.Region != "" {
+			return fmt.Errorf("%w: region of backup s3 is not supported by the %s type", ErrInvalidOperatorConfig,
+				OperatorDBType)
+		}
+	}
+
+	return nil
+}
+
+// GenerateOperatorResources generates the CloudNativePG Cluster resource along with the ScheduledBackup
+// if declared, and injects the credentials of the secret generated by the operator into the workload
+// with the host addresses of the read-write and read-only Services created by the operator.
+func (postgres *PostgreSQL) GenerateOperatorResources(request *module.GeneratorRequest) ([]kusionapiv1.Resource, *kusionapiv1.Patcher, error) {
+	var resources []kusionapiv1.Resource
+
+	// Build Cluster resource reconciled by the CloudNativePG operator.
+	cluster, err := postgres.generateCNPGCluster(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *cluster)
+
+	// Build ScheduledBackup resource taking the base backups of the cluster on schedule if declared.
+	if postgres.Operator.Backup != nil {
+		scheduledBackup, err := postgres.generateCNPGScheduledBackup(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, *scheduledBackup)
+	}
+
+	// The replicas of the cluster serve the read traffic through the read-only Service.
+	hostAddress := postgres.DatabaseName + operatorRWServiceSuffix
+	var readHostAddresses []string
+	if postgres.Operator.Instances > 1 {
+		readHostAddresses = append(readHostAddresses, postgres.DatabaseName+operatorROServiceSuffix)
+	}
+
+	// Build Kubernetes Secret with the hostAddress of the cluster, and inject the credentials referenced
+	// from the secret generated by the operator as the environment variable patcher.
+	dbSecret, patcher, err := postgres.GenerateDBSecret(request, hostAddress, "", "", readHostAddresses)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources = append(resources, *dbSecret)
+
+	return resources, patcher, nil
+}
+
+// generateOperatorSecretRef generates the reference to the username or password in the secret of the
+// workload user generated by the operator.
+func (postgres *PostgreSQL) generateOperatorSecretRef(key string) (*v1.SecretKeySelector, bool) {
+	if postgres.Operator == nil || (key != "username" && key != "password") {
+		return nil, false
+	}
+
+	return &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{
+			Name: postgres.DatabaseName + operatorAppSecretSuffix,
+		},
+		Key: key,
+	}, true
+}
+
+// generateCNPGCluster generates the CloudNativePG Cluster resource with the instances, the storage, the
+// resource requests and the engine parameters of the PostgreSQL instance, which bootstraps the logical
+// database owned by the workload user.
+func (postgres *PostgreSQL) generateCNPGCluster(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	storage := map[string]any{
+		"size": fmt.Sprintf("%dGi", postgres.Size),
+	}
+	if postgres.StorageClass != "" {
+		storage["storageClass"] = postgres.StorageClass
+	}
+
+	spec := map[string]any{
+		"instances": int64(postgres.Operator.Instances),
+		"imageName": postgres.generateOperatorImage(),
+		"storage":   storage,
+		"resources": map[string]any{
+			"requests": map[string]any{
+				"cpu":    postgres.CPU,
+				"memory": postgres.Memory,
+			},
+		},
+		"bootstrap": map[string]any{
+			"initdb": map[string]any{
+				"database": postgres.generateLogicalDBName(),
+				"owner":    postgres.Username,
+			},
+		},
+	}
+
+	if len(postgres.Parameters) > 0 {
+		parameters := make(map[string]any, len(postgres.Parameters))
+		for name, value := range postgres.Parameters {
+			parameters[name] = value
+		}
+		spec["postgresql"] = map[string]any{
+			"parameters": parameters,
+		}
+	}
+
+	if backup := postgres.Operator.Backup; backup != nil {
+		barmanObjectStore := map[string]any{
+			"destinationPath": postgres.generateOperatorBackupPath(),
+			"s3Credentials": map[string]any{
+				"accessKeyId": map[string]any{
+					"name": backup.S3.CredentialsSecret,
+					"key":  operatorAccessKeyIDKey,
+				},
+				"secretAccessKey": map[string]any{
+					"name": backup.S3.CredentialsSecret,
+					"key":  operatorSecretAccessKeyKey,
+				},
+			},
+		}
+		if backup.S3.Endpoint != "" {
+			barmanObjectStore["endpointURL"] = backup.S3.Endpoint
+		}
+
+		spec["backup"] = map[string]any{
+			"retentionPolicy":   fmt.Sprintf("%dd", backup.Retention),
+			"barmanObjectStore": barmanObjectStore,
+		}
+	}
+
+	cluster := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": cnpgAPIVersion,
+			"kind":       cnpgClusterKind,
+			"metadata": map[string]any{
+				"name":      postgres.DatabaseName,
+				"namespace": request.Project,
+			},
+			"spec": spec,
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(metav1.TypeMeta{
+		Kind:       cnpgClusterKind,
+		APIVersion: cnpgAPIVersion,
+	}, metav1.ObjectMeta{
+		Name:      postgres.DatabaseName,
+		Namespace: request.Project,
+	})
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateCNPGScheduledBackup generates the CloudNativePG ScheduledBackup resource, whose schedule is
+// prefixed with the seconds field required by the operator.
+func (postgres *PostgreSQL) generateCNPGScheduledBackup(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	schedule := postgres.Operator.Backup.Schedule
+	if !strings.HasPrefix(schedule, "@") {
+		schedule = "0 " + schedule
+	}
+
+	name := postgres.DatabaseName + operatorBackupSuffix
+	scheduledBackup := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": cnpgAPIVersion,
+			"kind":       cnpgScheduledBackupKind,
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": request.Project,
+			},
+			"spec": map[string]any{
+				"schedule":             schedule,
+				"backupOwnerReference": "self",
+				"cluster": map[string]any{
+					"name": postgres.DatabaseName,
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(metav1.TypeMeta{
+		Kind:       cnpgScheduledBackupKind,
+		APIVersion: cnpgAPIVersion,
+	}, metav1.ObjectMeta{
+		Name:      name,
+		Namespace: request.Project,
+	})
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, scheduledBackup)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateOperatorImage generates the image of the PostgreSQL instances, which defaults to the
+// CloudNativePG image tagged with the major version.
+func (postgres *PostgreSQL) generateOperatorImage() string {
+	if postgres.Operator.Image != "" {
+		return postgres.Operator.Image
+	}
+
+	major, _, _ := strings.Cut(postgres.Version, ".")
+
+	return cnpgImageRepository + ":" + major
+}
+
+// generateOperatorBackupPath generates the destination path of the backups in the bucket, whose key
+// prefix defaults to the name of the instance.
+func (postgres *PostgreSQL) generateOperatorBackupPath() string {
+	s3 := postgres.Operator.Backup.S3
+	prefix := strings.Trim(s3.Prefix, "/")
+	if prefix == "" {
+		prefix = postgres.DatabaseName
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s3.Bucket, prefix)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseOperator(t *testing.T) {
+	operator, err := parseOperator(map[string]any{
+		"instances": "2",
+		"backup": map[string]any{
+			"schedule": "0 3 * * *",
+			"s3": map[string]any{
+				"bucket":            "backups",
+				"credentialsSecret": "s3-credentials",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Operator{
+		Instances: 2,
+		Backup: &OperatorBackup{
+			Schedule:  "0 3 * * *",
+			Retention: 7,
+			S3:        &BackupS3{Bucket: "backups", CredentialsSecret: "s3-credentials"},
+		},
+	}, operator)
+
+	_, err = parseOperator(true)
+	assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+
+	_, err = parseOperator(map[string]any{"kind": "Cluster"})
+	assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+
+	_, err = parseOperator(map[string]any{"backup": map[string]any{"retention": "30d"}})
+	assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+}
+
+func TestPostgreSQLModule_ValidateOperator(t *testing.T) {
+	testcases := []struct {
+		name     string
+		postgres *PostgreSQL
+		success  bool
+	}{
+		{
+			name:     "no operator",
+			postgres: &PostgreSQL{Type: "local"},
+			success:  true,
+		},
+		{
+			name: "valid operator",
+			postgres: &PostgreSQL{
+				Type:      "operator",
+				Databases: []string{"orders"},
+				Operator: &Operator{
+					Instances: 3,
+					Backup: &OperatorBackup{
+						Schedule:  "@daily",
+						Retention: 30,
+						S3:        &BackupS3{Bucket: "backups", CredentialsSecret: "s3-credentials"},
+					},
+				},
+			},
+			success: true,
+		},
+		{
+			name: "operator of local database",
+			postgres: &PostgreSQL{
+				Type:     "local",
+				Operator: defaultOperator(),
+			},
+			success: false,
+		},
+		{
+			name: "invalid instances",
+			postgres: &PostgreSQL{
+				Type:     "operator",
+				Operator: &Operator{},
+			},
+			success: false,
+		},
+		{
+			name: "operator with replicas",
+			postgres: &PostgreSQL{
+				Type:     "operator",
+				Replicas: 1,
+				Operator: defaultOperator(),
+			},
+			success: false,
+		},
+		{
+			name: "invalid backup retention",
+			postgres: &PostgreSQL{
+				Type: "operator",
+				Operator: &Operator{
+					Instances: 3,
+					Backup: &OperatorBackup{
+						Schedule: "0 3 * * *",
+						S3:       &BackupS3{Bucket: "backups", CredentialsSecret: "s3-credentials"},
+					},
+				},
+			},
+			success: false,
+		},
+		{
+			name: "backup with s3 region",
+			postgres: &PostgreSQL{
+				Type: "operator",
+				Operator: &Operator{
+					Instances: 3,
+					Backup: &OperatorBackup{
+						Schedule:  "0 3 * * *",
+						Retention: 7,
+						S3:        &BackupS3{Bucket: "backups", Region: "us-east-1", CredentialsSecret: "s3-credentials"},
+					},
+				},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.postgres.validateOperator()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidOperatorConfig)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateOperatorResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "operator",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         defaultSize,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Operator:     defaultOperator(),
+	}
+
+	resources, patcher, err := postgres.GenerateOperatorResources(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resources))
+	assert.Equal(t, "postgresql.cnpg.io/v1:Cluster:test-project:test-database", resources[0].ID)
+
+	// The workload connects to the read-write Service with the secret generated by the operator.
+	dbSecretData := resources[1].Attributes["stringData"].(map[string]interface{})
+	assert.Equal(t, "test-database-rw", dbSecretData["hostAddress"])
+	assert.Equal(t, "test-database-ro", dbSecretData["readHostAddress"])
+	assert.NotContains(t, dbSecretData, "username")
+	assert.NotContains(t, dbSecretData, "password")
+
+	assert.Equal(t, "test-database-app", patcher.Environments[1].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "username", patcher.Environments[1].ValueFrom.SecretKeyRef.Key)
+	assert.Equal(t, "test-database-app", patcher.Environments[2].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", patcher.Environments[2].ValueFrom.SecretKeyRef.Key)
+}
+
+func TestPostgreSQLModule_GenerateCNPGCluster(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "operator",
+		Version:      "16.2",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         20,
+		CPU:          "1",
+		Memory:       "2Gi",
+		StorageClass: "standard",
+		Databases:    []string{"orders"},
+		Parameters:   map[string]string{"max_connections": "500"},
+		Operator: &Operator{
+			Instances: 3,
+			Backup: &OperatorBackup{
+				Schedule:  "0 3 * * *",
+				Retention: 30,
+				S3: &BackupS3{
+					Endpoint:          "https://minio.example.com",
+					Bucket:            "backups",
+					Prefix:            "staging/",
+					CredentialsSecret: "s3-credentials",
+				},
+			},
+		},
+	}
+
+	res, err := postgres.generateCNPGCluster(r)
+
+	assert.NoError(t, err)
+	spec := res.Attributes["spec"].(map[string]any)
+	assert.Equal(t, int64(3), spec["instances"])
+	assert.Equal(t, "ghcr.io/cloudnative-pg/postgresql:16", spec["imageName"])
+	assert.Equal(t, map[string]any{"size": "20Gi", "storageClass": "standard"}, spec["storage"])
+	assert.Equal(t, map[string]any{
+		"initdb": map[string]any{"database": "orders", "owner": "kusion_default"},
+	}, spec["bootstrap"])
+	assert.Equal(t, map[string]any{
+		"parameters": map[string]any{"max_connections": "500"},
+	}, spec["postgresql"])
+	assert.Equal(t, map[string]any{
+		"retentionPolicy": "30d",
+		"barmanObjectStore": map[string]any{
+			"destinationPath": "s3://backups/staging",
+			"endpointURL":     "https://minio.example.com",
+			"s3Credentials": map[string]any{
+				"accessKeyId":     map[string]any{"name": "s3-credentials", "key": "AWS_ACCESS_KEY_ID"},
+				"secretAccessKey": map[string]any{"name": "s3-credentials", "key": "AWS_SECRET_ACCESS_KEY"},
+			},
+		},
+	}, spec["backup"])
+}
+
+func TestPostgreSQLModule_GenerateCNPGScheduledBackup(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		Operator: &Operator{
+			Backup: &OperatorBackup{Schedule: "0 3 * * *"},
+		},
+	}
+
+	res, err := postgres.generateCNPGScheduledBackup(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "postgresql.cnpg.io/v1:ScheduledBackup:test-project:test-database-db-operator-backup", res.ID)
+	assert.Equal(t, map[string]any{
+		"schedule":             "0 0 3 * * *",
+		"backupOwnerReference": "self",
+		"cluster":              map[string]any{"name": "test-database"},
+	}, res.Attributes["spec"])
+
+	postgres.Operator.Backup.Schedule = "@daily"
+	res, err = postgres.generateCNPGScheduledBackup(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "@daily", res.Attributes["spec"].(map[string]any)["schedule"])
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidParameters     = errors.New("invalid parameters in postgres module config")
+	ErrUnsupportedParameters = errors.New("engine parameters are not supported for the postgres instance of this cloud provider")
+)
+
+var (
+	localConfigSuffix        = "-db-local-config"
+	localConfigVolume        = "config"
+	localConfigFile          = "postgresql.conf"
+	localConfigPath          = "/etc/postgresql/" + localConfigFile
+	configChecksumAnnotation = "kusionstack.io/config-checksum"
+)
+
+var parameterNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Parameter describes an engine parameter of the PostgreSQL instance.
+type Parameter struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// parseParameters parses the parameters block of the platform config, with the values of the
+// numbers and bools converted into strings.
+func parseParameters(config any) (map[string]string, error) {
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a map but got %T", ErrInvalidParameters, config)
+	}
+
+	parameters := make(map[string]string, len(configMap))
+	for name, value := range configMap {
+		str, ok := toConfigString(value)
+		if !ok {
+			b, isBool := value.(bool)
+			if !isBool {
+				return nil, fmt.Errorf("%w: value of %s should be a string, number or bool", ErrInvalidParameters, name)
+			}
+			str = fmt.Sprint(b)
+		}
+		parameters[name] = str
+	}
+
+	return parameters, nil
+}
+
+// validateParameters validates whether the parameter names are valid and the values are in a single line.
+func (postgres *PostgreSQL) validateParameters() error {
+	for name, value := range postgres.Parameters {
+		if !parameterNameRegexp.MatchString(name) {
+			return fmt.Errorf("%w: invalid parameter name %q", ErrInvalidParameters, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: value of %s should be in a single line", ErrInvalidParameters, name)
+		}
+	}
+
+	return nil
+}
+
+// generateParameters generates the engine parameters along with the one loading the libraries of the
+// declared extensions, sorted by the names.
+func (postgres *PostgreSQL) generateParameters() []Parameter {
+	return sortParameters(postgres.generateEngineParameters())
+}
+
+// sortParameters converts the engine parameters into the list sorted by the names.
+func sortParameters(values map[string]string) []Parameter {
+	parameters := make([]Parameter, 0, len(values))
+	for name, value := range values {
+		parameters = append(parameters, Parameter{Name: name, Value: value})
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		return parameters[i].Name < parameters[j].Name
+	})
+
+	return parameters
+}
+
+// generateParameterGroupFamily generates the family of the AWS parameter group from the major version
+// of the engine, such as "postgres14", which also contains the minor version before PostgreSQL 10.
+func (postgres *PostgreSQL) generateParameterGroupFamily(engine string) string {
+	parts := strings.SplitN(postgres.Version, ".", 3)
+	if major, err := strconv.Atoi(parts[0]); err == nil && major >= 10 || len(parts) < 2 {
+		return engine + parts[0]
+	}
+
+	return engine + parts[0] + "." + parts[1]
+}
+
+// generateLocalConfig generates the configuration file of the local PostgreSQL instance with the engine
+// parameters, which replaces the default one in the data directory and thus listens on all the addresses
+// as the image does.
+func (postgres *PostgreSQL) generateLocalConfig() string {
+	var sb strings.Builder
+	sb.WriteString("listen_addresses = '*'\n")
+	for _, parameter := range postgres.generateParameters() {
+		sb.WriteString(fmt.Sprintf("%s = '%s'\n", parameter.Name, strings.ReplaceAll(parameter.Value, "'", "''")))
+	}
+
+	return sb.String()
+}
+
+// generateLocalConfigArgs generates the arguments of postgres reading the configuration file.
+func (postgres *PostgreSQL) generateLocalConfigArgs() []string {
+	return []string{"-c", "config_file=" + localConfigPath}
+}
+
+// generateLocalConfigMap generates the Kubernetes ConfigMap resource holding the configuration file of
+// the local PostgreSQL instance.
+func (postgres *PostgreSQL) generateLocalConfigMap(request *module.GeneratorRequest) (*kusionapiv1.Resource, error) {
+	configMap := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + localConfigSuffix,
+			Namespace: request.Project,
+		},
+		Data: map[string]string{
+			localConfigFile: postgres.generateLocalConfig(),
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(configMap.TypeMeta, configMap.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateLocalConfigVolume generates the volume and the volume mount of the configuration file, which is
+// mounted as a single file outside the data directory initialized by the image entrypoint.
+func (postgres *PostgreSQL) generateLocalConfigVolume() (v1.Volume, v1.VolumeMount) {
+	volume := v1.Volume{
+		Name: localConfigVolume,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: postgres.DatabaseName + localConfigSuffix,
+				},
+			},
+		},
+	}
+	volumeMount := v1.VolumeMount{
+		Name:      localConfigVolume,
+		MountPath: localConfigPath,
+		SubPath:   localConfigFile,
+		ReadOnly:  true,
+	}
+
+	return volume, volumeMount
+}
+
+// generateLocalPodAnnotations generates the pod annotations of the local PostgreSQL instance, which carry
+// the checksum of the configuration file for the pods to be recreated once the parameters change, as the
+// file mounted with the sub path is not updated in place.
+func (postgres *PostgreSQL) generateLocalPodAnnotations() map[string]string {
+	annotations := postgres.generatePasswordRotationAnnotations()
+	if len(postgres.generateParameters()) == 0 {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	sum := sha256.Sum256([]byte(postgres.generateLocalConfig()))
+	annotations[configChecksumAnnotation] = hex.EncodeToString(sum[:])
+
+	return annotations
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseParameters(t *testing.T) {
+	parameters, err := parseParameters(map[string]any{
+		"max_connections":            float64(500),
+		"shared_buffers":             "256MB",
+		"log_min_duration_statement": "1s",
+		"jit":                        false,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"max_connections":            "500",
+		"shared_buffers":             "256MB",
+		"log_min_duration_statement": "1s",
+		"jit":                        "false",
+	}, parameters)
+
+	_, err = parseParameters([]any{"max_connections"})
+	assert.ErrorIs(t, err, ErrInvalidParameters)
+
+	_, err = parseParameters(map[string]any{"search_path": []any{"public"}})
+	assert.ErrorIs(t, err, ErrInvalidParameters)
+}
+
+func TestPostgreSQLModule_ValidateParameters(t *testing.T) {
+	postgres := &PostgreSQL{
+		Parameters: map[string]string{"max_connections": "500"},
+	}
+	assert.NoError(t, postgres.validateParameters())
+
+	postgres.Parameters = map[string]string{"max connections": "500"}
+	assert.ErrorIs(t, postgres.validateParameters(), ErrInvalidParameters)
+
+	postgres.Parameters = map[string]string{"max_connections": "500\nssl = off"}
+	assert.ErrorIs(t, postgres.validateParameters(), ErrInvalidParameters)
+}
+
+func TestPostgreSQLModule_GenerateParameterGroupFamily(t *testing.T) {
+	postgres := &PostgreSQL{Version: "14.10"}
+	assert.Equal(t, "postgres14", postgres.generateParameterGroupFamily("postgres"))
+
+	postgres.Version = "9.6.24"
+	assert.Equal(t, "postgres9.6", postgres.generateParameterGroupFamily("postgres"))
+
+	postgres.Version = "15.4"
+	assert.Equal(t, "aurora-postgresql15", postgres.generateParameterGroupFamily("aurora-postgresql"))
+}
+
+func TestPostgreSQLModule_GenerateLocalConfigMap(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		Parameters: map[string]string{
+			"max_connections": "500",
+			"shared_buffers":  "256MB",
+		},
+	}
+
+	res, err := postgres.generateLocalConfigMap(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1:ConfigMap:test-project:test-database-db-local-config", res.ID)
+	assert.Equal(t, map[string]any{
+		"postgresql.conf": "listen_addresses = '*'\nmax_connections = '500'\nshared_buffers = '256MB'\n",
+	}, res.Attributes["data"])
+}
+
+func TestPostgreSQLModule_GenerateLocalPodAnnotations(t *testing.T) {
+	postgres := &PostgreSQL{
+		PasswordRotation: "2024-01",
+	}
+	assert.Equal(t, map[string]string{passwordRotationAnnotation: "2024-01"}, postgres.generateLocalPodAnnotations())
+
+	postgres.Parameters = map[string]string{"max_connections": "500"}
+	annotations := postgres.generateLocalPodAnnotations()
+	assert.Len(t, annotations[configChecksumAnnotation], 64)
+
+	postgres.Parameters = map[string]string{"max_connections": "1000"}
+	assert.NotEqual(t, annotations[configChecksumAnnotation], postgres.generateLocalPodAnnotations()[configChecksumAnnotation])
+}
+
+func TestPostgreSQLModule_GenerateLocalPodSpecWithParameters(t *testing.T) {
+	postgres := &PostgreSQL{
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Parameters:   map[string]string{"max_connections": "500"},
+	}
+
+	podSpec, err := postgres.generateLocalPodSpec(nil)
+
+	assert.NoError(t, err)
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      "config",
+		MountPath: "/etc/postgresql/postgresql.conf",
+		SubPath:   "postgresql.conf",
+		ReadOnly:  true,
+	})
+	assert.Equal(t, "test-database-db-local-config", podSpec.Volumes[1].ConfigMap.Name)
+	assert.Equal(t, []string{"postgres", "-c", "config_file=/etc/postgresql/postgresql.conf"}, podSpec.Containers[0].Args)
+
+	entrypoint := postgres.generateLocalReplicaEntrypoint("test-host-address")
+	assert.Contains(t, entrypoint, `password=$PGPASSWORD" -c config_file=/etc/postgresql/postgresql.conf`)
+}
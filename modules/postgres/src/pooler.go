@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	ErrInvalidPoolerConfig = errors.New("invalid pooler config in postgres module config")
+	ErrUnsupportedPooler   = errors.New("pooler is not supported for the postgres instance of this cloud provider")
+)
+
+var (
+	poolerSuffix        = "-db-pooler"
+	poolerServiceSuffix = "-db-pooler-service"
+	poolerContainerName = "pooler"
+	poolerAuthType      = "scram-sha-256"
+
+	defaultPoolerImage                = "edoburu/pgbouncer:v1.23.1-p2"
+	defaultPoolerPoolMode             = "transaction"
+	defaultPoolerReplicas             = 1
+	defaultPoolerPoolSize             = 20
+	defaultPoolerMaxClientConnections = 1000
+	minAWSDBProxySubnets              = 2
+)
+
+// Pooler describes the connection pooler deployed in front of the PostgreSQL instance, which multiplexes
+// the connections of the workload replicas onto a limited number of server connections. It is PgBouncer
+// for the local instance and AWS RDS Proxy for the AWS provided instance, which authenticates the workload
+// with the credentials written into AWS Secrets Manager by the module.
+type Pooler struct {
+	// The number of the pooler replicas.
+	Replicas int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	// The maximum number of the server connections from the pooler to the PostgreSQL instance.
+	PoolSize int `json:"poolSize,omitempty" yaml:"poolSize,omitempty"`
+	// The maximum number of the client connections to the pooler.
+	MaxClientConnections int `json:"maxClientConnections,omitempty" yaml:"maxClientConnections,omitempty"`
+	// The pool mode of PgBouncer, which can be "session", "transaction" or "statement".
+	PoolMode string `json:"poolMode,omitempty" yaml:"poolMode,omitempty"`
+	// The image of the pooler.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	// The subnet IDs in at least two availability zones the AWS RDS Proxy is deployed in.
+	SubnetIDs []string `json:"subnetIDs,omitempty" yaml:"subnetIDs,omitempty"`
+	// The maximum percentage of the max_connections of the AWS provided instance used by the AWS RDS Proxy.
+	MaxConnectionsPercent int `json:"maxConnectionsPercent,omitempty" yaml:"maxConnectionsPercent,omitempty"`
+}
+
+// parsePooler parses the pooler of the platform config, which is either a bool enabling the pooler
+// with the default settings or a block with the settings.
+func parsePooler(config any) (*Pooler, error) {
+	pooler := &Pooler{
+		Replicas:             defaultPoolerReplicas,
+		PoolSize:             defaultPoolerPoolSize,
+		MaxClientConnections: defaultPoolerMaxClientConnections,
+		PoolMode:             defaultPoolerPoolMode,
+		Image:                defaultPoolerImage,
+	}
+
+	if enabled, ok := toConfigBool(config); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return pooler, nil
+	}
+
+	configMap, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a bool or map but got %T", ErrInvalidPoolerConfig, config)
+	}
+
+	if err := parseConfigBlock(configMap, ErrInvalidPoolerConfig, "", map[string]configValueParser{
+		"replicas":              configValue(&pooler.Replicas, toConfigInt),
+		"poolSize":              configValue(&pooler.PoolSize, toConfigInt),
+		"maxClientConnections":  configValue(&pooler.MaxClientConnections, toConfigInt),
+		"poolMode":              configValue(&pooler.PoolMode, toConfigString),
+		"image":                 configValue(&pooler.Image, toConfigString),
+		"subnetIDs":             configValue(&pooler.SubnetIDs, toConfigStringSlice),
+		"maxConnectionsPercent": configValue(&pooler.MaxConnectionsPercent, toConfigInt),
+	}); err != nil {
+		return nil, err
+	}
+
+	return pooler, nil
+}
+
+// validatePooler validates whether the pooler is deployed in front of the local PostgreSQL instance with
+// the positive replicas and connection limits and the supported pool mode, or in front of the cloud
+// provided PostgreSQL instance in the subnets of at least two availability zones.
+func (postgres *PostgreSQL) validatePooler() error {
+	pooler := postgres.Pooler
+	if pooler == nil {
+		return nil
+	}
+
+	if strings.EqualFold(postgres.Type, CloudDBType) {
+		return postgres.validateCloudPooler()
+	}
+
+	if !strings.EqualFold(postgres.Type, LocalDBType) {
+		return fmt.Errorf("%w: only supported by the %s and %s type", ErrInvalidPoolerConfig, LocalDBType, CloudDBType)
+	}
+
+	if pooler.Replicas <= 0 || pooler.PoolSize <= 0 || pooler.MaxClientConnections <= 0 {
+		return fmt.Errorf("%w: replicas, poolSize and maxClientConnections should be positive", ErrInvalidPoolerConfig)
+	}
+
+	switch pooler.PoolMode {
+	case "session", "transaction", "statement":
+	default:
+		return fmt.Errorf("%w: unsupported poolMode %q", ErrInvalidPoolerConfig, pooler.PoolMode)
+	}
+
+	if pooler.Image == "" {
+		return fmt.Errorf("%w: empty image", ErrInvalidPoolerConfig)
+	}
+
+	return nil
+}
+
+// validateCloudPooler validates whether AWS RDS Proxy is deployed in the subnets of at least two
+// availability zones, which authenticates the workload with the password instead of the IAM database
+// authentication tokens.
+func (postgres *PostgreSQL) validateCloudPooler() error {
+	pooler := postgres.Pooler
+	if len(pooler.SubnetIDs) < minAWSDBProxySubnets {
+		return fmt.Errorf("%w: at least %d subnetIDs should be declared", ErrInvalidPoolerConfig, minAWSDBProxySubnets)
+	}
+
+	if pooler.MaxConnectionsPercent < 0 || pooler.MaxConnectionsPercent > 100 {
+		return fmt.Errorf("%w: maxConnectionsPercent should be between 1 and 100", ErrInvalidPoolerConfig)
+	}
+
+	if postgres.isIAMAuth() {
+		return fmt.Errorf("%w: %s auth is not supported", ErrInvalidPoolerConfig, IAMAuth)
+	}
+
+	return nil
+}
+
+// generateLocalPoolerResources generates the Kubernetes Deployment and Service of the pooler in front
+// of the local PostgreSQL instance, and returns the host address of the pooler for the workload.
+func (postgres *PostgreSQL) generateLocalPoolerResources(request *module.GeneratorRequest, hostAddress string) (
+	[]kusionapiv1.Resource, string, error,
+) {
+	poolerDeployment, err := postgres.generateLocalPoolerDeployment(request, hostAddress)
+	if err != nil {
+		return nil, "", err
+	}
+
+	poolerService, poolerHostAddress, err := postgres.generateLocalPoolerService(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []kusionapiv1.Resource{*poolerDeployment, *poolerService}, poolerHostAddress, nil
+}
+
+// generateLocalPoolerDeployment generates the Kubernetes Deployment of the pooler, which authenticates
+// the workload with the credentials in the database secret. The PgBouncer config is rendered from the
+// environment variables by the entrypoint of the image.
+func (postgres *PostgreSQL) generateLocalPoolerDeployment(request *module.GeneratorRequest, hostAddress string) (
+	*kusionapiv1.Resource, error,
+) {
+	secretName := postgres.DatabaseName + dbResSuffix
+	probe := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			TCPSocket: &v1.TCPSocketAction{
+				Port: intstr.FromInt32(int32(dbPort)),
+			},
+		},
+		PeriodSeconds:  5,
+		TimeoutSeconds: 5,
+	}
+
+	container := v1.Container{
+		Name:  poolerContainerName,
+		Image: postgres.Pooler.Image,
+		Env: []v1.EnvVar{
+			{
+				Name:  "DB_HOST",
+				Value: hostAddress,
+			},
+			{
+				Name:  "DB_PORT",
+				Value: strconv.Itoa(dbPort),
+			},
+			{
+				Name: "DB_USER",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: postgres.generateSecretKeySelector(secretName, "username"),
+				},
+			},
+			{
+				Name: "DB_PASSWORD",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: postgres.generateSecretKeySelector(secretName, "password"),
+				},
+			},
+			{
+				Name:  "LISTEN_PORT",
+				Value: strconv.Itoa(dbPort),
+			},
+			{
+				Name:  "AUTH_TYPE",
+				Value: poolerAuthType,
+			},
+			{
+				Name:  "POOL_MODE",
+				Value: postgres.Pooler.PoolMode,
+			},
+			{
+				Name:  "MAX_CLIENT_CONN",
+				Value: strconv.Itoa(postgres.Pooler.MaxClientConnections),
+			},
+			{
+				Name:  "DEFAULT_POOL_SIZE",
+				Value: strconv.Itoa(postgres.Pooler.PoolSize),
+			},
+		},
+		Ports: []v1.ContainerPort{
+			{
+				ContainerPort: int32(dbPort),
+			},
+		},
+		ReadinessProbe: probe,
+		LivenessProbe:  probe,
+	}
+
+	replicas := int32(postgres.Pooler.Replicas)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgres.DatabaseName + poolerSuffix,
+			Namespace: request.Project,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: postgres.generatePoolerMatchLabels(),
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      postgres.generatePoolerMatchLabels(),
+					Annotations: postgres.generatePasswordRotationAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{container},
+				},
+			},
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(deployment.TypeMeta, deployment.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// generateLocalPoolerService generates the Kubernetes Service of the pooler.
+func (postgres *PostgreSQL) generateLocalPoolerService(request *module.GeneratorRequest) (*kusionapiv1.Resource, string, error) {
+	svcName := postgres.DatabaseName + poolerServiceSuffix
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svcName,
+			Namespace: request.Project,
+			Labels:    postgres.generatePoolerMatchLabels(),
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Port: int32(dbPort),
+				},
+			},
+			Selector: postgres.generatePoolerMatchLabels(),
+		},
+	}
+
+	resourceID := module.KubernetesResourceID(service.TypeMeta, service.ObjectMeta)
+	resource, err := module.WrapK8sResourceToKusionResource(resourceID, service)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resource, svcName, nil
+}
+
+// generatePoolerMatchLabels generates the match labels of the pooler, which differ from the ones of
+// the local PostgreSQL instance to keep the pooler out of the instance Service.
+func (postgres *PostgreSQL) generatePoolerMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": postgres.DatabaseName + poolerSuffix,
+	}
+}
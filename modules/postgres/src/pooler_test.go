@@ -0,0 +1,205 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParsePooler(t *testing.T) {
+	pooler, err := parsePooler(true)
+	assert.NoError(t, err)
+	assert.Equal(t, &Pooler{
+		Replicas:             1,
+		PoolSize:             20,
+		MaxClientConnections: 1000,
+		PoolMode:             "transaction",
+		Image:                "edoburu/pgbouncer:v1.23.1-p2",
+	}, pooler)
+
+	pooler, err = parsePooler("false")
+	assert.NoError(t, err)
+	assert.Nil(t, pooler)
+
+	pooler, err = parsePooler(map[string]any{"replicas": 2, "poolSize": "50"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Pooler{
+		Replicas:             2,
+		PoolSize:             50,
+		MaxClientConnections: 1000,
+		PoolMode:             "transaction",
+		Image:                "edoburu/pgbouncer:v1.23.1-p2",
+	}, pooler)
+
+	pooler, err = parsePooler(map[string]any{"subnetIDs": []any{"subnet-a", "subnet-b"}, "maxConnectionsPercent": 80})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, pooler.SubnetIDs)
+	assert.Equal(t, 80, pooler.MaxConnectionsPercent)
+
+	_, err = parsePooler(1)
+	assert.ErrorIs(t, err, ErrInvalidPoolerConfig)
+
+	_, err = parsePooler(map[string]any{"engine": "rdsProxy"})
+	assert.ErrorIs(t, err, ErrInvalidPoolerConfig)
+}
+
+func TestPostgreSQLModule_ValidatePooler(t *testing.T) {
+	testcases := []struct {
+		name     string
+		postgres *PostgreSQL
+		success  bool
+	}{
+		{
+			name:     "no pooler",
+			postgres: &PostgreSQL{Type: "cloud"},
+			success:  true,
+		},
+		{
+			name: "pooler of local database",
+			postgres: &PostgreSQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000, PoolMode: "transaction", Image: "edoburu/pgbouncer:v1.23.1-p2"},
+			},
+			success: true,
+		},
+		{
+			name: "pooler of cloud database",
+			postgres: &PostgreSQL{
+				Type:   "cloud",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a", "subnet-b"}, MaxConnectionsPercent: 80},
+			},
+			success: true,
+		},
+		{
+			name: "pooler of cloud database in single subnet",
+			postgres: &PostgreSQL{
+				Type:   "cloud",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a"}},
+			},
+			success: false,
+		},
+		{
+			name: "pooler of cloud database with invalid max connections percent",
+			postgres: &PostgreSQL{
+				Type:   "cloud",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a", "subnet-b"}, MaxConnectionsPercent: 120},
+			},
+			success: false,
+		},
+		{
+			name: "pooler of cloud database with iam auth",
+			postgres: &PostgreSQL{
+				Type:   "cloud",
+				Auth:   "iam",
+				Pooler: &Pooler{SubnetIDs: []string{"subnet-a", "subnet-b"}},
+			},
+			success: false,
+		},
+		{
+			name: "invalid pool size",
+			postgres: &PostgreSQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, MaxClientConnections: 1000, PoolMode: "transaction", Image: "edoburu/pgbouncer:v1.23.1-p2"},
+			},
+			success: false,
+		},
+		{
+			name: "unsupported pool mode",
+			postgres: &PostgreSQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000, PoolMode: "pipeline", Image: "edoburu/pgbouncer:v1.23.1-p2"},
+			},
+			success: false,
+		},
+		{
+			name: "empty image",
+			postgres: &PostgreSQL{
+				Type:   "local",
+				Pooler: &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000, PoolMode: "transaction"},
+			},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.postgres.validatePooler()
+			if tc.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidPoolerConfig)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLModule_GenerateLocalPoolerResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "local",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Pooler:       &Pooler{Replicas: 2, PoolSize: 20, MaxClientConnections: 1000, PoolMode: "transaction", Image: "edoburu/pgbouncer:v1.23.1-p2"},
+	}
+
+	resources, hostAddress, err := postgres.generateLocalPoolerResources(r, "test-database-db-local-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-database-db-pooler-service", hostAddress)
+	assert.Equal(t, 2, len(resources))
+	assert.Equal(t, "apps/v1:Deployment:test-project:test-database-db-pooler", resources[0].ID)
+	assert.Equal(t, "v1:Service:test-project:test-database-db-pooler-service", resources[1].ID)
+
+	spec := resources[0].Attributes["spec"].(map[string]any)
+	assert.Equal(t, int64(2), spec["replicas"])
+	assert.Equal(t, map[string]any{"accessory": "test-database-db-pooler"},
+		spec["selector"].(map[string]any)["matchLabels"])
+	assert.Equal(t, map[string]any{"accessory": "test-database-db-pooler"},
+		resources[1].Attributes["spec"].(map[string]any)["selector"])
+
+	container := spec["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+	assert.Equal(t, "edoburu/pgbouncer:v1.23.1-p2", container["image"])
+	env := container["env"].([]any)
+	assert.Equal(t, map[string]any{"name": "DB_HOST", "value": "test-database-db-local-service"}, env[0])
+	assert.Equal(t, "test-database-postgres",
+		env[3].(map[string]any)["valueFrom"].(map[string]any)["secretKeyRef"].(map[string]any)["name"])
+	assert.Contains(t, env, map[string]any{"name": "POOL_MODE", "value": "transaction"})
+	assert.Contains(t, env, map[string]any{"name": "DEFAULT_POOL_SIZE", "value": "20"})
+}
+
+func TestPostgreSQLModule_GenerateLocalResourcesWithPooler(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "local",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     defaultUsername,
+		Size:         defaultSize,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Pooler:       &Pooler{Replicas: 1, PoolSize: 20, MaxClientConnections: 1000, Image: "edoburu/pgbouncer:v1.23.1-p2"},
+	}
+
+	resources, _, err := postgres.GenerateLocalResources(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(resources))
+
+	// The workload connects to the instance through the pooler.
+	dbSecretData := resources[7].Attributes["stringData"].(map[string]interface{})
+	assert.Equal(t, "test-database-db-pooler-service", dbSecretData["hostAddress"])
+}
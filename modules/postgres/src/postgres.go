@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -19,28 +22,42 @@ import (
 )
 
 const (
-	CloudDBType = "cloud"
-	LocalDBType = "local"
+	CloudDBType    = "cloud"
+	LocalDBType    = "local"
+	ExternalDBType = "external"
+	OperatorDBType = "operator"
 )
 
 const (
 	dbEngine         = "postgres"
 	dbResSuffix      = "-postgres"
+	dbPort           = 5432
 	dbHostAddressEnv = "KUSION_DB_HOST"
 	dbUsernameEnv    = "KUSION_DB_USERNAME"
 	dbPasswordEnv    = "KUSION_DB_PASSWORD"
+	dbPortEnv        = "KUSION_DB_PORT"
+	dbDatabaseEnv    = "KUSION_DB_DATABASE"
+	dbReadHostEnv    = "KUSION_DB_READ_HOST"
+
+	passwordRotationAnnotation = "kusionstack.io/password-rotation"
 )
 
 var (
-	ErrEmptyInstanceTypeForCloudDB = errors.New("empty instance type for cloud managed postgres instance")
-	ErrEmptyCloudProviderType      = errors.New("empty cloud provider type in postgres module config")
+	ErrEmptyInstanceTypeForCloudDB  = errors.New("empty instance type for cloud managed postgres instance")
+	ErrEmptyCloudProviderType       = errors.New("empty cloud provider type in postgres module config")
+	ErrInvalidBackupRetentionPeriod = errors.New("backup retention period of postgres instance should be between 0 and 35 days")
+	ErrInvalidReplicas              = errors.New("replicas of postgres instance should be between 0 and 5")
+	ErrUnsupportedReplicas          = errors.New("read replicas are not supported for the postgres instance of this cloud provider")
 )
 
 var (
-	localDeploymentSuffix = "-db-local-deployment"
-	localSecretSuffix     = "-db-local-secret"
-	localPVCSuffix        = "-db-local-pvc"
-	localServiceSuffix    = "-db-local-service"
+	localStatefulSetSuffix = "-db-local-statefulset"
+	localSecretSuffix      = "-db-local-secret"
+	localPVCSuffix         = "-db-local-pvc"
+	localServiceSuffix     = "-db-local-service"
+	localInitScriptsSuffix = "-db-local-init-scripts"
+	localInitScriptsPath   = "/docker-entrypoint-initdb.d"
+	localInitScriptsVolume = "init-scripts"
 )
 
 var (
@@ -51,6 +68,14 @@ var (
 	defaultSecurityIPs    []string = []string{"0.0.0.0/0"}
 	defaultPrivateRouting bool     = true
 	defaultSize           int      = 10
+	defaultCPU            string   = "500m"
+	defaultMemory         string   = "512Mi"
+
+	defaultMultiAZ                 bool = false
+	defaultBackupRetentionPeriod   int  = 7
+	defaultDeletionProtection      bool = true
+	defaultSkipFinalSnapshot       bool = false
+	defaultAutoMinorVersionUpgrade bool = true
 )
 
 var defaultRandomProviderCfg = module.ProviderConfig{
@@ -58,7 +83,11 @@ var defaultRandomProviderCfg = module.ProviderConfig{
 	Version: "3.6.0",
 }
 
-var randomPassword = "random_password"
+var (
+	randomPassword         = "random_password"
+	randomID               = "random_id"
+	passwordRotationKeeper = "rotation"
+)
 
 // PostgreSQL describes the attributes to locally deploy or create a cloud provider
 // managed PostgreSQL database instance for the workload.
@@ -84,6 +113,98 @@ type PostgreSQL struct {
 	PrivateRouting bool `json:"privateRouting,omitempty" yaml:"privateRouting,omitempty"`
 	// The specified name of the PostgreSQL database instance.
 	DatabaseName string `json:"databaseName,omitempty" yaml:"databaseName,omitempty"`
+	// The resource group that the Azure PostgreSQL flexible server will be created in.
+	ResourceGroup string `json:"resourceGroup,omitempty" yaml:"resourceGroup,omitempty"`
+	// The private DNS zone ID of the Azure PostgreSQL flexible server in the delegated subnet.
+	PrivateDNSZoneID string `json:"privateDNSZoneID,omitempty" yaml:"privateDNSZoneID,omitempty"`
+	// Whether the AWS RDS PostgreSQL instance is deployed in multiple availability zones.
+	MultiAZ bool `json:"multiAZ,omitempty" yaml:"multiAZ,omitempty"`
+	// The days to retain the automated backups of the AWS RDS PostgreSQL instance.
+	BackupRetentionPeriod int `json:"backupRetentionPeriod,omitempty" yaml:"backupRetentionPeriod,omitempty"`
+	// The daily time range in UTC during which the automated backups are created, such as "03:00-04:00".
+	BackupWindow string `json:"backupWindow,omitempty" yaml:"backupWindow,omitempty"`
+	// The weekly time range in UTC during which the maintenance can occur, such as "sun:05:00-sun:06:00".
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty" yaml:"maintenanceWindow,omitempty"`
+	// Whether the cloud provided PostgreSQL instance is protected from being deleted, which is the deletion
+	// protection of AWS and GCP, and the management lock of the flexible server for Azure.
+	DeletionProtection bool `json:"deletionProtection,omitempty" yaml:"deletionProtection,omitempty"`
+	// Whether to skip creating the final snapshot before the AWS RDS PostgreSQL instance is deleted.
+	SkipFinalSnapshot bool `json:"skipFinalSnapshot,omitempty" yaml:"skipFinalSnapshot,omitempty"`
+	// The storage type of the AWS or Alicloud RDS PostgreSQL instance, such as "gp3" for AWS and "cloud_essd" for Alicloud.
+	StorageType string `json:"storageType,omitempty" yaml:"storageType,omitempty"`
+	// The provisioned IOPS of the AWS RDS PostgreSQL instance.
+	Iops int `json:"iops,omitempty" yaml:"iops,omitempty"`
+	// Whether the minor engine upgrades are applied automatically to the AWS RDS PostgreSQL instance.
+	AutoMinorVersionUpgrade bool `json:"autoMinorVersionUpgrade,omitempty" yaml:"autoMinorVersionUpgrade,omitempty"`
+	// The charge type of the Alicloud RDS PostgreSQL instance, which can be "Prepaid" or "Postpaid".
+	ChargeType string `json:"chargeType,omitempty" yaml:"chargeType,omitempty"`
+	// The subscription period in months of the prepaid Alicloud RDS PostgreSQL instance.
+	Period int `json:"period,omitempty" yaml:"period,omitempty"`
+	// The zones of the Alicloud RDS PostgreSQL instance, the first of which is the primary zone and the
+	// others are the secondary zones of the multi-zone instance.
+	Zones []string `json:"zones,omitempty" yaml:"zones,omitempty"`
+	// The scaling config of the Alicloud serverless RDS PostgreSQL instance.
+	Serverless *AlicloudServerless `json:"serverless,omitempty" yaml:"serverless,omitempty"`
+	// The engine parameters of the PostgreSQL instance, such as "max_connections" and "innodb_buffer_pool_size".
+	Parameters map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// The AWS Aurora PostgreSQL cluster created instead of the single AWS RDS PostgreSQL instance.
+	Aurora *AuroraCluster `json:"aurora,omitempty" yaml:"aurora,omitempty"`
+	// The cpu request of the locally deployed PostgreSQL instance.
+	CPU string `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	// The memory request of the locally deployed PostgreSQL instance.
+	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
+	// The storage class of the persistent volume for the locally deployed PostgreSQL instance.
+	StorageClass string `json:"storageClass,omitempty" yaml:"storageClass,omitempty"`
+	// The rotation trigger of the PostgreSQL password, changing it regenerates the password.
+	PasswordRotation string `json:"passwordRotation,omitempty" yaml:"passwordRotation,omitempty"`
+	// The connection string of the PostgreSQL database injected into the workload.
+	ConnectionURL *ConnectionURL `json:"connectionURL,omitempty" yaml:"connectionURL,omitempty"`
+	// The logical databases created in the PostgreSQL instance, the first of which is for the workload to connect with.
+	Databases []string `json:"databases,omitempty" yaml:"databases,omitempty"`
+	// The application users with the least privileges created in the PostgreSQL instance, the credentials
+	// of the first of which are injected into the workload.
+	Users []DatabaseUser `json:"users,omitempty" yaml:"users,omitempty"`
+	// The SQL scripts run on the first boot of the locally deployed PostgreSQL instance.
+	InitScripts []InitScript `json:"initScripts,omitempty" yaml:"initScripts,omitempty"`
+	// The extensions created in the logical databases of the PostgreSQL instance, such as "pgvector".
+	Extensions []string `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	// The number of the read replicas of the PostgreSQL instance.
+	Replicas int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	// The existing PostgreSQL database managed outside of Kusion for the external type.
+	External *ExternalDatabase `json:"external,omitempty" yaml:"external,omitempty"`
+	// The authentication of the workload connecting to the PostgreSQL instance, which can be "password" or
+	// "iam" for the IAM database authentication of the AWS provided instance.
+	Auth string `json:"auth,omitempty" yaml:"auth,omitempty"`
+	// The ARN of the IAM OIDC provider of the EKS cluster trusted by the IAM role for the IAM database authentication.
+	OIDCProviderARN string `json:"oidcProviderARN,omitempty" yaml:"oidcProviderARN,omitempty"`
+	// The dedicated ServiceAccount the workload runs as, which is created and annotated with the IAM role
+	// for the IAM database authentication.
+	ServiceAccount string `json:"serviceAccount,omitempty" yaml:"serviceAccount,omitempty"`
+	// The delivery of the generated password to the workload, which is synced from the secret manager
+	// of the cloud provider with the ExternalSecret instead of being stored in the Kubernetes Secret.
+	CredentialDelivery *CredentialDelivery `json:"credentialDelivery,omitempty" yaml:"credentialDelivery,omitempty"`
+	// The TLS connections enforced by the cloud provided PostgreSQL instance.
+	TLS *TLS `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// The snapshot or the point in time of the source instance the cloud provided PostgreSQL instance is restored from.
+	RestoreFrom *RestoreFrom `json:"restoreFrom,omitempty" yaml:"restoreFrom,omitempty"`
+	// The scheduled backups of the local PostgreSQL instance.
+	Backup *Backup `json:"backup,omitempty" yaml:"backup,omitempty"`
+	// The connection pooler deployed in front of the local PostgreSQL instance.
+	Pooler *Pooler `json:"pooler,omitempty" yaml:"pooler,omitempty"`
+	// The CloudNativePG cluster managed by the operator for the operator type.
+	Operator *Operator `json:"operator,omitempty" yaml:"operator,omitempty"`
+	// The exporter exposing the metrics of the PostgreSQL instance to Prometheus.
+	Exporter *Exporter `json:"exporter,omitempty" yaml:"exporter,omitempty"`
+	// The NetworkPolicy isolating the local PostgreSQL instance.
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty" yaml:"networkPolicy,omitempty"`
+	// The Kubernetes Job migrating the schema of the PostgreSQL database.
+	Migration *Migration `json:"migration,omitempty" yaml:"migration,omitempty"`
+
+	// The warnings of the ignored keys in the devConfig and platformConfig.
+	warnings []string
+	// Whether the default logical database is not created by the backend, without any databases,
+	// extensions or application users declared for the Job to create it.
+	noDefaultDatabase bool
 }
 
 func (postgres *PostgreSQL) Generate(ctx context.Context, request *module.GeneratorRequest) (response *module.GeneratorResponse, err error) {
@@ -113,6 +234,9 @@ func (postgres *PostgreSQL) Generate(ctx context.Context, request *module.Genera
 	if err != nil {
 		return nil, err
 	}
+	for _, warning := range postgres.warnings {
+		logger.Info("Ignoring the module config", "warning", warning)
+	}
 
 	// Set the database name.
 	if postgres.DatabaseName == "" {
@@ -126,6 +250,10 @@ func (postgres *PostgreSQL) Generate(ctx context.Context, request *module.Genera
 	switch strings.ToLower(postgres.Type) {
 	case LocalDBType:
 		resources, patcher, err = postgres.GenerateLocalResources(request)
+	case ExternalDBType:
+		resources, patcher, err = postgres.GenerateExternalResources(request)
+	case OperatorDBType:
+		resources, patcher, err = postgres.GenerateOperatorResources(request)
 	case CloudDBType:
 		providerType, err = GetCloudProviderType(request.PlatformConfig)
 		if err != nil {
@@ -143,12 +271,53 @@ func (postgres *PostgreSQL) Generate(ctx context.Context, request *module.Genera
 			if err != nil {
 				return nil, err
 			}
+		case "gcp":
+			resources, patcher, err = postgres.GenerateGCPResources(request)
+			if err != nil {
+				return nil, err
+			}
+		case "azure":
+			resources, patcher, err = postgres.GenerateAzureResources(request)
+			if err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("unsupported cloud provider type: %s", providerType)
 		}
 	default:
 		return nil, fmt.Errorf("unsupported postgres type: %s", postgres.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Build Kubernetes Secret with the CA bundle of the cloud provider if declared.
+	if postgres.hasCABundle() {
+		caSecret, err := postgres.generateCASecret(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *caSecret)
+	}
+
+	// Build ServiceMonitor scraping the metrics exposed by the exporter, along with the standalone
+	// exporter for the cloud provided instance, if declared.
+	if postgres.Exporter != nil {
+		exporterResources, err := postgres.generateExporterResources(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, exporterResources...)
+	}
+
+	// Build Kubernetes Job for the schema migration with the database credentials if declared.
+	if postgres.Migration != nil {
+		migrationJob, err := postgres.generateMigrationJob(request)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *migrationJob)
+	}
 
 	return &module.GeneratorResponse{
 		Resources: resources,
@@ -159,83 +328,117 @@ func (postgres *PostgreSQL) Generate(ctx context.Context, request *module.Genera
 // GetCompleteConfig combines the configs in devModuleConfig and platformModuleConfig to form a complete
 // configuration for the PostgreSQL instance.
 func (postgres *PostgreSQL) GetCompleteConfig(devConfig kusionapiv1.Accessory, platformConfig kusionapiv1.GenericConfig) error {
-	// Set the default values for PostgreSQL instance if platformConfig not exists.
-	if platformConfig == nil {
-		postgres.Username = defaultUsername
-		postgres.Category = defaultCategory
-		postgres.SecurityIPs = defaultSecurityIPs
-		postgres.PrivateRouting = defaultPrivateRouting
-		postgres.Size = defaultSize
+	// Set the default values for PostgreSQL instance, which are overridden by the configs below.
+	postgres.Username = defaultUsername
+	postgres.Category = defaultCategory
+	postgres.SecurityIPs = defaultSecurityIPs
+	postgres.PrivateRouting = defaultPrivateRouting
+	postgres.Size = defaultSize
+	postgres.CPU = defaultCPU
+	postgres.Memory = defaultMemory
+	postgres.MultiAZ = defaultMultiAZ
+	postgres.BackupRetentionPeriod = defaultBackupRetentionPeriod
+	postgres.DeletionProtection = defaultDeletionProtection
+	postgres.SkipFinalSnapshot = defaultSkipFinalSnapshot
+	postgres.AutoMinorVersionUpgrade = defaultAutoMinorVersionUpgrade
+
+	// Get the type, version, logical databases, application users, init scripts and schema
+	// migration of the PostgreSQL instance in devConfig.
+	postgres.warnings = nil
+	if err := postgres.decodeConfig(devConfig, devConfigSource); err != nil {
+		return err
 	}
 
-	// Get the type and version of the PostgreSQL instance in devConfig.
-	if postgresType, ok := devConfig["type"]; ok {
-		postgres.Type = postgresType.(string)
-	}
-	if postgresVersion, ok := devConfig["version"]; ok {
-		postgres.Version = postgresVersion.(string)
-	}
-
-	// Get the other configs of the PostgreSQL instance in platformConfig,
-	// and use the default values if some of them don't exist.
-	if username, ok := platformConfig["username"]; ok {
-		postgres.Username = username.(string)
-	} else {
-		postgres.Username = defaultUsername
+	// Get the other configs of the PostgreSQL instance in platformConfig.
+	if err := postgres.decodeConfig(platformConfig, platformConfigSource); err != nil {
+		return err
 	}
 
-	if category, ok := platformConfig["category"]; ok {
-		postgres.Category = category.(string)
-	} else {
-		postgres.Category = defaultCategory
-	}
-
-	if securityIPs, ok := platformConfig["securityIPs"]; ok {
-		postgres.SecurityIPs = securityIPs.([]string)
-	} else {
-		postgres.SecurityIPs = defaultSecurityIPs
+	// The CloudNativePG cluster is deployed with the default settings if not declared by the platform.
+	if strings.EqualFold(postgres.Type, OperatorDBType) && postgres.Operator == nil {
+		postgres.Operator = defaultOperator()
 	}
 
-	if privateRouting, ok := platformConfig["privateRouting"]; ok {
-		postgres.PrivateRouting = privateRouting.(bool)
-	} else {
-		postgres.PrivateRouting = defaultPrivateRouting
+	// The local PostgreSQL instance is isolated by the NetworkPolicy if not declared by the platform.
+	if strings.EqualFold(postgres.Type, LocalDBType) && postgres.NetworkPolicy == nil {
+		postgres.NetworkPolicy = &NetworkPolicy{Enabled: true}
 	}
 
-	if size, ok := platformConfig["size"]; ok {
-		postgres.Size = size.(int)
-	} else {
-		postgres.Size = defaultSize
-	}
+	return postgres.Validate()
+}
 
-	if instanceType, ok := platformConfig["instanceType"]; ok {
-		postgres.InstanceType = instanceType.(string)
-	}
+// GenerateDBSecret generates Kubernetes Secret resource to store the host address, port, database,
+// username and password of the PostgreSQL database instance, along with the host addresses of the read
+// replicas if any.
+func (postgres *PostgreSQL) GenerateDBSecret(request *module.GeneratorRequest, hostAddress, username, password string,
+	readHostAddresses []string,
+) (
+	*kusionapiv1.Resource, *kusionapiv1.Patcher, error,
+) {
+	return postgres.generateDBSecret(request, hostAddress, username, password, readHostAddresses,
+		postgres.generateRemoteSecretRef(request))
+}
 
-	if subnetID, ok := platformConfig["subnetID"]; ok {
-		postgres.SubnetID = subnetID.(string)
+// generateDBSecretID generates the ID of the resource emitting the database secret of GenerateDBSecret,
+// which is the ExternalSecret syncing the secret for the external secret delivery.
+func (postgres *PostgreSQL) generateDBSecretID(request *module.GeneratorRequest) string {
+	typeMeta := metav1.TypeMeta{
+		Kind:       "Secret",
+		APIVersion: v1.SchemeGroupVersion.String(),
 	}
-
-	if databaseName, ok := platformConfig["databaseName"]; ok {
-		postgres.DatabaseName = databaseName.(string)
+	if postgres.isExternalSecretDelivery() {
+		typeMeta = metav1.TypeMeta{
+			Kind:       externalSecretKind,
+			APIVersion: externalSecretAPIVersion,
+		}
 	}
 
-	return postgres.Validate()
+	return module.KubernetesResourceID(typeMeta, metav1.ObjectMeta{
+		Name:      postgres.DatabaseName + dbResSuffix,
+		Namespace: request.Project,
+	})
 }
 
-// GenerateDBSecret generates Kubernetes Secret resource to store the host address, username
-// and password of the local PostgreSQL database instance.
-func (postgres *PostgreSQL) GenerateDBSecret(request *module.GeneratorRequest, hostAddress, username, password string) (
+// generateDBSecret generates the Kubernetes Secret resource of GenerateDBSecret, which is synced by the
+// ExternalSecret with the password referred to by ref in the secret manager for the external secret
+// delivery.
+func (postgres *PostgreSQL) generateDBSecret(request *module.GeneratorRequest, hostAddress, username, password string,
+	readHostAddresses []string, ref remoteSecretRef,
+) (
 	*kusionapiv1.Resource, *kusionapiv1.Patcher, error,
 ) {
-	// Create the data map of Kubernetes Secret storing the database host address, username
-	// and password.
+	// Create the data map of Kubernetes Secret storing the database host address, port, database,
+	// username and password.
 	data := make(map[string]string)
 	data["hostAddress"] = hostAddress
+	data["port"] = strconv.Itoa(postgres.generatePort())
+	data["database"] = postgres.generateLogicalDBName()
 	data["username"] = username
 	data["password"] = password
+	for i, readHostAddress := range readHostAddresses {
+		data[generateReadHostAddressKey(i)] = readHostAddress
+	}
+
+	if postgres.TLS != nil {
+		data["sslMode"] = postgres.generateSSLMode()
+	}
 
-	// Create the Kubernetes Secret.
+	// The workload connects with the IAM authentication token generated on its own instead of the password.
+	if postgres.isIAMAuth() {
+		delete(data, "password")
+	}
+
+	// The credentials referenced from the existing secrets of the external database or from the secret
+	// generated by the operator are not stored.
+	for _, key := range []string{"username", "password"} {
+		_, isExternal := postgres.generateExternalSecretRef(key)
+		_, isOperator := postgres.generateOperatorSecretRef(key)
+		if isExternal || isOperator {
+			delete(data, key)
+		}
+	}
+
+	// Create the Kubernetes Secret, or the ExternalSecret syncing the password into it.
 	secret := &v1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Secret",
@@ -248,17 +451,25 @@ func (postgres *PostgreSQL) GenerateDBSecret(request *module.GeneratorRequest, h
 		StringData: data,
 	}
 
-	resourceID := module.KubernetesResourceID(secret.TypeMeta, secret.ObjectMeta)
-	resource, err := module.WrapK8sResourceToKusionResource(resourceID, secret)
+	var resource *kusionapiv1.Resource
+	var err error
+	if postgres.isExternalSecretDelivery() {
+		resource, err = postgres.generateExternalSecret(request, secret.Name, data, ref)
+	} else {
+		resourceID := module.KubernetesResourceID(secret.TypeMeta, secret.ObjectMeta)
+		resource, err = module.WrapK8sResourceToKusionResource(resourceID, secret)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Inject the database credentials into the workload as the environment variables with
 	// Kusion resource patcher.
-	hostAddressKey := dbHostAddressEnv + "_" + strings.ToUpper(strings.ReplaceAll(postgres.DatabaseName, "-", "_"))
-	usernameKey := dbUsernameEnv + "_" + strings.ToUpper(strings.ReplaceAll(postgres.DatabaseName, "-", "_"))
-	passwordKey := dbPasswordEnv + "_" + strings.ToUpper(strings.ReplaceAll(postgres.DatabaseName, "-", "_"))
+	hostAddressKey := dbHostAddressEnv + "_" + postgres.generateEnvSuffix()
+	usernameKey := dbUsernameEnv + "_" + postgres.generateEnvSuffix()
+	passwordKey := dbPasswordEnv + "_" + postgres.generateEnvSuffix()
+	portKey := dbPortEnv + "_" + postgres.generateEnvSuffix()
+	databaseKey := dbDatabaseEnv + "_" + postgres.generateEnvSuffix()
 
 	envVars := []v1.EnvVar{
 		{
@@ -274,48 +485,136 @@ func (postgres *PostgreSQL) GenerateDBSecret(request *module.GeneratorRequest, h
 		},
 		{
 			Name: usernameKey,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: postgres.generateSecretKeySelector(secret.Name, "username"),
+			},
+		},
+		{
+			Name: passwordKey,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: postgres.generateSecretKeySelector(secret.Name, "password"),
+			},
+		},
+		{
+			Name: portKey,
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
 						Name: secret.Name,
 					},
-					Key: "username",
+					Key: "port",
 				},
 			},
 		},
 		{
-			Name: passwordKey,
+			Name: databaseKey,
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
 						Name: secret.Name,
 					},
-					Key: "password",
+					Key: "database",
 				},
 			},
 		},
 	}
 
+	if postgres.isIAMAuth() {
+		envVars = slices.DeleteFunc(envVars, func(envVar v1.EnvVar) bool {
+			return envVar.Name == passwordKey
+		})
+	}
+
+	// Inject the host addresses of the read replicas for the workload to split the read traffic.
+	for i := range readHostAddresses {
+		envVars = append(envVars, v1.EnvVar{
+			Name: postgres.generateReadHostEnv(i),
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: secret.Name,
+					},
+					Key: generateReadHostAddressKey(i),
+				},
+			},
+		})
+	}
+
+	// Inject the ssl mode hint and the CA bundle for the TLS connections.
+	envVars = append(envVars, postgres.generateTLSEnvs(secret.Name)...)
+
+	// Compose the connection string with the credential environment variables above, which
+	// should be placed after them for the dependent variable expansion.
+	if postgres.ConnectionURL != nil {
+		envVars = append(envVars, postgres.generateConnectionURLEnv(hostAddressKey, usernameKey, passwordKey))
+	}
+
+	// Roll the workload together with the database secret once the password is rotated.
 	patcher := &kusionapiv1.Patcher{
-		Environments: envVars,
+		Environments:   envVars,
+		PodAnnotations: postgres.generatePasswordRotationAnnotations(),
 	}
 
 	return resource, patcher, nil
 }
 
+// generateEnvSuffix generates the suffix of the environment variable names injected into the workload.
+func (postgres *PostgreSQL) generateEnvSuffix() string {
+	return strings.ToUpper(strings.ReplaceAll(postgres.DatabaseName, "-", "_"))
+}
+
+// generateLogicalDBName generates the name of the logical database for the workload to connect with,
+// which is the first declared database or the instance name with dashes replaced by underscores. The
+// workload connects to the maintenance database if the default one is not created by the backend.
+func (postgres *PostgreSQL) generateLogicalDBName() string {
+	if len(postgres.Databases) > 0 {
+		return postgres.Databases[0]
+	}
+
+	if postgres.noDefaultDatabase {
+		return dbUsersMaintenanceDB
+	}
+
+	return strings.ReplaceAll(postgres.DatabaseName, "-", "_")
+}
+
+// generatePasswordRotationAnnotations generates the pod annotations carrying the password rotation
+// trigger, which restart the pods using the password when the trigger changes.
+func (postgres *PostgreSQL) generatePasswordRotationAnnotations() map[string]string {
+	if postgres.PasswordRotation == "" {
+		return nil
+	}
+
+	return map[string]string{
+		passwordRotationAnnotation: postgres.PasswordRotation,
+	}
+}
+
 // GenerateTFRandomPassword generates the terraform random_password resource as the password
-// of the cloud provided PostgreSQL database instance.
+// of the PostgreSQL database instance.
 func (postgres *PostgreSQL) GenerateTFRandomPassword(request *module.GeneratorRequest) (*kusionapiv1.Resource, string, error) {
+	return postgres.generateTFRandomPassword(postgres.DatabaseName + dbResSuffix)
+}
+
+// generateTFRandomPassword generates the terraform random_password resource with the specified name.
+func (postgres *PostgreSQL) generateTFRandomPassword(name string) (*kusionapiv1.Resource, string, error) {
 	resAttrs := map[string]any{
 		"length":           16,
 		"special":          true,
 		"override_special": "_",
 	}
 
+	// A new password will be generated once the rotation trigger changes.
+	if postgres.PasswordRotation != "" {
+		resAttrs["keepers"] = map[string]string{
+			passwordRotationKeeper: postgres.PasswordRotation,
+		}
+	}
+
 	// Set the random_password provider with the default provider config.
 	randomPasswordProvider := defaultRandomProviderCfg
 
-	id, err := module.TerraformResourceID(randomPasswordProvider, randomPassword, postgres.DatabaseName+dbResSuffix)
+	id, err := module.TerraformResourceID(randomPasswordProvider, randomPassword, name)
 	if err != nil {
 		return nil, "", err
 	}
@@ -330,11 +629,85 @@ func (postgres *PostgreSQL) GenerateTFRandomPassword(request *module.GeneratorRe
 
 // Validate validates whether the input of a PostgreSQL database instance is valid.
 func (postgres *PostgreSQL) Validate() error {
-	if postgres.Type == CloudDBType && postgres.InstanceType == "" {
+	// The instance type is fixed for the Aurora Serverless v2 instances.
+	isServerlessV2 := postgres.Aurora != nil && postgres.Aurora.ServerlessV2 != nil
+	if postgres.Type == CloudDBType && postgres.InstanceType == "" && !isServerlessV2 {
 		return ErrEmptyInstanceTypeForCloudDB
 	}
 
-	return nil
+	if postgres.BackupRetentionPeriod < 0 || postgres.BackupRetentionPeriod > 35 {
+		return ErrInvalidBackupRetentionPeriod
+	}
+
+	if postgres.Replicas < 0 || postgres.Replicas > maxReplicas {
+		return ErrInvalidReplicas
+	}
+
+	if err := postgres.validateExternalDatabase(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateAuth(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateCredentialDelivery(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateTLS(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateRestoreFrom(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateBackup(); err != nil {
+		return err
+	}
+
+	if err := postgres.validatePooler(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateOperator(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateExporter(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateNetworkPolicy(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateParameters(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateAuroraCluster(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateDatabaseUsers(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateInitScripts(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateExtensions(); err != nil {
+		return err
+	}
+
+	if err := postgres.validateMigration(); err != nil {
+		return err
+	}
+
+	return postgres.validateConnectionURL()
 }
 
 // GenerateDefaultPostgreSQLName generates the default name of the PostgreSQL instance.
@@ -351,7 +724,13 @@ func GetCloudProviderType(platformConfig kusionapiv1.GenericConfig) (string, err
 	}
 
 	if cloud, ok := platformConfig["cloud"]; ok {
-		return cloud.(string), nil
+		cloudType, ok := toConfigString(cloud)
+		if !ok {
+			return "", fmt.Errorf("%w: cloud in %s should be a string but got %T",
+				ErrInvalidModuleConfig, platformConfigSource, cloud)
+		}
+
+		return cloudType, nil
 	}
 
 	return "", ErrEmptyCloudProviderType
@@ -390,6 +769,28 @@ func IsCIDR(cidrStr string) bool {
 	return err == nil
 }
 
+// IPRange returns the first and the last IPv4 address of the input ip address
+// or CIDR record.
+func IPRange(ipStr string) (string, string, error) {
+	if IsIPAddress(ipStr) {
+		return ipStr, ipStr, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(ipStr)
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", "", fmt.Errorf("illegal security ip format: %s", ipStr)
+	}
+
+	start := binary.BigEndian.Uint32(ipNet.IP.To4())
+	end := start | ^binary.BigEndian.Uint32(net.IP(ipNet.Mask).To4())
+
+	startIP, endIP := make(net.IP, net.IPv4len), make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(startIP, start)
+	binary.BigEndian.PutUint32(endIP, end)
+
+	return startIP.String(), endIP.String(), nil
+}
+
 func main() {
 	server.Start(&PostgreSQL{})
 }
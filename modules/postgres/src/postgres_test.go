@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/bytedance/mockey"
@@ -18,14 +20,20 @@ func TestPostgreSQLModule_Generator(t *testing.T) {
 	// Set provider envs.
 	originAWSRegion := os.Getenv("AWS_REGION")
 	originAlicloudRegion := os.Getenv("ALICLOUD_REGION")
+	originGCPRegion := os.Getenv("GOOGLE_REGION")
+	originAzureRegion := os.Getenv("AZURE_REGION")
 
 	defer func() {
 		os.Setenv("AWS_REGION", originAWSRegion)
 		os.Setenv("ALICLOUD_REGION", originAlicloudRegion)
+		os.Setenv("GOOGLE_REGION", originGCPRegion)
+		os.Setenv("AZURE_REGION", originAzureRegion)
 	}()
 
 	os.Setenv("AWS_REGION", "us-east-1")
 	os.Setenv("ALICLOUD_REGION", "cn-beijing")
+	os.Setenv("GOOGLE_REGION", "us-central1")
+	os.Setenv("AZURE_REGION", "eastus")
 
 	r := &module.GeneratorRequest{
 		Project: "test-project",
@@ -84,6 +92,35 @@ func TestPostgreSQLModule_Generator(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "Generate GCP PostgreSQL Cloud SQL",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":    "cloud",
+				"version": "14.0",
+			},
+			platformConfig: kusionapiv1.GenericConfig{
+				"cloud":          "gcp",
+				"size":           20,
+				"instanceType":   "db-f1-micro",
+				"privateRouting": false,
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "Generate Azure PostgreSQL flexible server",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":    "cloud",
+				"version": "14.0",
+			},
+			platformConfig: kusionapiv1.GenericConfig{
+				"cloud":          "azure",
+				"size":           20,
+				"instanceType":   "B_Standard_B1ms",
+				"privateRouting": false,
+				"resourceGroup":  "test-resource-group",
+			},
+			expectedErr: nil,
+		},
 		{
 			name: "Unsupported PostgreSQL type",
 			devModuleConfig: kusionapiv1.Accessory{
@@ -149,42 +186,150 @@ func TestPostgreSQLModule_GetCompleteConfig(t *testing.T) {
 			devModuleConfig: kusionapiv1.Accessory{
 				"type":    "local",
 				"version": "14.0",
+				"initScripts": []any{
+					map[string]any{"name": "schema", "sql": "CREATE TABLE t (id INT);"},
+				},
+				"migration": map[string]any{
+					"tool":  "flyway",
+					"image": "test-migration:v1",
+				},
 			},
 			platformConfig: nil,
 			expectedPostgreSQL: &PostgreSQL{
-				Type:           "local",
-				Version:        "14.0",
-				Username:       defaultUsername,
-				Category:       defaultCategory,
-				SecurityIPs:    defaultSecurityIPs,
-				PrivateRouting: defaultPrivateRouting,
-				Size:           defaultSize,
+				Type:                    "local",
+				Version:                 "14.0",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          defaultPrivateRouting,
+				Size:                    defaultSize,
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 defaultMultiAZ,
+				BackupRetentionPeriod:   defaultBackupRetentionPeriod,
+				DeletionProtection:      defaultDeletionProtection,
+				SkipFinalSnapshot:       defaultSkipFinalSnapshot,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				InitScripts:             []InitScript{{Name: "schema", SQL: "CREATE TABLE t (id INT);"}},
+				NetworkPolicy:           &NetworkPolicy{Enabled: true},
+				Migration:               &Migration{Tool: FlywayTool, Image: "test-migration:v1"},
 			},
 		},
 		{
-			name: "Default config with specified platform config",
+			name: "Local database with extensions",
 			devModuleConfig: kusionapiv1.Accessory{
-				"type":    "cloud",
+				"type":       "local",
+				"version":    "16.2",
+				"extensions": []any{"pgvector", "pg_trgm"},
+			},
+			platformConfig: nil,
+			expectedPostgreSQL: &PostgreSQL{
+				Type:                    "local",
+				Version:                 "16.2",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          defaultPrivateRouting,
+				Size:                    defaultSize,
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 defaultMultiAZ,
+				BackupRetentionPeriod:   defaultBackupRetentionPeriod,
+				DeletionProtection:      defaultDeletionProtection,
+				SkipFinalSnapshot:       defaultSkipFinalSnapshot,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				Extensions:              []string{"pgvector", "pg_trgm"},
+				NetworkPolicy:           &NetworkPolicy{Enabled: true},
+			},
+		},
+		{
+			name: "Operator type with default cluster settings",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":    "operator",
 				"version": "14.0",
 			},
+			platformConfig: nil,
+			expectedPostgreSQL: &PostgreSQL{
+				Type:                    "operator",
+				Version:                 "14.0",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          defaultPrivateRouting,
+				Size:                    defaultSize,
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 defaultMultiAZ,
+				BackupRetentionPeriod:   defaultBackupRetentionPeriod,
+				DeletionProtection:      defaultDeletionProtection,
+				SkipFinalSnapshot:       defaultSkipFinalSnapshot,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				Operator:                defaultOperator(),
+			},
+		},
+		{
+			name: "Default config with specified platform config",
+			devModuleConfig: kusionapiv1.Accessory{
+				"type":      "cloud",
+				"version":   "14.0",
+				"databases": []any{"orders"},
+				"users": []any{
+					map[string]any{
+						"name": "app",
+						"grants": []any{
+							map[string]any{"database": "orders", "privileges": []any{"select"}},
+						},
+					},
+				},
+			},
 			platformConfig: kusionapiv1.GenericConfig{
-				"size":           100,
-				"privateRouting": true,
-				"instanceType":   "test-instance-type",
-				"subnetID":       "test-subnet-id",
-				"databaseName":   "test-database",
+				"size":                  100,
+				"privateRouting":        true,
+				"instanceType":          "test-instance-type",
+				"subnetID":              "test-subnet-id",
+				"databaseName":          "test-database",
+				"passwordRotation":      "2024-06-01",
+				"multiAZ":               true,
+				"backupRetentionPeriod": 14,
+				"backupWindow":          "03:00-04:00",
+				"deletionProtection":    false,
+				"skipFinalSnapshot":     true,
+				"storageType":           "io1",
+				"iops":                  3000,
+				"connectionURL": map[string]any{
+					"format": "dsn",
+				},
 			},
 			expectedPostgreSQL: &PostgreSQL{
-				Type:           "cloud",
-				Version:        "14.0",
-				Username:       defaultUsername,
-				Category:       defaultCategory,
-				SecurityIPs:    defaultSecurityIPs,
-				PrivateRouting: true,
-				Size:           100,
-				InstanceType:   "test-instance-type",
-				SubnetID:       "test-subnet-id",
-				DatabaseName:   "test-database",
+				Type:                    "cloud",
+				Version:                 "14.0",
+				Username:                defaultUsername,
+				Category:                defaultCategory,
+				SecurityIPs:             defaultSecurityIPs,
+				PrivateRouting:          true,
+				Size:                    100,
+				InstanceType:            "test-instance-type",
+				SubnetID:                "test-subnet-id",
+				DatabaseName:            "test-database",
+				CPU:                     defaultCPU,
+				Memory:                  defaultMemory,
+				MultiAZ:                 true,
+				BackupRetentionPeriod:   14,
+				BackupWindow:            "03:00-04:00",
+				DeletionProtection:      false,
+				SkipFinalSnapshot:       true,
+				StorageType:             "io1",
+				Iops:                    3000,
+				AutoMinorVersionUpgrade: defaultAutoMinorVersionUpgrade,
+				PasswordRotation:        "2024-06-01",
+				ConnectionURL: &ConnectionURL{
+					Format:    DSNFormat,
+					EnvPrefix: defaultConnectionURLEnvPrefix,
+				},
+				Databases: []string{"orders"},
+				Users: []DatabaseUser{
+					{Name: "app", Grants: []DatabaseGrant{{Database: "orders", Privileges: []string{"SELECT"}}}},
+				},
 			},
 		},
 	}
@@ -234,6 +379,8 @@ func TestPostgreSQLModule_GenerateDBSecret(t *testing.T) {
 		},
 		StringData: map[string]string{
 			"hostAddress": "test-host-address",
+			"port":        "5432",
+			"database":    "test_database",
 			"username":    "test-username",
 			"password":    "test-password",
 		},
@@ -280,10 +427,32 @@ func TestPostgreSQLModule_GenerateDBSecret(t *testing.T) {
 					},
 				},
 			},
+			{
+				Name: "KUSION_DB_PORT_TEST_DATABASE",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: "test-database-postgres",
+						},
+						Key: "port",
+					},
+				},
+			},
+			{
+				Name: "KUSION_DB_DATABASE_TEST_DATABASE",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: "test-database-postgres",
+						},
+						Key: "database",
+					},
+				},
+			},
 		},
 	}
 
-	actualResource, actualPatchers, err := postgres.GenerateDBSecret(r, hostAddress, username, password)
+	actualResource, actualPatchers, err := postgres.GenerateDBSecret(r, hostAddress, username, password, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, expectedPatcher, actualPatchers)
@@ -350,9 +519,38 @@ func TestPostgreSQLModule_GenerateTFRandomPassword(t *testing.T) {
 		res, id, err := postgres.GenerateTFRandomPassword(r)
 
 		assert.NotNil(t, res)
+		assert.NotContains(t, res.Attributes, "keepers")
 		assert.NotEqual(t, id, "")
 		assert.NoError(t, err)
 	})
+
+	t.Run("successfully generate random_password resource with rotation trigger", func(t *testing.T) {
+		rotatedPostgres := *postgres
+		rotatedPostgres.PasswordRotation = "2024-06-01"
+
+		res, id, err := rotatedPostgres.GenerateTFRandomPassword(r)
+
+		assert.NotNil(t, res)
+		assert.Equal(t, map[string]string{"rotation": "2024-06-01"}, res.Attributes["keepers"])
+		assert.NotEqual(t, id, "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestPostgreSQLModule_GeneratePasswordRotationAnnotations(t *testing.T) {
+	t.Run("empty rotation trigger", func(t *testing.T) {
+		postgres := &PostgreSQL{}
+
+		assert.Nil(t, postgres.generatePasswordRotationAnnotations())
+	})
+
+	t.Run("specified rotation trigger", func(t *testing.T) {
+		postgres := &PostgreSQL{PasswordRotation: "2024-06-01"}
+
+		assert.Equal(t, map[string]string{
+			"kusionstack.io/password-rotation": "2024-06-01",
+		}, postgres.generatePasswordRotationAnnotations())
+	})
 }
 
 func TestPostgreSQLModule_Validate(t *testing.T) {
@@ -367,6 +565,31 @@ func TestPostgreSQLModule_Validate(t *testing.T) {
 		assert.ErrorContains(t, err, ErrEmptyInstanceTypeForCloudDB.Error())
 	})
 
+	t.Run("invalid backup retention period", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:                  "cloud",
+			Version:               "14.0",
+			InstanceType:          "test-instance-type",
+			BackupRetentionPeriod: 36,
+		}
+
+		err := postgres.Validate()
+
+		assert.ErrorIs(t, err, ErrInvalidBackupRetentionPeriod)
+	})
+
+	t.Run("too many replicas", func(t *testing.T) {
+		postgres := &PostgreSQL{
+			Type:     "local",
+			Version:  "14.0",
+			Replicas: 6,
+		}
+
+		err := postgres.Validate()
+
+		assert.ErrorIs(t, err, ErrInvalidReplicas)
+	})
+
 	t.Run("valid postgres config", func(t *testing.T) {
 		postgres := &PostgreSQL{
 			Type:         "cloud",
@@ -380,6 +603,48 @@ func TestPostgreSQLModule_Validate(t *testing.T) {
 	})
 }
 
+func TestIPRange(t *testing.T) {
+	testcases := []struct {
+		name          string
+		ip            string
+		expectedStart string
+		expectedEnd   string
+		expectedErr   bool
+	}{
+		{
+			name:          "IP Address",
+			ip:            "172.16.0.1",
+			expectedStart: "172.16.0.1",
+			expectedEnd:   "172.16.0.1",
+		},
+		{
+			name:          "Public CIDR",
+			ip:            "0.0.0.0/0",
+			expectedStart: "0.0.0.0",
+			expectedEnd:   "255.255.255.255",
+		},
+		{
+			name:          "Private CIDR",
+			ip:            "172.16.0.0/24",
+			expectedStart: "172.16.0.0",
+			expectedEnd:   "172.16.0.255",
+		},
+		{
+			name:        "Illegal IP",
+			ip:          "illegal-ip",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		start, end, err := IPRange(tc.ip)
+
+		assert.Equal(t, tc.expectedStart, start)
+		assert.Equal(t, tc.expectedEnd, end)
+		assert.Equal(t, tc.expectedErr, err != nil)
+	}
+}
+
 func TestIsPublicAccessible(t *testing.T) {
 	testcases := []struct {
 		name        string
@@ -415,3 +680,31 @@ func TestIsPublicAccessible(t *testing.T) {
 		assert.Equal(t, tc.expected, actual)
 	}
 }
+
+// assertResolvableKusionPaths asserts that the implicit dependencies in the attributes of the resources
+// are the whole string values, since Kusion does not resolve the ones embedded in the other strings,
+// e.g. the marshalled JSON documents.
+func assertResolvableKusionPaths(t *testing.T, resources []kusionapiv1.Resource) {
+	t.Helper()
+
+	var walk func(path string, value any)
+	walk = func(path string, value any) {
+		switch v := value.(type) {
+		case string:
+			assert.False(t, strings.Index(v, "$kusion_path.") > 0,
+				"unresolvable kusion path embedded in %s: %s", path, v)
+		case map[string]any:
+			for key, item := range v {
+				walk(path+"."+key, item)
+			}
+		case []any:
+			for i, item := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		}
+	}
+
+	for _, resource := range resources {
+		walk(resource.ID, resource.Attributes)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var (
+	maxReplicas         = 5
+	replicaSuffix       = "-replica"
+	readHostAddressKey  = "readHostAddress"
+	localDataPath       = "/var/lib/postgresql/data"
+	localReplicationHBA = "host replication all all md5"
+)
+
+// generateReadHostAddressKey generates the key of the read replica host address in the database secret.
+func generateReadHostAddressKey(index int) string {
+	if index == 0 {
+		return readHostAddressKey
+	}
+
+	return readHostAddressKey + strconv.Itoa(index)
+}
+
+// generateReadHostEnv generates the name of the environment variable holding the read replica host
+// address, which is suffixed with the index for the replicas other than the first one.
+func (postgres *PostgreSQL) generateReadHostEnv(index int) string {
+	name := dbReadHostEnv + "_" + postgres.generateEnvSuffix()
+	if index == 0 {
+		return name
+	}
+
+	return name + "_" + strconv.Itoa(index)
+}
+
+// generateReplicaName generates the name of the read replica with the index.
+func (postgres *PostgreSQL) generateReplicaName(index int) string {
+	return fmt.Sprintf("%s%s-%d", postgres.DatabaseName, replicaSuffix, index)
+}
+
+// generateLocalReplicaResources generates the Kubernetes StatefulSet and headless Service of the
+// streaming replicas of the local PostgreSQL instance, and returns the Service name as the read host address.
+func (postgres *PostgreSQL) generateLocalReplicaResources(request *module.GeneratorRequest, sourceHostAddress string) (
+	[]kusionapiv1.Resource, string, error,
+) {
+	name := postgres.DatabaseName + replicaSuffix
+	labels := postgres.generateLocalReplicaMatchLabels()
+
+	podSpec, err := postgres.generateLocalReplicaPodSpec(sourceHostAddress)
+	if err != nil {
+		return nil, "", err
+	}
+
+	replicas := int32(postgres.Replicas)
+	statefulSet := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + localStatefulSetSuffix,
+			Namespace: request.Project,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: name + localServiceSuffix,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: postgres.generateLocalPodAnnotations(),
+				},
+				Spec: podSpec,
+			},
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   postgres.DatabaseName,
+						Labels: labels,
+					},
+					Spec: postgres.generateLocalPVCSpec(),
+				},
+			},
+		},
+	}
+
+	statefulSetID := module.KubernetesResourceID(statefulSet.TypeMeta, statefulSet.ObjectMeta)
+	statefulSetRes, err := module.WrapK8sResourceToKusionResource(statefulSetID, statefulSet)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The headless Service resolves to all the replica pods for the workload to spread the reads.
+	svcName := name + localServiceSuffix
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svcName,
+			Namespace: request.Project,
+			Labels:    labels,
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "None",
+			Ports:     postgres.generateLocalSvcPort(),
+			Selector:  labels,
+		},
+	}
+
+	serviceID := module.KubernetesResourceID(service.TypeMeta, service.ObjectMeta)
+	serviceRes, err := module.WrapK8sResourceToKusionResource(serviceID, service)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []kusionapiv1.Resource{*statefulSetRes, *serviceRes}, svcName, nil
+}
+
+// generateLocalReplicaPodSpec generates the Kubernetes PodSpec of the streaming replicas of the local
+// PostgreSQL instance, which replicate from the source with the superuser account.
+func (postgres *PostgreSQL) generateLocalReplicaPodSpec(sourceHostAddress string) (v1.PodSpec, error) {
+	secretName := postgres.DatabaseName + localSecretSuffix
+	envSource := func(key string) *v1.EnvVarSource {
+		return &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		}
+	}
+
+	resources, err := postgres.generateLocalResourceRequirements()
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      postgres.DatabaseName,
+			MountPath: localDataPath,
+		},
+	}
+
+	// The replicas share the configuration file with the engine parameters of the source.
+	var volumes []v1.Volume
+	if len(postgres.generateParameters()) > 0 {
+		configVolume, configVolumeMount := postgres.generateLocalConfigVolume()
+		volumeMounts = append(volumeMounts, configVolumeMount)
+		volumes = append(volumes, configVolume)
+	}
+
+	podSpec := v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name:    postgres.DatabaseName + replicaSuffix,
+				Image:   postgres.generateLocalImage(),
+				Command: []string{"sh", "-c", postgres.generateLocalReplicaEntrypoint(sourceHostAddress)},
+				Env: []v1.EnvVar{
+					{Name: "POSTGRES_USER", ValueFrom: envSource("username")},
+					{Name: "POSTGRES_PASSWORD", ValueFrom: envSource("password")},
+					{Name: "POSTGRES_DB", ValueFrom: envSource("database")},
+					{Name: "PGPASSWORD", ValueFrom: envSource("password")},
+				},
+				Ports: []v1.ContainerPort{
+					{
+						ContainerPort: int32(dbPort),
+					},
+				},
+				VolumeMounts: volumeMounts,
+				Resources:    resources,
+				ReadinessProbe: &v1.Probe{
+					ProbeHandler:     postgres.generateLocalProbeHandler(),
+					PeriodSeconds:    5,
+					TimeoutSeconds:   5,
+					FailureThreshold: 3,
+				},
+				StartupProbe: &v1.Probe{
+					ProbeHandler:     postgres.generateLocalProbeHandler(),
+					PeriodSeconds:    10,
+					TimeoutSeconds:   5,
+					FailureThreshold: 30,
+				},
+			},
+		},
+		Volumes: volumes,
+	}
+
+	return podSpec, nil
+}
+
+// generateLocalReplicaEntrypoint generates the entrypoint script of the replica, which clones the data
+// directory from the source with pg_basebackup on the first boot and then starts as a hot standby. The
+// connection to the source is passed on every start for the rotated password to take effect.
+func (postgres *PostgreSQL) generateLocalReplicaEntrypoint(sourceHostAddress string) string {
+	var args string
+	if len(postgres.generateParameters()) > 0 {
+		args = " " + strings.Join(postgres.generateLocalConfigArgs(), " ")
+	}
+
+	return fmt.Sprintf(`if [ ! -s "$PGDATA/PG_VERSION" ]; then
+  mkdir -p "$PGDATA" && chown postgres:postgres "$PGDATA" && chmod 700 "$PGDATA"
+  until gosu postgres pg_basebackup -h %[1]s -p %[2]d -U "$POSTGRES_USER" -D "$PGDATA" -R -X stream; do
+    rm -rf "$PGDATA"/*
+    sleep 5
+  done
+fi
+exec docker-entrypoint.sh postgres -c "primary_conninfo=host=%[1]s port=%[2]d user=$POSTGRES_USER password=$PGPASSWORD"%[3]s`,
+		sourceHostAddress, dbPort, args)
+}
+
+// generateLocalReplicaMatchLabels generates the match labels of the replicas of the local PostgreSQL instance,
+// which are distinct from the ones of the source for the Services to select separately.
+func (postgres *PostgreSQL) generateLocalReplicaMatchLabels() map[string]string {
+	return map[string]string{
+		"accessory": postgres.DatabaseName + replicaSuffix,
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestPostgreSQLModule_GenerateReadHostEnv(t *testing.T) {
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+	}
+
+	assert.Equal(t, "readHostAddress", generateReadHostAddressKey(0))
+	assert.Equal(t, "readHostAddress1", generateReadHostAddressKey(1))
+	assert.Equal(t, "KUSION_DB_READ_HOST_TEST_DATABASE", postgres.generateReadHostEnv(0))
+	assert.Equal(t, "KUSION_DB_READ_HOST_TEST_DATABASE_1", postgres.generateReadHostEnv(1))
+	assert.Equal(t, "test-database-replica-1", postgres.generateReplicaName(1))
+}
+
+func TestPostgreSQLModule_GenerateDBSecretWithReadHosts(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "local",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+	}
+
+	res, patcher, err := postgres.GenerateDBSecret(r, "test-host-address", "test-username", "test-password",
+		[]string{"test-read-host-address-0", "test-read-host-address-1"})
+	assert.NoError(t, err)
+
+	stringData := res.Attributes["stringData"].(map[string]any)
+	assert.Equal(t, "test-read-host-address-0", stringData["readHostAddress"])
+	assert.Equal(t, "test-read-host-address-1", stringData["readHostAddress1"])
+
+	envs := make(map[string]string)
+	for _, env := range patcher.Environments {
+		if env.ValueFrom != nil {
+			envs[env.Name] = env.ValueFrom.SecretKeyRef.Key
+		}
+	}
+	assert.Equal(t, "readHostAddress", envs["KUSION_DB_READ_HOST_TEST_DATABASE"])
+	assert.Equal(t, "readHostAddress1", envs["KUSION_DB_READ_HOST_TEST_DATABASE_1"])
+}
+
+func TestPostgreSQLModule_GenerateLocalReplicaResources(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:         "local",
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     "root",
+		Size:         10,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+		Replicas:     2,
+	}
+
+	resources, readHostAddress, err := postgres.generateLocalReplicaResources(r, "test-database-db-local-service")
+	assert.NoError(t, err)
+	assert.Len(t, resources, 2)
+	assert.Equal(t, "test-database-replica-db-local-service", readHostAddress)
+	assert.Equal(t, "apps/v1:StatefulSet:test-project:test-database-replica-db-local-statefulset", resources[0].ID)
+	assert.Equal(t, "v1:Service:test-project:test-database-replica-db-local-service", resources[1].ID)
+}
+
+func TestPostgreSQLModule_GenerateLocalReplicaEntrypoint(t *testing.T) {
+	postgres := &PostgreSQL{
+		Version: "14.0",
+	}
+
+	entrypoint := postgres.generateLocalReplicaEntrypoint("test-host-address")
+	assert.Contains(t, entrypoint, `gosu postgres pg_basebackup -h test-host-address -p 5432 -U "$POSTGRES_USER" -D "$PGDATA" -R -X stream`)
+	assert.True(t, strings.HasSuffix(entrypoint,
+		`exec docker-entrypoint.sh postgres -c "primary_conninfo=host=test-host-address port=5432 user=$POSTGRES_USER password=$PGPASSWORD"`))
+}
+
+func TestPostgreSQLModule_GenerateLocalReplicaPodSpec(t *testing.T) {
+	postgres := &PostgreSQL{
+		Version:      "14.0",
+		DatabaseName: "test-database",
+		Username:     "root",
+		Size:         10,
+		CPU:          defaultCPU,
+		Memory:       defaultMemory,
+	}
+
+	podSpec, err := postgres.generateLocalReplicaPodSpec("test-host-address")
+	assert.NoError(t, err)
+	assert.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "/var/lib/postgresql/data", podSpec.Containers[0].VolumeMounts[0].MountPath)
+
+	var envs []string
+	for _, env := range podSpec.Containers[0].Env {
+		envs = append(envs, env.Name+"="+env.ValueFrom.SecretKeyRef.Key)
+	}
+	assert.Equal(t, []string{
+		"POSTGRES_USER=username",
+		"POSTGRES_PASSWORD=password",
+		"POSTGRES_DB=database",
+		"PGPASSWORD=password",
+	}, envs)
+}
+
+func TestPostgreSQLModule_GenerateLocalLifecycleWithReplicas(t *testing.T) {
+	postgres := &PostgreSQL{
+		Version: "14.0",
+	}
+	assert.NotContains(t, postgres.generateLocalLifecycle().PostStart.Exec.Command[2], "pg_hba.conf")
+
+	postgres.Replicas = 1
+	script := postgres.generateLocalLifecycle().PostStart.Exec.Command[2]
+	assert.Contains(t, script, `echo 'host replication all all md5' >> "$PGDATA/pg_hba.conf"`)
+	assert.Contains(t, script, "SELECT pg_reload_conf();")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusionapiv1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+func TestParseTLS(t *testing.T) {
+	tls, err := parseTLS(true)
+	assert.NoError(t, err)
+	assert.Equal(t, &TLS{}, tls)
+
+	tls, err = parseTLS("false")
+	assert.NoError(t, err)
+	assert.Nil(t, tls)
+
+	tls, err = parseTLS(map[string]any{"caBundleFile": "certs/global-bundle.pem"})
+	assert.NoError(t, err)
+	assert.Equal(t, &TLS{CABundleFile: "certs/global-bundle.pem"}, tls)
+
+	_, err = parseTLS(1)
+	assert.ErrorIs(t, err, ErrInvalidTLSConfig)
+
+	_, err = parseTLS(map[string]any{"mode": "verify"})
+	assert.ErrorIs(t, err, ErrInvalidTLSConfig)
+}
+
+func TestPostgreSQLModule_ValidateTLS(t *testing.T) {
+	postgres := &PostgreSQL{Type: "cloud"}
+	assert.NoError(t, postgres.validateTLS())
+
+	postgres.TLS = &TLS{CABundle: "test-ca-bundle"}
+	assert.NoError(t, postgres.validateTLS())
+
+	postgres.TLS = &TLS{CABundle: "test-ca-bundle", CABundleFile: "global-bundle.pem"}
+	assert.ErrorIs(t, postgres.validateTLS(), ErrInvalidTLSConfig)
+
+	postgres = &PostgreSQL{Type: "local", TLS: &TLS{}}
+	assert.ErrorIs(t, postgres.validateTLS(), ErrInvalidTLSConfig)
+}
+
+func TestPostgreSQLModule_GenerateTLSParams(t *testing.T) {
+	postgres := &PostgreSQL{}
+	assert.Nil(t, postgres.generateTLSParams(URLFormat))
+
+	postgres.TLS = &TLS{}
+	assert.Equal(t, "require", postgres.generateSSLMode())
+	assert.Equal(t, map[string]string{"sslmode": "require"}, postgres.generateTLSParams(URLFormat))
+	assert.Equal(t, map[string]string{"sslmode": "require"}, postgres.generateTLSParams(JDBCFormat))
+
+	postgres.TLS = &TLS{CABundle: "test-ca-bundle"}
+	postgres.DatabaseName = "test-database"
+	assert.Equal(t, "verify-ca", postgres.generateSSLMode())
+	assert.Equal(t, map[string]string{"sslmode": "verify-ca", "sslrootcert": "/etc/kusion/db-ca/test-database/ca.pem"},
+		postgres.generateTLSParams(DSNFormat))
+}
+
+func TestPostgreSQLModule_GenerateCASecret(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+	}
+
+	file := filepath.Join(t.TempDir(), "global-bundle.pem")
+	assert.NoError(t, os.WriteFile(file, []byte("test-ca-bundle"), 0o600))
+
+	postgres := &PostgreSQL{
+		DatabaseName: "test-database",
+		TLS:          &TLS{CABundleFile: file},
+	}
+
+	res, err := postgres.generateCASecret(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1:Secret:test-project:test-database-db-ca", res.ID)
+	assert.Equal(t, map[string]any{"ca.pem": "test-ca-bundle"}, res.Attributes["stringData"])
+
+	postgres.TLS.CABundleFile = filepath.Join(t.TempDir(), "missing.pem")
+	_, err = postgres.generateCASecret(r)
+	assert.ErrorIs(t, err, ErrInvalidTLSConfig)
+}
+
+func TestPostgreSQLModule_GenerateDBSecretWithTLS(t *testing.T) {
+	r := &module.GeneratorRequest{
+		Project: "test-project",
+		Stack:   "test-stack",
+		App:     "test-app",
+		Workload: kusionapiv1.Accessory{
+			"_type": "service.Service",
+			"type":  "service",
+		},
+	}
+
+	postgres := &PostgreSQL{
+		Type:          "cloud",
+		Version:       "14.0",
+		DatabaseName:  "test-database",
+		TLS:           &TLS{CABundle: "test-ca-bundle"},
+		ConnectionURL: &ConnectionURL{Format: URLFormat, EnvPrefix: defaultConnectionURLEnvPrefix},
+	}
+
+	res, patcher, err := postgres.GenerateDBSecret(r, "test-host-address", "test-username", "test-password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-ca", res.Attributes["stringData"].(map[string]any)["sslMode"])
+
+	envs := make(map[string]int)
+	for i, env := range patcher.Environments {
+		envs[env.Name] = i
+	}
+	sslMode := patcher.Environments[envs["KUSION_DB_SSL_MODE_TEST_DATABASE"]]
+	assert.Equal(t, "sslMode", sslMode.ValueFrom.SecretKeyRef.Key)
+	caCert := patcher.Environments[envs["KUSION_DB_CA_CERT_TEST_DATABASE"]]
+	assert.Equal(t, "test-database-db-ca", caCert.ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "ca.pem", caCert.ValueFrom.SecretKeyRef.Key)
+	caCertFile := patcher.Environments[envs["KUSION_DB_CA_CERT_FILE_TEST_DATABASE"]]
+	assert.Equal(t, "/etc/kusion/db-ca/test-database/ca.pem", caCertFile.Value)
+	assert.Contains(t, patcher.Environments[envs["KUSION_DB_URL_TEST_DATABASE"]].Value,
+		"?sslmode=verify-ca&sslrootcert=/etc/kusion/db-ca/test-database/ca.pem")
+}